@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	"github.com/kubeapps/kubeapps/pkg/kube"
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes"
+)
+
+// admissionPath is the path of the validating admission webhook
+const admissionPath = "/validate"
+
+// admissionValidator is a validating admission webhook rejecting the
+// AppRepositories which the kubeops API would not create, together with the
+// ones referencing missing or malformed secrets.
+type admissionValidator struct {
+	kubeclientset     kubernetes.Interface
+	kubeappsNamespace string
+	// probeIndex makes the validator get the index of the repositories
+	probeIndex bool
+}
+
+func newAdmissionValidator(kubeclientset kubernetes.Interface, kubeappsNamespace string, probeIndex bool) *admissionValidator {
+	return &admissionValidator{
+		kubeclientset:     kubeclientset,
+		kubeappsNamespace: kubeappsNamespace,
+		probeIndex:        probeIndex,
+	}
+}
+
+// ServeHTTP handles an AdmissionReview. The admission.k8s.io/v1beta1 reviews
+// have the same fields, so the response uses the version of the request.
+func (v *admissionValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	response := v.validate(review.Request)
+	response.UID = review.Request.UID
+	review.Request = nil
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Errorf("Unable to write the admission response: %v", err)
+	}
+}
+
+// validate returns the admission response for the creation or update of an
+// AppRepository.
+func (v *admissionValidator) validate(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	apprepo, err := decodeAppRepository(req.Object.Raw)
+	if err != nil {
+		return deniedAdmission(errors.NewBadRequest(fmt.Sprintf("unable to decode the AppRepository: %v", err)))
+	}
+	if apprepo.Namespace == "" {
+		apprepo.Namespace = req.Namespace
+	}
+
+	if req.Operation == admissionv1.Update {
+		// The controller updates the finalizers of the AppRepositories, which
+		// are only validated when their spec changes.
+		old, err := decodeAppRepository(req.OldObject.Raw)
+		if err == nil && apiequality.Semantic.DeepEqual(old.Spec, apprepo.Spec) {
+			return &admissionv1.AdmissionResponse{Allowed: true}
+		}
+	}
+
+	errs := v.validateAppRepository(apprepo)
+	if len(errs) > 0 {
+		log.Infof("Rejecting AppRepository %s/%s: %v", apprepo.Namespace, apprepo.Name, errs.ToAggregate())
+		return deniedAdmission(errors.NewInvalid(apprepov1beta1.Kind("AppRepository"), apprepo.Name, errs))
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func (v *admissionValidator) validateAppRepository(apprepo *apprepov1beta1.AppRepository) field.ErrorList {
+	errs := kube.ValidateAppRepositorySpec(apprepo, v.kubeappsNamespace)
+	if len(errs) > 0 {
+		return errs
+	}
+	errs = kube.ValidateAppRepositorySecrets(apprepo, v.getSecret(apprepo.Namespace))
+	if len(errs) > 0 || !v.probeIndex {
+		return errs
+	}
+
+	var caSecret, authSecret *corev1.Secret
+	var err error
+	if apprepo.Spec.Auth.CustomCA != nil {
+		caSecret, err = v.getSecret(apprepo.Namespace)(apprepo.Spec.Auth.CustomCA.SecretKeyRef.Name)
+	}
+	if name := kube.AuthSecretName(apprepo.Spec.Auth); err == nil && name != "" {
+		authSecret, err = v.getSecret(apprepo.Namespace)(name)
+	}
+	if err == nil {
+		err = kube.ProbeAppRepositoryIndex(apprepo, caSecret, authSecret)
+	}
+	if err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "url"), apprepo.Spec.URL, err.Error()))
+	}
+	return errs
+}
+
+func (v *admissionValidator) getSecret(namespace string) kube.SecretGetter {
+	return func(name string) (*corev1.Secret, error) {
+		return v.kubeclientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	}
+}
+
+func deniedAdmission(err *errors.StatusError) *admissionv1.AdmissionResponse {
+	status := err.Status()
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result:  &status,
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func admissionReview(t *testing.T, operation admissionv1.Operation, apprepo, old *apprepov1beta1.AppRepository) []byte {
+	raw := func(obj *apprepov1beta1.AppRepository) runtime.RawExtension {
+		if obj == nil {
+			return runtime.RawExtension{}
+		}
+		data, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		return runtime.RawExtension{Raw: data}
+	}
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "review-uid",
+			Namespace: apprepo.Namespace,
+			Operation: operation,
+			Object:    raw(apprepo),
+			OldObject: raw(old),
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return body
+}
+
+func Test_admissionValidator(t *testing.T) {
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stable/index.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer index.Close()
+
+	newAppRepo := func(namespace, url string) *apprepov1beta1.AppRepository {
+		return &apprepov1beta1.AppRepository{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "kubeapps.com/v1beta1", Kind: "AppRepository"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: namespace},
+			Spec:       apprepov1beta1.AppRepositorySpec{URL: url, Type: "helm"},
+		}
+	}
+	withToken := func(apprepo *apprepov1beta1.AppRepository, secretName string) *apprepov1beta1.AppRepository {
+		apprepo.Spec.Auth.BearerToken = &apprepov1beta1.AppRepositoryBearerToken{
+			SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secretName}, Key: "token"},
+		}
+		return apprepo
+	}
+	withRegistrySecrets := func(apprepo *apprepov1beta1.AppRepository) *apprepov1beta1.AppRepository {
+		apprepo.Spec.DockerRegistrySecrets = []string{"regcred"}
+		return apprepo
+	}
+	withFinalizer := func(apprepo *apprepov1beta1.AppRepository) *apprepov1beta1.AppRepository {
+		apprepo.Finalizers = []string{"foo"}
+		return apprepo
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "my-namespace"},
+		Data:       map[string][]byte{"token": []byte("foo")},
+	}
+
+	tests := []struct {
+		name            string
+		operation       admissionv1.Operation
+		apprepo         *apprepov1beta1.AppRepository
+		old             *apprepov1beta1.AppRepository
+		probeIndex      bool
+		expectedAllowed bool
+		expectedMessage string
+	}{
+		{
+			name:            "a valid AppRepository is allowed",
+			operation:       admissionv1.Create,
+			apprepo:         withToken(newAppRepo("my-namespace", "https://charts.example.com"), "creds"),
+			expectedAllowed: true,
+		},
+		{
+			name:            "a malformed URL is rejected",
+			operation:       admissionv1.Create,
+			apprepo:         newAppRepo("my-namespace", "charts.example.com"),
+			expectedMessage: `spec.url: Invalid value: "charts.example.com": must be an http or https URL`,
+		},
+		{
+			name:            "docker registry secrets are rejected for a global AppRepository",
+			operation:       admissionv1.Create,
+			apprepo:         withRegistrySecrets(newAppRepo("kubeapps", "https://charts.example.com")),
+			expectedMessage: "spec.dockerRegistrySecrets: Forbidden",
+		},
+		{
+			name:            "a missing secret is rejected",
+			operation:       admissionv1.Create,
+			apprepo:         withToken(newAppRepo("my-namespace", "https://charts.example.com"), "missing"),
+			expectedMessage: `secrets "missing" not found`,
+		},
+		{
+			name:            "an update not changing the spec is allowed",
+			operation:       admissionv1.Update,
+			apprepo:         withFinalizer(withToken(newAppRepo("my-namespace", "https://charts.example.com"), "missing")),
+			old:             withToken(newAppRepo("my-namespace", "https://charts.example.com"), "missing"),
+			expectedAllowed: true,
+		},
+		{
+			name:            "an update changing the spec is validated",
+			operation:       admissionv1.Update,
+			apprepo:         withToken(newAppRepo("my-namespace", "https://charts.example.com"), "missing"),
+			old:             newAppRepo("my-namespace", "https://charts.example.com"),
+			expectedMessage: `secrets "missing" not found`,
+		},
+		{
+			name:            "an available index is allowed",
+			operation:       admissionv1.Create,
+			apprepo:         withToken(newAppRepo("my-namespace", index.URL+"/stable"), "creds"),
+			probeIndex:      true,
+			expectedAllowed: true,
+		},
+		{
+			name:            "a missing index is rejected",
+			operation:       admissionv1.Create,
+			apprepo:         withToken(newAppRepo("my-namespace", index.URL+"/unstable"), "creds"),
+			probeIndex:      true,
+			expectedMessage: "404 Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := newAdmissionValidator(fake.NewSimpleClientset(secret), "kubeapps", tt.probeIndex)
+			req := httptest.NewRequest(http.MethodPost, admissionPath, bytes.NewReader(admissionReview(t, tt.operation, tt.apprepo, tt.old)))
+			w := httptest.NewRecorder()
+			validator.ServeHTTP(w, req)
+
+			if got, want := w.Code, http.StatusOK; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+			review := admissionv1.AdmissionReview{}
+			if err := json.NewDecoder(w.Body).Decode(&review); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := review.APIVersion, "admission.k8s.io/v1"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got, want := string(review.Response.UID), "review-uid"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got, want := review.Response.Allowed, tt.expectedAllowed; got != want {
+				t.Errorf("got: %t, want: %t (%+v)", got, want, review.Response.Result)
+			}
+			if tt.expectedMessage != "" {
+				if review.Response.Result == nil || !strings.Contains(review.Response.Result.Message, tt.expectedMessage) {
+					t.Errorf("got: %+v, want a message containing %q", review.Response.Result, tt.expectedMessage)
+				}
+			}
+		})
+	}
+}
+
+func Test_admissionValidatorBadRequest(t *testing.T) {
+	validator := newAdmissionValidator(fake.NewSimpleClientset(), "kubeapps", false)
+	req := httptest.NewRequest(http.MethodPost, admissionPath, strings.NewReader(`{"kind": "AdmissionReview"}`))
+	w := httptest.NewRecorder()
+	validator.ServeHTTP(w, req)
+	if got, want := w.Code, http.StatusBadRequest; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+}
@@ -18,13 +18,15 @@ package main
 
 import (
 	"fmt"
+	"path"
+	"strconv"
 	"time"
 
-	apprepov1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	clientset "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned"
 	appreposcheme "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned/scheme"
 	informers "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/informers/externalversions"
-	listers "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/listers/apprepository/v1alpha1"
+	listers "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/listers/apprepository/v1beta1"
 	"github.com/kubeapps/kubeapps/pkg/kube"
 	log "github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
@@ -39,6 +41,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
 	batchlisters "k8s.io/client-go/listers/batch/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -65,6 +68,24 @@ const (
 	// MessageResourceSynced is the message used for an Event fired when an
 	// AppRepsitory is synced successfully
 	MessageResourceSynced = "AppRepository synced successfully"
+
+	// SyncJobSucceeded is used as part of the Event 'reason' when a sync Job
+	// of an AppRepository completes
+	SyncJobSucceeded = "SyncJobSucceeded"
+	// SyncJobFailed is used as part of the Event 'reason' when a sync Job of
+	// an AppRepository fails
+	SyncJobFailed = "SyncJobFailed"
+	// MessageSyncJobSucceeded is the message used for an Event fired when a
+	// sync Job completes
+	MessageSyncJobSucceeded = "Sync Job %q completed successfully"
+	// MessageSyncJobFailed is the message used for an Event fired when a sync
+	// Job fails, with the termination message of its failing pod
+	MessageSyncJobFailed = "Sync Job %q failed: %s"
+
+	// AnnotationSyncJobReported is set on the sync Jobs whose outcome has
+	// been reported, so that it is not reported again when the controller
+	// restarts.
+	AnnotationSyncJobReported = "apprepositories.kubeapps.com/reported"
 )
 
 // Controller is the controller implementation for AppRepository resources
@@ -76,6 +97,8 @@ type Controller struct {
 
 	cronjobsLister batchlisters.CronJobLister
 	cronjobsSynced cache.InformerSynced
+	jobsLister     batchv1listers.JobLister
+	jobsSynced     cache.InformerSynced
 	appreposLister listers.AppRepositoryLister
 	appreposSynced cache.InformerSynced
 
@@ -85,6 +108,9 @@ type Controller struct {
 	// time, and makes it easy to ensure we are never processing the same item
 	// simultaneously in two different workers.
 	workqueue workqueue.RateLimitingInterface
+	// jobsWorkqueue is a rate limited work queue of the finished sync Jobs,
+	// whose outcome is reported on their AppRepository.
+	jobsWorkqueue workqueue.RateLimitingInterface
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
@@ -100,10 +126,11 @@ func NewController(
 	apprepoInformerFactory informers.SharedInformerFactory,
 	kubeappsNamespace string) *Controller {
 
-	// obtain references to shared index informers for the CronJob and
+	// obtain references to shared index informers for the CronJob, Job and
 	// AppRepository types.
 	cronjobInformer := kubeInformerFactory.Batch().V1beta1().CronJobs()
-	apprepoInformer := apprepoInformerFactory.Kubeapps().V1alpha1().AppRepositories()
+	jobInformer := kubeInformerFactory.Batch().V1().Jobs()
+	apprepoInformer := apprepoInformerFactory.Kubeapps().V1beta1().AppRepositories()
 
 	// Create event broadcaster
 	// Add apprepository-controller types to the default Kubernetes Scheme so
@@ -120,9 +147,12 @@ func NewController(
 		apprepoclientset:  apprepoclientset,
 		cronjobsLister:    cronjobInformer.Lister(),
 		cronjobsSynced:    cronjobInformer.Informer().HasSynced,
+		jobsLister:        jobInformer.Lister(),
+		jobsSynced:        jobInformer.Informer().HasSynced,
 		appreposLister:    apprepoInformer.Lister(),
 		appreposSynced:    apprepoInformer.Informer().HasSynced,
 		workqueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "AppRepositories"),
+		jobsWorkqueue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "AppRepositoryJobs"),
 		recorder:          recorder,
 		kubeappsNamespace: kubeappsNamespace,
 	}
@@ -132,8 +162,8 @@ func NewController(
 	apprepoInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueAppRepo,
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			oldApp := oldObj.(*apprepov1alpha1.AppRepository)
-			newApp := newObj.(*apprepov1alpha1.AppRepository)
+			oldApp := oldObj.(*apprepov1beta1.AppRepository)
+			newApp := newObj.(*apprepov1beta1.AppRepository)
 			if oldApp.Spec.URL != newApp.Spec.URL || oldApp.Spec.ResyncRequests != newApp.Spec.ResyncRequests {
 				controller.enqueueAppRepo(newApp)
 			}
@@ -157,6 +187,19 @@ func NewController(
 		DeleteFunc: controller.handleObject,
 	})
 
+	// Set up an event handler for the sync Jobs, labeled with their
+	// AppRepository, so that the outcome of the finished Jobs is reported on
+	// the AppRepository and the old manual Jobs are pruned.
+	jobInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isSyncJob,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: controller.enqueueJob,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				controller.enqueueJob(newObj)
+			},
+		},
+	})
+
 	return controller
 }
 
@@ -167,21 +210,25 @@ func NewController(
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
 	defer c.workqueue.ShutDown()
+	defer c.jobsWorkqueue.ShutDown()
 
 	// Start the informer factories to begin populating the informer caches
 	log.Info("Starting AppRepository controller")
 
 	// Wait for the caches to be synced before starting workers
 	log.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.cronjobsSynced, c.appreposSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.cronjobsSynced, c.jobsSynced, c.appreposSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
-	log.Info("Starting workers")
-	// Launch two workers to process AppRepository resources
+	log.Infof("Starting %d workers", threadiness)
+	// Launch the workers to process AppRepository resources
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(func() { c.runWorker(c.workqueue, c.syncHandler) }, time.Second, stopCh)
 	}
+	// Reporting the outcome of the sync Jobs is cheap, a single worker is
+	// enough.
+	go wait.Until(func() { c.runWorker(c.jobsWorkqueue, c.syncJobHandler) }, time.Second, stopCh)
 
 	log.Info("Started workers")
 	<-stopCh
@@ -190,18 +237,23 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	return nil
 }
 
+// HasSynced returns whether the informer caches have synced
+func (c *Controller) HasSynced() bool {
+	return c.cronjobsSynced() && c.jobsSynced() && c.appreposSynced()
+}
+
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
-func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+func (c *Controller) runWorker(queue workqueue.RateLimitingInterface, handler func(key string) error) {
+	for c.processNextWorkItem(queue, handler) {
 	}
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
-// attempt to process it, by calling the syncHandler.
-func (c *Controller) processNextWorkItem() bool {
-	obj, shutdown := c.workqueue.Get()
+// attempt to process it, by calling the handler.
+func (c *Controller) processNextWorkItem(queue workqueue.RateLimitingInterface, handler func(key string) error) bool {
+	obj, shutdown := queue.Get()
 
 	if shutdown {
 		return false
@@ -215,7 +267,7 @@ func (c *Controller) processNextWorkItem() bool {
 		// not call Forget if a transient error occurs, instead the item is
 		// put back on the workqueue and attempted again after a back-off
 		// period.
-		defer c.workqueue.Done(obj)
+		defer queue.Done(obj)
 		var key string
 		var ok bool
 		// We expect strings to come off the workqueue. These are of the
@@ -227,18 +279,18 @@ func (c *Controller) processNextWorkItem() bool {
 			// As the item in the workqueue is actually invalid, we call
 			// Forget here else we'd go into a loop of attempting to
 			// process a work item that is invalid.
-			c.workqueue.Forget(obj)
+			queue.Forget(obj)
 			runtime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		// Run the syncHandler, passing it the namespace/name string of the
-		// AppRepository resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		// Run the handler, passing it the namespace/name string of the
+		// resource to be synced.
+		if err := handler(key); err != nil {
 			return fmt.Errorf("error syncing '%s': %s", key, err.Error())
 		}
 		// Finally, if no error occurs we Forget this item so it does not
 		// get queued again until another change happens.
-		c.workqueue.Forget(obj)
+		queue.Forget(obj)
 		log.Infof("Successfully synced '%s'", key)
 		return nil
 	}(obj)
@@ -390,12 +442,12 @@ func (c *Controller) handleObject(obj interface{}) {
 
 // ownerReferencesForAppRepo returns populated owner references for app repos in the same namespace
 // as the cronjob and nil otherwise.
-func ownerReferencesForAppRepo(apprepo *apprepov1alpha1.AppRepository, childNamespace string) []metav1.OwnerReference {
+func ownerReferencesForAppRepo(apprepo *apprepov1beta1.AppRepository, childNamespace string) []metav1.OwnerReference {
 	if apprepo.GetNamespace() == childNamespace {
 		return []metav1.OwnerReference{
 			*metav1.NewControllerRef(apprepo, schema.GroupVersionKind{
-				Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-				Version: apprepov1alpha1.SchemeGroupVersion.Version,
+				Group:   apprepov1beta1.SchemeGroupVersion.Group,
+				Version: apprepov1beta1.SchemeGroupVersion.Version,
 				Kind:    "AppRepository",
 			}),
 		}
@@ -406,7 +458,7 @@ func ownerReferencesForAppRepo(apprepo *apprepov1alpha1.AppRepository, childName
 // newCronJob creates a new CronJob for a AppRepository resource. It also sets
 // the appropriate OwnerReferences on the resource so handleObject can discover
 // the AppRepository resource that 'owns' it.
-func newCronJob(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace string) *batchv1beta1.CronJob {
+func newCronJob(apprepo *apprepov1beta1.AppRepository, kubeappsNamespace string) *batchv1beta1.CronJob {
 	return &batchv1beta1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            cronJobName(apprepo),
@@ -420,6 +472,9 @@ func newCronJob(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace string
 			// https://github.com/kubernetes/kubernetes/issues/54870
 			ConcurrencyPolicy: "Replace",
 			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: jobLabels(apprepo),
+				},
 				Spec: syncJobSpec(apprepo, kubeappsNamespace),
 			},
 		},
@@ -428,18 +483,19 @@ func newCronJob(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace string
 
 // newSyncJob triggers a job for the AppRepository resource. It also sets the
 // appropriate OwnerReferences on the resource
-func newSyncJob(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace string) *batchv1.Job {
+func newSyncJob(apprepo *apprepov1beta1.AppRepository, kubeappsNamespace string) *batchv1.Job {
 	return &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName:    cronJobName(apprepo) + "-",
 			OwnerReferences: ownerReferencesForAppRepo(apprepo, kubeappsNamespace),
+			Labels:          jobLabels(apprepo),
 		},
 		Spec: syncJobSpec(apprepo, kubeappsNamespace),
 	}
 }
 
 // jobSpec returns a batchv1.JobSpec for running the chart-repo sync job
-func syncJobSpec(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace string) batchv1.JobSpec {
+func syncJobSpec(apprepo *apprepov1beta1.AppRepository, kubeappsNamespace string) batchv1.JobSpec {
 	volumes := []corev1.Volume{}
 	volumeMounts := []corev1.VolumeMount{}
 	if apprepo.Spec.Auth.CustomCA != nil {
@@ -460,6 +516,9 @@ func syncJobSpec(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace strin
 			MountPath: "/usr/local/share/ca-certificates",
 		})
 	}
+	dbVolumes, dbVolumeMounts := databaseVolumes()
+	volumes = append(volumes, dbVolumes...)
+	volumeMounts = append(volumeMounts, dbVolumeMounts...)
 	// Get the predefined pod spec for the apprepo definition if exists
 	podTemplateSpec := apprepo.Spec.SyncJobPodTemplate
 	// Add labels
@@ -481,6 +540,8 @@ func syncJobSpec(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace strin
 	podTemplateSpec.Spec.Containers[0].Image = repoSyncImage
 	podTemplateSpec.Spec.Containers[0].Command = []string{repoSyncCommand}
 	podTemplateSpec.Spec.Containers[0].Args = apprepoSyncJobArgs(apprepo)
+	// The end of the logs of a failing sync is reported on the AppRepository
+	podTemplateSpec.Spec.Containers[0].TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
 	podTemplateSpec.Spec.Containers[0].Env = append(podTemplateSpec.Spec.Containers[0].Env, apprepoSyncJobEnvVars(apprepo, kubeappsNamespace)...)
 	podTemplateSpec.Spec.Containers[0].VolumeMounts = append(podTemplateSpec.Spec.Containers[0].VolumeMounts, volumeMounts...)
 	// Add volumes
@@ -505,6 +566,7 @@ func newCleanupJob(reponame, namespace, kubeappsNamespace string) *batchv1.Job {
 
 // cleanupJobSpec returns a batchv1.JobSpec for running the chart-repo delete job
 func cleanupJobSpec(repoName, repoNamespace string) batchv1.JobSpec {
+	volumes, volumeMounts := databaseVolumes()
 	return batchv1.JobSpec{
 		Template: corev1.PodTemplateSpec{
 			Spec: corev1.PodSpec{
@@ -512,30 +574,61 @@ func cleanupJobSpec(repoName, repoNamespace string) batchv1.JobSpec {
 				RestartPolicy: "Never",
 				Containers: []corev1.Container{
 					{
-						Name:    "delete",
-						Image:   repoSyncImage,
-						Command: []string{repoSyncCommand},
-						Args:    apprepoCleanupJobArgs(repoName, repoNamespace),
-						Env: []corev1.EnvVar{
-							{
-								Name: "DB_PASSWORD",
-								ValueFrom: &corev1.EnvVarSource{
-									SecretKeyRef: &corev1.SecretKeySelector{
-										LocalObjectReference: corev1.LocalObjectReference{Name: dbSecretName},
-										Key:                  dbSecretKey,
-									},
-								},
-							},
-						},
+						Name:         "delete",
+						Image:        repoSyncImage,
+						Command:      []string{repoSyncCommand},
+						Args:         apprepoCleanupJobArgs(repoName, repoNamespace),
+						Env:          databaseEnvVars(),
+						VolumeMounts: volumeMounts,
 					},
 				},
+				Volumes: volumes,
 			},
 		},
 	}
 }
 
+// databaseEnvVars returns the env variables with the database credentials,
+// which a bolt database does not need.
+func databaseEnvVars() []corev1.EnvVar {
+	if dbType == "bolt" {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{
+			Name: "DB_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: dbSecretName},
+					Key:                  dbSecretKey,
+				},
+			},
+		},
+	}
+}
+
+// databaseVolumes returns the volume with the database file of a bolt
+// database, mounted in the directory of the database URL.
+func databaseVolumes() ([]corev1.Volume, []corev1.VolumeMount) {
+	if dbType != "bolt" || dbVolumeClaim == "" {
+		return nil, nil
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: "database",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: dbVolumeClaim},
+			},
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{Name: "database", MountPath: path.Dir(dbURL)},
+	}
+	return volumes, volumeMounts
+}
+
 // jobLabels returns the labels for the job and cronjob resources
-func jobLabels(apprepo *apprepov1alpha1.AppRepository) map[string]string {
+func jobLabels(apprepo *apprepov1beta1.AppRepository) map[string]string {
 	return map[string]string{
 		LabelRepoName:      apprepo.GetName(),
 		LabelRepoNamespace: apprepo.GetNamespace(),
@@ -543,7 +636,7 @@ func jobLabels(apprepo *apprepov1alpha1.AppRepository) map[string]string {
 }
 
 // cronJobName returns a unique name for the CronJob managed by an AppRepository
-func cronJobName(apprepo *apprepov1alpha1.AppRepository) string {
+func cronJobName(apprepo *apprepov1beta1.AppRepository) string {
 	return fmt.Sprintf("apprepo-%s-sync-%s", apprepo.GetNamespace(), apprepo.GetName())
 }
 
@@ -553,28 +646,36 @@ func deleteJobName(reponame, reponamespace string) string {
 }
 
 // apprepoSyncJobArgs returns a list of args for the sync container
-func apprepoSyncJobArgs(apprepo *apprepov1alpha1.AppRepository) []string {
+func apprepoSyncJobArgs(apprepo *apprepov1beta1.AppRepository) []string {
 	args := append([]string{"sync"}, dbFlags()...)
 
 	if userAgentComment != "" {
 		args = append(args, "--user-agent-comment="+userAgentComment)
 	}
 
+	if apprepo.Spec.Mirror {
+		args = append(args, "--mirror")
+	}
+
+	if syncWorkers > 0 {
+		args = append(args, fmt.Sprintf("--workers=%d", syncWorkers))
+	}
+	if syncRateLimit > 0 {
+		args = append(args, "--rate-limit="+strconv.FormatFloat(syncRateLimit, 'f', -1, 64))
+	}
+	if syncMaxRetries >= 0 {
+		args = append(args, fmt.Sprintf("--max-retries=%d", syncMaxRetries))
+	}
+	if syncRetryBackoff > 0 {
+		args = append(args, "--retry-backoff="+syncRetryBackoff.String())
+	}
+
 	return append(args, "--namespace="+apprepo.GetNamespace(), apprepo.GetName(), apprepo.Spec.URL)
 }
 
 // apprepoSyncJobEnvVars returns a list of env variables for the sync container
-func apprepoSyncJobEnvVars(apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace string) []corev1.EnvVar {
-	var envVars []corev1.EnvVar
-	envVars = append(envVars, corev1.EnvVar{
-		Name: "DB_PASSWORD",
-		ValueFrom: &corev1.EnvVarSource{
-			SecretKeyRef: &corev1.SecretKeySelector{
-				LocalObjectReference: corev1.LocalObjectReference{Name: dbSecretName},
-				Key:                  dbSecretKey,
-			},
-		},
-	})
+func apprepoSyncJobEnvVars(apprepo *apprepov1beta1.AppRepository, kubeappsNamespace string) []corev1.EnvVar {
+	envVars := databaseEnvVars()
 	if apprepo.Spec.Auth.Header != nil {
 		envVars = append(envVars, corev1.EnvVar{
 			Name: "AUTHORIZATION_HEADER",
@@ -583,14 +684,35 @@ func apprepoSyncJobEnvVars(apprepo *apprepov1alpha1.AppRepository, kubeappsNames
 			},
 		})
 	}
+	if apprepo.Spec.Auth.BasicAuth != nil {
+		envVars = append(envVars, secretEnvVar("BASIC_AUTH_USERNAME", apprepo.Spec.Auth.BasicAuth.UsernameSecretKeyRef, apprepo, kubeappsNamespace))
+		envVars = append(envVars, secretEnvVar("BASIC_AUTH_PASSWORD", apprepo.Spec.Auth.BasicAuth.PasswordSecretKeyRef, apprepo, kubeappsNamespace))
+	}
+	if apprepo.Spec.Auth.BearerToken != nil {
+		envVars = append(envVars, secretEnvVar("BEARER_TOKEN", apprepo.Spec.Auth.BearerToken.SecretKeyRef, apprepo, kubeappsNamespace))
+	}
+	if apprepo.Spec.Auth.ClientCert != nil {
+		envVars = append(envVars, secretEnvVar("CLIENT_CERT", apprepo.Spec.Auth.ClientCert.CertSecretKeyRef, apprepo, kubeappsNamespace))
+		envVars = append(envVars, secretEnvVar("CLIENT_KEY", apprepo.Spec.Auth.ClientCert.KeySecretKeyRef, apprepo, kubeappsNamespace))
+	}
 	return envVars
 }
 
+// secretEnvVar returns an env var with the value of a key of the repo secret.
+func secretEnvVar(name string, keyRef corev1.SecretKeySelector, apprepo *apprepov1beta1.AppRepository, kubeappsNamespace string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: secretKeyRefForRepo(keyRef, apprepo, kubeappsNamespace),
+		},
+	}
+}
+
 // secretKeyRefForRepo returns a secret key ref with a name depending on whether
 // this repo is in the kubeapps namespace or not. If the repo is not in the
 // kubeapps namespace, then the secret will have been copied from another namespace
 // into the kubeapps namespace and have a slightly different name.
-func secretKeyRefForRepo(keyRef corev1.SecretKeySelector, apprepo *apprepov1alpha1.AppRepository, kubeappsNamespace string) *corev1.SecretKeySelector {
+func secretKeyRefForRepo(keyRef corev1.SecretKeySelector, apprepo *apprepov1beta1.AppRepository, kubeappsNamespace string) *corev1.SecretKeySelector {
 	if apprepo.ObjectMeta.Namespace == kubeappsNamespace {
 		return &keyRef
 	}
@@ -2,9 +2,10 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
-	apprepov1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -20,17 +21,17 @@ func Test_newCronJob(t *testing.T) {
 	const kubeappsNamespace = "kubeapps"
 	tests := []struct {
 		name             string
-		apprepo          *apprepov1alpha1.AppRepository
+		apprepo          *apprepov1beta1.AppRepository
 		expected         batchv1beta1.CronJob
 		userAgentComment string
 		crontab          string
 	}{
 		{
 			"my-charts",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -40,7 +41,7 @@ func Test_newCronJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
 				},
@@ -50,10 +51,10 @@ func Test_newCronJob(t *testing.T) {
 					Name: "apprepo-kubeapps-sync-my-charts",
 					OwnerReferences: []metav1.OwnerReference{
 						*metav1.NewControllerRef(
-							&apprepov1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
+							&apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
 							schema.GroupVersionKind{
-								Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-								Version: apprepov1alpha1.SchemeGroupVersion.Version,
+								Group:   apprepov1beta1.SchemeGroupVersion.Group,
+								Version: apprepov1beta1.SchemeGroupVersion.Version,
 								Kind:    "AppRepository",
 							}),
 					},
@@ -66,6 +67,12 @@ func Test_newCronJob(t *testing.T) {
 					Schedule:          "*/10 * * * *",
 					ConcurrencyPolicy: "Replace",
 					JobTemplate: batchv1beta1.JobTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								LabelRepoName:      "my-charts",
+								LabelRepoNamespace: "kubeapps",
+							},
+						},
 						Spec: batchv1.JobSpec{
 							Template: corev1.PodTemplateSpec{
 								ObjectMeta: metav1.ObjectMeta{
@@ -91,6 +98,7 @@ func Test_newCronJob(t *testing.T) {
 												"my-charts",
 												"https://charts.acme.com/my-charts",
 											},
+											TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 											Env: []corev1.EnvVar{
 												{
 													Name: "DB_PASSWORD",
@@ -113,10 +121,10 @@ func Test_newCronJob(t *testing.T) {
 		},
 		{
 			"my-charts with auth, userAgent and crontab configuration",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -126,11 +134,11 @@ func Test_newCronJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
-					Auth: apprepov1alpha1.AppRepositoryAuth{
-						Header: &apprepov1alpha1.AppRepositoryAuthHeader{
+					Auth: apprepov1beta1.AppRepositoryAuth{
+						Header: &apprepov1beta1.AppRepositoryAuthHeader{
 							SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "apprepo-my-charts-secrets"}, Key: "AuthorizationHeader"}},
 					},
 				},
@@ -140,10 +148,10 @@ func Test_newCronJob(t *testing.T) {
 					Name: "apprepo-kubeapps-sync-my-charts",
 					OwnerReferences: []metav1.OwnerReference{
 						*metav1.NewControllerRef(
-							&apprepov1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
+							&apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
 							schema.GroupVersionKind{
-								Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-								Version: apprepov1alpha1.SchemeGroupVersion.Version,
+								Group:   apprepov1beta1.SchemeGroupVersion.Group,
+								Version: apprepov1beta1.SchemeGroupVersion.Version,
 								Kind:    "AppRepository",
 							}),
 					},
@@ -156,6 +164,12 @@ func Test_newCronJob(t *testing.T) {
 					Schedule:          "*/20 * * * *",
 					ConcurrencyPolicy: "Replace",
 					JobTemplate: batchv1beta1.JobTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								LabelRepoName:      "my-charts",
+								LabelRepoNamespace: "kubeapps",
+							},
+						},
 						Spec: batchv1.JobSpec{
 							Template: corev1.PodTemplateSpec{
 								ObjectMeta: metav1.ObjectMeta{
@@ -182,6 +196,7 @@ func Test_newCronJob(t *testing.T) {
 												"my-charts",
 												"https://charts.acme.com/my-charts",
 											},
+											TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 											Env: []corev1.EnvVar{
 												{
 													Name: "DB_PASSWORD",
@@ -209,10 +224,10 @@ func Test_newCronJob(t *testing.T) {
 		},
 		{
 			"a cronjob for an app repo in another namespace references the repo secret in kubeapps",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts-in-otherns",
@@ -222,11 +237,11 @@ func Test_newCronJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
-					Auth: apprepov1alpha1.AppRepositoryAuth{
-						Header: &apprepov1alpha1.AppRepositoryAuthHeader{
+					Auth: apprepov1beta1.AppRepositoryAuth{
+						Header: &apprepov1beta1.AppRepositoryAuthHeader{
 							SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "apprepo-my-charts-in-otherns"}, Key: "AuthorizationHeader"}},
 					},
 				},
@@ -243,6 +258,12 @@ func Test_newCronJob(t *testing.T) {
 					Schedule:          "*/20 * * * *",
 					ConcurrencyPolicy: "Replace",
 					JobTemplate: batchv1beta1.JobTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								LabelRepoName:      "my-charts-in-otherns",
+								LabelRepoNamespace: "otherns",
+							},
+						},
 						Spec: batchv1.JobSpec{
 							Template: corev1.PodTemplateSpec{
 								ObjectMeta: metav1.ObjectMeta{
@@ -269,6 +290,7 @@ func Test_newCronJob(t *testing.T) {
 												"my-charts-in-otherns",
 												"https://charts.acme.com/my-charts",
 											},
+											TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 											Env: []corev1.EnvVar{
 												{
 													Name: "DB_PASSWORD",
@@ -322,16 +344,16 @@ func Test_newSyncJob(t *testing.T) {
 	const kubeappsNamespace = "kubeapps"
 	tests := []struct {
 		name             string
-		apprepo          *apprepov1alpha1.AppRepository
+		apprepo          *apprepov1beta1.AppRepository
 		expected         batchv1.Job
 		userAgentComment string
 	}{
 		{
 			"my-charts",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -341,7 +363,7 @@ func Test_newSyncJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
 				},
@@ -351,14 +373,18 @@ func Test_newSyncJob(t *testing.T) {
 					GenerateName: "apprepo-kubeapps-sync-my-charts-",
 					OwnerReferences: []metav1.OwnerReference{
 						*metav1.NewControllerRef(
-							&apprepov1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
+							&apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
 							schema.GroupVersionKind{
-								Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-								Version: apprepov1alpha1.SchemeGroupVersion.Version,
+								Group:   apprepov1beta1.SchemeGroupVersion.Group,
+								Version: apprepov1beta1.SchemeGroupVersion.Version,
 								Kind:    "AppRepository",
 							},
 						),
 					},
+					Labels: map[string]string{
+						LabelRepoName:      "my-charts",
+						LabelRepoNamespace: "kubeapps",
+					},
 				},
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
@@ -385,6 +411,7 @@ func Test_newSyncJob(t *testing.T) {
 										"my-charts",
 										"https://charts.acme.com/my-charts",
 									},
+									TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 									Env: []corev1.EnvVar{
 										{
 											Name: "DB_PASSWORD",
@@ -404,10 +431,10 @@ func Test_newSyncJob(t *testing.T) {
 		},
 		{
 			"an app repository in another namespace results in jobs without owner references",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -417,7 +444,7 @@ func Test_newSyncJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
 				},
@@ -425,6 +452,10 @@ func Test_newSyncJob(t *testing.T) {
 			batchv1.Job{
 				ObjectMeta: metav1.ObjectMeta{
 					GenerateName: "apprepo-my-other-namespace-sync-my-charts-",
+					Labels: map[string]string{
+						LabelRepoName:      "my-charts",
+						LabelRepoNamespace: "my-other-namespace",
+					},
 				},
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
@@ -451,6 +482,7 @@ func Test_newSyncJob(t *testing.T) {
 										"my-charts",
 										"https://charts.acme.com/my-charts",
 									},
+									TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 									Env: []corev1.EnvVar{
 										{
 											Name: "DB_PASSWORD",
@@ -470,10 +502,10 @@ func Test_newSyncJob(t *testing.T) {
 		},
 		{
 			"my-charts with auth and userAgent comment",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -483,11 +515,11 @@ func Test_newSyncJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
-					Auth: apprepov1alpha1.AppRepositoryAuth{
-						Header: &apprepov1alpha1.AppRepositoryAuthHeader{
+					Auth: apprepov1beta1.AppRepositoryAuth{
+						Header: &apprepov1beta1.AppRepositoryAuthHeader{
 							SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "apprepo-my-charts-secrets"}, Key: "AuthorizationHeader"}},
 					},
 				},
@@ -497,14 +529,18 @@ func Test_newSyncJob(t *testing.T) {
 					GenerateName: "apprepo-kubeapps-sync-my-charts-",
 					OwnerReferences: []metav1.OwnerReference{
 						*metav1.NewControllerRef(
-							&apprepov1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
+							&apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
 							schema.GroupVersionKind{
-								Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-								Version: apprepov1alpha1.SchemeGroupVersion.Version,
+								Group:   apprepov1beta1.SchemeGroupVersion.Group,
+								Version: apprepov1beta1.SchemeGroupVersion.Version,
 								Kind:    "AppRepository",
 							},
 						),
 					},
+					Labels: map[string]string{
+						LabelRepoName:      "my-charts",
+						LabelRepoNamespace: "kubeapps",
+					},
 				},
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
@@ -532,6 +568,7 @@ func Test_newSyncJob(t *testing.T) {
 										"my-charts",
 										"https://charts.acme.com/my-charts",
 									},
+									TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 									Env: []corev1.EnvVar{
 										{
 											Name: "DB_PASSWORD",
@@ -556,10 +593,10 @@ func Test_newSyncJob(t *testing.T) {
 		},
 		{
 			"my-charts with a customCA",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -569,11 +606,11 @@ func Test_newSyncJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
-					Auth: apprepov1alpha1.AppRepositoryAuth{
-						CustomCA: &apprepov1alpha1.AppRepositoryCustomCA{
+					Auth: apprepov1beta1.AppRepositoryAuth{
+						CustomCA: &apprepov1beta1.AppRepositoryCustomCA{
 							SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ca-cert-test"}, Key: "foo"},
 						},
 					},
@@ -584,14 +621,18 @@ func Test_newSyncJob(t *testing.T) {
 					GenerateName: "apprepo-kubeapps-sync-my-charts-",
 					OwnerReferences: []metav1.OwnerReference{
 						*metav1.NewControllerRef(
-							&apprepov1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
+							&apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
 							schema.GroupVersionKind{
-								Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-								Version: apprepov1alpha1.SchemeGroupVersion.Version,
+								Group:   apprepov1beta1.SchemeGroupVersion.Group,
+								Version: apprepov1beta1.SchemeGroupVersion.Version,
 								Kind:    "AppRepository",
 							},
 						),
 					},
+					Labels: map[string]string{
+						LabelRepoName:      "my-charts",
+						LabelRepoNamespace: "kubeapps",
+					},
 				},
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
@@ -618,6 +659,7 @@ func Test_newSyncJob(t *testing.T) {
 										"my-charts",
 										"https://charts.acme.com/my-charts",
 									},
+									TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 									Env: []corev1.EnvVar{
 										{
 											Name: "DB_PASSWORD",
@@ -651,10 +693,10 @@ func Test_newSyncJob(t *testing.T) {
 		},
 		{
 			"my-charts with a customCA and auth header",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -664,14 +706,14 @@ func Test_newSyncJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
-					Auth: apprepov1alpha1.AppRepositoryAuth{
-						CustomCA: &apprepov1alpha1.AppRepositoryCustomCA{
+					Auth: apprepov1beta1.AppRepositoryAuth{
+						CustomCA: &apprepov1beta1.AppRepositoryCustomCA{
 							SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ca-cert-test"}, Key: "foo"},
 						},
-						Header: &apprepov1alpha1.AppRepositoryAuthHeader{
+						Header: &apprepov1beta1.AppRepositoryAuthHeader{
 							SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "apprepo-my-charts-secrets"}, Key: "AuthorizationHeader"},
 						},
 					},
@@ -682,14 +724,18 @@ func Test_newSyncJob(t *testing.T) {
 					GenerateName: "apprepo-kubeapps-sync-my-charts-",
 					OwnerReferences: []metav1.OwnerReference{
 						*metav1.NewControllerRef(
-							&apprepov1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
+							&apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
 							schema.GroupVersionKind{
-								Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-								Version: apprepov1alpha1.SchemeGroupVersion.Version,
+								Group:   apprepov1beta1.SchemeGroupVersion.Group,
+								Version: apprepov1beta1.SchemeGroupVersion.Version,
 								Kind:    "AppRepository",
 							},
 						),
 					},
+					Labels: map[string]string{
+						LabelRepoName:      "my-charts",
+						LabelRepoNamespace: "kubeapps",
+					},
 				},
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
@@ -716,6 +762,7 @@ func Test_newSyncJob(t *testing.T) {
 										"my-charts",
 										"https://charts.acme.com/my-charts",
 									},
+									TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 									Env: []corev1.EnvVar{
 										{
 											Name: "DB_PASSWORD",
@@ -754,10 +801,10 @@ func Test_newSyncJob(t *testing.T) {
 		},
 		{
 			"my-charts with a custom pod template",
-			&apprepov1alpha1.AppRepository{
+			&apprepov1beta1.AppRepository{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AppRepository",
-					APIVersion: "kubeapps.com/v1alpha1",
+					APIVersion: "kubeapps.com/v1beta1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
@@ -767,7 +814,7 @@ func Test_newSyncJob(t *testing.T) {
 						"created-by": "kubeapps",
 					},
 				},
-				Spec: apprepov1alpha1.AppRepositorySpec{
+				Spec: apprepov1beta1.AppRepositorySpec{
 					Type: "helm",
 					URL:  "https://charts.acme.com/my-charts",
 					SyncJobPodTemplate: corev1.PodTemplateSpec{
@@ -796,14 +843,18 @@ func Test_newSyncJob(t *testing.T) {
 					GenerateName: "apprepo-kubeapps-sync-my-charts-",
 					OwnerReferences: []metav1.OwnerReference{
 						*metav1.NewControllerRef(
-							&apprepov1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
+							&apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts"}},
 							schema.GroupVersionKind{
-								Group:   apprepov1alpha1.SchemeGroupVersion.Group,
-								Version: apprepov1alpha1.SchemeGroupVersion.Version,
+								Group:   apprepov1beta1.SchemeGroupVersion.Group,
+								Version: apprepov1beta1.SchemeGroupVersion.Version,
 								Kind:    "AppRepository",
 							},
 						),
 					},
+					Labels: map[string]string{
+						LabelRepoName:      "my-charts",
+						LabelRepoNamespace: "kubeapps",
+					},
 				},
 				Spec: batchv1.JobSpec{
 					Template: corev1.PodTemplateSpec{
@@ -832,6 +883,7 @@ func Test_newSyncJob(t *testing.T) {
 										"my-charts",
 										"https://charts.acme.com/my-charts",
 									},
+									TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 									Env: []corev1.EnvVar{
 										{Name: "FOO", Value: "BAR"},
 										{
@@ -867,6 +919,125 @@ func Test_newSyncJob(t *testing.T) {
 	}
 }
 
+func Test_apprepoSyncJobArgs(t *testing.T) {
+	dbURL = "mongodb.kubeapps"
+	dbName = "assets"
+	dbUser = "admin"
+	userAgentComment = ""
+	defer func() {
+		syncWorkers, syncRateLimit, syncMaxRetries, syncRetryBackoff = 0, 0, -1, 0
+	}()
+
+	tests := []struct {
+		name     string
+		mirror   bool
+		fetch    func()
+		expected []string
+	}{
+		{
+			"without mirroring",
+			false,
+			func() {},
+			[]string{"sync", "--database-type=mongodb", "--database-url=mongodb.kubeapps", "--database-user=admin", "--database-name=assets", "--namespace=kubeapps", "my-charts", "https://charts.acme.com/my-charts"},
+		},
+		{
+			"with mirroring",
+			true,
+			func() {},
+			[]string{"sync", "--database-type=mongodb", "--database-url=mongodb.kubeapps", "--database-user=admin", "--database-name=assets", "--mirror", "--namespace=kubeapps", "my-charts", "https://charts.acme.com/my-charts"},
+		},
+		{
+			"with the fetch options",
+			false,
+			func() {
+				syncWorkers, syncRateLimit, syncMaxRetries, syncRetryBackoff = 5, 2.5, 0, 2*time.Second
+			},
+			[]string{"sync", "--database-type=mongodb", "--database-url=mongodb.kubeapps", "--database-user=admin", "--database-name=assets", "--workers=5", "--rate-limit=2.5", "--max-retries=0", "--retry-backoff=2s", "--namespace=kubeapps", "my-charts", "https://charts.acme.com/my-charts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fetch()
+			apprepo := &apprepov1beta1.AppRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"},
+				Spec:       apprepov1beta1.AppRepositorySpec{URL: "https://charts.acme.com/my-charts", Mirror: tt.mirror},
+			}
+			if got, want := apprepoSyncJobArgs(apprepo), tt.expected; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func Test_apprepoSyncJobEnvVars(t *testing.T) {
+	dbSecretName = "mongodb"
+	dbSecretKey = "mongodb-root-password"
+	const kubeappsNamespace = "kubeapps"
+	keyRef := func(name, key string) corev1.SecretKeySelector {
+		return corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+	}
+	envVar := func(name, secretName, key string) corev1.EnvVar {
+		ref := keyRef(secretName, key)
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &ref}}
+	}
+	dbPassword := envVar("DB_PASSWORD", "mongodb", "mongodb-root-password")
+
+	tests := []struct {
+		name      string
+		namespace string
+		auth      apprepov1beta1.AppRepositoryAuth
+		expected  []corev1.EnvVar
+	}{
+		{
+			"basic auth and bearer token",
+			"kubeapps",
+			apprepov1beta1.AppRepositoryAuth{
+				BasicAuth: &apprepov1beta1.AppRepositoryBasicAuth{
+					UsernameSecretKeyRef: keyRef("apprepo-my-charts", "username"),
+					PasswordSecretKeyRef: keyRef("apprepo-my-charts", "password"),
+				},
+				BearerToken: &apprepov1beta1.AppRepositoryBearerToken{
+					SecretKeyRef: keyRef("apprepo-my-charts", "token"),
+				},
+			},
+			[]corev1.EnvVar{
+				dbPassword,
+				envVar("BASIC_AUTH_USERNAME", "apprepo-my-charts", "username"),
+				envVar("BASIC_AUTH_PASSWORD", "apprepo-my-charts", "password"),
+				envVar("BEARER_TOKEN", "apprepo-my-charts", "token"),
+			},
+		},
+		{
+			"client certificate of a repo in another namespace",
+			"otherns",
+			apprepov1beta1.AppRepositoryAuth{
+				ClientCert: &apprepov1beta1.AppRepositoryClientCert{
+					CertSecretKeyRef: keyRef("apprepo-my-charts", "tls.crt"),
+					KeySecretKeyRef:  keyRef("apprepo-my-charts", "tls.key"),
+				},
+			},
+			[]corev1.EnvVar{
+				dbPassword,
+				envVar("CLIENT_CERT", "otherns-apprepo-my-charts", "tls.crt"),
+				envVar("CLIENT_KEY", "otherns-apprepo-my-charts", "tls.key"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apprepo := &apprepov1beta1.AppRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: tt.namespace},
+				Spec:       apprepov1beta1.AppRepositorySpec{Auth: tt.auth},
+			}
+			if got, want := apprepoSyncJobEnvVars(apprepo, kubeappsNamespace), tt.expected; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
 func Test_newCleanupJob(t *testing.T) {
 	dbURL = "mongodb.kubeapps"
 	dbName = "assets"
@@ -952,7 +1123,7 @@ func TestObjectBelongsTo(t *testing.T) {
 					},
 				},
 			},
-			parent: &apprepov1alpha1.AppRepository{
+			parent: &apprepov1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
 					Namespace: "my-namespace",
@@ -972,7 +1143,7 @@ func TestObjectBelongsTo(t *testing.T) {
 					},
 				},
 			},
-			parent: &apprepov1alpha1.AppRepository{
+			parent: &apprepov1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "my-charts",
 					Namespace: "my-namespace2",
@@ -990,3 +1161,80 @@ func TestObjectBelongsTo(t *testing.T) {
 		})
 	}
 }
+
+func Test_databaseVolumes(t *testing.T) {
+	defer func(typ, url, claim string) { dbType, dbURL, dbVolumeClaim = typ, url, claim }(dbType, dbURL, dbVolumeClaim)
+	dbSecretName = "mongodb"
+	dbSecretKey = "mongodb-root-password"
+
+	tests := []struct {
+		name             string
+		dbType           string
+		dbVolumeClaim    string
+		wantVolumes      []corev1.Volume
+		wantVolumeMounts []corev1.VolumeMount
+		wantEnvVars      []corev1.EnvVar
+	}{
+		{
+			"mongodb",
+			"mongodb",
+			"",
+			nil,
+			nil,
+			[]corev1.EnvVar{
+				{
+					Name: "DB_PASSWORD",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "mongodb"}, Key: "mongodb-root-password"}},
+				},
+			},
+		},
+		{
+			"bolt without a volume claim",
+			"bolt",
+			"",
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"bolt with a volume claim",
+			"bolt",
+			"kubeapps-db",
+			[]corev1.Volume{
+				{
+					Name: "database",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "kubeapps-db"},
+					},
+				},
+			},
+			[]corev1.VolumeMount{{Name: "database", MountPath: "/data"}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbType = tt.dbType
+			dbURL = "/data/assets.db"
+			dbVolumeClaim = tt.dbVolumeClaim
+
+			volumes, volumeMounts := databaseVolumes()
+			if got, want := volumes, tt.wantVolumes; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if got, want := volumeMounts, tt.wantVolumeMounts; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if got, want := databaseEnvVars(), tt.wantEnvVars; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+
+			job := newCleanupJob("my-charts", "kubeapps", "kubeapps")
+			if got, want := job.Spec.Template.Spec.Volumes, tt.wantVolumes; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	apprepov1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	log "github.com/sirupsen/logrus"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+const (
+	// conversionPath is the path of the conversion webhook
+	conversionPath = "/convert"
+	// appRepositoryCRDName is the name of the CustomResourceDefinition of the
+	// AppRepositories, whose conversion webhook is registered by the
+	// controller
+	appRepositoryCRDName = "apprepositories.kubeapps.com"
+)
+
+// conversionScheme knows the versions of the AppRepositories and the
+// conversions between them.
+var conversionScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(apprepov1alpha1.AddToScheme(conversionScheme))
+	utilruntime.Must(apprepov1beta1.AddToScheme(conversionScheme))
+}
+
+// conversionHandler is the conversion webhook of the AppRepository CRD. The
+// apiextensions.k8s.io/v1beta1 reviews have the same fields, so the response
+// uses the version of the request.
+type conversionHandler struct{}
+
+func (conversionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "invalid ConversionReview", http.StatusBadRequest)
+		return
+	}
+
+	response := &apiextensionsv1.ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	converted, err := convertAppRepositories(review.Request.Objects, review.Request.DesiredAPIVersion)
+	if err != nil {
+		log.Errorf("Unable to convert AppRepositories to %s: %v", review.Request.DesiredAPIVersion, err)
+		response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+	} else {
+		response.ConvertedObjects = converted
+	}
+	review.Request = nil
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Errorf("Unable to write the conversion response: %v", err)
+	}
+}
+
+// convertAppRepositories converts the serialized AppRepositories to the given
+// API version.
+func convertAppRepositories(objects []runtime.RawExtension, desiredAPIVersion string) ([]runtime.RawExtension, error) {
+	desired, err := schema.ParseGroupVersion(desiredAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	converted := []runtime.RawExtension{}
+	for _, raw := range objects {
+		typeMeta := metav1.TypeMeta{}
+		if err := json.Unmarshal(raw.Raw, &typeMeta); err != nil {
+			return nil, err
+		}
+		if typeMeta.APIVersion == desiredAPIVersion {
+			converted = append(converted, raw)
+			continue
+		}
+		in, err := conversionScheme.New(typeMeta.GroupVersionKind())
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw.Raw, in); err != nil {
+			return nil, err
+		}
+		out, err := conversionScheme.ConvertToVersion(in, desired)
+		if err != nil {
+			return nil, err
+		}
+		conversionScheme.Default(out)
+		data, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, runtime.RawExtension{Raw: data})
+	}
+	return converted, nil
+}
+
+// decodeAppRepository returns the v1beta1 AppRepository of a serialized
+// AppRepository of any version.
+func decodeAppRepository(raw []byte) (*apprepov1beta1.AppRepository, error) {
+	converted, err := convertAppRepositories([]runtime.RawExtension{{Raw: raw}}, apprepov1beta1.SchemeGroupVersion.String())
+	if err != nil {
+		return nil, err
+	}
+	apprepo := &apprepov1beta1.AppRepository{}
+	if err := json.Unmarshal(converted[0].Raw, apprepo); err != nil {
+		return nil, err
+	}
+	return apprepo, nil
+}
+
+// registerConversionWebhook sets the conversion webhook of the AppRepository
+// CRD to the given service of the controller, which is served with a
+// certificate signed by the caBundle. The CRD is installed without a webhook
+// as its service depends on the namespace of the controller.
+func registerConversionWebhook(client apiextensionsclientset.Interface, namespace, name string, caBundle []byte) error {
+	port := int32(443)
+	path := conversionPath
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"conversion": apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig: &apiextensionsv1.WebhookClientConfig{
+						Service: &apiextensionsv1.ServiceReference{
+							Namespace: namespace,
+							Name:      name,
+							Path:      &path,
+							Port:      &port,
+						},
+						CABundle: caBundle,
+					},
+					ConversionReviewVersions: []string{"v1", "v1beta1"},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = client.ApiextensionsV1().CustomResourceDefinitions().Patch(appRepositoryCRDName, types.MergePatchType, data)
+	if err != nil {
+		return fmt.Errorf("unable to register the conversion webhook: %v", err)
+	}
+	return nil
+}
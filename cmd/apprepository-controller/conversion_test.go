@@ -0,0 +1,198 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apprepov1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func Test_conversionHandler(t *testing.T) {
+	v1alpha1Repo := &apprepov1alpha1.AppRepository{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "kubeapps.com/v1alpha1", Kind: "AppRepository"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"},
+		Spec: apprepov1alpha1.AppRepositorySpec{
+			URL:  "https://charts.example.com",
+			Auth: apprepov1alpha1.AppRepositoryAuth{BearerToken: &apprepov1alpha1.AppRepositoryBearerToken{}},
+		},
+	}
+	v1beta1Repo := &apprepov1beta1.AppRepository{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "kubeapps.com/v1beta1", Kind: "AppRepository"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"},
+		Spec: apprepov1beta1.AppRepositorySpec{
+			Type: "helm",
+			URL:  "https://charts.example.com",
+			Auth: apprepov1beta1.AppRepositoryAuth{BearerToken: &apprepov1beta1.AppRepositoryBearerToken{}},
+		},
+	}
+	v1alpha1Defaulted := v1alpha1Repo.DeepCopy()
+	v1alpha1Defaulted.Spec.Type = "helm"
+
+	tests := []struct {
+		name              string
+		object            runtime.Object
+		desiredAPIVersion string
+		expected          runtime.Object
+		expectedFailure   bool
+	}{
+		{
+			name:              "it converts and defaults a v1alpha1 AppRepository",
+			object:            v1alpha1Repo,
+			desiredAPIVersion: "kubeapps.com/v1beta1",
+			expected:          v1beta1Repo,
+		},
+		{
+			name:              "it converts a v1beta1 AppRepository",
+			object:            v1beta1Repo,
+			desiredAPIVersion: "kubeapps.com/v1alpha1",
+			expected:          v1alpha1Defaulted,
+		},
+		{
+			name:              "it keeps an AppRepository of the desired version",
+			object:            v1alpha1Repo,
+			desiredAPIVersion: "kubeapps.com/v1alpha1",
+			expected:          v1alpha1Repo,
+		},
+		{
+			name:              "it fails for an unknown version",
+			object:            v1alpha1Repo,
+			desiredAPIVersion: "kubeapps.com/v2",
+			expectedFailure:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := json.Marshal(tt.object)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			review := apiextensionsv1.ConversionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+				Request: &apiextensionsv1.ConversionRequest{
+					UID:               "review-uid",
+					DesiredAPIVersion: tt.desiredAPIVersion,
+					Objects:           []runtime.RawExtension{{Raw: raw}},
+				},
+			}
+			body, err := json.Marshal(review)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			w := httptest.NewRecorder()
+			conversionHandler{}.ServeHTTP(w, httptest.NewRequest(http.MethodPost, conversionPath, bytes.NewReader(body)))
+			if got, want := w.Code, http.StatusOK; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+
+			response := apiextensionsv1.ConversionReview{}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := string(response.Response.UID), "review-uid"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if tt.expectedFailure {
+				if got, want := response.Response.Result.Status, metav1.StatusFailure; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+				return
+			}
+			if got, want := len(response.Response.ConvertedObjects), 1; got != want {
+				t.Fatalf("got: %d, want: %d (%+v)", got, want, response.Response.Result)
+			}
+			got, err := conversionScheme.New(tt.expected.GetObjectKind().GroupVersionKind())
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if err := json.Unmarshal(response.Response.ConvertedObjects[0].Raw, got); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if want := tt.expected; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func Test_decodeAppRepository(t *testing.T) {
+	raw := []byte(`{"apiVersion": "kubeapps.com/v1alpha1", "kind": "AppRepository", "metadata": {"name": "my-charts"}, "spec": {"url": "https://charts.example.com"}}`)
+	apprepo, err := decodeAppRepository(raw)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := apprepo.APIVersion, "kubeapps.com/v1beta1"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := apprepo.Spec.URL, "https://charts.example.com"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func Test_registerConversionWebhook(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: appRepositoryCRDName},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group:      "kubeapps.com",
+			Conversion: &apiextensionsv1.CustomResourceConversion{Strategy: apiextensionsv1.NoneConverter},
+		},
+	}
+	client := apiextensionsfake.NewSimpleClientset(crd)
+
+	err := registerConversionWebhook(client, "kubeapps", "apprepository-controller", []byte("ca"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	got, err := client.ApiextensionsV1().CustomResourceDefinitions().Get(appRepositoryCRDName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	path := conversionPath
+	port := int32(443)
+	want := &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: "kubeapps",
+					Name:      "apprepository-controller",
+					Path:      &path,
+					Port:      &port,
+				},
+				CABundle: []byte("ca"),
+			},
+			ConversionReviewVersions: []string{"v1", "v1beta1"},
+		},
+	}
+	if !cmp.Equal(want, got.Spec.Conversion) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got.Spec.Conversion))
+	}
+	if got, want := got.Spec.Group, "kubeapps.com"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
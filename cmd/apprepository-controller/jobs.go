@@ -0,0 +1,217 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// isSyncJob returns whether the object is a sync Job of an AppRepository,
+// created manually or by its CronJob.
+func isSyncJob(obj interface{}) bool {
+	job, ok := obj.(*batchv1.Job)
+	return ok && job.GetLabels()[LabelRepoName] != ""
+}
+
+// isManualSyncJob returns whether the sync Job was created by the controller
+// rather than by the CronJob of the AppRepository.
+func isManualSyncJob(job *batchv1.Job) bool {
+	ownerRef := metav1.GetControllerOf(job)
+	return ownerRef == nil || ownerRef.Kind != "CronJob"
+}
+
+// finishedCondition returns the condition of a Job which has completed or
+// failed, or nil if it is still running.
+func finishedCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// enqueueJob puts the namespace/name of a finished sync Job onto the jobs
+// work queue.
+func (c *Controller) enqueueJob(obj interface{}) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok || finishedCondition(job) == nil {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(job)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.jobsWorkqueue.Add(key)
+}
+
+// syncJobHandler reports the outcome of a finished sync Job on its
+// AppRepository, then prunes the old manual sync Jobs of the AppRepository.
+func (c *Controller) syncJobHandler(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+	job, err := c.jobsLister.Jobs(namespace).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("Error fetching Job with key %s from store: %v", key, err)
+	}
+	condition := finishedCondition(job)
+	if condition == nil {
+		return nil
+	}
+
+	jobLabels := job.GetLabels()
+	apprepo, err := c.appreposLister.AppRepositories(jobLabels[LabelRepoNamespace]).Get(jobLabels[LabelRepoName])
+	if err != nil {
+		// The Jobs of a deleted AppRepository are deleted with its CronJob or
+		// by the garbage collector.
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if job.GetAnnotations()[AnnotationSyncJobReported] == "" {
+		// The Job is annotated before the Event is recorded so that the
+		// outcome is reported at most once.
+		reported := job.DeepCopy()
+		if reported.Annotations == nil {
+			reported.Annotations = map[string]string{}
+		}
+		reported.Annotations[AnnotationSyncJobReported] = "true"
+		if _, err := c.kubeclientset.BatchV1().Jobs(namespace).Update(reported); err != nil {
+			return err
+		}
+		c.reportSyncJob(apprepo, job, condition)
+	}
+
+	return c.pruneSyncJobs(apprepo)
+}
+
+// reportSyncJob records an Event on the AppRepository with the outcome of its
+// sync Job.
+func (c *Controller) reportSyncJob(apprepo *apprepov1beta1.AppRepository, job *batchv1.Job, condition *batchv1.JobCondition) {
+	eventType, reason, msg := corev1.EventTypeNormal, SyncJobSucceeded, fmt.Sprintf(MessageSyncJobSucceeded, job.GetName())
+	if condition.Type == batchv1.JobFailed {
+		eventType, reason, msg = corev1.EventTypeWarning, SyncJobFailed, fmt.Sprintf(MessageSyncJobFailed, job.GetName(), c.syncJobFailureMessage(job, condition))
+	}
+	log.Infof("AppRepository %q in namespace %q: %s", apprepo.GetName(), apprepo.GetNamespace(), msg)
+	// As for the Synced event, the controller may only record events in the
+	// kubeapps namespace.
+	if apprepo.GetNamespace() == c.kubeappsNamespace {
+		c.recorder.Event(apprepo, eventType, reason, msg)
+	}
+}
+
+// syncJobFailureMessage returns the termination message of the failing pod of
+// a Job, or the message of its failed condition if there is none.
+func (c *Controller) syncJobFailureMessage(job *batchv1.Job, condition *batchv1.JobCondition) string {
+	selector := labels.Set{"job-name": job.GetName()}.AsSelector()
+	if job.Spec.Selector != nil {
+		if s, err := metav1.LabelSelectorAsSelector(job.Spec.Selector); err == nil {
+			selector = s
+		}
+	}
+	pods, err := c.kubeclientset.CoreV1().Pods(job.GetNamespace()).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		log.Errorf("Unable to list the pods of the Job %q: %v", job.GetName(), err)
+	} else if msg := podTerminationMessage(pods.Items); msg != "" {
+		return msg
+	}
+	if condition.Message != "" {
+		return condition.Message
+	}
+	return condition.Reason
+}
+
+// podTerminationMessage returns the termination message of the last container
+// which failed in the most recent of the pods.
+func podTerminationMessage(pods []corev1.Pod) string {
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+	})
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			// The sync container is restarted on failure, so its previous
+			// termination is the failing one.
+			for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+				if terminated == nil || terminated.ExitCode == 0 {
+					continue
+				}
+				if msg := strings.TrimSpace(terminated.Message); msg != "" {
+					return msg
+				}
+				return fmt.Sprintf("container %q exited with code %d: %s", status.Name, terminated.ExitCode, terminated.Reason)
+			}
+		}
+	}
+	return ""
+}
+
+// pruneSyncJobs deletes the finished manual sync Jobs of the AppRepository
+// beyond the history limit, the most recent ones being kept. The Jobs created
+// by the CronJob are pruned according to its own history limits.
+func (c *Controller) pruneSyncJobs(apprepo *apprepov1beta1.AppRepository) error {
+	jobs, err := c.jobsLister.Jobs(c.kubeappsNamespace).List(labels.SelectorFromSet(jobLabels(apprepo)))
+	if err != nil {
+		return err
+	}
+	finished := []*batchv1.Job{}
+	for _, job := range jobs {
+		if isManualSyncJob(job) && finishedCondition(job) != nil {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= syncJobHistoryLimit {
+		return nil
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		if !finished[i].CreationTimestamp.Equal(&finished[j].CreationTimestamp) {
+			return finished[j].CreationTimestamp.Before(&finished[i].CreationTimestamp)
+		}
+		return finished[i].GetName() > finished[j].GetName()
+	})
+	// The pods of the Jobs are deleted as well.
+	propagation := metav1.DeletePropagationBackground
+	for _, job := range finished[syncJobHistoryLimit:] {
+		log.Infof("Deleting the sync Job %q of AppRepository %q in namespace %q", job.GetName(), apprepo.GetName(), apprepo.GetNamespace())
+		err := c.kubeclientset.BatchV1().Jobs(job.GetNamespace()).Delete(job.GetName(), &metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
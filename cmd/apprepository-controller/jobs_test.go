@@ -0,0 +1,254 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	listers "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/listers/apprepository/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func newSyncJobForTest(name string, created time.Time, apprepo *apprepov1beta1.AppRepository, condition batchv1.JobConditionType) *batchv1.Job {
+	job := newSyncJob(apprepo, "kubeapps")
+	job.Name = name
+	job.Namespace = "kubeapps"
+	job.CreationTimestamp = metav1.NewTime(created)
+	if condition != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"}}
+	}
+	return job
+}
+
+// newJobsTestController returns a controller whose listers and clientset
+// contain the given objects.
+func newJobsTestController(apprepos []*apprepov1beta1.AppRepository, jobs []*batchv1.Job, pods []corev1.Pod) (*Controller, *fake.Clientset, *record.FakeRecorder) {
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	jobIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)
+	apprepoIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers)
+	objects := []runtime.Object{}
+	for _, job := range jobs {
+		jobIndexer.Add(job)
+		objects = append(objects, job)
+	}
+	for _, apprepo := range apprepos {
+		apprepoIndexer.Add(apprepo)
+	}
+	for i := range pods {
+		objects = append(objects, &pods[i])
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+	recorder := record.NewFakeRecorder(10)
+	return &Controller{
+		kubeclientset:     clientset,
+		jobsLister:        batchv1listers.NewJobLister(jobIndexer),
+		appreposLister:    listers.NewAppRepositoryLister(apprepoIndexer),
+		recorder:          recorder,
+		kubeappsNamespace: "kubeapps",
+	}, clientset, recorder
+}
+
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func Test_syncJobHandler(t *testing.T) {
+	syncJobHistoryLimit = 3
+	now := time.Now()
+	apprepo := &apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"}}
+	otherApprepo := &apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "other-namespace"}}
+	failingPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-job-abcde", Namespace: "kubeapps", Labels: map[string]string{"job-name": "my-job"}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 "sync",
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "Error: unable to fetch the index\n"}},
+			}},
+		},
+	}
+	reported := newSyncJobForTest("my-job", now, apprepo, batchv1.JobComplete)
+	reported.Annotations = map[string]string{AnnotationSyncJobReported: "true"}
+
+	tests := []struct {
+		name         string
+		job          *batchv1.Job
+		pods         []corev1.Pod
+		wantEvents   []string
+		wantReported bool
+	}{
+		{
+			"completed job",
+			newSyncJobForTest("my-job", now, apprepo, batchv1.JobComplete),
+			nil,
+			[]string{`Normal SyncJobSucceeded Sync Job "my-job" completed successfully`},
+			true,
+		},
+		{
+			"failed job with the termination message of its pod",
+			newSyncJobForTest("my-job", now, apprepo, batchv1.JobFailed),
+			[]corev1.Pod{failingPod},
+			[]string{`Warning SyncJobFailed Sync Job "my-job" failed: Error: unable to fetch the index`},
+			true,
+		},
+		{
+			"failed job without pods",
+			newSyncJobForTest("my-job", now, apprepo, batchv1.JobFailed),
+			nil,
+			[]string{`Warning SyncJobFailed Sync Job "my-job" failed: BackoffLimitExceeded`},
+			true,
+		},
+		{
+			"job already reported",
+			reported,
+			nil,
+			[]string{},
+			true,
+		},
+		{
+			"running job",
+			newSyncJobForTest("my-job", now, apprepo, ""),
+			nil,
+			[]string{},
+			false,
+		},
+		{
+			"job of an app repository in another namespace",
+			newSyncJobForTest("my-job", now, otherApprepo, batchv1.JobComplete),
+			nil,
+			[]string{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, clientset, recorder := newJobsTestController([]*apprepov1beta1.AppRepository{apprepo, otherApprepo}, []*batchv1.Job{tt.job}, tt.pods)
+
+			if err := c.syncJobHandler("kubeapps/my-job"); err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if got, want := recordedEvents(recorder), tt.wantEvents; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			job, err := clientset.BatchV1().Jobs("kubeapps").Get("my-job", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := job.Annotations[AnnotationSyncJobReported] != "", tt.wantReported; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+		})
+	}
+}
+
+func Test_pruneSyncJobs(t *testing.T) {
+	syncJobHistoryLimit = 2
+	defer func() { syncJobHistoryLimit = 3 }()
+	now := time.Now()
+	apprepo := &apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"}}
+	otherApprepo := &apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "other-charts", Namespace: "kubeapps"}}
+	cronJobRun := newSyncJobForTest("cron-job-run", now.Add(-time.Hour), apprepo, batchv1.JobComplete)
+	cronJobRun.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(&batchv1beta1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: cronJobName(apprepo)}}, batchv1beta1.SchemeGroupVersion.WithKind("CronJob")),
+	}
+	jobs := []*batchv1.Job{
+		newSyncJobForTest("job-1", now.Add(-5*time.Minute), apprepo, batchv1.JobComplete),
+		newSyncJobForTest("job-2", now.Add(-4*time.Minute), apprepo, batchv1.JobFailed),
+		newSyncJobForTest("job-3", now.Add(-3*time.Minute), apprepo, batchv1.JobComplete),
+		newSyncJobForTest("job-4", now.Add(-2*time.Minute), apprepo, batchv1.JobComplete),
+		newSyncJobForTest("job-5", now.Add(-time.Minute), apprepo, ""),
+		newSyncJobForTest("other-job", now.Add(-time.Hour), otherApprepo, batchv1.JobComplete),
+		cronJobRun,
+	}
+	c, clientset, _ := newJobsTestController([]*apprepov1beta1.AppRepository{apprepo, otherApprepo}, jobs, nil)
+
+	if err := c.pruneSyncJobs(apprepo); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	list, err := clientset.BatchV1().Jobs("kubeapps").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got := []string{}
+	for _, job := range list.Items {
+		got = append(got, job.Name)
+	}
+	sort.Strings(got)
+	want := []string{"cron-job-run", "job-3", "job-4", "job-5", "other-job"}
+	if !cmp.Equal(want, got) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func Test_podTerminationMessage(t *testing.T) {
+	now := time.Now()
+	terminated := func(name string, created time.Time, exitCode int32, message string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "sync",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: "Error", Message: message}},
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		pods []corev1.Pod
+		want string
+	}{
+		{"no pods", nil, ""},
+		{"successful pod", []corev1.Pod{terminated("pod", now, 0, "done")}, ""},
+		{"failed pod with a message", []corev1.Pod{terminated("pod", now, 1, " boom\n")}, "boom"},
+		{"failed pod without a message", []corev1.Pod{terminated("pod", now, 2, "")}, `container "sync" exited with code 2: Error`},
+		{
+			"most recent failed pod",
+			[]corev1.Pod{terminated("old", now.Add(-time.Minute), 1, "old failure"), terminated("new", now, 1, "new failure")},
+			"new failure",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := podTerminationMessage(tt.pods), tt.want; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
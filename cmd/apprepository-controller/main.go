@@ -17,35 +17,75 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/heptiolabs/healthcheck"
 	clientset "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned"
 	informers "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/informers/externalversions"
 	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/signals"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd" // Uncomment the following line to load the gcp plugin (only required to authenticate against GKE clusters).
 	// _ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
-	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
 )
 
 var (
-	masterURL         string
-	kubeconfig        string
-	repoSyncImage     string
-	repoSyncCommand   string
-	namespace         string
-	dbType            string
-	dbURL             string
-	dbUser            string
-	dbName            string
-	dbSecretName      string
-	dbSecretKey       string
-	userAgentComment  string
-	crontab           string
-	reposPerNamespace bool
+	masterURL           string
+	kubeconfig          string
+	repoSyncImage       string
+	repoSyncCommand     string
+	namespace           string
+	dbType              string
+	dbURL               string
+	dbUser              string
+	dbName              string
+	dbSecretName        string
+	dbSecretKey         string
+	dbVolumeClaim       string
+	userAgentComment    string
+	crontab             string
+	reposPerNamespace   bool
+	syncJobHistoryLimit int
+	syncWorkers         int
+	syncRateLimit       float64
+	// syncMaxRetries is not passed to the sync jobs when negative
+	syncMaxRetries      = -1
+	syncRetryBackoff    time.Duration
+	workers             int
+	leaderElect         bool
+	leaseDuration       time.Duration
+	renewDeadline       time.Duration
+	retryPeriod         time.Duration
+	metricsAddress      string
+	webhookAddress      string
+	webhookDebounce     time.Duration
+	tlsAddress          string
+	tlsCertFile         string
+	tlsKeyFile          string
+	tlsCAFile           string
+	conversionService   string
+	admissionProbeIndex bool
 )
 
+// leaderElectionLockName is the name of the Lease used for the leader election
+const leaderElectionLockName = "apprepository-controller"
+
 func main() {
 	flag.Parse()
 
@@ -77,14 +117,133 @@ func main() {
 		apprepoInformerFactory = informers.NewFilteredSharedInformerFactory(apprepoClient, 0, namespace, nil)
 	}
 
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("Error getting the hostname: %s", err.Error())
+	}
+	identity := fmt.Sprintf("%s_%s", hostname, uuid.NewUUID())
+
+	// The metrics provider must be set before creating the work queue.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	workqueue.SetProvider(newWorkqueueMetricsProvider(registry))
+	status := newLeaderStatus(registry, identity)
+
 	controller := NewController(kubeClient, apprepoClient, kubeInformerFactory, apprepoInformerFactory, namespace)
 
+	// The informers are started by every replica so that a new leader does not
+	// wait for its caches to sync.
 	go kubeInformerFactory.Start(stopCh)
 	go apprepoInformerFactory.Start(stopCh)
 
-	if err = controller.Run(2, stopCh); err != nil {
-		log.Fatalf("Error running controller: %s", err.Error())
+	health := healthcheck.NewMetricsHandler(registry, metricsNamespace)
+	health.AddReadinessCheck("informers-synced", func() error {
+		if !controller.HasSynced() {
+			return fmt.Errorf("the informer caches have not synced")
+		}
+		return nil
+	})
+	watchdog := leaderelection.NewLeaderHealthzAdaptor(20 * time.Second)
+	health.AddLivenessCheck("leader-election", func() error {
+		return watchdog.Check(nil)
+	})
+	if metricsAddress != "" {
+		go func() {
+			log.Infof("Serving metrics and health checks on %s", metricsAddress)
+			handler := newStatusHandler(registry, health, controller, status, workers)
+			if err := http.ListenAndServe(metricsAddress, handler); err != nil {
+				log.Fatalf("Error serving metrics: %s", err.Error())
+			}
+		}()
 	}
+
+	if webhookAddress != "" {
+		// The webhooks are received by every replica, which creates the sync
+		// Jobs itself unless a sync Job of the AppRepository is already active.
+		receiver := newWebhookReceiver(controller, webhookDebounce)
+		go receiver.Run(stopCh)
+		go func() {
+			log.Infof("Serving the AppRepository webhooks on %s", webhookAddress)
+			mux := http.NewServeMux()
+			mux.Handle(webhookPathPrefix, receiver)
+			if err := http.ListenAndServe(webhookAddress, mux); err != nil {
+				log.Fatalf("Error serving webhooks: %s", err.Error())
+			}
+		}()
+	}
+
+	if tlsAddress != "" {
+		go func() {
+			log.Infof("Serving the AppRepository admission and conversion webhooks on %s", tlsAddress)
+			mux := http.NewServeMux()
+			mux.Handle(admissionPath, newAdmissionValidator(kubeClient, namespace, admissionProbeIndex))
+			mux.Handle(conversionPath, conversionHandler{})
+			if err := http.ListenAndServeTLS(tlsAddress, tlsCertFile, tlsKeyFile, mux); err != nil {
+				log.Fatalf("Error serving the webhooks: %s", err.Error())
+			}
+		}()
+	}
+	if conversionService != "" {
+		if err := registerConversionWebhookFromFlags(cfg); err != nil {
+			// The versions of the AppRepositories have the same fields, so the
+			// API server can still serve them without the webhook.
+			log.Errorf("Error registering the conversion webhook: %s", err.Error())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+	run := func(ctx context.Context) {
+		status.setLeader(true)
+		if err := controller.Run(workers, ctx.Done()); err != nil {
+			log.Fatalf("Error running controller: %s", err.Error())
+		}
+	}
+
+	if !leaderElect {
+		run(ctx)
+		return
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaderElectionLockName,
+			Namespace: namespace,
+		},
+		Client:     kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	log.Infof("Waiting to acquire the lease %s/%s as %s", namespace, leaderElectionLockName, identity)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		WatchDog:        watchdog,
+		Name:            leaderElectionLockName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: run,
+			OnStoppedLeading: func() {
+				status.setLeader(false)
+				select {
+				case <-ctx.Done():
+					log.Info("Released the leader election lease")
+				default:
+					// Another replica may already be running the workers.
+					log.Fatal("Lost the leader election lease")
+				}
+			},
+			OnNewLeader: func(current string) {
+				if current != identity {
+					log.Infof("The current leader is %s", current)
+				}
+			},
+		},
+	})
 }
 
 func init() {
@@ -94,12 +253,50 @@ func init() {
 	flag.StringVar(&repoSyncCommand, "repo-sync-cmd", "/chart-repo", "command used to sync/delete repos for repo-sync-image")
 	flag.StringVar(&namespace, "namespace", "kubeapps", "Namespace to discover AppRepository resources")
 	flag.BoolVar(&reposPerNamespace, "repos-per-namespace", false, "Enables syncing app repositories across all namespaces.")
-	flag.StringVar(&dbType, "database-type", "mongodb", "Database type. Allowed values: mongodb, postgresql")
+	flag.StringVar(&dbType, "database-type", "mongodb", "Database type. Allowed values: mongodb, postgresql, bolt")
 	flag.StringVar(&dbURL, "database-url", "localhost", "Database URL")
 	flag.StringVar(&dbUser, "database-user", "root", "Database user")
 	flag.StringVar(&dbName, "database-name", "charts", "Database name")
 	flag.StringVar(&dbSecretName, "database-secret-name", "mongodb", "Kubernetes secret name for database credentials")
 	flag.StringVar(&dbSecretKey, "database-secret-key", "mongodb-root-password", "Kubernetes secret key used for database credentials")
+	flag.StringVar(&dbVolumeClaim, "database-volume-claim", "", "PersistentVolumeClaim mounted in the jobs with the database file of the bolt database type. Since the file can only be shared by pods on the same node, it must be bound to a volume pinned to the node of assetsvc, such as a local PersistentVolume, unless the cluster has a single node")
 	flag.StringVar(&userAgentComment, "user-agent-comment", "", "UserAgent comment used during outbound requests")
 	flag.StringVar(&crontab, "crontab", "*/10 * * * *", "CronTab to specify schedule")
+	flag.IntVar(&syncJobHistoryLimit, "sync-job-history-limit", 3, "Number of finished manual sync Jobs to keep for each AppRepository")
+	flag.IntVar(&syncWorkers, "sync-workers", 0, "Number of chart icons and files fetched concurrently by the sync jobs. The asset-syncer default is used when 0")
+	flag.Float64Var(&syncRateLimit, "sync-rate-limit", 0, "Maximum number of requests per second sent to each host by the sync jobs, unlimited when 0")
+	flag.IntVar(&syncMaxRetries, "sync-max-retries", -1, "Number of times the sync jobs retry a request which times out or gets a 429 or 5xx response. The asset-syncer default is used when negative")
+	flag.DurationVar(&syncRetryBackoff, "sync-retry-backoff", 0, "Delay before the sync jobs retry a request, doubled for each retry. The asset-syncer default is used when 0")
+	flag.IntVar(&workers, "workers", 2, "Number of workers processing AppRepository resources concurrently")
+	flag.BoolVar(&leaderElect, "leader-elect", false, "Elect a leader with a Lease in the namespace before running the workers, to run several replicas")
+	flag.DurationVar(&leaseDuration, "leader-elect-lease-duration", 15*time.Second, "Duration the replicas wait before trying to acquire a lease not renewed by its leader")
+	flag.DurationVar(&renewDeadline, "leader-elect-renew-deadline", 10*time.Second, "Duration the leader retries renewing its lease before giving it up")
+	flag.DurationVar(&retryPeriod, "leader-elect-retry-period", 2*time.Second, "Duration the replicas wait between attempts to acquire or renew the lease")
+	flag.StringVar(&webhookAddress, "webhook-bind-address", "", "Address serving the webhooks triggering the sync of AppRepositories on /webhooks/<namespace>/<name>. Disabled if empty")
+	flag.DurationVar(&webhookDebounce, "webhook-debounce", 10*time.Second, "Duration during which the webhook calls for an AppRepository trigger a single sync")
+	flag.StringVar(&tlsAddress, "tls-bind-address", "", "Address serving the validating admission webhook for AppRepositories on /validate and the conversion webhook of their CRD on /convert. Disabled if empty")
+	flag.StringVar(&tlsCertFile, "tls-cert-file", "", "Certificate file of the admission and conversion webhooks")
+	flag.StringVar(&tlsKeyFile, "tls-key-file", "", "Private key file of the admission and conversion webhooks")
+	flag.StringVar(&tlsCAFile, "tls-ca-file", "", "CA certificate file signing the certificate of the webhooks, registered with the conversion webhook")
+	flag.StringVar(&conversionService, "conversion-webhook-service", "", "Service of the controller, as <namespace>/<name>, registered as the conversion webhook of the AppRepository CRD. Not registered if empty")
+	flag.BoolVar(&admissionProbeIndex, "admission-probe-index", false, "Reject the AppRepositories whose index cannot be retrieved")
+	flag.StringVar(&metricsAddress, "metrics-bind-address", ":8080", "Address serving the metrics on /metrics, the status on /status and the health checks on /live and /ready. Disabled if empty")
+}
+
+// registerConversionWebhookFromFlags registers the conversion webhook of the
+// AppRepository CRD with the service and CA of the flags.
+func registerConversionWebhookFromFlags(cfg *rest.Config) error {
+	parts := strings.SplitN(conversionService, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid service %q, expected <namespace>/<name>", conversionService)
+	}
+	caBundle, err := ioutil.ReadFile(tlsCAFile)
+	if err != nil {
+		return err
+	}
+	client, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return registerConversionWebhook(client, parts[0], parts[1], caBundle)
 }
@@ -0,0 +1,175 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/heptiolabs/healthcheck"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const metricsNamespace = "apprepository_controller"
+
+// workqueueMetricsProvider exports the metrics of the work queues to
+// prometheus, labeled with the name of the queue.
+type workqueueMetricsProvider struct {
+	depth                   *prometheus.GaugeVec
+	adds                    *prometheus.CounterVec
+	latency                 *prometheus.HistogramVec
+	workDuration            *prometheus.HistogramVec
+	unfinishedWorkSeconds   *prometheus.GaugeVec
+	longestRunningProcessor *prometheus.GaugeVec
+	retries                 *prometheus.CounterVec
+}
+
+func newWorkqueueMetricsProvider(registry prometheus.Registerer) *workqueueMetricsProvider {
+	labels := []string{"name"}
+	p := &workqueueMetricsProvider{
+		depth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace, Subsystem: "workqueue", Name: "depth",
+			Help: "Current depth of the workqueue",
+		}, labels),
+		adds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace, Subsystem: "workqueue", Name: "adds_total",
+			Help: "Total number of adds handled by the workqueue",
+		}, labels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace, Subsystem: "workqueue", Name: "queue_duration_seconds",
+			Help:    "How long in seconds an item stays in the workqueue before being requested",
+			Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+		}, labels),
+		workDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace, Subsystem: "workqueue", Name: "work_duration_seconds",
+			Help:    "How long in seconds processing an item from the workqueue takes",
+			Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+		}, labels),
+		unfinishedWorkSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace, Subsystem: "workqueue", Name: "unfinished_work_seconds",
+			Help: "How many seconds of work has been done that is in progress",
+		}, labels),
+		longestRunningProcessor: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace, Subsystem: "workqueue", Name: "longest_running_processor_seconds",
+			Help: "How many seconds the longest running processor of the workqueue has been running",
+		}, labels),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace, Subsystem: "workqueue", Name: "retries_total",
+			Help: "Total number of retries handled by the workqueue",
+		}, labels),
+	}
+	registry.MustRegister(p.depth, p.adds, p.latency, p.workDuration, p.unfinishedWorkSeconds, p.longestRunningProcessor, p.retries)
+	return p
+}
+
+func (p *workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return p.depth.WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return p.adds.WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return p.latency.WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return p.workDuration.WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return p.unfinishedWorkSeconds.WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return p.longestRunningProcessor.WithLabelValues(name)
+}
+
+func (p *workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return p.retries.WithLabelValues(name)
+}
+
+// leaderStatus records whether this replica is the leader running the
+// workers, also exported as a prometheus gauge.
+type leaderStatus struct {
+	mutex    sync.RWMutex
+	identity string
+	leader   bool
+	gauge    prometheus.Gauge
+}
+
+func newLeaderStatus(registry prometheus.Registerer, identity string) *leaderStatus {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Name:        "leader",
+		Help:        "Whether this replica is the leader running the workers (1) or not (0)",
+		ConstLabels: prometheus.Labels{"identity": identity},
+	})
+	registry.MustRegister(gauge)
+	return &leaderStatus{identity: identity, gauge: gauge}
+}
+
+func (s *leaderStatus) setLeader(leader bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.leader = leader
+	if leader {
+		s.gauge.Set(1)
+	} else {
+		s.gauge.Set(0)
+	}
+}
+
+func (s *leaderStatus) isLeader() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.leader
+}
+
+// controllerStatus is the response of the status endpoint
+type controllerStatus struct {
+	Identity   string `json:"identity"`
+	Leader     bool   `json:"leader"`
+	Workers    int    `json:"workers"`
+	QueueDepth int    `json:"queueDepth"`
+	Synced     bool   `json:"synced"`
+}
+
+// newStatusHandler returns the handler serving the prometheus metrics on
+// /metrics, the status of the controller on /status and the liveness and
+// readiness checks on /live and /ready.
+func newStatusHandler(registry prometheus.Gatherer, health healthcheck.Handler, c *Controller, status *leaderStatus, workers int) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/live", health.LiveEndpoint)
+	mux.HandleFunc("/ready", health.ReadyEndpoint)
+	mux.HandleFunc("/status", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(controllerStatus{
+			Identity:   status.identity,
+			Leader:     status.isLeader(),
+			Workers:    workers,
+			QueueDepth: c.workqueue.Len(),
+			Synced:     c.HasSynced(),
+		})
+	})
+	return mux
+}
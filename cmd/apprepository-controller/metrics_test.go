@@ -0,0 +1,124 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptiolabs/healthcheck"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func Test_workqueueMetricsProvider(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	provider := newWorkqueueMetricsProvider(registry)
+	workqueue.SetProvider(provider)
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test")
+	defer queue.ShutDown()
+	queue.Add("kubeapps/stable")
+	queue.Add("kubeapps/bitnami")
+	queue.AddRateLimited("kubeapps/incubator")
+
+	if got, want := testutil.ToFloat64(provider.depth.WithLabelValues("test")), 2.0; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := testutil.ToFloat64(provider.adds.WithLabelValues("test")), 2.0; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := testutil.ToFloat64(provider.retries.WithLabelValues("test")), 1.0; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func Test_leaderStatus(t *testing.T) {
+	status := newLeaderStatus(prometheus.NewRegistry(), "my-pod")
+	if status.isLeader() {
+		t.Errorf("got: leader, want: not leader")
+	}
+	status.setLeader(true)
+	if !status.isLeader() {
+		t.Errorf("got: not leader, want: leader")
+	}
+	if got, want := testutil.ToFloat64(status.gauge), 1.0; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	status.setLeader(false)
+	if got, want := testutil.ToFloat64(status.gauge), 0.0; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func Test_newStatusHandler(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	status := newLeaderStatus(registry, "my-pod")
+	status.setLeader(true)
+	synced := func() bool { return true }
+	controller := &Controller{
+		cronjobsSynced: synced,
+		jobsSynced:     synced,
+		appreposSynced: synced,
+		workqueue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "AppRepositories"),
+	}
+	defer controller.workqueue.ShutDown()
+	controller.workqueue.Add("kubeapps/stable")
+	handler := newStatusHandler(registry, healthcheck.NewHandler(), controller, status, 4)
+
+	t.Run("status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("got: %d, want: %d", got, want)
+		}
+		var got controllerStatus
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		want := controllerStatus{Identity: "my-pod", Leader: true, Workers: 4, QueueDepth: 1, Synced: true}
+		if !cmp.Equal(want, got) {
+			t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+		}
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("got: %d, want: %d", got, want)
+		}
+		if want := `apprepository_controller_leader{identity="my-pod"} 1`; !strings.Contains(w.Body.String(), want) {
+			t.Errorf("got: %q, want to contain: %q", w.Body.String(), want)
+		}
+	})
+
+	for _, path := range []string{"/live", "/ready"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			if got, want := w.Code, http.StatusOK; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
@@ -39,9 +39,10 @@ func Resource(resource string) schema.GroupResource {
 
 var (
 	// SchemeBuilder is the SchemeBuilder for AppRepository
-	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	SchemeBuilder      = runtime.NewSchemeBuilder(addKnownTypes)
+	localSchemeBuilder = &SchemeBuilder
 	// AddToScheme is the function to add to the scheme for AppRepository
-	AddToScheme = SchemeBuilder.AddToScheme
+	AddToScheme = localSchemeBuilder.AddToScheme
 )
 
 // Adds the list of known types to Scheme.
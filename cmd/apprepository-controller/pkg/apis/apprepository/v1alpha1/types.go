@@ -45,12 +45,25 @@ type AppRepositorySpec struct {
 	// in the same namespace as the AppRepository and should be included
 	// automatically for matching images.
 	DockerRegistrySecrets []string `json:"dockerRegistrySecrets,omitempty"`
+	// Mirror stores the chart tarballs in the asset database when syncing,
+	// so that charts can be installed when the repository is unreachable.
+	Mirror bool `json:"mirror,omitempty"`
+	// Webhook enables the endpoint of the controller triggering a sync of
+	// the repository when charts are pushed to it.
+	Webhook *AppRepositoryWebhook `json:"webhook,omitempty"`
 }
 
 // AppRepositoryAuth is the auth for an AppRepository resource
 type AppRepositoryAuth struct {
 	Header   *AppRepositoryAuthHeader `json:"header,omitempty"`
 	CustomCA *AppRepositoryCustomCA   `json:"customCA,omitempty"`
+	// BasicAuth and BearerToken are typed alternatives to the raw Header,
+	// which is used if set.
+	BasicAuth   *AppRepositoryBasicAuth   `json:"basicAuth,omitempty"`
+	BearerToken *AppRepositoryBearerToken `json:"bearerToken,omitempty"`
+	// ClientCert is the client certificate presented to repositories
+	// requiring mutual TLS.
+	ClientCert *AppRepositoryClientCert `json:"clientCert,omitempty"`
 }
 
 type AppRepositoryAuthHeader struct {
@@ -58,11 +71,36 @@ type AppRepositoryAuthHeader struct {
 	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
+type AppRepositoryBasicAuth struct {
+	// Select keys of a secret in the pod's namespace
+	UsernameSecretKeyRef corev1.SecretKeySelector `json:"usernameSecretKeyRef,omitempty"`
+	PasswordSecretKeyRef corev1.SecretKeySelector `json:"passwordSecretKeyRef,omitempty"`
+}
+
+type AppRepositoryBearerToken struct {
+	// Selects a key of a secret in the pod's namespace
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+type AppRepositoryClientCert struct {
+	// Select the PEM encoded certificate and key of a secret in the pod's
+	// namespace
+	CertSecretKeyRef corev1.SecretKeySelector `json:"certSecretKeyRef,omitempty"`
+	KeySecretKeyRef  corev1.SecretKeySelector `json:"keySecretKeyRef,omitempty"`
+}
+
 type AppRepositoryCustomCA struct {
 	// Selects a key of a secret in the pod's namespace
 	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
+// AppRepositoryWebhook is the webhook triggering syncs of an AppRepository
+type AppRepositoryWebhook struct {
+	// Selects a key of a secret in the pod's namespace with the secret used
+	// to sign or authorize the webhook requests
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
 // AppRepositoryStatus is the status for an AppRepository resource
 type AppRepositoryStatus struct {
 	Status string `json:"status"`
@@ -0,0 +1,414 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2018 Bitnami.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by conversion-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	unsafe "unsafe"
+
+	v1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	conversion "k8s.io/apimachinery/pkg/conversion"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	localSchemeBuilder.Register(RegisterConversions)
+}
+
+// RegisterConversions adds conversion functions to the given scheme.
+// Public to allow building arbitrary schemes.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*AppRepository)(nil), (*v1beta1.AppRepository)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepository_To_v1beta1_AppRepository(a.(*AppRepository), b.(*v1beta1.AppRepository), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepository)(nil), (*AppRepository)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepository_To_v1alpha1_AppRepository(a.(*v1beta1.AppRepository), b.(*AppRepository), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryAuth)(nil), (*v1beta1.AppRepositoryAuth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryAuth_To_v1beta1_AppRepositoryAuth(a.(*AppRepositoryAuth), b.(*v1beta1.AppRepositoryAuth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryAuth)(nil), (*AppRepositoryAuth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryAuth_To_v1alpha1_AppRepositoryAuth(a.(*v1beta1.AppRepositoryAuth), b.(*AppRepositoryAuth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryAuthHeader)(nil), (*v1beta1.AppRepositoryAuthHeader)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryAuthHeader_To_v1beta1_AppRepositoryAuthHeader(a.(*AppRepositoryAuthHeader), b.(*v1beta1.AppRepositoryAuthHeader), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryAuthHeader)(nil), (*AppRepositoryAuthHeader)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryAuthHeader_To_v1alpha1_AppRepositoryAuthHeader(a.(*v1beta1.AppRepositoryAuthHeader), b.(*AppRepositoryAuthHeader), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryBasicAuth)(nil), (*v1beta1.AppRepositoryBasicAuth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryBasicAuth_To_v1beta1_AppRepositoryBasicAuth(a.(*AppRepositoryBasicAuth), b.(*v1beta1.AppRepositoryBasicAuth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryBasicAuth)(nil), (*AppRepositoryBasicAuth)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryBasicAuth_To_v1alpha1_AppRepositoryBasicAuth(a.(*v1beta1.AppRepositoryBasicAuth), b.(*AppRepositoryBasicAuth), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryBearerToken)(nil), (*v1beta1.AppRepositoryBearerToken)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryBearerToken_To_v1beta1_AppRepositoryBearerToken(a.(*AppRepositoryBearerToken), b.(*v1beta1.AppRepositoryBearerToken), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryBearerToken)(nil), (*AppRepositoryBearerToken)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryBearerToken_To_v1alpha1_AppRepositoryBearerToken(a.(*v1beta1.AppRepositoryBearerToken), b.(*AppRepositoryBearerToken), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryClientCert)(nil), (*v1beta1.AppRepositoryClientCert)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryClientCert_To_v1beta1_AppRepositoryClientCert(a.(*AppRepositoryClientCert), b.(*v1beta1.AppRepositoryClientCert), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryClientCert)(nil), (*AppRepositoryClientCert)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryClientCert_To_v1alpha1_AppRepositoryClientCert(a.(*v1beta1.AppRepositoryClientCert), b.(*AppRepositoryClientCert), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryCustomCA)(nil), (*v1beta1.AppRepositoryCustomCA)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryCustomCA_To_v1beta1_AppRepositoryCustomCA(a.(*AppRepositoryCustomCA), b.(*v1beta1.AppRepositoryCustomCA), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryCustomCA)(nil), (*AppRepositoryCustomCA)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryCustomCA_To_v1alpha1_AppRepositoryCustomCA(a.(*v1beta1.AppRepositoryCustomCA), b.(*AppRepositoryCustomCA), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryList)(nil), (*v1beta1.AppRepositoryList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryList_To_v1beta1_AppRepositoryList(a.(*AppRepositoryList), b.(*v1beta1.AppRepositoryList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryList)(nil), (*AppRepositoryList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryList_To_v1alpha1_AppRepositoryList(a.(*v1beta1.AppRepositoryList), b.(*AppRepositoryList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositorySpec)(nil), (*v1beta1.AppRepositorySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositorySpec_To_v1beta1_AppRepositorySpec(a.(*AppRepositorySpec), b.(*v1beta1.AppRepositorySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositorySpec)(nil), (*AppRepositorySpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositorySpec_To_v1alpha1_AppRepositorySpec(a.(*v1beta1.AppRepositorySpec), b.(*AppRepositorySpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryStatus)(nil), (*v1beta1.AppRepositoryStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryStatus_To_v1beta1_AppRepositoryStatus(a.(*AppRepositoryStatus), b.(*v1beta1.AppRepositoryStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryStatus)(nil), (*AppRepositoryStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryStatus_To_v1alpha1_AppRepositoryStatus(a.(*v1beta1.AppRepositoryStatus), b.(*AppRepositoryStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AppRepositoryWebhook)(nil), (*v1beta1.AppRepositoryWebhook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AppRepositoryWebhook_To_v1beta1_AppRepositoryWebhook(a.(*AppRepositoryWebhook), b.(*v1beta1.AppRepositoryWebhook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.AppRepositoryWebhook)(nil), (*AppRepositoryWebhook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AppRepositoryWebhook_To_v1alpha1_AppRepositoryWebhook(a.(*v1beta1.AppRepositoryWebhook), b.(*AppRepositoryWebhook), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoConvert_v1alpha1_AppRepository_To_v1beta1_AppRepository(in *AppRepository, out *v1beta1.AppRepository, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha1_AppRepositorySpec_To_v1beta1_AppRepositorySpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1alpha1_AppRepositoryStatus_To_v1beta1_AppRepositoryStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_AppRepository_To_v1beta1_AppRepository is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepository_To_v1beta1_AppRepository(in *AppRepository, out *v1beta1.AppRepository, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepository_To_v1beta1_AppRepository(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepository_To_v1alpha1_AppRepository(in *v1beta1.AppRepository, out *AppRepository, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_AppRepositorySpec_To_v1alpha1_AppRepositorySpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_AppRepositoryStatus_To_v1alpha1_AppRepositoryStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_AppRepository_To_v1alpha1_AppRepository is an autogenerated conversion function.
+func Convert_v1beta1_AppRepository_To_v1alpha1_AppRepository(in *v1beta1.AppRepository, out *AppRepository, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepository_To_v1alpha1_AppRepository(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryAuth_To_v1beta1_AppRepositoryAuth(in *AppRepositoryAuth, out *v1beta1.AppRepositoryAuth, s conversion.Scope) error {
+	out.Header = (*v1beta1.AppRepositoryAuthHeader)(unsafe.Pointer(in.Header))
+	out.CustomCA = (*v1beta1.AppRepositoryCustomCA)(unsafe.Pointer(in.CustomCA))
+	out.BasicAuth = (*v1beta1.AppRepositoryBasicAuth)(unsafe.Pointer(in.BasicAuth))
+	out.BearerToken = (*v1beta1.AppRepositoryBearerToken)(unsafe.Pointer(in.BearerToken))
+	out.ClientCert = (*v1beta1.AppRepositoryClientCert)(unsafe.Pointer(in.ClientCert))
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryAuth_To_v1beta1_AppRepositoryAuth is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryAuth_To_v1beta1_AppRepositoryAuth(in *AppRepositoryAuth, out *v1beta1.AppRepositoryAuth, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryAuth_To_v1beta1_AppRepositoryAuth(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryAuth_To_v1alpha1_AppRepositoryAuth(in *v1beta1.AppRepositoryAuth, out *AppRepositoryAuth, s conversion.Scope) error {
+	out.Header = (*AppRepositoryAuthHeader)(unsafe.Pointer(in.Header))
+	out.CustomCA = (*AppRepositoryCustomCA)(unsafe.Pointer(in.CustomCA))
+	out.BasicAuth = (*AppRepositoryBasicAuth)(unsafe.Pointer(in.BasicAuth))
+	out.BearerToken = (*AppRepositoryBearerToken)(unsafe.Pointer(in.BearerToken))
+	out.ClientCert = (*AppRepositoryClientCert)(unsafe.Pointer(in.ClientCert))
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryAuth_To_v1alpha1_AppRepositoryAuth is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryAuth_To_v1alpha1_AppRepositoryAuth(in *v1beta1.AppRepositoryAuth, out *AppRepositoryAuth, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryAuth_To_v1alpha1_AppRepositoryAuth(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryAuthHeader_To_v1beta1_AppRepositoryAuthHeader(in *AppRepositoryAuthHeader, out *v1beta1.AppRepositoryAuthHeader, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryAuthHeader_To_v1beta1_AppRepositoryAuthHeader is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryAuthHeader_To_v1beta1_AppRepositoryAuthHeader(in *AppRepositoryAuthHeader, out *v1beta1.AppRepositoryAuthHeader, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryAuthHeader_To_v1beta1_AppRepositoryAuthHeader(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryAuthHeader_To_v1alpha1_AppRepositoryAuthHeader(in *v1beta1.AppRepositoryAuthHeader, out *AppRepositoryAuthHeader, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryAuthHeader_To_v1alpha1_AppRepositoryAuthHeader is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryAuthHeader_To_v1alpha1_AppRepositoryAuthHeader(in *v1beta1.AppRepositoryAuthHeader, out *AppRepositoryAuthHeader, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryAuthHeader_To_v1alpha1_AppRepositoryAuthHeader(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryBasicAuth_To_v1beta1_AppRepositoryBasicAuth(in *AppRepositoryBasicAuth, out *v1beta1.AppRepositoryBasicAuth, s conversion.Scope) error {
+	out.UsernameSecretKeyRef = in.UsernameSecretKeyRef
+	out.PasswordSecretKeyRef = in.PasswordSecretKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryBasicAuth_To_v1beta1_AppRepositoryBasicAuth is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryBasicAuth_To_v1beta1_AppRepositoryBasicAuth(in *AppRepositoryBasicAuth, out *v1beta1.AppRepositoryBasicAuth, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryBasicAuth_To_v1beta1_AppRepositoryBasicAuth(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryBasicAuth_To_v1alpha1_AppRepositoryBasicAuth(in *v1beta1.AppRepositoryBasicAuth, out *AppRepositoryBasicAuth, s conversion.Scope) error {
+	out.UsernameSecretKeyRef = in.UsernameSecretKeyRef
+	out.PasswordSecretKeyRef = in.PasswordSecretKeyRef
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryBasicAuth_To_v1alpha1_AppRepositoryBasicAuth is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryBasicAuth_To_v1alpha1_AppRepositoryBasicAuth(in *v1beta1.AppRepositoryBasicAuth, out *AppRepositoryBasicAuth, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryBasicAuth_To_v1alpha1_AppRepositoryBasicAuth(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryBearerToken_To_v1beta1_AppRepositoryBearerToken(in *AppRepositoryBearerToken, out *v1beta1.AppRepositoryBearerToken, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryBearerToken_To_v1beta1_AppRepositoryBearerToken is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryBearerToken_To_v1beta1_AppRepositoryBearerToken(in *AppRepositoryBearerToken, out *v1beta1.AppRepositoryBearerToken, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryBearerToken_To_v1beta1_AppRepositoryBearerToken(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryBearerToken_To_v1alpha1_AppRepositoryBearerToken(in *v1beta1.AppRepositoryBearerToken, out *AppRepositoryBearerToken, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryBearerToken_To_v1alpha1_AppRepositoryBearerToken is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryBearerToken_To_v1alpha1_AppRepositoryBearerToken(in *v1beta1.AppRepositoryBearerToken, out *AppRepositoryBearerToken, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryBearerToken_To_v1alpha1_AppRepositoryBearerToken(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryClientCert_To_v1beta1_AppRepositoryClientCert(in *AppRepositoryClientCert, out *v1beta1.AppRepositoryClientCert, s conversion.Scope) error {
+	out.CertSecretKeyRef = in.CertSecretKeyRef
+	out.KeySecretKeyRef = in.KeySecretKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryClientCert_To_v1beta1_AppRepositoryClientCert is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryClientCert_To_v1beta1_AppRepositoryClientCert(in *AppRepositoryClientCert, out *v1beta1.AppRepositoryClientCert, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryClientCert_To_v1beta1_AppRepositoryClientCert(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryClientCert_To_v1alpha1_AppRepositoryClientCert(in *v1beta1.AppRepositoryClientCert, out *AppRepositoryClientCert, s conversion.Scope) error {
+	out.CertSecretKeyRef = in.CertSecretKeyRef
+	out.KeySecretKeyRef = in.KeySecretKeyRef
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryClientCert_To_v1alpha1_AppRepositoryClientCert is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryClientCert_To_v1alpha1_AppRepositoryClientCert(in *v1beta1.AppRepositoryClientCert, out *AppRepositoryClientCert, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryClientCert_To_v1alpha1_AppRepositoryClientCert(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryCustomCA_To_v1beta1_AppRepositoryCustomCA(in *AppRepositoryCustomCA, out *v1beta1.AppRepositoryCustomCA, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryCustomCA_To_v1beta1_AppRepositoryCustomCA is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryCustomCA_To_v1beta1_AppRepositoryCustomCA(in *AppRepositoryCustomCA, out *v1beta1.AppRepositoryCustomCA, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryCustomCA_To_v1beta1_AppRepositoryCustomCA(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryCustomCA_To_v1alpha1_AppRepositoryCustomCA(in *v1beta1.AppRepositoryCustomCA, out *AppRepositoryCustomCA, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryCustomCA_To_v1alpha1_AppRepositoryCustomCA is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryCustomCA_To_v1alpha1_AppRepositoryCustomCA(in *v1beta1.AppRepositoryCustomCA, out *AppRepositoryCustomCA, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryCustomCA_To_v1alpha1_AppRepositoryCustomCA(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryList_To_v1beta1_AppRepositoryList(in *AppRepositoryList, out *v1beta1.AppRepositoryList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	out.Items = *(*[]v1beta1.AppRepository)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryList_To_v1beta1_AppRepositoryList is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryList_To_v1beta1_AppRepositoryList(in *AppRepositoryList, out *v1beta1.AppRepositoryList, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryList_To_v1beta1_AppRepositoryList(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryList_To_v1alpha1_AppRepositoryList(in *v1beta1.AppRepositoryList, out *AppRepositoryList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	out.Items = *(*[]AppRepository)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryList_To_v1alpha1_AppRepositoryList is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryList_To_v1alpha1_AppRepositoryList(in *v1beta1.AppRepositoryList, out *AppRepositoryList, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryList_To_v1alpha1_AppRepositoryList(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositorySpec_To_v1beta1_AppRepositorySpec(in *AppRepositorySpec, out *v1beta1.AppRepositorySpec, s conversion.Scope) error {
+	out.Type = in.Type
+	out.URL = in.URL
+	if err := Convert_v1alpha1_AppRepositoryAuth_To_v1beta1_AppRepositoryAuth(&in.Auth, &out.Auth, s); err != nil {
+		return err
+	}
+	out.ResyncRequests = in.ResyncRequests
+	out.SyncJobPodTemplate = in.SyncJobPodTemplate
+	out.DockerRegistrySecrets = *(*[]string)(unsafe.Pointer(&in.DockerRegistrySecrets))
+	out.Mirror = in.Mirror
+	out.Webhook = (*v1beta1.AppRepositoryWebhook)(unsafe.Pointer(in.Webhook))
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositorySpec_To_v1beta1_AppRepositorySpec is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositorySpec_To_v1beta1_AppRepositorySpec(in *AppRepositorySpec, out *v1beta1.AppRepositorySpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositorySpec_To_v1beta1_AppRepositorySpec(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositorySpec_To_v1alpha1_AppRepositorySpec(in *v1beta1.AppRepositorySpec, out *AppRepositorySpec, s conversion.Scope) error {
+	out.Type = in.Type
+	out.URL = in.URL
+	if err := Convert_v1beta1_AppRepositoryAuth_To_v1alpha1_AppRepositoryAuth(&in.Auth, &out.Auth, s); err != nil {
+		return err
+	}
+	out.ResyncRequests = in.ResyncRequests
+	out.SyncJobPodTemplate = in.SyncJobPodTemplate
+	out.DockerRegistrySecrets = *(*[]string)(unsafe.Pointer(&in.DockerRegistrySecrets))
+	out.Mirror = in.Mirror
+	out.Webhook = (*AppRepositoryWebhook)(unsafe.Pointer(in.Webhook))
+	return nil
+}
+
+// Convert_v1beta1_AppRepositorySpec_To_v1alpha1_AppRepositorySpec is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositorySpec_To_v1alpha1_AppRepositorySpec(in *v1beta1.AppRepositorySpec, out *AppRepositorySpec, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositorySpec_To_v1alpha1_AppRepositorySpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryStatus_To_v1beta1_AppRepositoryStatus(in *AppRepositoryStatus, out *v1beta1.AppRepositoryStatus, s conversion.Scope) error {
+	out.Status = in.Status
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryStatus_To_v1beta1_AppRepositoryStatus is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryStatus_To_v1beta1_AppRepositoryStatus(in *AppRepositoryStatus, out *v1beta1.AppRepositoryStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryStatus_To_v1beta1_AppRepositoryStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryStatus_To_v1alpha1_AppRepositoryStatus(in *v1beta1.AppRepositoryStatus, out *AppRepositoryStatus, s conversion.Scope) error {
+	out.Status = in.Status
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryStatus_To_v1alpha1_AppRepositoryStatus is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryStatus_To_v1alpha1_AppRepositoryStatus(in *v1beta1.AppRepositoryStatus, out *AppRepositoryStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryStatus_To_v1alpha1_AppRepositoryStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_AppRepositoryWebhook_To_v1beta1_AppRepositoryWebhook(in *AppRepositoryWebhook, out *v1beta1.AppRepositoryWebhook, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_AppRepositoryWebhook_To_v1beta1_AppRepositoryWebhook is an autogenerated conversion function.
+func Convert_v1alpha1_AppRepositoryWebhook_To_v1beta1_AppRepositoryWebhook(in *AppRepositoryWebhook, out *v1beta1.AppRepositoryWebhook, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AppRepositoryWebhook_To_v1beta1_AppRepositoryWebhook(in, out, s)
+}
+
+func autoConvert_v1beta1_AppRepositoryWebhook_To_v1alpha1_AppRepositoryWebhook(in *v1beta1.AppRepositoryWebhook, out *AppRepositoryWebhook, s conversion.Scope) error {
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1beta1_AppRepositoryWebhook_To_v1alpha1_AppRepositoryWebhook is an autogenerated conversion function.
+func Convert_v1beta1_AppRepositoryWebhook_To_v1alpha1_AppRepositoryWebhook(in *v1beta1.AppRepositoryWebhook, out *AppRepositoryWebhook, s conversion.Scope) error {
+	return autoConvert_v1beta1_AppRepositoryWebhook_To_v1alpha1_AppRepositoryWebhook(in, out, s)
+}
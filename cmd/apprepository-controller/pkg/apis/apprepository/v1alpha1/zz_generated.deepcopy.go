@@ -73,6 +73,33 @@ func (in *AppRepositoryAuth) DeepCopyInto(out *AppRepositoryAuth) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.BasicAuth != nil {
+		in, out := &in.BasicAuth, &out.BasicAuth
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AppRepositoryBasicAuth)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.BearerToken != nil {
+		in, out := &in.BearerToken, &out.BearerToken
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AppRepositoryBearerToken)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.ClientCert != nil {
+		in, out := &in.ClientCert, &out.ClientCert
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AppRepositoryClientCert)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -103,6 +130,59 @@ func (in *AppRepositoryAuthHeader) DeepCopy() *AppRepositoryAuthHeader {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppRepositoryBasicAuth) DeepCopyInto(out *AppRepositoryBasicAuth) {
+	*out = *in
+	in.UsernameSecretKeyRef.DeepCopyInto(&out.UsernameSecretKeyRef)
+	in.PasswordSecretKeyRef.DeepCopyInto(&out.PasswordSecretKeyRef)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRepositoryBasicAuth.
+func (in *AppRepositoryBasicAuth) DeepCopy() *AppRepositoryBasicAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(AppRepositoryBasicAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppRepositoryBearerToken) DeepCopyInto(out *AppRepositoryBearerToken) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRepositoryBearerToken.
+func (in *AppRepositoryBearerToken) DeepCopy() *AppRepositoryBearerToken {
+	if in == nil {
+		return nil
+	}
+	out := new(AppRepositoryBearerToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppRepositoryClientCert) DeepCopyInto(out *AppRepositoryClientCert) {
+	*out = *in
+	in.CertSecretKeyRef.DeepCopyInto(&out.CertSecretKeyRef)
+	in.KeySecretKeyRef.DeepCopyInto(&out.KeySecretKeyRef)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRepositoryClientCert.
+func (in *AppRepositoryClientCert) DeepCopy() *AppRepositoryClientCert {
+	if in == nil {
+		return nil
+	}
+	out := new(AppRepositoryClientCert)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppRepositoryCustomCA) DeepCopyInto(out *AppRepositoryCustomCA) {
 	*out = *in
@@ -157,6 +237,11 @@ func (in *AppRepositoryList) DeepCopyObject() runtime.Object {
 func (in *AppRepositorySpec) DeepCopyInto(out *AppRepositorySpec) {
 	*out = *in
 	in.Auth.DeepCopyInto(&out.Auth)
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(AppRepositoryWebhook)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -185,3 +270,20 @@ func (in *AppRepositoryStatus) DeepCopy() *AppRepositoryStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppRepositoryWebhook) DeepCopyInto(out *AppRepositoryWebhook) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRepositoryWebhook.
+func (in *AppRepositoryWebhook) DeepCopy() *AppRepositoryWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(AppRepositoryWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2020 Bitnami.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DefaultType is the type of the AppRepositories which do not set one
+const DefaultType = "helm"
+
+func addDefaultingFuncs(scheme *runtime.Scheme) error {
+	return RegisterDefaults(scheme)
+}
+
+// SetDefaults_AppRepositorySpec sets the type of the repository, as the CRD
+// schema does for the requests to the API server.
+func SetDefaults_AppRepositorySpec(obj *AppRepositorySpec) {
+	if obj.Type == "" {
+		obj.Type = DefaultType
+	}
+}
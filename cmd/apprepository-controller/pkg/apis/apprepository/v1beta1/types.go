@@ -0,0 +1,122 @@
+/*
+Copyright 2020 Bitnami.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AppRepository is a specification for an AppRepository resource. Its status
+// is a subresource.
+type AppRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AppRepositorySpec   `json:"spec"`
+	Status AppRepositoryStatus `json:"status"`
+}
+
+// AppRepositorySpec is the spec for an AppRepository resource
+type AppRepositorySpec struct {
+	// Type of the repository, only "helm" is supported
+	Type string `json:"type"`
+	// URL of the repository, with its index.yaml
+	URL  string            `json:"url"`
+	Auth AppRepositoryAuth `json:"auth,omitempty"`
+	// ResyncRequests is incremented to request a sync of the repository
+	ResyncRequests uint `json:"resyncRequests"`
+	// SyncJobPodTemplate is completed with the sync container, which is the
+	// first of its containers
+	SyncJobPodTemplate corev1.PodTemplateSpec `json:"syncJobPodTemplate"`
+	// DockerRegistrySecrets is a list of dockerconfigjson secrets which exist
+	// in the same namespace as the AppRepository and should be included
+	// automatically for matching images.
+	DockerRegistrySecrets []string `json:"dockerRegistrySecrets,omitempty"`
+	// Mirror stores the chart tarballs in the asset database when syncing,
+	// so that charts can be installed when the repository is unreachable.
+	Mirror bool `json:"mirror,omitempty"`
+	// Webhook enables the endpoint of the controller triggering a sync of
+	// the repository when charts are pushed to it.
+	Webhook *AppRepositoryWebhook `json:"webhook,omitempty"`
+}
+
+// AppRepositoryAuth is the auth for an AppRepository resource
+type AppRepositoryAuth struct {
+	Header   *AppRepositoryAuthHeader `json:"header,omitempty"`
+	CustomCA *AppRepositoryCustomCA   `json:"customCA,omitempty"`
+	// BasicAuth and BearerToken are typed alternatives to the raw Header,
+	// which is used if set.
+	BasicAuth   *AppRepositoryBasicAuth   `json:"basicAuth,omitempty"`
+	BearerToken *AppRepositoryBearerToken `json:"bearerToken,omitempty"`
+	// ClientCert is the client certificate presented to repositories
+	// requiring mutual TLS.
+	ClientCert *AppRepositoryClientCert `json:"clientCert,omitempty"`
+}
+
+type AppRepositoryAuthHeader struct {
+	// Selects a key of a secret in the pod's namespace
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+type AppRepositoryBasicAuth struct {
+	// Select keys of a secret in the pod's namespace
+	UsernameSecretKeyRef corev1.SecretKeySelector `json:"usernameSecretKeyRef,omitempty"`
+	PasswordSecretKeyRef corev1.SecretKeySelector `json:"passwordSecretKeyRef,omitempty"`
+}
+
+type AppRepositoryBearerToken struct {
+	// Selects a key of a secret in the pod's namespace
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+type AppRepositoryClientCert struct {
+	// Select the PEM encoded certificate and key of a secret in the pod's
+	// namespace
+	CertSecretKeyRef corev1.SecretKeySelector `json:"certSecretKeyRef,omitempty"`
+	KeySecretKeyRef  corev1.SecretKeySelector `json:"keySecretKeyRef,omitempty"`
+}
+
+type AppRepositoryCustomCA struct {
+	// Selects a key of a secret in the pod's namespace
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// AppRepositoryWebhook is the webhook triggering syncs of an AppRepository
+type AppRepositoryWebhook struct {
+	// Selects a key of a secret in the pod's namespace with the secret used
+	// to sign or authorize the webhook requests
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// AppRepositoryStatus is the status for an AppRepository resource
+type AppRepositoryStatus struct {
+	Status string `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AppRepositoryList is a list of AppRepository resources
+type AppRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AppRepository `json:"items"`
+}
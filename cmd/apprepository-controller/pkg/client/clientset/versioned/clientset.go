@@ -19,8 +19,10 @@ limitations under the License.
 package versioned
 
 import (
-	log "github.com/sirupsen/logrus"
+	"fmt"
+
 	kubeappsv1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned/typed/apprepository/v1alpha1"
+	kubeappsv1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned/typed/apprepository/v1beta1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -29,8 +31,7 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	KubeappsV1alpha1() kubeappsv1alpha1.KubeappsV1alpha1Interface
-	// Deprecated: please explicitly pick a version if possible.
-	Kubeapps() kubeappsv1alpha1.KubeappsV1alpha1Interface
+	KubeappsV1beta1() kubeappsv1beta1.KubeappsV1beta1Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
@@ -38,6 +39,7 @@ type Interface interface {
 type Clientset struct {
 	*discovery.DiscoveryClient
 	kubeappsV1alpha1 *kubeappsv1alpha1.KubeappsV1alpha1Client
+	kubeappsV1beta1  *kubeappsv1beta1.KubeappsV1beta1Client
 }
 
 // KubeappsV1alpha1 retrieves the KubeappsV1alpha1Client
@@ -45,10 +47,9 @@ func (c *Clientset) KubeappsV1alpha1() kubeappsv1alpha1.KubeappsV1alpha1Interfac
 	return c.kubeappsV1alpha1
 }
 
-// Deprecated: Kubeapps retrieves the default version of KubeappsClient.
-// Please explicitly pick a version.
-func (c *Clientset) Kubeapps() kubeappsv1alpha1.KubeappsV1alpha1Interface {
-	return c.kubeappsV1alpha1
+// KubeappsV1beta1 retrieves the KubeappsV1beta1Client
+func (c *Clientset) KubeappsV1beta1() kubeappsv1beta1.KubeappsV1beta1Interface {
+	return c.kubeappsV1beta1
 }
 
 // Discovery retrieves the DiscoveryClient
@@ -60,9 +61,14 @@ func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 }
 
 // NewForConfig creates a new Clientset for the given config.
+// If config's RateLimiter is not set and QPS and Burst are acceptable,
+// NewForConfig will generate a rate-limiter in configShallowCopy.
 func NewForConfig(c *rest.Config) (*Clientset, error) {
 	configShallowCopy := *c
 	if configShallowCopy.RateLimiter == nil && configShallowCopy.QPS > 0 {
+		if configShallowCopy.Burst <= 0 {
+			return nil, fmt.Errorf("Burst is required to be greater than 0 when RateLimiter is not set and QPS is set to greater than 0")
+		}
 		configShallowCopy.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(configShallowCopy.QPS, configShallowCopy.Burst)
 	}
 	var cs Clientset
@@ -71,10 +77,13 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.kubeappsV1beta1, err = kubeappsv1beta1.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
-		log.Errorf("failed to create the DiscoveryClient: %v", err)
 		return nil, err
 	}
 	return &cs, nil
@@ -85,6 +94,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.kubeappsV1alpha1 = kubeappsv1alpha1.NewForConfigOrDie(c)
+	cs.kubeappsV1beta1 = kubeappsv1beta1.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -94,6 +104,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.kubeappsV1alpha1 = kubeappsv1alpha1.New(c)
+	cs.kubeappsV1beta1 = kubeappsv1beta1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
@@ -62,7 +62,7 @@ func (c *FakeAppRepositories) List(opts v1.ListOptions) (result *v1alpha1.AppRep
 	if label == nil {
 		label = labels.Everything()
 	}
-	list := &v1alpha1.AppRepositoryList{}
+	list := &v1alpha1.AppRepositoryList{ListMeta: obj.(*v1alpha1.AppRepositoryList).ListMeta}
 	for _, item := range obj.(*v1alpha1.AppRepositoryList).Items {
 		if label.Matches(labels.Set(item.Labels)) {
 			list.Items = append(list.Items, item)
@@ -21,7 +21,7 @@ package v1alpha1
 import (
 	time "time"
 
-	apprepository_v1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	apprepositoryv1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
 	versioned "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned"
 	internalinterfaces "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/informers/externalversions/internalinterfaces"
 	v1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/listers/apprepository/v1alpha1"
@@ -70,7 +70,7 @@ func NewFilteredAppRepositoryInformer(client versioned.Interface, namespace stri
 				return client.KubeappsV1alpha1().AppRepositories(namespace).Watch(options)
 			},
 		},
-		&apprepository_v1alpha1.AppRepository{},
+		&apprepositoryv1alpha1.AppRepository{},
 		resyncPeriod,
 		indexers,
 	)
@@ -81,7 +81,7 @@ func (f *appRepositoryInformer) defaultInformer(client versioned.Interface, resy
 }
 
 func (f *appRepositoryInformer) Informer() cache.SharedIndexInformer {
-	return f.factory.InformerFor(&apprepository_v1alpha1.AppRepository{}, f.defaultInformer)
+	return f.factory.InformerFor(&apprepositoryv1alpha1.AppRepository{}, f.defaultInformer)
 }
 
 func (f *appRepositoryInformer) Lister() v1alpha1.AppRepositoryLister {
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	v1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	v1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -56,6 +57,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	case v1alpha1.SchemeGroupVersion.WithResource("apprepositories"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Kubeapps().V1alpha1().AppRepositories().Informer()}, nil
 
+		// Group=kubeapps.com, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("apprepositories"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kubeapps().V1beta1().AppRepositories().Informer()}, nil
+
 	}
 
 	return nil, fmt.Errorf("no informer found for %v", resource)
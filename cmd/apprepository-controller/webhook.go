@@ -0,0 +1,305 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// webhookPathPrefix is followed by the namespace and name of the
+	// AppRepository to sync
+	webhookPathPrefix = "/webhooks/"
+	// webhookSignatureHeader is the header with the HMAC-SHA256 signature of
+	// the payload, as "sha256=<hex digest>"
+	webhookSignatureHeader = "X-Kubeapps-Signature"
+	maxWebhookPayloadSize  = 1 << 20
+	// minWebhookRequeueDelay is the minimum delay before a sync postponed by
+	// an active Job is tried again
+	minWebhookRequeueDelay = time.Second
+
+	webhookProviderGeneric     = "generic"
+	webhookProviderHarbor      = "harbor"
+	webhookProviderChartMuseum = "chartmuseum"
+)
+
+// harborSyncEvents are the types of the Harbor events changing the charts of
+// a repository, lowercased and without underscores to match the types of the
+// Harbor versions.
+var harborSyncEvents = map[string]bool{
+	"uploadchart":    true,
+	"deletechart":    true,
+	"pushartifact":   true,
+	"deleteartifact": true,
+}
+
+// webhookProviders are the senders whose payloads are understood.
+var webhookProviders = map[string]bool{
+	webhookProviderGeneric:     true,
+	webhookProviderHarbor:      true,
+	webhookProviderChartMuseum: true,
+}
+
+// webhookReceiver triggers a sync of an AppRepository when its webhook is
+// called. The calls are debounced so that a burst of pushes results in a
+// single sync Job, and no Job is created while another sync Job of the
+// AppRepository is active, since every replica receives webhooks. The sync is
+// then created once the active Job finishes.
+type webhookReceiver struct {
+	controller *Controller
+	queue      workqueue.RateLimitingInterface
+	debounce   time.Duration
+}
+
+// webhookResponse is the response to a webhook call
+type webhookResponse struct {
+	Triggered bool   `json:"triggered"`
+	Message   string `json:"message,omitempty"`
+}
+
+func newWebhookReceiver(c *Controller, debounce time.Duration) *webhookReceiver {
+	return &webhookReceiver{
+		controller: c,
+		queue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "AppRepositoryWebhooks"),
+		debounce:   debounce,
+	}
+}
+
+// Run creates the sync Jobs of the AppRepositories whose webhook has been
+// called until stopCh is closed.
+func (r *webhookReceiver) Run(stopCh <-chan struct{}) {
+	defer r.queue.ShutDown()
+	go wait.Until(func() { r.controller.runWorker(r.queue, r.syncHandler) }, time.Second, stopCh)
+	<-stopCh
+}
+
+// syncHandler creates a sync Job for the AppRepository. While one is already
+// pending or running, for instance created by another replica or by the
+// CronJob, the sync is requeued until the Job finishes.
+func (r *webhookReceiver) syncHandler(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	apprepo, err := r.controller.appreposLister.AppRepositories(namespace).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	// The Jobs are listed from the API rather than the informer cache, which
+	// may not have seen the Job just created by another replica yet.
+	selector := labels.Set(jobLabels(apprepo)).AsSelector()
+	jobs, err := r.controller.kubeclientset.BatchV1().Jobs(r.controller.kubeappsNamespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		if finishedCondition(&jobs.Items[i]) == nil {
+			// The active Job may have fetched the index before the push, so
+			// the sync is postponed rather than dropped.
+			log.Infof("Postponing the sync triggered by the webhook of AppRepository %q in namespace %q since Job %q is active", name, namespace, jobs.Items[i].GetName())
+			r.queue.AddAfter(key, r.requeueDelay())
+			return nil
+		}
+	}
+	log.Infof("Creating a sync Job for AppRepository %q in namespace %q triggered by its webhook", name, namespace)
+	_, err = r.controller.kubeclientset.BatchV1().Jobs(r.controller.kubeappsNamespace).Create(newSyncJob(apprepo, r.controller.kubeappsNamespace))
+	return err
+}
+
+// requeueDelay returns the delay before checking again whether the sync Job
+// of an AppRepository is still active, at least a second so that an active
+// Job is not polled continuously when the calls are not debounced.
+func (r *webhookReceiver) requeueDelay() time.Duration {
+	if r.debounce < minWebhookRequeueDelay {
+		return minWebhookRequeueDelay
+	}
+	return r.debounce
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST requests are accepted", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, webhookPathPrefix), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.NotFound(w, req)
+		return
+	}
+	namespace, name := parts[0], parts[1]
+	provider := req.URL.Query().Get("provider")
+	if provider == "" {
+		provider = webhookProviderGeneric
+	}
+	if !webhookProviders[provider] {
+		http.Error(w, fmt.Sprintf("unknown webhook provider %q", provider), http.StatusBadRequest)
+		return
+	}
+	// The requests without credentials are rejected before reading the
+	// payload or the secret.
+	if req.Header.Get(webhookSignatureHeader) == "" && req.Header.Get("Authorization") == "" {
+		unauthorizedWebhook(w)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxWebhookPayloadSize+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read the payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxWebhookPayloadSize {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// The unknown AppRepositories, or those without a webhook, get the same
+	// response as an invalid signature so that they are not disclosed.
+	apprepo, err := r.controller.appreposLister.AppRepositories(namespace).Get(name)
+	if err != nil || apprepo.Spec.Webhook == nil {
+		unauthorizedWebhook(w)
+		return
+	}
+	secret, err := r.webhookSecret(apprepo)
+	if err != nil {
+		log.Errorf("Unable to get the webhook secret of AppRepository %q in namespace %q: %v", name, namespace, err)
+		unauthorizedWebhook(w)
+		return
+	}
+	if !authorizedWebhook(secret, req, body) {
+		unauthorizedWebhook(w)
+		return
+	}
+
+	trigger, err := webhookTriggersSync(provider, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response := webhookResponse{Triggered: trigger}
+	status := http.StatusOK
+	if trigger {
+		key, _ := cache.MetaNamespaceKeyFunc(apprepo)
+		// A key already waiting is not added again, so the pushes within the
+		// debounce period trigger a single sync.
+		r.queue.AddAfter(key, r.debounce)
+		status = http.StatusAccepted
+	} else {
+		response.Message = "the event does not change the charts of the repository"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func unauthorizedWebhook(w http.ResponseWriter) {
+	http.Error(w, "invalid signature or authorization", http.StatusUnauthorized)
+}
+
+// webhookSecret returns the secret of the webhook of an AppRepository, read
+// from the namespace of the AppRepository as the admission webhook checks it.
+// Unlike the secrets of its auth, it is not copied to the kubeapps namespace.
+func (r *webhookReceiver) webhookSecret(apprepo *apprepov1beta1.AppRepository) ([]byte, error) {
+	keyRef := apprepo.Spec.Webhook.SecretKeyRef
+	secret, err := r.controller.kubeclientset.CoreV1().Secrets(apprepo.Namespace).Get(keyRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	value := bytes.TrimSpace(secret.Data[keyRef.Key])
+	if len(value) == 0 {
+		return nil, fmt.Errorf("key %q of secret %q is empty", keyRef.Key, keyRef.Name)
+	}
+	return value, nil
+}
+
+// authorizedWebhook returns whether the request is signed with the secret or,
+// for the senders which cannot sign their payloads like Harbor, whether its
+// Authorization header is the secret.
+func authorizedWebhook(secret []byte, req *http.Request, body []byte) bool {
+	if signature := req.Header.Get(webhookSignatureHeader); signature != "" {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(signature), []byte(expected))
+	}
+	auth := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return auth != "" && subtle.ConstantTimeCompare([]byte(auth), secret) == 1
+}
+
+// webhookTriggersSync returns whether the payload of a provider is an event
+// changing the charts of the repository.
+func webhookTriggersSync(provider string, body []byte) (bool, error) {
+	switch provider {
+	case webhookProviderGeneric:
+		// Any payload triggers a sync, but for ping events.
+		var event struct {
+			Event string `json:"event"`
+		}
+		if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &event) != nil {
+			return true, nil
+		}
+		return event.Event != "ping", nil
+	case webhookProviderHarbor:
+		var event struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return false, fmt.Errorf("invalid Harbor payload: %v", err)
+		}
+		if event.Type == "" {
+			return false, fmt.Errorf("invalid Harbor payload: missing event type")
+		}
+		return harborSyncEvents[strings.Replace(strings.ToLower(event.Type), "_", "", -1)], nil
+	case webhookProviderChartMuseum:
+		// ChartMuseum clients post the chart version they uploaded.
+		var chart struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(body, &chart); err != nil {
+			return false, fmt.Errorf("invalid ChartMuseum payload: %v", err)
+		}
+		if chart.Name == "" {
+			return false, fmt.Errorf("invalid ChartMuseum payload: missing chart name")
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown webhook provider %q", provider)
+	}
+}
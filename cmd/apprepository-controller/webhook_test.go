@@ -0,0 +1,392 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apprepov1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Test_authorizedWebhook(t *testing.T) {
+	const body = `{"event": "push"}`
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"valid signature", map[string]string{webhookSignatureHeader: sign("s3cret", body)}, true},
+		{"signature with another secret", map[string]string{webhookSignatureHeader: sign("other", body)}, false},
+		{"invalid signature ignores the authorization", map[string]string{webhookSignatureHeader: "sha256=abc", "Authorization": "s3cret"}, false},
+		{"authorization header", map[string]string{"Authorization": "s3cret"}, true},
+		{"bearer authorization header", map[string]string{"Authorization": "Bearer s3cret"}, true},
+		{"wrong authorization header", map[string]string{"Authorization": "Bearer other"}, false},
+		{"no signature nor authorization", map[string]string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/webhooks/kubeapps/my-charts", strings.NewReader(body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got, want := authorizedWebhook([]byte("s3cret"), req, []byte(body)), tt.want; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+		})
+	}
+}
+
+func Test_webhookTriggersSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+		want     bool
+		wantErr  bool
+	}{
+		{"generic empty payload", webhookProviderGeneric, "", true, false},
+		{"generic push", webhookProviderGeneric, `{"event": "push"}`, true, false},
+		{"generic ping", webhookProviderGeneric, `{"event": "ping"}`, false, false},
+		{"generic non json payload", webhookProviderGeneric, "pushed", true, false},
+		{"harbor chart upload", webhookProviderHarbor, `{"type": "UPLOAD_CHART", "event_data": {"repository": {"name": "my-chart"}}}`, true, false},
+		{"harbor artifact push", webhookProviderHarbor, `{"type": "PUSH_ARTIFACT"}`, true, false},
+		{"harbor chart download", webhookProviderHarbor, `{"type": "DOWNLOAD_CHART"}`, false, false},
+		{"harbor without type", webhookProviderHarbor, `{}`, false, true},
+		{"harbor invalid payload", webhookProviderHarbor, `pushed`, false, true},
+		{"chartmuseum upload", webhookProviderChartMuseum, `{"name": "my-chart", "version": "1.0.0"}`, true, false},
+		{"chartmuseum without name", webhookProviderChartMuseum, `{"version": "1.0.0"}`, false, true},
+		{"unknown provider", "quay", `{}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := webhookTriggersSync(tt.provider, []byte(tt.body))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error: %v, want error: %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got: %t, want: %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhookReceiver(t *testing.T) {
+	webhookRepo := &apprepov1beta1.AppRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"},
+		Spec: apprepov1beta1.AppRepositorySpec{
+			URL: "https://charts.acme.com/my-charts",
+			Webhook: &apprepov1beta1.AppRepositoryWebhook{
+				SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "my-charts-webhook"}, Key: "secret"},
+			},
+		},
+	}
+	otherNamespaceRepo := webhookRepo.DeepCopy()
+	otherNamespaceRepo.Namespace = "other-namespace"
+	noWebhookRepo := &apprepov1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "no-webhook", Namespace: "kubeapps"}}
+	const pushBody = `{"name": "my-chart", "version": "1.0.0"}`
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		headers       map[string]string
+		body          string
+		wantStatus    int
+		wantTriggered bool
+	}{
+		{
+			"signed push",
+			"POST", "/webhooks/kubeapps/my-charts?provider=chartmuseum",
+			map[string]string{webhookSignatureHeader: sign("s3cret", pushBody)},
+			pushBody,
+			http.StatusAccepted,
+			true,
+		},
+		{
+			"push of a repository in another namespace",
+			"POST", "/webhooks/other-namespace/my-charts?provider=chartmuseum",
+			map[string]string{webhookSignatureHeader: sign("other-s3cret", pushBody)},
+			pushBody,
+			http.StatusAccepted,
+			true,
+		},
+		{
+			"push of a repository in another namespace signed with a secret of the kubeapps namespace",
+			"POST", "/webhooks/other-namespace/my-charts?provider=chartmuseum",
+			map[string]string{webhookSignatureHeader: sign("s3cret", pushBody)},
+			pushBody,
+			http.StatusUnauthorized,
+			false,
+		},
+		{
+			"harbor event ignored",
+			"POST", "/webhooks/kubeapps/my-charts?provider=harbor",
+			map[string]string{"Authorization": "s3cret"},
+			`{"type": "PULL_ARTIFACT"}`,
+			http.StatusOK,
+			false,
+		},
+		{
+			"invalid signature",
+			"POST", "/webhooks/kubeapps/my-charts?provider=chartmuseum",
+			map[string]string{webhookSignatureHeader: sign("other", pushBody)},
+			pushBody,
+			http.StatusUnauthorized,
+			false,
+		},
+		{
+			"invalid payload",
+			"POST", "/webhooks/kubeapps/my-charts?provider=chartmuseum",
+			map[string]string{webhookSignatureHeader: sign("s3cret", "{}")},
+			"{}",
+			http.StatusBadRequest,
+			false,
+		},
+		{
+			"unsigned request",
+			"POST", "/webhooks/kubeapps/my-charts?provider=chartmuseum",
+			map[string]string{},
+			pushBody,
+			http.StatusUnauthorized,
+			false,
+		},
+		{
+			"unknown provider",
+			"POST", "/webhooks/kubeapps/my-charts?provider=other",
+			map[string]string{webhookSignatureHeader: sign("s3cret", pushBody)},
+			pushBody,
+			http.StatusBadRequest,
+			false,
+		},
+		{
+			"repository without webhook",
+			"POST", "/webhooks/kubeapps/no-webhook",
+			map[string]string{webhookSignatureHeader: sign("s3cret", pushBody)},
+			pushBody,
+			http.StatusUnauthorized,
+			false,
+		},
+		{
+			"unknown repository",
+			"POST", "/webhooks/kubeapps/unknown",
+			map[string]string{webhookSignatureHeader: sign("s3cret", pushBody)},
+			pushBody,
+			http.StatusUnauthorized,
+			false,
+		},
+		{
+			"invalid path",
+			"POST", "/webhooks/kubeapps",
+			map[string]string{},
+			pushBody,
+			http.StatusNotFound,
+			false,
+		},
+		{
+			"GET request",
+			"GET", "/webhooks/kubeapps/my-charts",
+			map[string]string{},
+			"",
+			http.StatusMethodNotAllowed,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, clientset, _ := newJobsTestController([]*apprepov1beta1.AppRepository{webhookRepo, otherNamespaceRepo, noWebhookRepo}, nil, nil)
+			// The webhook secrets are in the namespace of each AppRepository,
+			// as created by the users
+			for namespace, value := range map[string]string{"kubeapps": "s3cret\n", "other-namespace": "other-s3cret"} {
+				secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-charts-webhook", Namespace: namespace}, Data: map[string][]byte{"secret": []byte(value)}}
+				if _, err := clientset.CoreV1().Secrets(namespace).Create(secret); err != nil {
+					t.Fatalf("%+v", err)
+				}
+			}
+			receiver := newWebhookReceiver(c, 0)
+			defer receiver.queue.ShutDown()
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			receiver.ServeHTTP(w, req)
+
+			if got, want := w.Code, tt.wantStatus; got != want {
+				t.Errorf("got: %d, want: %d (%s)", got, want, w.Body.String())
+			}
+			if got, want := receiver.queue.Len() == 1, tt.wantTriggered; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+		})
+	}
+}
+
+func Test_webhookReceiverDebounce(t *testing.T) {
+	apprepo := &apprepov1beta1.AppRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"},
+		Spec: apprepov1beta1.AppRepositorySpec{
+			URL: "https://charts.acme.com/my-charts",
+			Webhook: &apprepov1beta1.AppRepositoryWebhook{
+				SecretKeyRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "my-charts-webhook"}, Key: "secret"},
+			},
+		},
+	}
+	c, _, _ := newJobsTestController([]*apprepov1beta1.AppRepository{apprepo}, nil, nil)
+	c.kubeclientset = fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-charts-webhook", Namespace: "kubeapps"},
+		Data:       map[string][]byte{"secret": []byte("s3cret")},
+	})
+	receiver := newWebhookReceiver(c, 0)
+	defer receiver.queue.ShutDown()
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("POST", "/webhooks/kubeapps/my-charts", strings.NewReader(""))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		receiver.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if got, want := receiver.queue.Len(), 1; got != want {
+		t.Fatalf("got: %d, want: %d", got, want)
+	}
+
+	key, _ := receiver.queue.Get()
+	if err := receiver.syncHandler(key.(string)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	receiver.queue.Done(key)
+	jobs, err := c.kubeclientset.BatchV1().Jobs("kubeapps").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := len(jobs.Items), 1; got != want {
+		t.Fatalf("got: %d, want: %d", got, want)
+	}
+	if got, want := jobs.Items[0].GenerateName, "apprepo-kubeapps-sync-my-charts-"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func Test_webhookReceiverActiveJob(t *testing.T) {
+	apprepo := &apprepov1beta1.AppRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"},
+		Spec:       apprepov1beta1.AppRepositorySpec{URL: "https://charts.acme.com/my-charts"},
+	}
+	otherRepo := apprepo.DeepCopy()
+	otherRepo.Name = "other-charts"
+	finished := batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}}
+
+	tests := []struct {
+		name         string
+		existingJobs []*batchv1.Job
+		wantJobs     int
+	}{
+		{"no previous job", nil, 1},
+		{"finished job", []*batchv1.Job{{ObjectMeta: metav1.ObjectMeta{Name: "finished", Namespace: "kubeapps", Labels: jobLabels(apprepo)}, Status: finished}}, 2},
+		{"active job", []*batchv1.Job{{ObjectMeta: metav1.ObjectMeta{Name: "active", Namespace: "kubeapps", Labels: jobLabels(apprepo)}}}, 1},
+		{"active job of another repository", []*batchv1.Job{{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "kubeapps", Labels: jobLabels(otherRepo)}}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := newJobsTestController([]*apprepov1beta1.AppRepository{apprepo}, nil, nil)
+			clientset := fake.NewSimpleClientset()
+			for _, job := range tt.existingJobs {
+				if _, err := clientset.BatchV1().Jobs("kubeapps").Create(job); err != nil {
+					t.Fatalf("%+v", err)
+				}
+			}
+			c.kubeclientset = clientset
+			receiver := newWebhookReceiver(c, 0)
+			defer receiver.queue.ShutDown()
+
+			if err := receiver.syncHandler("kubeapps/my-charts"); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			jobs, err := clientset.BatchV1().Jobs("kubeapps").List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := len(jobs.Items), tt.wantJobs; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
+func Test_webhookReceiverRequeuesWhileJobActive(t *testing.T) {
+	apprepo := &apprepov1beta1.AppRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-charts", Namespace: "kubeapps"},
+		Spec:       apprepov1beta1.AppRepositorySpec{URL: "https://charts.acme.com/my-charts"},
+	}
+	// The Job of the CronJob is active when the webhook is called
+	active := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "cron-sync", Namespace: "kubeapps", Labels: jobLabels(apprepo)}}
+	c, _, _ := newJobsTestController([]*apprepov1beta1.AppRepository{apprepo}, nil, nil)
+	clientset := fake.NewSimpleClientset(active)
+	c.kubeclientset = clientset
+	receiver := newWebhookReceiver(c, 0)
+	defer receiver.queue.ShutDown()
+
+	if err := receiver.syncHandler("kubeapps/my-charts"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	jobs, err := clientset.BatchV1().Jobs("kubeapps").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := len(jobs.Items), 1; got != want {
+		t.Fatalf("got: %d, want: %d", got, want)
+	}
+
+	// Once the active Job finishes, the requeued sync creates a Job
+	active.Status = batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}}
+	if _, err := clientset.BatchV1().Jobs("kubeapps").Update(active); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	key, shutdown := receiver.queue.Get()
+	if shutdown {
+		t.Fatal("the sync was not requeued")
+	}
+	if got, want := key.(string), "kubeapps/my-charts"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if err := receiver.syncHandler(key.(string)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	receiver.queue.Done(key)
+	jobs, err = clientset.BatchV1().Jobs("kubeapps").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := len(jobs.Items), 2; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+}
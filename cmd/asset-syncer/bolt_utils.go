@@ -0,0 +1,241 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kubeapps/common/datastore"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+	bolt "go.etcd.io/bbolt"
+)
+
+type boltAssetManager struct {
+	*dbutils.BoltAssetManager
+}
+
+func newBoltManager(config datastore.Config, kubeappsNamespace string) assetManager {
+	m := dbutils.NewBoltManager(config, kubeappsNamespace)
+	return &boltAssetManager{m}
+}
+
+func (m *boltAssetManager) Sync(repo models.Repo, charts []models.Chart) error {
+	return m.Update(func(tx *bolt.Tx) error {
+		repoKey := dbutils.BoltKey(repo.Namespace, repo.Name)
+		if tx.Bucket([]byte(dbutils.BoltRepositoryBucket)).Get(repoKey) == nil {
+			err := dbutils.BoltPut(tx, dbutils.BoltRepositoryBucket, repoKey, dbutils.BoltRepo{Namespace: repo.Namespace, Name: repo.Name})
+			if err != nil {
+				return err
+			}
+		}
+
+		chartKeys := map[string]bool{}
+		for _, c := range charts {
+			if c.Repo == nil || c.Repo.Namespace != repo.Namespace || c.Repo.Name != repo.Name {
+				return fmt.Errorf("%w: chart repo: %+v, import repo: %+v", ErrRepoMismatch, c.Repo, repo)
+			}
+			key := dbutils.BoltKey(repo.Namespace, c.ID)
+			chartKeys[string(key)] = true
+			if err := dbutils.BoltPut(tx, dbutils.BoltChartBucket, key, c); err != nil {
+				return err
+			}
+		}
+
+		// Remove charts no longer existing in index
+		return deletePrefix(tx, dbutils.BoltChartBucket, dbutils.BoltRepoPrefix(repo.Namespace, repo.Name), func(k []byte) bool {
+			return !chartKeys[string(k)]
+		})
+	})
+}
+
+// deletePrefix deletes the keys of a bucket with a prefix for which remove
+// returns true.
+func deletePrefix(tx *bolt.Tx, bucket string, prefix []byte, remove func(k []byte) bool) error {
+	keys := [][]byte{}
+	err := dbutils.BoltForEachPrefix(tx, bucket, prefix, func(k, v []byte) error {
+		if remove(k) {
+			keys = append(keys, append([]byte{}, k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := tx.Bucket([]byte(bucket)).Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *boltAssetManager) RepoAlreadyProcessed(repo models.Repo, checksum string) bool {
+	var lastCheck dbutils.BoltRepo
+	err := m.View(func(tx *bolt.Tx) error {
+		return dbutils.BoltGet(tx, dbutils.BoltRepositoryBucket, dbutils.BoltKey(repo.Namespace, repo.Name), &lastCheck)
+	})
+	return err == nil && checksum == lastCheck.Checksum
+}
+
+func (m *boltAssetManager) UpdateLastCheck(repoNamespace, repoName, checksum string, now time.Time) error {
+	return m.Update(func(tx *bolt.Tx) error {
+		return dbutils.BoltPut(tx, dbutils.BoltRepositoryBucket, dbutils.BoltKey(repoNamespace, repoName), dbutils.BoltRepo{
+			Namespace:  repoNamespace,
+			Name:       repoName,
+			Checksum:   checksum,
+			LastUpdate: now,
+		})
+	})
+}
+
+func (m *boltAssetManager) Delete(repo models.Repo) error {
+	all := func(k []byte) bool { return true }
+	return m.Update(func(tx *bolt.Tx) error {
+		prefix := dbutils.BoltRepoPrefix(repo.Namespace, repo.Name)
+		if err := deletePrefix(tx, dbutils.BoltChartBucket, prefix, all); err != nil {
+			return err
+		}
+		if err := deletePrefix(tx, dbutils.BoltChartFilesBucket, prefix, all); err != nil {
+			return err
+		}
+		tarballPrefix := []byte(dbutils.ChartTarballID(repo.Namespace, repo.Name, ""))
+		if err := deletePrefix(tx, dbutils.BoltChartTarballsBucket, tarballPrefix, all); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte(dbutils.BoltFailedVersionsBucket)).Delete(dbutils.BoltKey(repo.Namespace, repo.Name)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(dbutils.BoltRepositoryBucket)).Delete(dbutils.BoltKey(repo.Namespace, repo.Name))
+	})
+}
+
+func (m *boltAssetManager) updateIcon(repo models.Repo, data []byte, contentType, ID string) error {
+	return m.Update(func(tx *bolt.Tx) error {
+		key := dbutils.BoltKey(repo.Namespace, ID)
+		var chart models.Chart
+		if err := dbutils.BoltGet(tx, dbutils.BoltChartBucket, key, &chart); err != nil {
+			return err
+		}
+		chart.RawIcon = data
+		chart.IconContentType = contentType
+		return dbutils.BoltPut(tx, dbutils.BoltChartBucket, key, chart)
+	})
+}
+
+func (m *boltAssetManager) filesExist(repo models.Repo, chartFilesID, digest string) bool {
+	var files models.ChartFiles
+	err := m.View(func(tx *bolt.Tx) error {
+		return dbutils.BoltGet(tx, dbutils.BoltChartFilesBucket, dbutils.BoltKey(repo.Namespace, chartFilesID), &files)
+	})
+	return err == nil && files.Repo != nil && files.Repo.Name == repo.Name && files.Digest == digest
+}
+
+func (m *boltAssetManager) insertFiles(chartId string, files models.ChartFiles) error {
+	if files.Repo == nil {
+		return fmt.Errorf("unable to insert file without repo: %q", files.ID)
+	}
+	return m.Update(func(tx *bolt.Tx) error {
+		return dbutils.BoltPut(tx, dbutils.BoltChartFilesBucket, dbutils.BoltKey(files.Repo.Namespace, files.ID), files)
+	})
+}
+
+func (m *boltAssetManager) tarballExists(repo models.Repo, digest string) bool {
+	exists := false
+	m.View(func(tx *bolt.Tx) error {
+		exists = tx.Bucket([]byte(dbutils.BoltChartTarballsBucket)).Get([]byte(dbutils.ChartTarballID(repo.Namespace, repo.Name, digest))) != nil
+		return nil
+	})
+	return exists
+}
+
+func (m *boltAssetManager) insertTarball(repo models.Repo, digest string, data []byte) error {
+	return m.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(dbutils.BoltChartTarballsBucket)).Put([]byte(dbutils.ChartTarballID(repo.Namespace, repo.Name, digest)), data)
+	})
+}
+
+func (m *boltAssetManager) listFailedVersions(repo models.Repo) ([]models.FailedChartVersion, error) {
+	var versions []models.FailedChartVersion
+	err := m.View(func(tx *bolt.Tx) error {
+		return dbutils.BoltGet(tx, dbutils.BoltFailedVersionsBucket, dbutils.BoltKey(repo.Namespace, repo.Name), &versions)
+	})
+	if err == dbutils.ErrBoltKeyNotFound {
+		return nil, nil
+	}
+	return versions, err
+}
+
+func (m *boltAssetManager) updateFailedVersions(repo models.Repo, versions []models.FailedChartVersion) error {
+	return m.Update(func(tx *bolt.Tx) error {
+		key := dbutils.BoltKey(repo.Namespace, repo.Name)
+		if len(versions) == 0 {
+			return tx.Bucket([]byte(dbutils.BoltFailedVersionsBucket)).Delete(key)
+		}
+		return dbutils.BoltPut(tx, dbutils.BoltFailedVersionsBucket, key, versions)
+	})
+}
+
+func (m *boltAssetManager) listRepos(namespace, name string) ([]models.RepoInternal, error) {
+	repos := []models.RepoInternal{}
+	prefix := []byte{}
+	if namespace != dbutils.AllNamespaces {
+		prefix = dbutils.BoltKey(namespace, "")
+	}
+	err := m.View(func(tx *bolt.Tx) error {
+		return dbutils.BoltForEachPrefix(tx, dbutils.BoltRepositoryBucket, prefix, func(k, v []byte) error {
+			var repo dbutils.BoltRepo
+			if err := json.Unmarshal(v, &repo); err != nil {
+				return err
+			}
+			if name == "" || repo.Name == name {
+				repos = append(repos, models.RepoInternal{Namespace: repo.Namespace, Name: repo.Name, Checksum: repo.Checksum})
+			}
+			return nil
+		})
+	})
+	return repos, err
+}
+
+func (m *boltAssetManager) listCharts(repo models.Repo) ([]models.Chart, error) {
+	result := []models.Chart{}
+	err := m.View(func(tx *bolt.Tx) error {
+		charts, err := dbutils.BoltCharts(tx, dbutils.BoltRepoPrefix(repo.Namespace, repo.Name))
+		for _, c := range charts {
+			result = append(result, *c)
+		}
+		return err
+	})
+	return result, err
+}
+
+func (m *boltAssetManager) listFiles(repo models.Repo) ([]models.ChartFiles, error) {
+	result := []models.ChartFiles{}
+	err := m.View(func(tx *bolt.Tx) error {
+		return dbutils.BoltForEachPrefix(tx, dbutils.BoltChartFilesBucket, dbutils.BoltRepoPrefix(repo.Namespace, repo.Name), func(k, v []byte) error {
+			var files models.ChartFiles
+			if err := json.Unmarshal(v, &files); err != nil {
+				return err
+			}
+			result = append(result, files)
+			return nil
+		})
+	})
+	return result, err
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+	"github.com/kubeapps/kubeapps/pkg/dbutils/dbutilstest/bolttest"
+)
+
+func getBoltManager(t *testing.T) (*boltAssetManager, func()) {
+	bam, cleanup := bolttest.GetInitializedManager(t)
+	return &boltAssetManager{bam}, cleanup
+}
+
+func Test_BoltSync(t *testing.T) {
+	manager, cleanup := getBoltManager(t)
+	defer cleanup()
+	repo := models.Repo{Namespace: "my-namespace", Name: "my-repo"}
+	otherRepo := models.Repo{Namespace: "my-namespace", Name: "my-repo-2"}
+
+	charts := []models.Chart{
+		{ID: "my-repo/foo", Name: "foo", Repo: &repo},
+		{ID: "my-repo/bar", Name: "bar", Repo: &repo},
+	}
+	if err := manager.Sync(repo, charts); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := manager.Sync(otherRepo, []models.Chart{{ID: "my-repo-2/foo", Name: "foo", Repo: &otherRepo}}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// Syncing again removes the charts no longer in the index.
+	if err := manager.Sync(repo, charts[1:]); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	got, err := manager.listCharts(repo)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := charts[1:]; !cmp.Equal(want, got) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+	if got, want := bolttest.CountKeys(t, manager.BoltAssetManager, dbutils.BoltChartBucket), 2; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+
+	if err := manager.Sync(repo, []models.Chart{{ID: "my-repo-2/foo", Repo: &otherRepo}}); err == nil {
+		t.Errorf("got: nil, want: error")
+	}
+}
+
+func Test_BoltLastCheck(t *testing.T) {
+	manager, cleanup := getBoltManager(t)
+	defer cleanup()
+	repo := models.Repo{Namespace: "my-namespace", Name: "my-repo"}
+
+	if manager.RepoAlreadyProcessed(repo, "abc") {
+		t.Errorf("got: true, want: false")
+	}
+	if err := manager.UpdateLastCheck(repo.Namespace, repo.Name, "abc", time.Now()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !manager.RepoAlreadyProcessed(repo, "abc") {
+		t.Errorf("got: false, want: true")
+	}
+	if manager.RepoAlreadyProcessed(repo, "def") {
+		t.Errorf("got: true, want: false")
+	}
+
+	repos, err := manager.listRepos(dbutils.AllNamespaces, "")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := []models.RepoInternal{{Namespace: repo.Namespace, Name: repo.Name, Checksum: "abc"}}; !cmp.Equal(want, repos) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, repos))
+	}
+}
+
+func Test_BoltFilesAndIcons(t *testing.T) {
+	manager, cleanup := getBoltManager(t)
+	defer cleanup()
+	repo := models.Repo{Namespace: "my-namespace", Name: "my-repo"}
+	bolttest.EnsureChartsExist(t, manager.BoltAssetManager, []models.Chart{{ID: "my-repo/foo", Name: "foo", Repo: &repo}}, repo)
+
+	if err := manager.updateIcon(repo, []byte("icon"), "image/svg", "my-repo/foo"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := manager.updateIcon(repo, []byte("icon"), "image/svg", "my-repo/missing"); err == nil {
+		t.Errorf("got: nil, want: error")
+	}
+	charts, err := manager.listCharts(repo)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := string(charts[0].RawIcon), "icon"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	files := models.ChartFiles{ID: "my-repo/foo-1.0.0", Readme: "readme", Repo: &repo, Digest: "123"}
+	if err := manager.insertFiles("my-repo/foo", files); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !manager.filesExist(repo, files.ID, "123") {
+		t.Errorf("got: false, want: true")
+	}
+	if manager.filesExist(repo, files.ID, "456") {
+		t.Errorf("got: true, want: false")
+	}
+
+	if err := manager.insertTarball(repo, "123", []byte("tarball")); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !manager.tarballExists(repo, "123") {
+		t.Errorf("got: false, want: true")
+	}
+
+	failed := []models.FailedChartVersion{{Chart: "foo", Version: "1.0.0", Error: "503"}}
+	if err := manager.updateFailedVersions(repo, failed); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	gotFailed, err := manager.listFailedVersions(repo)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !cmp.Equal(failed, gotFailed) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(failed, gotFailed))
+	}
+
+	if err := manager.Delete(repo); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, bucket := range []string{dbutils.BoltRepositoryBucket, dbutils.BoltChartBucket, dbutils.BoltChartFilesBucket, dbutils.BoltChartTarballsBucket, dbutils.BoltFailedVersionsBucket} {
+		if got, want := bolttest.CountKeys(t, manager.BoltAssetManager, bucket), 0; got != want {
+			t.Errorf("%s: got: %d, want: %d", bucket, got, want)
+		}
+	}
+}
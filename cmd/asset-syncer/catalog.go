@@ -0,0 +1,261 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// A catalog archive is a tar file with the following entries for each
+// repository:
+//
+//	<namespace>/<repo>/repo.json    the repository and its last checksum
+//	<namespace>/<repo>/charts.json  the charts, without their icons
+//	<namespace>/<repo>/files.json   the chart files of every version
+//	<namespace>/<repo>/icons/<chart name>
+//
+// The content type of the icons is kept in the charts.
+const (
+	catalogRepoFile   = "repo.json"
+	catalogChartsFile = "charts.json"
+	catalogFilesFile  = "files.json"
+	catalogIconsDir   = "icons"
+)
+
+// catalogRepoInfo is the content of the repo.json entry of a repository.
+type catalogRepoInfo struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Checksum  string `json:"checksum"`
+}
+
+// catalogRepo holds the assets of a repository in a catalog archive.
+type catalogRepo struct {
+	Info   catalogRepoInfo
+	Charts []models.Chart
+	Files  []models.ChartFiles
+}
+
+func (r catalogRepo) dir() string {
+	return path.Join(r.Info.Namespace, r.Info.Name)
+}
+
+// exportCatalog writes a catalog archive with the repositories of a
+// namespace, or those of every namespace with dbutils.AllNamespaces. It
+// returns the number of exported repositories.
+func exportCatalog(manager assetManager, namespace, name string, w io.Writer) (int, error) {
+	repos, err := manager.listRepos(namespace, name)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].Namespace != repos[j].Namespace {
+			return repos[i].Namespace < repos[j].Namespace
+		}
+		return repos[i].Name < repos[j].Name
+	})
+
+	tw := tar.NewWriter(w)
+	for _, r := range repos {
+		repo := models.Repo{Namespace: r.Namespace, Name: r.Name}
+		charts, err := manager.listCharts(repo)
+		if err != nil {
+			return 0, fmt.Errorf("unable to list the charts of %s/%s: %v", r.Namespace, r.Name, err)
+		}
+		files, err := manager.listFiles(repo)
+		if err != nil {
+			return 0, fmt.Errorf("unable to list the chart files of %s/%s: %v", r.Namespace, r.Name, err)
+		}
+		info := catalogRepoInfo{Namespace: r.Namespace, Name: r.Name, Checksum: r.Checksum}
+		// The URL of a repository is only stored in its charts.
+		if len(charts) > 0 && charts[0].Repo != nil {
+			info.URL = charts[0].Repo.URL
+		}
+		if err := writeCatalogRepo(tw, catalogRepo{Info: info, Charts: charts, Files: files}); err != nil {
+			return 0, err
+		}
+		log.Infof("Exported %d charts of %s/%s", len(charts), r.Namespace, r.Name)
+	}
+	return len(repos), tw.Close()
+}
+
+func writeCatalogRepo(tw *tar.Writer, r catalogRepo) error {
+	charts := make([]models.Chart, len(r.Charts))
+	for i, c := range r.Charts {
+		if len(c.RawIcon) > 0 {
+			iconPath := path.Join(r.dir(), catalogIconsDir, url.PathEscape(c.Name))
+			if err := writeCatalogEntry(tw, iconPath, c.RawIcon); err != nil {
+				return err
+			}
+		}
+		c.RawIcon = nil
+		charts[i] = c
+	}
+
+	entries := []struct {
+		name  string
+		value interface{}
+	}{
+		{catalogRepoFile, r.Info},
+		{catalogChartsFile, charts},
+		{catalogFilesFile, r.Files},
+	}
+	for _, e := range entries {
+		data, err := json.Marshal(e.value)
+		if err != nil {
+			return err
+		}
+		if err := writeCatalogEntry(tw, path.Join(r.dir(), e.name), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCatalogEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// readCatalog reads the repositories of a catalog archive.
+func readCatalog(r io.Reader) ([]catalogRepo, error) {
+	repos := map[string]*catalogRepo{}
+	icons := map[string]map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		parts := strings.Split(path.Clean(hdr.Name), "/")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("unexpected entry %q in the catalog", hdr.Name)
+		}
+		dir := path.Join(parts[0], parts[1])
+		if _, ok := repos[dir]; !ok {
+			repos[dir] = &catalogRepo{}
+			icons[dir] = map[string][]byte{}
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case len(parts) == 3 && parts[2] == catalogRepoFile:
+			err = json.Unmarshal(data, &repos[dir].Info)
+		case len(parts) == 3 && parts[2] == catalogChartsFile:
+			err = json.Unmarshal(data, &repos[dir].Charts)
+		case len(parts) == 3 && parts[2] == catalogFilesFile:
+			err = json.Unmarshal(data, &repos[dir].Files)
+		case len(parts) == 4 && parts[2] == catalogIconsDir:
+			var chartName string
+			chartName, err = url.PathUnescape(parts[3])
+			icons[dir][chartName] = data
+		default:
+			log.Warnf("Ignoring unexpected entry %q in the catalog", hdr.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %v", hdr.Name, err)
+		}
+	}
+
+	result := []catalogRepo{}
+	for dir, r := range repos {
+		if r.Info.Name == "" {
+			return nil, fmt.Errorf("missing %s for %q in the catalog", catalogRepoFile, dir)
+		}
+		for i, c := range r.Charts {
+			r.Charts[i].RawIcon = icons[dir][c.Name]
+		}
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].dir() < result[j].dir()
+	})
+	return result, nil
+}
+
+// importCatalog stores the repositories of a catalog, replacing the charts
+// they may already have.
+func importCatalog(manager assetManager, repos []catalogRepo) error {
+	for _, r := range repos {
+		repo := models.Repo{Namespace: r.Info.Namespace, Name: r.Info.Name, URL: r.Info.URL}
+		if len(r.Charts) == 0 {
+			log.Warnf("Skipping %s/%s with no charts", repo.Namespace, repo.Name)
+			continue
+		}
+		// The icons are part of the charts so they are stored by the sync.
+		if err := manager.Sync(repo, r.Charts); err != nil {
+			return fmt.Errorf("unable to import the charts of %s/%s: %v", repo.Namespace, repo.Name, err)
+		}
+
+		// The chart files are identified by the chart ID and the version.
+		chartIDs := map[string]string{}
+		for _, c := range r.Charts {
+			for _, cv := range c.ChartVersions {
+				chartIDs[fmt.Sprintf("%s-%s", c.ID, cv.Version)] = c.ID
+			}
+		}
+		for _, f := range r.Files {
+			chartID, ok := chartIDs[f.ID]
+			if !ok {
+				log.Warnf("Skipping chart files %q of %s/%s with no chart", f.ID, repo.Namespace, repo.Name)
+				continue
+			}
+			if err := manager.insertFiles(chartID, f); err != nil {
+				return fmt.Errorf("unable to import the chart files %q of %s/%s: %v", f.ID, repo.Namespace, repo.Name, err)
+			}
+		}
+
+		// Keeping the checksum avoids processing the repository again until
+		// its index changes.
+		if err := manager.UpdateLastCheck(repo.Namespace, repo.Name, r.Info.Checksum, time.Now()); err != nil {
+			return err
+		}
+		log.Infof("Imported %d charts of %s/%s", len(r.Charts), repo.Namespace, repo.Name)
+	}
+	return nil
+}
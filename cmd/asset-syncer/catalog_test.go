@@ -0,0 +1,235 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+)
+
+// fakeCatalogManager stores the repositories of a catalog in memory.
+type fakeCatalogManager struct {
+	assetManager
+	repos  []models.RepoInternal
+	charts map[string][]models.Chart
+	files  map[string][]models.ChartFiles
+}
+
+func newFakeCatalogManager() *fakeCatalogManager {
+	return &fakeCatalogManager{
+		charts: map[string][]models.Chart{},
+		files:  map[string][]models.ChartFiles{},
+	}
+}
+
+func (f *fakeCatalogManager) listRepos(namespace, name string) ([]models.RepoInternal, error) {
+	repos := []models.RepoInternal{}
+	for _, r := range f.repos {
+		if (namespace == dbutils.AllNamespaces || r.Namespace == namespace) && (name == "" || r.Name == name) {
+			repos = append(repos, r)
+		}
+	}
+	return repos, nil
+}
+
+func (f *fakeCatalogManager) listCharts(repo models.Repo) ([]models.Chart, error) {
+	return f.charts[repo.Namespace+"/"+repo.Name], nil
+}
+
+func (f *fakeCatalogManager) listFiles(repo models.Repo) ([]models.ChartFiles, error) {
+	return f.files[repo.Namespace+"/"+repo.Name], nil
+}
+
+func (f *fakeCatalogManager) Sync(repo models.Repo, charts []models.Chart) error {
+	f.charts[repo.Namespace+"/"+repo.Name] = charts
+	return nil
+}
+
+func (f *fakeCatalogManager) insertFiles(chartID string, files models.ChartFiles) error {
+	key := files.Repo.Namespace + "/" + files.Repo.Name
+	f.files[key] = append(f.files[key], files)
+	return nil
+}
+
+func (f *fakeCatalogManager) UpdateLastCheck(repoNamespace, repoName, checksum string, now time.Time) error {
+	for i, r := range f.repos {
+		if r.Namespace == repoNamespace && r.Name == repoName {
+			f.repos[i].Checksum = checksum
+			return nil
+		}
+	}
+	f.repos = append(f.repos, models.RepoInternal{Namespace: repoNamespace, Name: repoName, Checksum: checksum})
+	return nil
+}
+
+func tarEntries(t *testing.T, data []byte) []string {
+	entries := []string{}
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		entries = append(entries, hdr.Name)
+	}
+	return entries
+}
+
+func Test_exportImportCatalog(t *testing.T) {
+	repo := &models.Repo{Namespace: "my-namespace", Name: "my-repo", URL: "https://example.com"}
+	otherRepo := &models.Repo{Namespace: "other-namespace", Name: "other-repo", URL: "https://other.example.com"}
+	source := newFakeCatalogManager()
+	source.repos = []models.RepoInternal{
+		{Namespace: otherRepo.Namespace, Name: otherRepo.Name, Checksum: "def"},
+		{Namespace: repo.Namespace, Name: repo.Name, Checksum: "abc"},
+	}
+	source.charts = map[string][]models.Chart{
+		"my-namespace/my-repo": {
+			{
+				ID:              "my-repo/my-chart",
+				Name:            "my-chart",
+				Repo:            repo,
+				RawIcon:         []byte("icon"),
+				IconContentType: "image/svg",
+				ChartVersions:   []models.ChartVersion{{Version: "1.0.0", Digest: "123"}},
+			},
+			{
+				ID:            "my-repo/no-icon",
+				Name:          "no-icon",
+				Repo:          repo,
+				ChartVersions: []models.ChartVersion{{Version: "0.1.0", Digest: "456"}},
+			},
+		},
+		"other-namespace/other-repo": {
+			{ID: "other-repo/other-chart", Name: "other-chart", Repo: otherRepo, ChartVersions: []models.ChartVersion{{Version: "2.0.0"}}},
+		},
+	}
+	source.files = map[string][]models.ChartFiles{
+		"my-namespace/my-repo": {
+			{ID: "my-repo/my-chart-1.0.0", Readme: "readme", Values: "values", Repo: repo, Digest: "123"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		repo        string
+		wantEntries []string
+		wantRepos   []models.RepoInternal
+	}{
+		{
+			"all namespaces",
+			dbutils.AllNamespaces,
+			"",
+			[]string{
+				"my-namespace/my-repo/icons/my-chart",
+				"my-namespace/my-repo/repo.json",
+				"my-namespace/my-repo/charts.json",
+				"my-namespace/my-repo/files.json",
+				"other-namespace/other-repo/repo.json",
+				"other-namespace/other-repo/charts.json",
+				"other-namespace/other-repo/files.json",
+			},
+			[]models.RepoInternal{
+				{Namespace: repo.Namespace, Name: repo.Name, Checksum: "abc"},
+				{Namespace: otherRepo.Namespace, Name: otherRepo.Name, Checksum: "def"},
+			},
+		},
+		{
+			"single repository",
+			"my-namespace",
+			"my-repo",
+			[]string{
+				"my-namespace/my-repo/icons/my-chart",
+				"my-namespace/my-repo/repo.json",
+				"my-namespace/my-repo/charts.json",
+				"my-namespace/my-repo/files.json",
+			},
+			[]models.RepoInternal{
+				{Namespace: repo.Namespace, Name: repo.Name, Checksum: "abc"},
+			},
+		},
+		{
+			"namespace without repositories",
+			"empty-namespace",
+			"",
+			[]string{},
+			[]models.RepoInternal{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := exportCatalog(source, tt.namespace, tt.repo, &buf)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := n, len(tt.wantRepos); got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			if got, want := tarEntries(t, buf.Bytes()), tt.wantEntries; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+
+			repos, err := readCatalog(&buf)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			target := newFakeCatalogManager()
+			target.repos = []models.RepoInternal{}
+			if err := importCatalog(target, repos); err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			if got, want := target.repos, tt.wantRepos; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			for _, r := range tt.wantRepos {
+				key := r.Namespace + "/" + r.Name
+				if got, want := target.charts[key], source.charts[key]; !cmp.Equal(want, got) {
+					t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+				}
+				if got, want := target.files[key], source.files[key]; !cmp.Equal(want, got) {
+					t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+				}
+			}
+		})
+	}
+}
+
+func Test_readCatalogMissingRepo(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := writeCatalogEntry(tw, "my-namespace/my-repo/charts.json", []byte("[]")); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	tw.Close()
+
+	if _, err := readCatalog(&buf); err == nil {
+		t.Errorf("got: nil, want: error")
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2018 The Helm Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/kubeapps/common/datastore"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var exportOutput string
+
+var exportCmd = &cobra.Command{
+	Use:   "export [REPO NAME]",
+	Short: "export the chart repositories to a catalog archive",
+	Long: `Export the chart repositories to a catalog archive, a tar file with the
+charts, chart files and icons of each repository. Every repository of the
+namespace is exported when no repository name is given, and every repository of
+every namespace when no namespace is given either.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			logrus.Info("Need at most one argument: [REPO NAME]")
+			cmd.Help()
+			return
+		}
+		repoName := ""
+		if len(args) == 1 {
+			repoName = args[0]
+		}
+		exportNamespace := namespace
+		if exportNamespace == "" {
+			if repoName != "" {
+				logrus.Fatal("The namespace of the repository is required")
+			}
+			exportNamespace = dbutils.AllNamespaces
+		}
+
+		if debug {
+			logrus.SetLevel(logrus.DebugLevel)
+		}
+
+		dbConfig := datastore.Config{URL: databaseURL, Database: databaseName, Username: databaseUser, Password: databasePassword}
+		kubeappsNamespace := os.Getenv("POD_NAMESPACE")
+		manager, err := newManager(databaseType, dbConfig, kubeappsNamespace)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = manager.Init()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer manager.Close()
+
+		var w io.Writer = os.Stdout
+		if exportOutput != "-" {
+			f, err := os.Create(exportOutput)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		n, err := exportCatalog(manager, exportNamespace, repoName, w)
+		if err != nil {
+			logrus.Fatalf("Can't export the chart repositories: %v", err)
+		}
+		logrus.Infof("Successfully exported %d chart repositories", n)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "-", "File to write the catalog archive to, - for the standard output")
+}
@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// maxRetryDelay caps the delay between two attempts of a request, including
+// the one requested by a Retry-After header.
+const maxRetryDelay = time.Minute
+
+var (
+	// workers is the number of chart icons and files fetched concurrently.
+	workers int
+	// maxRetries is the number of times a request is retried after it times
+	// out or gets a 429 or 5xx response.
+	maxRetries int
+	// retryBackoff is the delay before the first retry, doubled for each of
+	// the following ones.
+	retryBackoff time.Duration
+	// hostLimiter limits the requests sent to each host, it does not limit
+	// them when nil.
+	hostLimiter *hostRateLimiter
+)
+
+// hostRateLimiter limits the rate of the requests sent to each host, so that
+// syncing a big repository does not overload (or get throttled by) its server.
+type hostRateLimiter struct {
+	limit    rate.Limit
+	mutex    sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// newHostRateLimiter returns a limiter allowing the given number of requests
+// per second to each host, or nil when it is not positive.
+func newHostRateLimiter(requestsPerSecond float64) *hostRateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	return &hostRateLimiter{limit: rate.Limit(requestsPerSecond), limiters: map[string]*rate.Limiter{}}
+}
+
+// wait blocks until a request can be sent to the host.
+func (l *hostRateLimiter) wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	limiter, ok := l.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, 1)
+		l.limiters[host] = limiter
+	}
+	l.mutex.Unlock()
+	return limiter.Wait(ctx)
+}
+
+// fetchWithRetries sends a request once the rate limit of its host allows it,
+// retrying it with an exponential backoff while it times out or gets a 429 or
+// 5xx response. The last response is returned when the retries are exhausted.
+func fetchWithRetries(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := hostLimiter.wait(req.Context(), req.URL.Host); err != nil {
+			return nil, err
+		}
+		res, err := netClient.Do(req)
+		if attempt >= maxRetries || !isRetryable(res, err) {
+			return res, err
+		}
+
+		delay := retryDelay(res, attempt)
+		fields := log.Fields{"url": req.URL.String(), "attempt": attempt + 1, "delay": delay}
+		if res != nil {
+			fields["status"] = res.StatusCode
+			res.Body.Close()
+		}
+		log.WithFields(fields).WithError(err).Info("retrying request")
+		time.Sleep(delay)
+	}
+}
+
+// isRetryable returns whether a request could succeed if sent again.
+func isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError
+}
+
+// retryDelay returns the delay before retrying a request: the backoff of the
+// attempt, unless the server asks for a longer one with a Retry-After header.
+func retryDelay(res *http.Response, attempt int) time.Duration {
+	delay := retryBackoff
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if res != nil {
+		var retryAfter time.Duration
+		header := res.Header.Get("Retry-After")
+		if seconds, err := strconv.Atoi(header); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(header); err == nil {
+			retryAfter = time.Until(date)
+		}
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
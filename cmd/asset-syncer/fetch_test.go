@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error timing out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// sequenceHTTPClient returns the given status codes in order, or the error
+// when the status code is 0, and then 200.
+type sequenceHTTPClient struct {
+	statusCodes []int
+	err         error
+	requests    int
+}
+
+func (h *sequenceHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	h.requests++
+	if len(h.statusCodes) == 0 {
+		return httptest.NewRecorder().Result(), nil
+	}
+	code := h.statusCodes[0]
+	h.statusCodes = h.statusCodes[1:]
+	if code == 0 {
+		return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: h.err}
+	}
+	w := httptest.NewRecorder()
+	w.WriteHeader(code)
+	return w.Result(), nil
+}
+
+func Test_fetchWithRetries(t *testing.T) {
+	defer func(backoff time.Duration, retries int) { retryBackoff, maxRetries = backoff, retries }(retryBackoff, maxRetries)
+	retryBackoff = 0
+	maxRetries = 2
+
+	tests := []struct {
+		name         string
+		statusCodes  []int
+		err          error
+		wantStatus   int
+		wantErr      bool
+		wantRequests int
+	}{
+		{"success", nil, nil, 200, false, 1},
+		{"retried 5xx", []int{500, 503}, nil, 200, false, 3},
+		{"retried 429", []int{429}, nil, 200, false, 2},
+		{"exhausted retries", []int{502, 502, 502, 502}, nil, 502, false, 3},
+		{"not found is not retried", []int{404}, nil, 404, false, 1},
+		{"retried timeout", []int{0}, timeoutError{}, 200, false, 2},
+		{"other errors are not retried", []int{0}, errors.New("connection refused"), 0, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &sequenceHTTPClient{statusCodes: tt.statusCodes, err: tt.err}
+			netClient = client
+			req, err := http.NewRequest("GET", "http://testrepo.com/chart.tgz", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := fetchWithRetries(req)
+			if got, want := err != nil, tt.wantErr; got != want {
+				t.Fatalf("got error: %v, want error: %t", err, want)
+			}
+			if err == nil {
+				if got, want := res.StatusCode, tt.wantStatus; got != want {
+					t.Errorf("got: %d, want: %d", got, want)
+				}
+			}
+			if got, want := client.requests, tt.wantRequests; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
+func Test_retryDelay(t *testing.T) {
+	defer func(backoff time.Duration) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff = time.Second
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{"first attempt", "", 0, time.Second},
+		{"exponential backoff", "", 3, 8 * time.Second},
+		{"capped backoff", "", 40, maxRetryDelay},
+		{"longer Retry-After", "10", 0, 10 * time.Second},
+		{"shorter Retry-After", "1", 2, 4 * time.Second},
+		{"capped Retry-After", "3600", 0, maxRetryDelay},
+		{"invalid Retry-After", "soon", 1, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				res.Header.Set("Retry-After", tt.retryAfter)
+			}
+			if got, want := retryDelay(res, tt.attempt), tt.want; got != want {
+				t.Errorf("got: %s, want: %s", got, want)
+			}
+		})
+	}
+}
+
+func Test_hostRateLimiter(t *testing.T) {
+	if got := newHostRateLimiter(0); got != nil {
+		t.Errorf("got: %v, want: nil", got)
+	}
+	var unlimited *hostRateLimiter
+	if err := unlimited.wait(context.Background(), "testrepo.com"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	limiter := newHostRateLimiter(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(ctx, "testrepo.com"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// Other hosts are limited separately
+	if err := limiter.wait(ctx, "other.com"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := limiter.wait(ctx, "testrepo.com"); err == nil {
+		t.Errorf("expected the second request to testrepo.com to be limited")
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) 2018 The Helm Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/kubeapps/common/datastore"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [FILE]",
+	Short: "import the chart repositories of a catalog archive",
+	Long: `Import the chart repositories of a catalog archive created with the export
+command, reading it from the standard input when the file is -. The charts of
+the imported repositories are replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Info("Need exactly one argument: [FILE]")
+			cmd.Help()
+			return
+		}
+
+		if debug {
+			logrus.SetLevel(logrus.DebugLevel)
+		}
+
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			defer f.Close()
+			r = f
+		}
+		repos, err := readCatalog(r)
+		if err != nil {
+			logrus.Fatalf("Can't read the catalog archive: %v", err)
+		}
+
+		dbConfig := datastore.Config{URL: databaseURL, Database: databaseName, Username: databaseUser, Password: databasePassword}
+		kubeappsNamespace := os.Getenv("POD_NAMESPACE")
+		manager, err := newManager(databaseType, dbConfig, kubeappsNamespace)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = manager.Init()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer manager.Close()
+
+		if err = importCatalog(manager, repos); err != nil {
+			logrus.Fatalf("Can't import the chart repositories: %v", err)
+		}
+		logrus.Infof("Successfully imported %d chart repositories", len(repos))
+	},
+}
@@ -48,8 +48,8 @@ func main() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&databaseType, "database-type", "mongodb", "Database to use. Choice: mongodb, postgresql")
-	rootCmd.PersistentFlags().StringVar(&databaseURL, "database-url", "localhost", "Database URL")
+	rootCmd.PersistentFlags().StringVar(&databaseType, "database-type", "mongodb", "Database to use. Choice: mongodb, postgresql, bolt")
+	rootCmd.PersistentFlags().StringVar(&databaseURL, "database-url", "localhost", "Database URL, or path of the database file for bolt")
 	rootCmd.PersistentFlags().StringVar(&databaseName, "database-name", "charts", "Name of the database to use")
 	rootCmd.PersistentFlags().StringVar(&databaseUser, "database-user", "", "Database user")
 	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "", "Namespace of the repository being synced")
@@ -59,7 +59,7 @@ func init() {
 
 	databasePassword = os.Getenv("DB_PASSWORD")
 
-	cmds := []*cobra.Command{syncCmd, deleteCmd, invalidateCacheCmd}
+	cmds := []*cobra.Command{syncCmd, deleteCmd, invalidateCacheCmd, exportCmd, importCmd, migrateCmd, validateCmd}
 	for _, cmd := range cmds {
 		rootCmd.AddCommand(cmd)
 	}
@@ -0,0 +1,225 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubeapps/common/datastore"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	targetDatabaseURL  string
+	targetDatabaseName string
+	targetDatabaseUser string
+	migrateDryRun      bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate the chart repositories from mongodb to postgresql",
+	Long: `Migrate the chart repositories, charts, chart files and icons from the mongodb
+database to a postgresql database, without syncing the repositories again.
+
+The copy of each repository is verified before it is recorded with the checksum
+of its index, so an interrupted migration can be run again to resume it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			logrus.Info("This command does not take any arguments")
+			cmd.Help()
+			return
+		}
+		if databaseType != "mongodb" {
+			logrus.Fatalf("Unsupported database type %s to migrate from", databaseType)
+		}
+
+		if debug {
+			logrus.SetLevel(logrus.DebugLevel)
+		}
+
+		kubeappsNamespace := os.Getenv("POD_NAMESPACE")
+		dbConfig := datastore.Config{URL: databaseURL, Database: databaseName, Username: databaseUser, Password: databasePassword}
+		source, err := newManager(databaseType, dbConfig, kubeappsNamespace)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = source.Init()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer source.Close()
+
+		targetConfig := datastore.Config{URL: targetDatabaseURL, Database: targetDatabaseName, Username: targetDatabaseUser, Password: os.Getenv("TARGET_DB_PASSWORD")}
+		target, err := newManager("postgresql", targetConfig, kubeappsNamespace)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = target.Init()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer target.Close()
+
+		results, err := migrateCatalog(source, target, migrateDryRun)
+		for _, r := range results {
+			logrus.Infof("%s/%s: %d charts, %d chart files, %s", r.Namespace, r.Name, r.Charts, r.Files, r.Status)
+		}
+		if err != nil {
+			logrus.Fatalf("Can't migrate the chart repositories: %v", err)
+		}
+		if migrateDryRun {
+			logrus.Infof("Dry run, %d chart repositories would be processed", len(results))
+			return
+		}
+		logrus.Infof("Successfully migrated %d chart repositories", len(results))
+	},
+}
+
+func init() {
+	migrateCmd.Flags().StringVar(&targetDatabaseURL, "target-database-url", "localhost", "URL of the postgresql database to migrate to")
+	migrateCmd.Flags().StringVar(&targetDatabaseName, "target-database-name", "assets", "Name of the postgresql database to migrate to")
+	migrateCmd.Flags().StringVar(&targetDatabaseUser, "target-database-user", "postgres", "User of the postgresql database to migrate to")
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Report the chart repositories to migrate without copying them")
+}
+
+const (
+	migrationCopied  = "migrated"
+	migrationSkipped = "already migrated"
+	migrationPending = "to migrate"
+)
+
+// migrationResult reports the migration of a repository.
+type migrationResult struct {
+	Namespace string
+	Name      string
+	Charts    int
+	Files     int
+	Status    string
+}
+
+// migrateCatalog copies the repositories of the source to the target,
+// skipping those already migrated. It returns the results up to the first
+// repository which could not be migrated.
+func migrateCatalog(source, target assetManager, dryRun bool) ([]migrationResult, error) {
+	repos, err := source.listRepos(dbutils.AllNamespaces, "")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].Namespace != repos[j].Namespace {
+			return repos[i].Namespace < repos[j].Namespace
+		}
+		return repos[i].Name < repos[j].Name
+	})
+
+	results := []migrationResult{}
+	for _, r := range repos {
+		repo := models.Repo{Namespace: r.Namespace, Name: r.Name}
+		charts, err := source.listCharts(repo)
+		if err != nil {
+			return results, fmt.Errorf("unable to list the charts of %s/%s: %v", r.Namespace, r.Name, err)
+		}
+		files, err := source.listFiles(repo)
+		if err != nil {
+			return results, fmt.Errorf("unable to list the chart files of %s/%s: %v", r.Namespace, r.Name, err)
+		}
+		result := migrationResult{Namespace: r.Namespace, Name: r.Name, Charts: len(charts), Files: len(files)}
+
+		// A repository is only recorded with its checksum once its copy is
+		// verified.
+		if r.Checksum != "" && target.RepoAlreadyProcessed(repo, r.Checksum) {
+			result.Status = migrationSkipped
+			results = append(results, result)
+			continue
+		}
+		if dryRun {
+			result.Status = migrationPending
+			results = append(results, result)
+			continue
+		}
+
+		info := catalogRepoInfo{Namespace: r.Namespace, Name: r.Name}
+		if len(charts) > 0 && charts[0].Repo != nil {
+			info.URL = charts[0].Repo.URL
+		}
+		if err := importCatalog(target, []catalogRepo{{Info: info, Charts: charts, Files: files}}); err != nil {
+			return results, err
+		}
+		if err := verifyMigration(target, repo, charts, files); err != nil {
+			return results, fmt.Errorf("unable to verify the migration of %s/%s: %v", r.Namespace, r.Name, err)
+		}
+		if err := target.UpdateLastCheck(r.Namespace, r.Name, r.Checksum, time.Now()); err != nil {
+			return results, err
+		}
+		result.Status = migrationCopied
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// verifyMigration checks that the target has the same charts and chart files
+// for the repository as the source.
+func verifyMigration(target assetManager, repo models.Repo, charts []models.Chart, files []models.ChartFiles) error {
+	targetCharts, err := target.listCharts(repo)
+	if err != nil {
+		return err
+	}
+	targetFiles, err := target.listFiles(repo)
+	if err != nil {
+		return err
+	}
+	if len(targetCharts) != len(charts) {
+		return fmt.Errorf("got %d charts, want %d", len(targetCharts), len(charts))
+	}
+	if len(targetFiles) != len(files) {
+		return fmt.Errorf("got %d chart files, want %d", len(targetFiles), len(files))
+	}
+	if got, want := catalogDigest(targetCharts, targetFiles), catalogDigest(charts, files); got != want {
+		return fmt.Errorf("got digest %s, want %s", got, want)
+	}
+	return nil
+}
+
+// catalogDigest returns a digest of the chart versions, icons and chart files
+// of a repository, which does not depend on the order of the charts and files
+// nor on the fields a database does not store.
+func catalogDigest(charts []models.Chart, files []models.ChartFiles) string {
+	lines := []string{}
+	for _, c := range charts {
+		lines = append(lines, fmt.Sprintf("chart %s icon %x %s", c.ID, sha256.Sum256(c.RawIcon), c.IconContentType))
+		for _, cv := range c.ChartVersions {
+			lines = append(lines, fmt.Sprintf("chart %s version %s %s", c.ID, cv.Version, cv.Digest))
+		}
+	}
+	for _, f := range files {
+		content := sha256.Sum256([]byte(strings.Join([]string{
+			f.Readme, f.Values, f.Schema, f.ChartYAML, f.Notes, f.Changelog, f.License, fmt.Sprintf("%v %v", f.CRDs, f.Dependencies),
+		}, "\x00")))
+		lines = append(lines, fmt.Sprintf("files %s %s %x", f.ID, f.Digest, content))
+	}
+	sort.Strings(lines)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(lines, "\n"))))
+}
@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+)
+
+func (f *fakeCatalogManager) RepoAlreadyProcessed(repo models.Repo, checksum string) bool {
+	for _, r := range f.repos {
+		if r.Namespace == repo.Namespace && r.Name == repo.Name {
+			return r.Checksum == checksum
+		}
+	}
+	return false
+}
+
+// lossyCatalogManager drops the chart files it is given.
+type lossyCatalogManager struct {
+	*fakeCatalogManager
+}
+
+func (f *lossyCatalogManager) insertFiles(chartID string, files models.ChartFiles) error {
+	return nil
+}
+
+func newMigrationSource() *fakeCatalogManager {
+	repo := &models.Repo{Namespace: "my-namespace", Name: "my-repo", URL: "https://example.com"}
+	otherRepo := &models.Repo{Namespace: "other-namespace", Name: "other-repo", URL: "https://other.example.com"}
+	source := newFakeCatalogManager()
+	source.repos = []models.RepoInternal{
+		{Namespace: otherRepo.Namespace, Name: otherRepo.Name, Checksum: "def"},
+		{Namespace: repo.Namespace, Name: repo.Name, Checksum: "abc"},
+	}
+	source.charts = map[string][]models.Chart{
+		"my-namespace/my-repo": {
+			{ID: "my-repo/my-chart", Name: "my-chart", Repo: repo, RawIcon: []byte("icon"), ChartVersions: []models.ChartVersion{{Version: "1.0.0", Digest: "123"}}},
+		},
+		"other-namespace/other-repo": {
+			{ID: "other-repo/other-chart", Name: "other-chart", Repo: otherRepo, ChartVersions: []models.ChartVersion{{Version: "2.0.0", Digest: "456"}}},
+		},
+	}
+	source.files = map[string][]models.ChartFiles{
+		"my-namespace/my-repo": {
+			{ID: "my-repo/my-chart-1.0.0", Readme: "readme", Repo: repo, Digest: "123"},
+		},
+	}
+	return source
+}
+
+func Test_migrateCatalog(t *testing.T) {
+	tests := []struct {
+		name        string
+		targetRepos []models.RepoInternal
+		dryRun      bool
+		wantResults []migrationResult
+		wantCharts  int
+	}{
+		{
+			"migrates every repository",
+			nil,
+			false,
+			[]migrationResult{
+				{Namespace: "my-namespace", Name: "my-repo", Charts: 1, Files: 1, Status: migrationCopied},
+				{Namespace: "other-namespace", Name: "other-repo", Charts: 1, Files: 0, Status: migrationCopied},
+			},
+			2,
+		},
+		{
+			"skips the repositories already migrated",
+			[]models.RepoInternal{{Namespace: "my-namespace", Name: "my-repo", Checksum: "abc"}},
+			false,
+			[]migrationResult{
+				{Namespace: "my-namespace", Name: "my-repo", Charts: 1, Files: 1, Status: migrationSkipped},
+				{Namespace: "other-namespace", Name: "other-repo", Charts: 1, Files: 0, Status: migrationCopied},
+			},
+			1,
+		},
+		{
+			"migrates again a repository with a different checksum",
+			[]models.RepoInternal{{Namespace: "my-namespace", Name: "my-repo", Checksum: "old"}},
+			false,
+			[]migrationResult{
+				{Namespace: "my-namespace", Name: "my-repo", Charts: 1, Files: 1, Status: migrationCopied},
+				{Namespace: "other-namespace", Name: "other-repo", Charts: 1, Files: 0, Status: migrationCopied},
+			},
+			2,
+		},
+		{
+			"dry run",
+			[]models.RepoInternal{{Namespace: "my-namespace", Name: "my-repo", Checksum: "abc"}},
+			true,
+			[]migrationResult{
+				{Namespace: "my-namespace", Name: "my-repo", Charts: 1, Files: 1, Status: migrationSkipped},
+				{Namespace: "other-namespace", Name: "other-repo", Charts: 1, Files: 0, Status: migrationPending},
+			},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newMigrationSource()
+			target := newFakeCatalogManager()
+			target.repos = tt.targetRepos
+
+			results, err := migrateCatalog(source, target, tt.dryRun)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !cmp.Equal(tt.wantResults, results) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.wantResults, results))
+			}
+			if got, want := len(target.charts), tt.wantCharts; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
+func Test_migrateCatalogVerification(t *testing.T) {
+	source := newMigrationSource()
+	target := &lossyCatalogManager{newFakeCatalogManager()}
+
+	results, err := migrateCatalog(source, target, false)
+	if err == nil {
+		t.Fatalf("got: nil, want: error")
+	}
+	if got, want := len(results), 0; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+	if target.RepoAlreadyProcessed(models.Repo{Namespace: "my-namespace", Name: "my-repo"}, "abc") {
+		t.Errorf("the repository should not be recorded as migrated")
+	}
+}
+
+func Test_catalogDigest(t *testing.T) {
+	charts := []models.Chart{
+		{ID: "repo/a", ChartVersions: []models.ChartVersion{{Version: "1.0.0", Digest: "123", Readme: "ignored"}}},
+		{ID: "repo/b", RawIcon: []byte("icon")},
+	}
+	reordered := []models.Chart{charts[1], {ID: "repo/a", ChartVersions: []models.ChartVersion{{Version: "1.0.0", Digest: "123"}}}}
+	if got, want := catalogDigest(reordered, nil), catalogDigest(charts, nil); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	changedIcon := []models.Chart{charts[0], {ID: "repo/b", RawIcon: []byte("other icon")}}
+	if catalogDigest(changedIcon, nil) == catalogDigest(charts, nil) {
+		t.Errorf("the digest should depend on the icons")
+	}
+}
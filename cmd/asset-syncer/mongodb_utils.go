@@ -18,8 +18,10 @@ package main
 
 import (
 	"fmt"
+	"regexp"
 	"time"
 
+	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	"github.com/kubeapps/common/datastore"
 	"github.com/kubeapps/kubeapps/pkg/chart/models"
@@ -84,6 +86,22 @@ func (m *mongodbAssetManager) Delete(repo models.Repo) error {
 		return err
 	}
 
+	// Remove the mirrored tarballs, whose chunks are found by the prefix of
+	// the file IDs since they do not include the repository.
+	_, err = db.C(dbutils.ChartTarballsGridFS + ".files").RemoveAll(bson.M{
+		"metadata.repo.name":      repo.Name,
+		"metadata.repo.namespace": repo.Namespace,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.C(dbutils.ChartTarballsGridFS + ".chunks").RemoveAll(bson.M{
+		"files_id": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(dbutils.ChartTarballID(repo.Namespace, repo.Name, ""))},
+	})
+	if err != nil {
+		return err
+	}
+
 	_, err = db.C(dbutils.RepositoryCollection).RemoveAll(bson.M{
 		"name":      repo.Name,
 		"namespace": repo.Namespace,
@@ -145,3 +163,82 @@ func (m *mongodbAssetManager) insertFiles(chartId string, files models.ChartFile
 	_, err := db.C(dbutils.ChartFilesCollection).Upsert(bson.M{"file_id": files.ID, "repo.name": files.Repo.Name, "repo.namespace": files.Repo.Namespace}, files)
 	return err
 }
+
+func (m *mongodbAssetManager) tarballExists(repo models.Repo, digest string) bool {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	err := db.C(dbutils.ChartTarballsGridFS + ".files").FindId(dbutils.ChartTarballID(repo.Namespace, repo.Name, digest)).One(&bson.M{})
+	return err == nil
+}
+
+func (m *mongodbAssetManager) insertTarball(repo models.Repo, digest string, data []byte) error {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	gfs, err := dbutils.GridFS(db, dbutils.ChartTarballsGridFS)
+	if err != nil {
+		return err
+	}
+
+	f, err := gfs.Create(digest)
+	if err != nil {
+		return err
+	}
+	f.SetId(dbutils.ChartTarballID(repo.Namespace, repo.Name, digest))
+	f.SetContentType("application/gzip")
+	f.SetMeta(bson.M{"repo": bson.M{"name": repo.Name, "namespace": repo.Namespace}, "digest": digest})
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (m *mongodbAssetManager) listFailedVersions(repo models.Repo) ([]models.FailedChartVersion, error) {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	lastCheck := &models.RepoCheck{}
+	err := db.C(dbutils.RepositoryCollection).Find(bson.M{"name": repo.Name, "namespace": repo.Namespace}).One(lastCheck)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return lastCheck.FailedVersions, err
+}
+
+func (m *mongodbAssetManager) updateFailedVersions(repo models.Repo, versions []models.FailedChartVersion) error {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	_, err := db.C(dbutils.RepositoryCollection).Upsert(bson.M{"name": repo.Name, "namespace": repo.Namespace}, bson.M{"$set": bson.M{"failed_versions": versions}})
+	return err
+}
+
+func (m *mongodbAssetManager) listRepos(namespace, name string) ([]models.RepoInternal, error) {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	query := bson.M{}
+	if namespace != dbutils.AllNamespaces {
+		query["namespace"] = namespace
+	}
+	if name != "" {
+		query["name"] = name
+	}
+	var repos []models.RepoInternal
+	err := db.C(dbutils.RepositoryCollection).Find(query).All(&repos)
+	return repos, err
+}
+
+func (m *mongodbAssetManager) listCharts(repo models.Repo) ([]models.Chart, error) {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	var charts []models.Chart
+	err := db.C(dbutils.ChartCollection).Find(bson.M{"repo.name": repo.Name, "repo.namespace": repo.Namespace}).All(&charts)
+	return charts, err
+}
+
+func (m *mongodbAssetManager) listFiles(repo models.Repo) ([]models.ChartFiles, error) {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	var files []models.ChartFiles
+	err := db.C(dbutils.ChartFilesCollection).Find(bson.M{"repo.name": repo.Name, "repo.namespace": repo.Namespace}).All(&files)
+	return files, err
+}
@@ -72,6 +72,13 @@ func Test_DeleteRepo(t *testing.T) {
 		"repo.name":      repo.Name,
 		"repo.namespace": repo.Namespace,
 	})
+	m.On("RemoveAll", bson.M{
+		"metadata.repo.name":      repo.Name,
+		"metadata.repo.namespace": repo.Namespace,
+	})
+	m.On("RemoveAll", bson.M{
+		"files_id": bson.RegEx{Pattern: "^repo-namespace/repo-name/"},
+	})
 	m.On("RemoveAll", bson.M{
 		"name":      repo.Name,
 		"namespace": repo.Namespace,
@@ -138,3 +145,48 @@ func Test_updateLastCheck(t *testing.T) {
 		t.Errorf("Expected one call got %d", len(m.Calls))
 	}
 }
+
+func Test_updateFailedVersions(t *testing.T) {
+	m := &mock.Mock{}
+	failed := []models.FailedChartVersion{{Chart: "foo", Version: "1.0.0", Error: "503"}}
+	m.On("Upsert", bson.M{"name": "repo-name", "namespace": "repo-namespace"}, bson.M{"$set": bson.M{"failed_versions": failed}}).Return(nil)
+	manager := getMockManager(m)
+	err := manager.updateFailedVersions(models.Repo{Name: "repo-name", Namespace: "repo-namespace"}, failed)
+	m.AssertExpectations(t)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func Test_listFailedVersions(t *testing.T) {
+	m := &mock.Mock{}
+	failed := []models.FailedChartVersion{{Chart: "foo", Version: "1.0.0", Error: "503"}}
+	m.On("One", &models.RepoCheck{}).Run(func(args mock.Arguments) {
+		*args.Get(0).(*models.RepoCheck) = models.RepoCheck{FailedVersions: failed}
+	}).Return(nil)
+	manager := getMockManager(m)
+	got, err := manager.listFailedVersions(models.Repo{Name: "repo-name", Namespace: "repo-namespace"})
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	assert.Equal(t, got, failed, "failed versions")
+	m.AssertExpectations(t)
+}
+
+func Test_tarballExists(t *testing.T) {
+	m := &mock.Mock{}
+	m.On("One", &bson.M{}).Return(nil)
+	manager := getMockManager(m)
+	if !manager.tarballExists(models.Repo{Name: "repo-name", Namespace: "repo-namespace"}, "abc") {
+		t.Errorf("expected the tarball to exist")
+	}
+	m.AssertExpectations(t)
+}
+
+func Test_insertTarballWithoutGridFS(t *testing.T) {
+	manager := getMockManager(&mock.Mock{})
+	err := manager.insertTarball(models.Repo{Name: "repo-name", Namespace: "repo-namespace"}, "abc", []byte("tarball"))
+	if got, want := err, dbutils.ErrGridFSNotSupported; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
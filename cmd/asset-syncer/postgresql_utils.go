@@ -186,3 +186,140 @@ func (m *postgresAssetManager) insertFiles(chartId string, files models.ChartFil
 	}
 	return err
 }
+
+func (m *postgresAssetManager) tarballExists(repo models.Repo, digest string) bool {
+	var exists bool
+	err := m.DB.QueryRow(
+		fmt.Sprintf(`
+SELECT EXISTS(
+	SELECT 1 FROM %s
+	WHERE repo_name = $1 AND
+		repo_namespace = $2 AND
+		digest = $3
+	)`, dbutils.ChartTarballsTable),
+		repo.Name, repo.Namespace, digest).Scan(&exists)
+	return err == nil && exists
+}
+
+func (m *postgresAssetManager) insertTarball(repo models.Repo, digest string, data []byte) error {
+	query := fmt.Sprintf(`INSERT INTO %s (repo_name, repo_namespace, digest, data)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (repo_namespace, repo_name, digest)
+	DO NOTHING
+	`, dbutils.ChartTarballsTable)
+	rows, err := m.DB.Query(query, repo.Name, repo.Namespace, digest, data)
+	if rows != nil {
+		defer rows.Close()
+	}
+	return err
+}
+
+func (m *postgresAssetManager) listFailedVersions(repo models.Repo) ([]models.FailedChartVersion, error) {
+	var info string
+	err := m.DB.QueryRow(fmt.Sprintf("SELECT info FROM %s WHERE repo_namespace = $1 AND repo_name = $2", dbutils.FailedVersionsTable), repo.Namespace, repo.Name).Scan(&info)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []models.FailedChartVersion
+	err = json.Unmarshal([]byte(info), &versions)
+	return versions, err
+}
+
+func (m *postgresAssetManager) updateFailedVersions(repo models.Repo, versions []models.FailedChartVersion) error {
+	if len(versions) == 0 {
+		rows, err := m.DB.Query(fmt.Sprintf("DELETE FROM %s WHERE repo_namespace = $1 AND repo_name = $2", dbutils.FailedVersionsTable), repo.Namespace, repo.Name)
+		if rows != nil {
+			defer rows.Close()
+		}
+		return err
+	}
+	d, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (repo_name, repo_namespace, info)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (repo_namespace, repo_name)
+	DO UPDATE SET info = $3
+	`, dbutils.FailedVersionsTable)
+	rows, err := m.DB.Query(query, repo.Name, repo.Namespace, string(d))
+	if rows != nil {
+		defer rows.Close()
+	}
+	return err
+}
+
+func (m *postgresAssetManager) listRepos(namespace, name string) ([]models.RepoInternal, error) {
+	clauses := []string{}
+	queryParams := []interface{}{}
+	if namespace != dbutils.AllNamespaces {
+		queryParams = append(queryParams, namespace)
+		clauses = append(clauses, fmt.Sprintf("namespace = $%d", len(queryParams)))
+	}
+	if name != "" {
+		queryParams = append(queryParams, name)
+		clauses = append(clauses, fmt.Sprintf("name = $%d", len(queryParams)))
+	}
+	query := fmt.Sprintf("SELECT namespace, name, checksum FROM %s", dbutils.RepositoryTable)
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	rows, err := m.DB.Query(query, queryParams...)
+	if rows != nil {
+		defer rows.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	repos := []models.RepoInternal{}
+	for rows.Next() {
+		var repo models.RepoInternal
+		var checksum sql.NullString
+		if err := rows.Scan(&repo.Namespace, &repo.Name, &checksum); err != nil {
+			return nil, err
+		}
+		repo.Checksum = checksum.String
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
+
+func (m *postgresAssetManager) listCharts(repo models.Repo) ([]models.Chart, error) {
+	// The raw icons are stored base64 encoded, which is how they are
+	// unmarshaled into a []byte.
+	charts, err := m.QueryAllCharts(fmt.Sprintf("SELECT info FROM %s WHERE repo_namespace = $1 AND repo_name = $2", dbutils.ChartTable), repo.Namespace, repo.Name)
+	if err != nil {
+		return nil, err
+	}
+	result := []models.Chart{}
+	for _, c := range charts {
+		result = append(result, *c)
+	}
+	return result, nil
+}
+
+func (m *postgresAssetManager) listFiles(repo models.Repo) ([]models.ChartFiles, error) {
+	rows, err := m.DB.Query(fmt.Sprintf("SELECT info FROM %s WHERE repo_namespace = $1 AND repo_name = $2", dbutils.ChartFilesTable), repo.Namespace, repo.Name)
+	if rows != nil {
+		defer rows.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	files := []models.ChartFiles{}
+	for rows.Next() {
+		var info string
+		if err := rows.Scan(&info); err != nil {
+			return nil, err
+		}
+		var f models.ChartFiles
+		if err := json.Unmarshal([]byte(info), &f); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
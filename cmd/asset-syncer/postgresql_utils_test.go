@@ -2,10 +2,12 @@ package main
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/go-cmp/cmp"
 	"github.com/kubeapps/common/datastore"
 	"github.com/kubeapps/kubeapps/pkg/chart/models"
 	"github.com/kubeapps/kubeapps/pkg/dbutils"
@@ -172,3 +174,185 @@ func Test_PGinsertFiles(t *testing.T) {
 	}
 	m.AssertExpectations(t)
 }
+
+func Test_PGtarballExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	rows := sqlmock.NewRows([]string{"exists"}).AddRow(`true`)
+	mock.ExpectQuery(`^SELECT EXISTS\(
+	SELECT 1 FROM tarballs
+	WHERE repo_name = \$1 AND
+		repo_namespace = \$2 AND
+		digest = \$3
+	\)$`).WithArgs("repo-name", "namespace", "abc").WillReturnRows(rows)
+	man := &dbutils.PostgresAssetManager{DB: db}
+	pgManager := &postgresAssetManager{man}
+	exists := pgManager.tarballExists(models.Repo{Namespace: "namespace", Name: "repo-name"}, "abc")
+	if exists != true {
+		t.Errorf("Failed to check if tarball exists")
+	}
+	err = mock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("err %v", err)
+	}
+}
+
+func Test_PGinsertTarball(t *testing.T) {
+	data := []byte("tarball")
+	m := &mockDB{&mock.Mock{}}
+	man, _ := dbutils.NewPGManager(datastore.Config{URL: "localhost:4123"}, dbutilstest.KubeappsTestNamespace)
+	man.DB = m
+	pgManager := &postgresAssetManager{man}
+	m.On(
+		"Query",
+		`INSERT INTO tarballs (repo_name, repo_namespace, digest, data)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (repo_namespace, repo_name, digest)
+	DO NOTHING
+	`,
+		[]interface{}{"my-repo", "my-namespace", "abc", data},
+	)
+	err := pgManager.insertTarball(models.Repo{Namespace: "my-namespace", Name: "my-repo"}, "abc", data)
+	if err != nil {
+		t.Errorf("Failed to insert tarball: %+v", err)
+	}
+	m.AssertExpectations(t)
+}
+
+func Test_PGlistFailedVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+		want []models.FailedChartVersion
+	}{
+		{"no failed versions", sqlmock.NewRows([]string{"info"}), nil},
+		{"failed versions", sqlmock.NewRows([]string{"info"}).AddRow(`[{"chart": "foo", "version": "1.0.0", "error": "503"}]`), []models.FailedChartVersion{{Chart: "foo", Version: "1.0.0", Error: "503"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			mock.ExpectQuery(`^SELECT info FROM failed_versions WHERE repo_namespace = \$1 AND repo_name = \$2$`).WithArgs("my-namespace", "my-repo").WillReturnRows(tt.rows)
+			man := &dbutils.PostgresAssetManager{DB: db}
+			pgManager := &postgresAssetManager{man}
+
+			failed, err := pgManager.listFailedVersions(models.Repo{Namespace: "my-namespace", Name: "my-repo"})
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !cmp.Equal(tt.want, failed) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.want, failed))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("err %v", err)
+			}
+		})
+	}
+}
+
+func Test_PGupdateFailedVersions(t *testing.T) {
+	repo := models.Repo{Namespace: "my-namespace", Name: "my-repo"}
+	t.Run("failed versions", func(t *testing.T) {
+		m := &mockDB{&mock.Mock{}}
+		man, _ := dbutils.NewPGManager(datastore.Config{URL: "localhost:4123"}, dbutilstest.KubeappsTestNamespace)
+		man.DB = m
+		pgManager := &postgresAssetManager{man}
+		m.On(
+			"Query",
+			`INSERT INTO failed_versions (repo_name, repo_namespace, info)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (repo_namespace, repo_name)
+	DO UPDATE SET info = $3
+	`,
+			[]interface{}{"my-repo", "my-namespace", `[{"chart":"foo","version":"1.0.0","error":"503"}]`},
+		)
+		err := pgManager.updateFailedVersions(repo, []models.FailedChartVersion{{Chart: "foo", Version: "1.0.0", Error: "503"}})
+		if err != nil {
+			t.Errorf("Failed to update failed versions: %+v", err)
+		}
+		m.AssertExpectations(t)
+	})
+
+	t.Run("no failed versions", func(t *testing.T) {
+		m := &mockDB{&mock.Mock{}}
+		man, _ := dbutils.NewPGManager(datastore.Config{URL: "localhost:4123"}, dbutilstest.KubeappsTestNamespace)
+		man.DB = m
+		pgManager := &postgresAssetManager{man}
+		m.On("Query", "DELETE FROM failed_versions WHERE repo_namespace = $1 AND repo_name = $2", []interface{}{"my-namespace", "my-repo"})
+		if err := pgManager.updateFailedVersions(repo, nil); err != nil {
+			t.Errorf("Failed to update failed versions: %+v", err)
+		}
+		m.AssertExpectations(t)
+	})
+}
+
+func Test_PGlistRepos(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		repo      string
+		query     string
+		args      []driver.Value
+	}{
+		{"all namespaces", dbutils.AllNamespaces, "", `^SELECT namespace, name, checksum FROM repos$`, []driver.Value{}},
+		{"namespace", "my-namespace", "", `^SELECT namespace, name, checksum FROM repos WHERE namespace = \$1$`, []driver.Value{"my-namespace"}},
+		{"repository", "my-namespace", "my-repo", `^SELECT namespace, name, checksum FROM repos WHERE namespace = \$1 AND name = \$2$`, []driver.Value{"my-namespace", "my-repo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			rows := sqlmock.NewRows([]string{"namespace", "name", "checksum"}).
+				AddRow("my-namespace", "my-repo", "abc").
+				AddRow("my-namespace", "other-repo", nil)
+			mock.ExpectQuery(tt.query).WithArgs(tt.args...).WillReturnRows(rows)
+			man := &dbutils.PostgresAssetManager{DB: db}
+			pgManager := &postgresAssetManager{man}
+
+			repos, err := pgManager.listRepos(tt.namespace, tt.repo)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			want := []models.RepoInternal{
+				{Namespace: "my-namespace", Name: "my-repo", Checksum: "abc"},
+				{Namespace: "my-namespace", Name: "other-repo"},
+			}
+			if !cmp.Equal(want, repos) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, repos))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("err %v", err)
+			}
+		})
+	}
+}
+
+func Test_PGlistFiles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	rows := sqlmock.NewRows([]string{"info"}).AddRow(`{"ID": "my-repo/my-chart-1.0.0", "Readme": "readme"}`)
+	mock.ExpectQuery(`^SELECT info FROM files WHERE repo_namespace = \$1 AND repo_name = \$2$`).
+		WithArgs("my-namespace", "my-repo").WillReturnRows(rows)
+	man := &dbutils.PostgresAssetManager{DB: db}
+	pgManager := &postgresAssetManager{man}
+
+	files, err := pgManager.listFiles(models.Repo{Namespace: "my-namespace", Name: "my-repo"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := []models.ChartFiles{{ID: "my-repo/my-chart-1.0.0", Readme: "readme"}}
+	if !cmp.Equal(want, files) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, files))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("err %v", err)
+	}
+}
@@ -26,6 +26,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	mirror    bool
+	rateLimit float64
+)
+
 var syncCmd = &cobra.Command{
 	Use:   "sync [REPO NAME] [REPO URL]",
 	Short: "add a new chart repository, and resync its charts periodically",
@@ -40,6 +45,8 @@ var syncCmd = &cobra.Command{
 			logrus.SetLevel(logrus.DebugLevel)
 		}
 
+		hostLimiter = newHostRateLimiter(rateLimit)
+
 		dbConfig := datastore.Config{URL: databaseURL, Database: databaseName, Username: databaseUser, Password: databasePassword}
 		kubeappsNamespace := os.Getenv("POD_NAMESPACE")
 		manager, err := newManager(databaseType, dbConfig, kubeappsNamespace)
@@ -52,42 +59,81 @@ var syncCmd = &cobra.Command{
 		}
 		defer manager.Close()
 
-		authorizationHeader := os.Getenv("AUTHORIZATION_HEADER")
-		repo, repoContent, err := getRepo(namespace, args[0], args[1], authorizationHeader)
+		authorizationHeader := authorizationHeaderFromEnv()
+		repo, charts, err := getRepo(namespace, args[0], args[1], authorizationHeader)
 		if err != nil {
 			logrus.Fatal(err)
 		}
-
-		// Check if the repo has been already processed
-		if manager.RepoAlreadyProcessed(models.Repo{Namespace: repo.Namespace, Name: repo.Name}, repo.Checksum) {
-			logrus.WithFields(logrus.Fields{"url": repo.URL}).Info("Skipping repository since there are no updates")
-			return
+		repo.Mirror = mirror
+		if mirror {
+			// Record mirrored repositories with a different checksum so that the
+			// tarballs are fetched when mirroring is enabled for a synced repo.
+			repo.Checksum += "-mirror"
 		}
 
-		index, err := parseRepoIndex(repoContent)
-		if err != nil {
-			logrus.Fatal(err)
+		// Check if the repo has been already processed, in which case only the
+		// chart versions that failed in the previous sync are fetched again
+		repoModel := models.Repo{Namespace: repo.Namespace, Name: repo.Name}
+		if manager.RepoAlreadyProcessed(repoModel, repo.Checksum) {
+			failed, err := manager.listFailedVersions(repoModel)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if len(failed) == 0 {
+				logrus.WithFields(logrus.Fields{"url": repo.URL}).Info("Skipping repository since there are no updates")
+				return
+			}
+
+			fImporter := fileImporter{manager}
+			stillFailed := fImporter.retryFailedVersions(charts, failed, repo)
+			// Update the last check when files were imported, so that the
+			// responses cached by the clients are revalidated
+			if len(stillFailed) < len(failed) {
+				if err = manager.UpdateLastCheck(repo.Namespace, repo.Name, repo.Checksum, time.Now()); err != nil {
+					logrus.Fatal(err)
+				}
+			}
+			if err = manager.updateFailedVersions(repoModel, stillFailed); err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.WithFields(logrus.Fields{"url": repo.URL, "retried": len(failed), "failed": len(stillFailed)}).Info("Retried the chart versions that failed in the previous sync")
+			return
 		}
 
-		charts := chartsFromIndex(index, &models.Repo{Namespace: repo.Namespace, Name: repo.Name, URL: repo.URL})
 		if len(charts) == 0 {
 			logrus.Fatal("no charts in repository index")
 		}
 
-		if err = manager.Sync(models.Repo{Name: repo.Name, Namespace: repo.Namespace}, charts); err != nil {
+		if err = manager.Sync(repoModel, charts); err != nil {
 			logrus.Fatalf("Can't add chart repository to database: %v", err)
 		}
 
 		// Fetch and store chart icons
 		fImporter := fileImporter{manager}
-		fImporter.fetchFiles(charts, repo)
+		failed := fImporter.fetchFiles(charts, repo)
 
 		// Update cache in the database
 		if err = manager.UpdateLastCheck(repo.Namespace, repo.Name, repo.Checksum, time.Now()); err != nil {
 			logrus.Fatal(err)
 		}
+		// Record the chart versions that could not be imported, to retry them
+		// in the next sync even if the repository index does not change
+		if err = manager.updateFailedVersions(repoModel, failed); err != nil {
+			logrus.Fatal(err)
+		}
+		if len(failed) > 0 {
+			logrus.WithFields(logrus.Fields{"url": repo.URL, "failed": len(failed)}).Warn("Some chart versions could not be imported, they will be retried in the next sync")
+		}
 		logrus.WithFields(logrus.Fields{"url": repo.URL}).Info("Stored repository update in cache")
 
 		logrus.Infof("Successfully added the chart repository %s to database", args[0])
 	},
 }
+
+func init() {
+	syncCmd.Flags().BoolVar(&mirror, "mirror", false, "store the chart tarballs in the database, to install them when the repository is unreachable")
+	syncCmd.Flags().IntVar(&workers, "workers", 10, "number of chart icons and files fetched concurrently")
+	syncCmd.Flags().Float64Var(&rateLimit, "rate-limit", 0, "maximum number of requests per second sent to each host, unlimited when 0")
+	syncCmd.Flags().IntVar(&maxRetries, "max-retries", 3, "number of times a request is retried when it times out or gets a 429 or 5xx response")
+	syncCmd.Flags().DurationVar(&retryBackoff, "retry-backoff", time.Second, "delay before retrying a request, doubled for each retry")
+}
@@ -23,6 +23,7 @@ import (
 	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -31,6 +32,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -40,7 +42,9 @@ import (
 	"github.com/jinzhu/copier"
 	"github.com/kubeapps/common/datastore"
 	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/repoindex"
 	log "github.com/sirupsen/logrus"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 	helmrepo "k8s.io/helm/pkg/repo"
 )
 
@@ -68,7 +72,7 @@ func parseRepoURL(repoURL string) (*url.URL, error) {
 
 func init() {
 	var err error
-	netClient, err = initNetClient(additionalCAFile)
+	netClient, err = initNetClient(additionalCAFile, os.Getenv("CLIENT_CERT"), os.Getenv("CLIENT_KEY"))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -85,6 +89,13 @@ type assetManager interface {
 	updateIcon(repo models.Repo, data []byte, contentType, ID string) error
 	filesExist(repo models.Repo, chartFilesID, digest string) bool
 	insertFiles(chartId string, files models.ChartFiles) error
+	tarballExists(repo models.Repo, digest string) bool
+	insertTarball(repo models.Repo, digest string, data []byte) error
+	listFailedVersions(repo models.Repo) ([]models.FailedChartVersion, error)
+	updateFailedVersions(repo models.Repo, versions []models.FailedChartVersion) error
+	listRepos(namespace, name string) ([]models.RepoInternal, error)
+	listCharts(repo models.Repo) ([]models.Chart, error)
+	listFiles(repo models.Repo) ([]models.ChartFiles, error)
 }
 
 func newManager(databaseType string, config datastore.Config, kubeappsNamespace string) (assetManager, error) {
@@ -92,6 +103,8 @@ func newManager(databaseType string, config datastore.Config, kubeappsNamespace
 		return newMongoDBManager(config, kubeappsNamespace), nil
 	} else if databaseType == "postgresql" {
 		return newPGManager(config, kubeappsNamespace)
+	} else if databaseType == "bolt" {
+		return newBoltManager(config, kubeappsNamespace), nil
 	} else {
 		return nil, fmt.Errorf("Unsupported database type %s", databaseType)
 	}
@@ -106,27 +119,46 @@ func getSha256(src []byte) (string, error) {
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
-func getRepo(namespace, name, repoURL, authorizationHeader string) (*models.RepoInternal, []byte, error) {
+// getRepo requests the index of a repository and decodes its charts. The
+// checksum of the index is computed while it is decoded, from its decompressed
+// content, so that it is the same whether the index is served compressed or
+// not.
+func getRepo(namespace, name, repoURL, authorizationHeader string) (*models.RepoInternal, []models.Chart, error) {
 	url, err := parseRepoURL(repoURL)
 	if err != nil {
 		log.WithFields(log.Fields{"url": repoURL}).WithError(err).Error("failed to parse URL")
-		return nil, []byte{}, err
+		return nil, nil, err
 	}
 
-	repoBytes, err := fetchRepoIndex(url.String(), authorizationHeader)
+	body, err := fetchRepoIndex(url.String(), authorizationHeader)
 	if err != nil {
-		return nil, []byte{}, err
+		return nil, nil, err
 	}
+	defer body.Close()
 
-	repoChecksum, err := getSha256(repoBytes)
+	content, err := repoindex.Decompress(body)
 	if err != nil {
-		return nil, []byte{}, err
+		return nil, nil, err
 	}
+	h := sha256.New()
+	index := io.TeeReader(content, h)
+	charts, err := chartsFromIndexReader(index, &models.Repo{Namespace: namespace, Name: name, URL: url.String()})
+	if err != nil {
+		return nil, nil, err
+	}
+	// Read what is left after the charts, like the end of a JSON index, so
+	// that the checksum is the one of the whole index
+	if _, err := io.Copy(ioutil.Discard, index); err != nil {
+		return nil, nil, err
+	}
+	repoChecksum := fmt.Sprintf("%x", h.Sum(nil))
 
-	return &models.RepoInternal{Namespace: namespace, Name: name, URL: url.String(), Checksum: repoChecksum, AuthorizationHeader: authorizationHeader}, repoBytes, nil
+	return &models.RepoInternal{Namespace: namespace, Name: name, URL: url.String(), Checksum: repoChecksum, AuthorizationHeader: authorizationHeader}, charts, nil
 }
 
-func fetchRepoIndex(url, authHeader string) ([]byte, error) {
+// fetchRepoIndex returns the body of the response to the index request, which
+// must be closed by the caller
+func fetchRepoIndex(url, authHeader string) (io.ReadCloser, error) {
 	indexURL, err := parseRepoURL(url)
 	if err != nil {
 		log.WithFields(log.Fields{"url": url}).WithError(err).Error("failed to parse URL")
@@ -140,39 +172,33 @@ func fetchRepoIndex(url, authHeader string) ([]byte, error) {
 	}
 
 	req.Header.Set("User-Agent", userAgent())
+	// Request the index compressed when the server supports it, it is
+	// decompressed while its charts are decoded
+	req.Header.Set("Accept-Encoding", "gzip")
 	if len(authHeader) > 0 {
 		req.Header.Set("Authorization", authHeader)
 	}
 
 	res, err := netClient.Do(req)
-	if res != nil {
-		defer res.Body.Close()
-	}
 	if err != nil {
+		if res != nil {
+			res.Body.Close()
+		}
 		log.WithFields(log.Fields{"url": req.URL.String()}).WithError(err).Error("error requesting repo index")
 		return nil, err
 	}
 
 	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
 		log.WithFields(log.Fields{"url": req.URL.String(), "status": res.StatusCode}).Error("error requesting repo index, are you sure this is a chart repository?")
 		return nil, errors.New("repo index request failed")
 	}
 
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	return body, nil
+	return res.Body, nil
 }
 
 func parseRepoIndex(body []byte) (*helmrepo.IndexFile, error) {
-	var index helmrepo.IndexFile
-	err := yaml.Unmarshal(body, &index)
-	if err != nil {
-		return nil, err
-	}
-	index.SortEntries()
-	return &index, nil
+	return repoindex.Load(bytes.NewReader(body))
 }
 
 func chartsFromIndex(index *helmrepo.IndexFile, r *models.Repo) []models.Chart {
@@ -187,6 +213,24 @@ func chartsFromIndex(index *helmrepo.IndexFile, r *models.Repo) []models.Chart {
 	return charts
 }
 
+// chartsFromIndexReader decodes the charts of an index one at a time with
+// repoindex.Decode. The index can be in YAML or JSON and gzip compressed.
+func chartsFromIndexReader(index io.Reader, r *models.Repo) ([]models.Chart, error) {
+	var charts []models.Chart
+	_, err := repoindex.Decode(index, func(name string, versions helmrepo.ChartVersions) error {
+		if versions[0].GetDeprecated() {
+			log.WithFields(log.Fields{"name": versions[0].GetName()}).Info("skipping deprecated chart")
+			return nil
+		}
+		charts = append(charts, newChart(versions, r))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return charts, nil
+}
+
 // Takes an entry from the index and constructs a database representation of the
 // object.
 func newChart(entry helmrepo.ChartVersions, r *models.Repo) models.Chart {
@@ -198,6 +242,9 @@ func newChart(entry helmrepo.ChartVersions, r *models.Repo) models.Chart {
 	return c
 }
 
+// extractFilesFromTarball returns the content of the given files of a
+// tarball. A filename ending with a slash matches every file of the
+// directory, which is returned with its own name.
 func extractFilesFromTarball(filenames []string, tarf *tar.Reader) (map[string]string, error) {
 	ret := make(map[string]string)
 	for {
@@ -210,17 +257,96 @@ func extractFilesFromTarball(filenames []string, tarf *tar.Reader) (map[string]s
 		}
 
 		for _, f := range filenames {
-			if strings.EqualFold(header.Name, f) {
-				var b bytes.Buffer
-				io.Copy(&b, tarf)
-				ret[f] = string(b.Bytes())
-				break
+			name := f
+			if strings.HasSuffix(f, "/") {
+				if header.Typeflag == tar.TypeDir || !strings.HasPrefix(header.Name, f) {
+					continue
+				}
+				name = header.Name
+			} else if !strings.EqualFold(header.Name, f) {
+				continue
 			}
+			var b bytes.Buffer
+			io.Copy(&b, tarf)
+			ret[name] = string(b.Bytes())
+			break
 		}
 	}
 	return ret, nil
 }
 
+// dependenciesFromFiles returns the dependencies declared in the Chart.yaml of
+// a chart or, if there are none, in its requirements.yaml
+func dependenciesFromFiles(chartYAML, requirements, chartName string) []models.ChartDependency {
+	for _, f := range []struct {
+		name    string
+		content string
+	}{{"Chart.yaml", chartYAML}, {"requirements.yaml", requirements}} {
+		if f.content == "" {
+			continue
+		}
+		var deps struct {
+			Dependencies []models.ChartDependency `json:"dependencies"`
+		}
+		if err := yaml.Unmarshal([]byte(f.content), &deps); err != nil {
+			log.WithFields(log.Fields{"name": chartName, "file": f.name}).WithError(err).Info("unable to parse the dependencies")
+			continue
+		}
+		if len(deps.Dependencies) > 0 {
+			return deps.Dependencies
+		}
+	}
+	return nil
+}
+
+// crdsFromFiles returns the CustomResourceDefinitions of the YAML or JSON
+// files of a chart, which may have several documents. Other resources are
+// ignored.
+func crdsFromFiles(files map[string]string, chartName string) []models.ChartCRD {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	crds := []models.ChartCRD{}
+	for _, name := range names {
+		decoder := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(files[name]), 4096)
+		for {
+			var doc struct {
+				Kind     string `json:"kind"`
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Spec struct {
+					Group string `json:"group"`
+					Names struct {
+						Kind string `json:"kind"`
+					} `json:"names"`
+				} `json:"spec"`
+			}
+			err := decoder.Decode(&doc)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.WithFields(log.Fields{"name": chartName, "file": name}).WithError(err).Info("invalid CRD file")
+				break
+			}
+			if doc.Kind != "CustomResourceDefinition" {
+				continue
+			}
+			crds = append(crds, models.ChartCRD{
+				Name:  doc.Metadata.Name,
+				Group: doc.Spec.Group,
+				Kind:  doc.Spec.Names.Kind,
+				File:  strings.TrimPrefix(name, chartName+"/"),
+			})
+		}
+	}
+	return crds
+}
+
 func chartTarballURL(r *models.RepoInternal, cv models.ChartVersion) string {
 	source := cv.URLs[0]
 	if _, err := parseRepoURL(source); err != nil {
@@ -234,7 +360,26 @@ func chartTarballURL(r *models.RepoInternal, cv models.ChartVersion) string {
 	return source
 }
 
-func initNetClient(additionalCA string) (*http.Client, error) {
+// authorizationHeaderFromEnv returns the Authorization header to use with the
+// repository, from the env vars set by the apprepository controller. An
+// explicit header takes precedence over a bearer token or basic auth.
+func authorizationHeaderFromEnv() string {
+	if header := os.Getenv("AUTHORIZATION_HEADER"); header != "" {
+		return header
+	}
+	if token := os.Getenv("BEARER_TOKEN"); token != "" {
+		return "Bearer " + token
+	}
+	if username := os.Getenv("BASIC_AUTH_USERNAME"); username != "" {
+		credentials := username + ":" + os.Getenv("BASIC_AUTH_PASSWORD")
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	return ""
+}
+
+// initNetClient returns an HTTP client trusting the additionalCA, if it
+// exists, and presenting the client certificate, if any (PEM encoded).
+func initNetClient(additionalCA, clientCert, clientKey string) (*http.Client, error) {
 	// Get the SystemCertPool, continue with an empty pool on error
 	caCertPool, _ := x509.SystemCertPool()
 	if caCertPool == nil {
@@ -254,14 +399,23 @@ func initNetClient(additionalCA string) (*http.Client, error) {
 		}
 	}
 
+	tlsConfig := &tls.Config{
+		RootCAs: caCertPool,
+	}
+	if clientCert != "" || clientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
 	// Return Transport for testing purposes
 	return &http.Client{
 		Timeout: time.Second * defaultTimeoutSeconds,
 		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs: caCertPool,
-			},
-			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+			Proxy:           http.ProxyFromEnvironment,
 		},
 	}, nil
 }
@@ -270,17 +424,63 @@ type fileImporter struct {
 	manager assetManager
 }
 
-func (f *fileImporter) fetchFiles(charts []models.Chart, r *models.RepoInternal) {
-	// Process 10 charts at a time
-	numWorkers := 10
+// fetchFiles fetches and stores the icons of the charts and the files of
+// their versions, returning the versions whose files could not be imported.
+func (f *fileImporter) fetchFiles(charts []models.Chart, r *models.RepoInternal) []models.FailedChartVersion {
+	// Enqueue the latest chart versions first, so that the charts can be
+	// displayed before all of their versions are processed
+	var jobs, toEnqueue []importChartFilesJob
+	for _, c := range charts {
+		jobs = append(jobs, importChartFilesJob{c.Name, c.Repo, c.ChartVersions[0]})
+		for _, cv := range c.ChartVersions[1:] {
+			toEnqueue = append(toEnqueue, importChartFilesJob{c.Name, c.Repo, cv})
+		}
+	}
+	return f.importFiles(charts, append(jobs, toEnqueue...), r)
+}
+
+// retryFailedVersions fetches again the files of the chart versions that
+// failed in a previous sync, and the icons of their charts, returning the
+// ones still failing. Versions that were removed from the repository index
+// are not retried.
+func (f *fileImporter) retryFailedVersions(charts []models.Chart, failed []models.FailedChartVersion, r *models.RepoInternal) []models.FailedChartVersion {
+	toRetry := map[string]bool{}
+	chartsToRetry := map[string]bool{}
+	for _, v := range failed {
+		toRetry[v.Chart+"/"+v.Version] = true
+		chartsToRetry[v.Chart] = true
+	}
+	var icons []models.Chart
+	var jobs []importChartFilesJob
+	for _, c := range charts {
+		if chartsToRetry[c.Name] {
+			icons = append(icons, c)
+		}
+		for _, cv := range c.ChartVersions {
+			if toRetry[c.Name+"/"+cv.Version] {
+				jobs = append(jobs, importChartFilesJob{c.Name, c.Repo, cv})
+			}
+		}
+	}
+	return f.importFiles(icons, jobs, r)
+}
+
+// importFiles processes the icons of the charts and then the chart files jobs
+// with a pool of workers.
+func (f *fileImporter) importFiles(charts []models.Chart, jobs []importChartFilesJob, r *models.RepoInternal) []models.FailedChartVersion {
+	numWorkers := workers
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
 	iconJobs := make(chan models.Chart, numWorkers)
 	chartFilesJobs := make(chan importChartFilesJob, numWorkers)
+	failed := &failedVersions{}
 	var wg sync.WaitGroup
 
 	log.Debugf("starting %d workers", numWorkers)
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
-		go f.importWorker(&wg, iconJobs, chartFilesJobs, r)
+		go f.importWorker(&wg, iconJobs, chartFilesJobs, r, failed)
 	}
 
 	// Enqueue jobs to process chart icons
@@ -291,20 +491,8 @@ func (f *fileImporter) fetchFiles(charts []models.Chart, r *models.RepoInternal)
 	// chart files jobs
 	close(iconJobs)
 
-	// Iterate through the list of charts and enqueue the latest chart version to
-	// be processed. Append the rest of the chart versions to a list to be
-	// enqueued later
-	var toEnqueue []importChartFilesJob
-	for _, c := range charts {
-		chartFilesJobs <- importChartFilesJob{c.Name, c.Repo, c.ChartVersions[0]}
-		for _, cv := range c.ChartVersions[1:] {
-			toEnqueue = append(toEnqueue, importChartFilesJob{c.Name, c.Repo, cv})
-		}
-	}
-
-	// Enqueue all the remaining chart versions
-	for _, cfj := range toEnqueue {
-		chartFilesJobs <- cfj
+	for _, j := range jobs {
+		chartFilesJobs <- j
 	}
 	// Close the chartFilesJobs channel to signal the worker pools that there are
 	// no more jobs to process
@@ -312,20 +500,42 @@ func (f *fileImporter) fetchFiles(charts []models.Chart, r *models.RepoInternal)
 
 	// Wait for the worker pools to finish processing
 	wg.Wait()
+
+	sort.Slice(failed.versions, func(i, j int) bool {
+		if failed.versions[i].Chart != failed.versions[j].Chart {
+			return failed.versions[i].Chart < failed.versions[j].Chart
+		}
+		return failed.versions[i].Version < failed.versions[j].Version
+	})
+	return failed.versions
 }
 
-func (f *fileImporter) importWorker(wg *sync.WaitGroup, icons <-chan models.Chart, chartFiles <-chan importChartFilesJob, r *models.RepoInternal) {
+// failedVersions collects the chart versions failed by the workers
+type failedVersions struct {
+	mutex    sync.Mutex
+	versions []models.FailedChartVersion
+}
+
+func (f *failedVersions) add(name, version string, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.versions = append(f.versions, models.FailedChartVersion{Chart: name, Version: version, Error: err.Error()})
+}
+
+func (f *fileImporter) importWorker(wg *sync.WaitGroup, icons <-chan models.Chart, chartFiles <-chan importChartFilesJob, r *models.RepoInternal, failed *failedVersions) {
 	defer wg.Done()
 	for c := range icons {
 		log.WithFields(log.Fields{"name": c.Name}).Debug("importing icon")
 		if err := f.fetchAndImportIcon(c, r); err != nil {
 			log.WithFields(log.Fields{"name": c.Name}).WithError(err).Error("failed to import icon")
+			failed.add(c.Name, "", err)
 		}
 	}
 	for j := range chartFiles {
 		log.WithFields(log.Fields{"name": j.Name, "version": j.ChartVersion.Version}).Debug("importing readme and values")
 		if err := f.fetchAndImportFiles(j.Name, r, j.ChartVersion); err != nil {
 			log.WithFields(log.Fields{"name": j.Name, "version": j.ChartVersion.Version}).WithError(err).Error("failed to import files")
+			failed.add(j.Name, j.ChartVersion.Version, err)
 		}
 	}
 }
@@ -345,7 +555,7 @@ func (f *fileImporter) fetchAndImportIcon(c models.Chart, r *models.RepoInternal
 		req.Header.Set("Authorization", r.AuthorizationHeader)
 	}
 
-	res, err := netClient.Do(req)
+	res, err := fetchWithRetries(req)
 	if res != nil {
 		defer res.Body.Close()
 	}
@@ -390,8 +600,9 @@ func (f *fileImporter) fetchAndImportFiles(name string, r *models.RepoInternal,
 	chartID := fmt.Sprintf("%s/%s", r.Name, name)
 	chartFilesID := fmt.Sprintf("%s-%s", chartID, cv.Version)
 
-	// Check if we already have indexed files for this chart version and digest
-	if f.manager.filesExist(models.Repo{Namespace: r.Namespace, Name: r.Name}, chartFilesID, cv.Digest) {
+	// Check if we already have indexed files (and the tarball, when mirroring)
+	// for this chart version and digest
+	if f.manager.filesExist(models.Repo{Namespace: r.Namespace, Name: r.Name}, chartFilesID, cv.Digest) && !f.tarballMissing(r, cv) {
 		log.WithFields(log.Fields{"name": name, "version": cv.Version}).Debug("skipping existing files")
 		return nil
 	}
@@ -407,16 +618,31 @@ func (f *fileImporter) fetchAndImportFiles(name string, r *models.RepoInternal,
 		req.Header.Set("Authorization", r.AuthorizationHeader)
 	}
 
-	res, err := netClient.Do(req)
+	res, err := fetchWithRetries(req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%d %s", res.StatusCode, url)
+	}
+
 	// We read the whole chart into memory, this should be okay since the chart
 	// tarball needs to be small enough to fit into a GRPC call (Tiller
 	// requirement)
-	gzf, err := gzip.NewReader(res.Body)
+	tarball, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if r.Mirror {
+		if err := f.mirrorTarball(name, r, cv, tarball); err != nil {
+			return err
+		}
+	}
+
+	gzf, err := gzip.NewReader(bytes.NewReader(tarball))
 	if err != nil {
 		return err
 	}
@@ -427,7 +653,13 @@ func (f *fileImporter) fetchAndImportFiles(name string, r *models.RepoInternal,
 	readmeFileName := name + "/README.md"
 	valuesFileName := name + "/values.yaml"
 	schemaFileName := name + "/values.schema.json"
-	filenames := []string{valuesFileName, readmeFileName, schemaFileName}
+	chartYAMLFileName := name + "/Chart.yaml"
+	notesFileName := name + "/templates/NOTES.txt"
+	changelogFileName := name + "/CHANGELOG.md"
+	licenseFileName := name + "/LICENSE"
+	requirementsFileName := name + "/requirements.yaml"
+	crdsDir := name + "/crds/"
+	filenames := []string{valuesFileName, readmeFileName, schemaFileName, chartYAMLFileName, notesFileName, changelogFileName, licenseFileName, requirementsFileName, crdsDir}
 
 	files, err := extractFilesFromTarball(filenames, tarf)
 	if err != nil {
@@ -450,8 +682,54 @@ func (f *fileImporter) fetchAndImportFiles(name string, r *models.RepoInternal,
 	} else {
 		log.WithFields(log.Fields{"name": name, "version": cv.Version}).Info("values.schema.json not found")
 	}
+	// The other files are optional
+	chartFiles.ChartYAML = files[chartYAMLFileName]
+	chartFiles.Notes = files[notesFileName]
+	chartFiles.Changelog = files[changelogFileName]
+	chartFiles.License = files[licenseFileName]
+	crdFiles := map[string]string{}
+	for f, content := range files {
+		if strings.HasPrefix(f, crdsDir) {
+			crdFiles[f] = content
+		}
+	}
+	if len(crdFiles) > 0 {
+		chartFiles.CRDs = crdsFromFiles(crdFiles, name)
+	}
+	chartFiles.Dependencies = dependenciesFromFiles(files[chartYAMLFileName], files[requirementsFileName], name)
 
 	// inserts the chart files if not already indexed, or updates the existing
 	// entry if digest has changed
 	return f.manager.insertFiles(chartID, chartFiles)
 }
+
+// tarballMissing checks whether the tarball of a chart version of a mirrored
+// repository still needs to be stored.
+func (f *fileImporter) tarballMissing(r *models.RepoInternal, cv models.ChartVersion) bool {
+	if !r.Mirror || cv.Digest == "" {
+		return false
+	}
+	return !f.manager.tarballExists(models.Repo{Namespace: r.Namespace, Name: r.Name}, cv.Digest)
+}
+
+// mirrorTarball stores the tarball of a chart version, keyed by the digest
+// from the repository index once it is verified. Chart versions without a
+// digest are not mirrored since they could not be found when installing.
+func (f *fileImporter) mirrorTarball(name string, r *models.RepoInternal, cv models.ChartVersion, tarball []byte) error {
+	if cv.Digest == "" {
+		log.WithFields(log.Fields{"name": name, "version": cv.Version}).Info("digest not found, skipping mirroring")
+		return nil
+	}
+	digest, err := getSha256(tarball)
+	if err != nil {
+		return err
+	}
+	if digest != cv.Digest {
+		return fmt.Errorf("digest mismatch for %s version %s: got %s, want %s", name, cv.Version, digest, cv.Digest)
+	}
+	repo := models.Repo{Namespace: r.Namespace, Name: r.Name}
+	if f.manager.tarballExists(repo, digest) {
+		return nil
+	}
+	return f.manager.insertTarball(repo, digest, tarball)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/kubeapps/kubeapps/pkg/repovalidation"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateSamples int
+	validateOutput  string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [REPO URL]",
+	Short: "validate a chart repository, its index and a sample of its charts",
+	Long: `Validate a chart repository: get and parse its index, report the chart
+versions with missing digests, invalid or duplicate versions or missing URLs,
+and download the tarball and icon of the latest version of a sample of its
+charts. The credentials are read from the same environment variables as sync.
+
+The command exits with an error when the repository is not valid, so that it
+can be used in CI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			logrus.Info("Need exactly one argument: [REPO URL]")
+			cmd.Help()
+			return
+		}
+		if validateOutput != "text" && validateOutput != "json" {
+			logrus.Fatalf("Unsupported output %q, it must be text or json", validateOutput)
+		}
+
+		report := repovalidation.Validate(netClient, args[0], repovalidation.Options{
+			AuthorizationHeader: authorizationHeaderFromEnv(),
+			UserAgent:           userAgent(),
+			SampleSize:          validateSamples,
+		})
+		if err := writeValidationReport(os.Stdout, report, validateOutput); err != nil {
+			logrus.Fatal(err)
+		}
+		if !report.Valid {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	validateCmd.Flags().IntVar(&validateSamples, "samples", repovalidation.DefaultSampleSize, "number of charts whose latest tarball and icon are downloaded")
+	validateCmd.Flags().StringVarP(&validateOutput, "output", "o", "text", "format of the report: text or json")
+}
+
+// writeValidationReport writes the report as JSON or as a summary for humans
+func writeValidationReport(w io.Writer, report *repovalidation.Report, output string) error {
+	if output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	fmt.Fprintf(w, "Repository: %s\n", report.URL)
+	if report.TLS != nil {
+		fmt.Fprintf(w, "TLS: %s, certificate of %q issued by %q, expires on %s\n", report.TLS.Version, report.TLS.Subject, report.TLS.Issuer, report.TLS.NotAfter.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Authenticated: %t\n", report.Authenticated)
+	fmt.Fprintf(w, "Charts: %d, versions: %d\n", report.Charts, report.Versions)
+	for _, s := range report.Samples {
+		status := "reachable"
+		if !s.Reachable {
+			status = "unreachable: " + s.Error
+		}
+		fmt.Fprintf(w, "Sample %s %s %s: %s\n", s.Chart, s.Version, s.Kind, status)
+	}
+	errs, warnings := 0, 0
+	for _, issue := range report.Issues {
+		if issue.Severity == repovalidation.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+		subject := ""
+		if issue.Chart != "" {
+			subject = fmt.Sprintf(" %s %s", issue.Chart, issue.Version)
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", issue.Severity, subject, issue.Message)
+	}
+	result := "valid"
+	if !report.Valid {
+		result = "invalid"
+	}
+	_, err := fmt.Fprintf(w, "The repository is %s (%d errors, %d warnings)\n", result, errs, warnings)
+	return err
+}
@@ -0,0 +1,75 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/repovalidation"
+)
+
+func Test_writeValidationReport(t *testing.T) {
+	report := &repovalidation.Report{
+		URL:      "https://example.com/charts",
+		Charts:   1,
+		Versions: 2,
+		Samples: []repovalidation.Sample{
+			{Chart: "foo", Version: "1.0.0", Kind: "tarball", URL: "https://example.com/charts/foo-1.0.0.tgz", Reachable: true, StatusCode: 200},
+			{Chart: "foo", Version: "1.0.0", Kind: "icon", URL: "https://example.com/foo.png", StatusCode: 404, Error: "404 Not Found"},
+		},
+		Issues: []repovalidation.Issue{
+			{Severity: repovalidation.SeverityError, Chart: "foo", Version: "0.1.0", Message: "duplicate version"},
+			{Severity: repovalidation.SeverityWarning, Chart: "foo", Version: "1.0.0", Message: "unable to download the icon: 404 Not Found"},
+		},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := writeValidationReport(&b, report, "text"); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		want := `Repository: https://example.com/charts
+Authenticated: false
+Charts: 1, versions: 2
+Sample foo 1.0.0 tarball: reachable
+Sample foo 1.0.0 icon: unreachable: 404 Not Found
+error foo 0.1.0: duplicate version
+warning foo 1.0.0: unable to download the icon: 404 Not Found
+The repository is invalid (1 errors, 1 warnings)
+`
+		if got := b.String(); got != want {
+			t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := writeValidationReport(&b, report, "json"); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		var got repovalidation.Report
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !cmp.Equal(*report, got) {
+			t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(*report, got))
+		}
+	})
+}
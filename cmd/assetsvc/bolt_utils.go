@@ -0,0 +1,218 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/kubeapps/common/datastore"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+	bolt "go.etcd.io/bbolt"
+)
+
+type boltAssetManager struct {
+	*dbutils.BoltAssetManager
+}
+
+func newBoltManager(config datastore.Config, kubeappsNamespace string) assetManager {
+	m := dbutils.NewBoltManager(config, kubeappsNamespace)
+	return &boltAssetManager{m}
+}
+
+func (m *boltAssetManager) getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool, query chartQuery) ([]*models.Chart, int, error) {
+	prefixes := [][]byte{}
+	if namespace == dbutils.AllNamespaces {
+		prefixes = append(prefixes, []byte{})
+	} else {
+		namespaces := []string{namespace}
+		if namespace != m.KubeappsNamespace {
+			namespaces = append(namespaces, m.KubeappsNamespace)
+		}
+		for _, ns := range namespaces {
+			if repo != "" {
+				prefixes = append(prefixes, dbutils.BoltRepoPrefix(ns, repo))
+			} else {
+				prefixes = append(prefixes, dbutils.BoltKey(ns, ""))
+			}
+		}
+	}
+
+	charts := []*models.Chart{}
+	err := m.View(func(tx *bolt.Tx) error {
+		for _, prefix := range prefixes {
+			found, err := dbutils.BoltCharts(tx, prefix)
+			if err != nil {
+				return err
+			}
+			charts = append(charts, found...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if namespace == dbutils.AllNamespaces && repo != "" {
+		filtered := []*models.Chart{}
+		for _, c := range charts {
+			if c.Repo != nil && c.Repo.Name == repo {
+				filtered = append(filtered, c)
+			}
+		}
+		charts = filtered
+	}
+	charts = query.filter(charts)
+	query.sort(charts)
+
+	if !showDuplicates {
+		// Group by unique digest for the latest version (remove duplicates)
+		uniqueCharts := []*models.Chart{}
+		digests := map[string]bool{}
+		for _, c := range charts {
+			if len(c.ChartVersions) == 0 {
+				continue
+			}
+			if !digests[c.ChartVersions[0].Digest] {
+				digests[c.ChartVersions[0].Digest] = true
+				uniqueCharts = append(uniqueCharts, c)
+			}
+		}
+		charts = uniqueCharts
+	}
+
+	totalPages := 1
+	if pageSize != 0 {
+		// If a pageSize is given, returns only the the specified number of charts and
+		// the number of pages
+		totalPages = int(math.Ceil(float64(len(charts)) / float64(pageSize)))
+		if totalPages == 0 {
+			totalPages = 1
+		}
+		// If the page number is out of range, return the last one
+		if pageNumber > totalPages {
+			pageNumber = totalPages
+		}
+		if pageNumber < 1 {
+			pageNumber = 1
+		}
+		start := pageSize * (pageNumber - 1)
+		end := start + pageSize
+		if end > len(charts) {
+			end = len(charts)
+		}
+		charts = charts[start:end]
+	}
+	return charts, totalPages, nil
+}
+
+func (m *boltAssetManager) getChart(namespace, chartID string) (models.Chart, error) {
+	var chart models.Chart
+	err := m.View(func(tx *bolt.Tx) error {
+		return dbutils.BoltGet(tx, dbutils.BoltChartBucket, dbutils.BoltKey(namespace, chartID), &chart)
+	})
+	return chart, err
+}
+
+func (m *boltAssetManager) getChartVersion(namespace, chartID, version string) (models.Chart, error) {
+	chart, err := m.getChart(namespace, chartID)
+	if err != nil {
+		return models.Chart{}, err
+	}
+	for _, c := range chart.ChartVersions {
+		if c.Version == version {
+			chart.ChartVersions = []models.ChartVersion{c}
+			return chart, nil
+		}
+	}
+	return models.Chart{}, ErrChartVersionNotFound
+}
+
+func (m *boltAssetManager) getChartFiles(namespace, filesID string) (models.ChartFiles, error) {
+	var files models.ChartFiles
+	err := m.View(func(tx *bolt.Tx) error {
+		return dbutils.BoltGet(tx, dbutils.BoltChartFilesBucket, dbutils.BoltKey(namespace, filesID), &files)
+	})
+	return files, err
+}
+
+func (m *boltAssetManager) getChartTarball(namespace, repo, digest string) ([]byte, error) {
+	var data []byte
+	err := m.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket([]byte(dbutils.BoltChartTarballsBucket)).Get([]byte(dbutils.ChartTarballID(namespace, repo, digest)))
+		if value == nil {
+			return dbutils.ErrBoltKeyNotFound
+		}
+		// The value is only valid during the transaction.
+		data = append([]byte{}, value...)
+		return nil
+	})
+	return data, err
+}
+
+func (m *boltAssetManager) getChartsWithFilters(namespace, name, version, appVersion string) ([]*models.Chart, error) {
+	result := []*models.Chart{}
+	err := m.View(func(tx *bolt.Tx) error {
+		charts, err := dbutils.BoltCharts(tx, dbutils.BoltKey(namespace, ""))
+		if err != nil {
+			return err
+		}
+		for _, c := range charts {
+			if c.Name != name {
+				continue
+			}
+			if _, found := containsVersionAndAppVersion(c.ChartVersions, version, appVersion); found {
+				result = append(result, c)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (m *boltAssetManager) getRepoLastChecks(namespace, repo string) ([]repoLastCheck, error) {
+	prefixes := [][]byte{}
+	if namespace == dbutils.AllNamespaces {
+		prefixes = append(prefixes, []byte{})
+	} else {
+		prefixes = append(prefixes, dbutils.BoltKey(namespace, ""))
+		if namespace != m.KubeappsNamespace {
+			prefixes = append(prefixes, dbutils.BoltKey(m.KubeappsNamespace, ""))
+		}
+	}
+
+	checks := []repoLastCheck{}
+	err := m.View(func(tx *bolt.Tx) error {
+		for _, prefix := range prefixes {
+			err := dbutils.BoltForEachPrefix(tx, dbutils.BoltRepositoryBucket, prefix, func(k, v []byte) error {
+				var r dbutils.BoltRepo
+				if err := json.Unmarshal(v, &r); err != nil {
+					return err
+				}
+				if repo == "" || r.Name == repo {
+					checks = append(checks, repoLastCheck{Namespace: r.Namespace, Name: r.Name, Checksum: r.Checksum, LastUpdate: r.LastUpdate})
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return checks, err
+}
@@ -0,0 +1,232 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+	"github.com/kubeapps/kubeapps/pkg/dbutils/dbutilstest"
+	"github.com/kubeapps/kubeapps/pkg/dbutils/dbutilstest/bolttest"
+	bolt "go.etcd.io/bbolt"
+)
+
+func getBoltManager(t *testing.T) (*boltAssetManager, func()) {
+	bam, cleanup := bolttest.GetInitializedManager(t)
+	return &boltAssetManager{bam}, cleanup
+}
+
+func chartIDs(charts []*models.Chart) []string {
+	ids := []string{}
+	for _, c := range charts {
+		ids = append(ids, c.Repo.Namespace+"/"+c.ID)
+	}
+	return ids
+}
+
+func Test_BoltGetPaginatedChartList(t *testing.T) {
+	manager, cleanup := getBoltManager(t)
+	defer cleanup()
+	repo := models.Repo{Namespace: "my-namespace", Name: "my-repo"}
+	globalRepo := models.Repo{Namespace: dbutilstest.KubeappsTestNamespace, Name: "stable"}
+	otherRepo := models.Repo{Namespace: "other-namespace", Name: "my-repo"}
+	bolttest.EnsureChartsExist(t, manager.BoltAssetManager, []models.Chart{
+		{ID: "my-repo/foo", Name: "foo", Repo: &repo, ChartVersions: []models.ChartVersion{{Digest: "123"}}},
+		{ID: "my-repo/bar", Name: "bar", Repo: &repo, ChartVersions: []models.ChartVersion{{Digest: "456"}}},
+	}, repo)
+	bolttest.EnsureChartsExist(t, manager.BoltAssetManager, []models.Chart{
+		{ID: "stable/foo", Name: "foo", Repo: &globalRepo, ChartVersions: []models.ChartVersion{{Digest: "123"}}},
+		{ID: "stable/baz", Name: "baz", Repo: &globalRepo, ChartVersions: []models.ChartVersion{{Digest: "789"}}},
+	}, globalRepo)
+	bolttest.EnsureChartsExist(t, manager.BoltAssetManager, []models.Chart{
+		{ID: "my-repo/other", Name: "other", Repo: &otherRepo, ChartVersions: []models.ChartVersion{{Digest: "000"}}},
+	}, otherRepo)
+
+	tests := []struct {
+		name           string
+		namespace      string
+		repo           string
+		pageNumber     int
+		pageSize       int
+		showDuplicates bool
+		wantCharts     []string
+		wantPages      int
+	}{
+		{
+			"namespace with global charts",
+			"my-namespace", "", 1, 0, true,
+			[]string{"my-namespace/my-repo/bar", "kubeapps/stable/baz", "my-namespace/my-repo/foo", "kubeapps/stable/foo"},
+			1,
+		},
+		{
+			"without duplicates",
+			"my-namespace", "", 1, 0, false,
+			[]string{"my-namespace/my-repo/bar", "kubeapps/stable/baz", "my-namespace/my-repo/foo"},
+			1,
+		},
+		{
+			"repository",
+			"my-namespace", "my-repo", 1, 0, true,
+			[]string{"my-namespace/my-repo/bar", "my-namespace/my-repo/foo"},
+			1,
+		},
+		{
+			"all namespaces with a repository",
+			dbutils.AllNamespaces, "my-repo", 1, 0, true,
+			[]string{"my-namespace/my-repo/bar", "my-namespace/my-repo/foo", "other-namespace/my-repo/other"},
+			1,
+		},
+		{
+			"second page",
+			"my-namespace", "", 2, 3, true,
+			[]string{"kubeapps/stable/foo"},
+			2,
+		},
+		{
+			"page out of range",
+			"my-namespace", "", 5, 3, true,
+			[]string{"kubeapps/stable/foo"},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charts, pages, err := manager.getPaginatedChartList(tt.namespace, tt.repo, tt.pageNumber, tt.pageSize, tt.showDuplicates, chartQuery{})
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := chartIDs(charts), tt.wantCharts; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if got, want := pages, tt.wantPages; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
+func Test_BoltGetChartVersion(t *testing.T) {
+	manager, cleanup := getBoltManager(t)
+	defer cleanup()
+	repo := models.Repo{Namespace: "my-namespace", Name: "my-repo"}
+	bolttest.EnsureChartsExist(t, manager.BoltAssetManager, []models.Chart{
+		{ID: "my-repo/foo", Name: "foo", Repo: &repo, ChartVersions: []models.ChartVersion{{Version: "2.0.0", AppVersion: "2"}, {Version: "1.0.0", AppVersion: "1"}}},
+	}, repo)
+
+	chart, err := manager.getChartVersion("my-namespace", "my-repo/foo", "1.0.0")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := []models.ChartVersion{{Version: "1.0.0", AppVersion: "1"}}; !cmp.Equal(want, chart.ChartVersions) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, chart.ChartVersions))
+	}
+	if _, err := manager.getChartVersion("my-namespace", "my-repo/foo", "3.0.0"); err != ErrChartVersionNotFound {
+		t.Errorf("got: %v, want: %v", err, ErrChartVersionNotFound)
+	}
+	if _, err := manager.getChart("other-namespace", "my-repo/foo"); err != dbutils.ErrBoltKeyNotFound {
+		t.Errorf("got: %v, want: %v", err, dbutils.ErrBoltKeyNotFound)
+	}
+
+	charts, err := manager.getChartsWithFilters("my-namespace", "foo", "2.0.0", "2")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := len(charts), 1; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+	charts, err = manager.getChartsWithFilters("my-namespace", "foo", "2.0.0", "1")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if got, want := len(charts), 0; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+}
+
+func Test_BoltGetRepoLastChecks(t *testing.T) {
+	manager, cleanup := getBoltManager(t)
+	defer cleanup()
+	lastUpdate := time.Date(2020, 3, 4, 10, 20, 30, 0, time.UTC)
+	err := manager.Update(func(tx *bolt.Tx) error {
+		for _, r := range []dbutils.BoltRepo{
+			{Namespace: "my-namespace", Name: "my-repo", Checksum: "abc", LastUpdate: lastUpdate},
+			{Namespace: "my-namespace", Name: "other-repo", Checksum: "def", LastUpdate: lastUpdate},
+			{Namespace: dbutilstest.KubeappsTestNamespace, Name: "stable", Checksum: "ghi", LastUpdate: lastUpdate},
+			{Namespace: "other-namespace", Name: "my-repo", Checksum: "jkl", LastUpdate: lastUpdate},
+		} {
+			if err := dbutils.BoltPut(tx, dbutils.BoltRepositoryBucket, dbutils.BoltKey(r.Namespace, r.Name), r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		repo      string
+		want      []string
+	}{
+		{"namespace with global repositories", "my-namespace", "", []string{"my-namespace/my-repo abc", "my-namespace/other-repo def", "kubeapps/stable ghi"}},
+		{"repository", "my-namespace", "my-repo", []string{"my-namespace/my-repo abc"}},
+		{"all namespaces", dbutils.AllNamespaces, "my-repo", []string{"my-namespace/my-repo abc", "other-namespace/my-repo jkl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := manager.getRepoLastChecks(tt.namespace, tt.repo)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			got := []string{}
+			for _, c := range checks {
+				if !c.LastUpdate.Equal(lastUpdate) {
+					t.Errorf("got: %v, want: %v", c.LastUpdate, lastUpdate)
+				}
+				got = append(got, c.Namespace+"/"+c.Name+" "+c.Checksum)
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func Test_BoltGetPaginatedChartListWithQuery(t *testing.T) {
+	manager, cleanup := getBoltManager(t)
+	defer cleanup()
+	for _, c := range queryTestCharts() {
+		bolttest.EnsureChartsExist(t, manager.BoltAssetManager, []models.Chart{*c}, *c.Repo)
+	}
+
+	for _, tt := range chartQueryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			charts, _, err := manager.getPaginatedChartList(dbutilstest.KubeappsTestNamespace, "", 1, 0, true, tt.query)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got := chartIDList(charts); !cmp.Equal(tt.want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
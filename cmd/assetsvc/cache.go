@@ -0,0 +1,197 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// The responses are private since they depend on the namespaces the user
+	// has access to. Lists are always revalidated with their ETag.
+	cacheControlRevalidate = "private, no-cache"
+	// Icons may change when a repository is synced.
+	cacheControlIcon = "private, max-age=3600"
+	// The files of a chart version do not change.
+	cacheControlChartFiles = "private, max-age=86400"
+)
+
+// repoLastCheck is the checksum and time of the last update of a repository,
+// recorded when it is synced
+type repoLastCheck struct {
+	Namespace  string    `bson:"namespace"`
+	Name       string    `bson:"name"`
+	Checksum   string    `bson:"checksum"`
+	LastUpdate time.Time `bson:"last_update"`
+}
+
+// repoValidators returns the ETag and Last-Modified time of a response which
+// only depends on the repositories.
+func repoValidators(checks []repoLastCheck) (string, time.Time) {
+	lines := []string{}
+	lastModified := time.Time{}
+	for _, c := range checks {
+		lines = append(lines, fmt.Sprintf("%s/%s %s %d", c.Namespace, c.Name, c.Checksum, c.LastUpdate.UnixNano()))
+		if c.LastUpdate.After(lastModified) {
+			lastModified = c.LastUpdate
+		}
+	}
+	sort.Strings(lines)
+	// The ETag is weak since the response may be compressed or not.
+	etag := fmt.Sprintf(`W/"%x"`, sha256.Sum256([]byte(strings.Join(lines, "\n"))))
+	return etag, lastModified
+}
+
+// notModified returns whether the request validators match the response
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// validatorsResponseWriter sets the Cache-Control, ETag and Last-Modified
+// headers of successful responses only, so that errors such as a 404 are not
+// cached by the clients.
+type validatorsResponseWriter struct {
+	http.ResponseWriter
+	cacheControl string
+	etag         string
+	lastModified time.Time
+	wroteHeader  bool
+}
+
+func (w *validatorsResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code == http.StatusOK {
+		w.Header().Set("Cache-Control", w.cacheControl)
+		if w.etag != "" {
+			setValidators(w.Header(), w.etag, w.lastModified)
+		}
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *validatorsResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func setValidators(h http.Header, etag string, lastModified time.Time) {
+	h.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// withRepoValidators wraps a handler whose response only changes when the
+// repositories of the namespace are synced, setting the Cache-Control, ETag
+// and Last-Modified headers of successful responses and responding 304 Not
+// Modified to conditional requests.
+func withRepoValidators(cacheControl string, h func(http.ResponseWriter, *http.Request, Params)) func(http.ResponseWriter, *http.Request, Params) {
+	return func(w http.ResponseWriter, req *http.Request, params Params) {
+		checks, err := manager.getRepoLastChecks(params["namespace"], params["repo"])
+		if err != nil {
+			log.WithError(err).Error("could not fetch the last checks of the repositories")
+		}
+		if err != nil || len(checks) == 0 {
+			h(&validatorsResponseWriter{ResponseWriter: w, cacheControl: cacheControl}, req, params)
+			return
+		}
+
+		etag, lastModified := repoValidators(checks)
+		if notModified(req, etag, lastModified) {
+			w.Header().Set("Cache-Control", cacheControl)
+			setValidators(w.Header(), etag, lastModified)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h(&validatorsResponseWriter{ResponseWriter: w, cacheControl: cacheControl, etag: etag, lastModified: lastModified}, req, params)
+	}
+}
+
+// gzipResponseWriter compresses the body of the response, unless it is
+// already compressed or has no body.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	if code != http.StatusNotModified && code != http.StatusNoContent && h.Get("Content-Encoding") == "" && h.Get("Content-Type") != "application/gzip" {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// The content type is detected before compressing the body.
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// gzipHandler compresses the responses for the clients accepting it
+func gzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, req)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(gw, req)
+		if gw.gz != nil {
+			gw.gz.Close()
+		}
+	})
+}
@@ -0,0 +1,228 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeRepoChecksManager returns the same last checks for every namespace
+type fakeRepoChecksManager struct {
+	assetManager
+	checks []repoLastCheck
+}
+
+func (f *fakeRepoChecksManager) getRepoLastChecks(namespace, repo string) ([]repoLastCheck, error) {
+	return f.checks, nil
+}
+
+func Test_repoValidators(t *testing.T) {
+	older := time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)
+	newer := time.Date(2020, 3, 5, 10, 0, 0, 0, time.UTC)
+	checks := []repoLastCheck{
+		{Namespace: "my-namespace", Name: "my-repo", Checksum: "abc", LastUpdate: newer},
+		{Namespace: "kubeapps", Name: "stable", Checksum: "def", LastUpdate: older},
+	}
+
+	etag, lastModified := repoValidators(checks)
+	if !lastModified.Equal(newer) {
+		t.Errorf("got: %v, want: %v", lastModified, newer)
+	}
+	if reordered, _ := repoValidators([]repoLastCheck{checks[1], checks[0]}); reordered != etag {
+		t.Errorf("got: %q, want: %q", reordered, etag)
+	}
+	changed := []repoLastCheck{checks[0], {Namespace: "kubeapps", Name: "stable", Checksum: "xyz", LastUpdate: older}}
+	if other, _ := repoValidators(changed); other == etag {
+		t.Errorf("the ETag should depend on the checksums")
+	}
+}
+
+func Test_notModified(t *testing.T) {
+	lastModified := time.Date(2020, 3, 4, 10, 20, 30, 500, time.UTC)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no conditional headers", map[string]string{}, false},
+		{"matching ETag", map[string]string{"If-None-Match": `W/"abc"`}, true},
+		{"strong ETag", map[string]string{"If-None-Match": `"abc"`}, true},
+		{"list of ETags", map[string]string{"If-None-Match": `W/"def", W/"abc"`}, true},
+		{"any ETag", map[string]string{"If-None-Match": "*"}, true},
+		{"different ETag", map[string]string{"If-None-Match": `W/"def"`}, false},
+		{"ETag takes precedence", map[string]string{"If-None-Match": `W/"def"`, "If-Modified-Since": "Wed, 04 Mar 2020 10:20:30 GMT"}, false},
+		{"not modified since", map[string]string{"If-Modified-Since": "Wed, 04 Mar 2020 10:20:30 GMT"}, true},
+		{"modified since", map[string]string{"If-Modified-Since": "Wed, 04 Mar 2020 10:20:29 GMT"}, false},
+		{"invalid date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got, want := notModified(req, `W/"abc"`, lastModified), tt.want; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+		})
+	}
+}
+
+func Test_withRepoValidators(t *testing.T) {
+	lastUpdate := time.Date(2020, 3, 4, 10, 20, 30, 0, time.UTC)
+	checks := []repoLastCheck{{Namespace: "my-namespace", Name: "my-repo", Checksum: "abc", LastUpdate: lastUpdate}}
+	etag, _ := repoValidators(checks)
+
+	tests := []struct {
+		name             string
+		checks           []repoLastCheck
+		ifNoneMatch      string
+		handlerCode      int
+		wantCode         int
+		wantETag         string
+		wantCacheControl string
+	}{
+		{"first request", checks, "", http.StatusOK, http.StatusOK, etag, cacheControlIcon},
+		{"not modified", checks, etag, http.StatusOK, http.StatusNotModified, etag, cacheControlIcon},
+		{"modified", checks, `W/"old"`, http.StatusOK, http.StatusOK, etag, cacheControlIcon},
+		{"no repositories", nil, etag, http.StatusOK, http.StatusOK, "", cacheControlIcon},
+		{"not found", checks, "", http.StatusNotFound, http.StatusNotFound, "", ""},
+		{"not found without repositories", nil, "", http.StatusNotFound, http.StatusNotFound, "", ""},
+		{"internal error", checks, "", http.StatusInternalServerError, http.StatusInternalServerError, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager = &fakeRepoChecksManager{checks: tt.checks}
+			called := false
+			h := withRepoValidators(cacheControlIcon, func(w http.ResponseWriter, req *http.Request, params Params) {
+				called = true
+				if tt.handlerCode != http.StatusOK {
+					w.WriteHeader(tt.handlerCode)
+				}
+				w.Write([]byte("charts"))
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			h(w, req, Params{"namespace": "my-namespace"})
+
+			if got, want := w.Code, tt.wantCode; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			if got, want := called, tt.wantCode != http.StatusNotModified; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+			if got, want := w.Header().Get("ETag"), tt.wantETag; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got, want := w.Header().Get("Cache-Control"), tt.wantCacheControl; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if tt.wantETag != "" {
+				if got, want := w.Header().Get("Last-Modified"), "Wed, 04 Mar 2020 10:20:30 GMT"; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_gzipHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		code           int
+		contentType    string
+		wantEncoding   string
+	}{
+		{"compressed", "gzip, deflate", http.StatusOK, "application/json", "gzip"},
+		{"not accepted", "", http.StatusOK, "application/json", ""},
+		{"not modified", "gzip", http.StatusNotModified, "", ""},
+		{"already compressed", "gzip", http.StatusOK, "application/gzip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"data": []}`
+			h := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.code)
+				if tt.code == http.StatusOK {
+					w.Write([]byte(body))
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if got, want := w.Code, tt.code; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			if got, want := w.Header().Get("Content-Encoding"), tt.wantEncoding; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got, want := w.Header().Get("Vary"), "Accept-Encoding"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			got := w.Body.String()
+			if tt.wantEncoding == "gzip" {
+				gz, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				data, err := ioutil.ReadAll(gz)
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				got = string(data)
+			}
+			if got != body {
+				t.Errorf("got: %q, want: %q", got, body)
+			}
+		})
+	}
+}
+
+func Test_gzipHandlerDetectsContentType(t *testing.T) {
+	h := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("# Quickstart"))
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/kubeapps/common/response"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxDependencyDepth limits the resolution of the transitive dependencies
+const maxDependencyDepth = 10
+
+// dependencyNode is a dependency of a chart version, resolved to a chart of
+// the catalog when possible, with its own dependencies
+type dependencyNode struct {
+	models.ChartDependency
+	Resolved bool `json:"resolved"`
+	// Reason explains why the dependency is not resolved, or why its
+	// dependencies are not
+	Reason string `json:"reason,omitempty"`
+	// ChartID, Repo and ChartVersion identify the chart version of the
+	// catalog the dependency is resolved to
+	ChartID      string            `json:"chartID,omitempty"`
+	Repo         *models.Repo      `json:"repo,omitempty"`
+	ChartVersion string            `json:"chartVersion,omitempty"`
+	Dependencies []*dependencyNode `json:"dependencies,omitempty"`
+}
+
+// dependencyResolver resolves dependencies to the charts available in a
+// namespace, including the global repositories
+type dependencyResolver struct {
+	reposByURL  map[string]*models.Repo
+	reposByName map[string]*models.Repo
+	// charts by namespace and chart ID
+	charts map[string]*models.Chart
+}
+
+func normalizeRepoURL(u string) string {
+	return strings.TrimSuffix(u, "/")
+}
+
+func newDependencyResolver(namespace string) (*dependencyResolver, error) {
+	charts, _, err := manager.getPaginatedChartList(namespace, "", 1, 0, true, chartQuery{})
+	if err != nil {
+		return nil, err
+	}
+	r := &dependencyResolver{
+		reposByURL:  map[string]*models.Repo{},
+		reposByName: map[string]*models.Repo{},
+		charts:      map[string]*models.Chart{},
+	}
+	for _, c := range charts {
+		if c.Repo == nil {
+			continue
+		}
+		r.charts[c.Repo.Namespace+"/"+c.ID] = c
+		// The repositories of the namespace take precedence over the global
+		// ones with the same URL or name.
+		url := normalizeRepoURL(c.Repo.URL)
+		if existing, ok := r.reposByURL[url]; !ok || (existing.Namespace != namespace && c.Repo.Namespace == namespace) {
+			r.reposByURL[url] = c.Repo
+		}
+		if existing, ok := r.reposByName[c.Repo.Name]; !ok || (existing.Namespace != namespace && c.Repo.Namespace == namespace) {
+			r.reposByName[c.Repo.Name] = c.Repo
+		}
+	}
+	return r, nil
+}
+
+// repo returns the AppRepository of the repository of a dependency
+func (r *dependencyResolver) repo(repository string) (*models.Repo, error) {
+	for _, prefix := range []string{"@", "alias:"} {
+		if strings.HasPrefix(repository, prefix) {
+			name := strings.TrimPrefix(repository, prefix)
+			if repo, ok := r.reposByName[name]; ok {
+				return repo, nil
+			}
+			return nil, fmt.Errorf("no AppRepository named %s", name)
+		}
+	}
+	if repo, ok := r.reposByURL[normalizeRepoURL(repository)]; ok {
+		return repo, nil
+	}
+	return nil, fmt.Errorf("no AppRepository with the URL %s", repository)
+}
+
+// latestVersion returns the latest version of a chart matching a constraint
+func latestVersion(chart *models.Chart, constraint string) (string, error) {
+	if constraint == "" {
+		constraint = "*"
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %s: %v", constraint, err)
+	}
+	var latest *semver.Version
+	for _, cv := range chart.ChartVersions {
+		v, err := semver.NewVersion(cv.Version)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no version of %s matching %s", chart.ID, constraint)
+	}
+	return latest.Original(), nil
+}
+
+// resolve resolves the dependencies and their own dependencies. The path is
+// the chart versions depending on them, to detect cycles.
+func (r *dependencyResolver) resolve(deps []models.ChartDependency, path []string) []*dependencyNode {
+	nodes := []*dependencyNode{}
+	for _, dep := range deps {
+		node := &dependencyNode{ChartDependency: dep}
+		nodes = append(nodes, node)
+		if dep.Repository == "" || strings.HasPrefix(dep.Repository, "file://") {
+			node.Reason = "the dependency is bundled with the chart"
+			continue
+		}
+		repo, err := r.repo(dep.Repository)
+		if err != nil {
+			node.Reason = err.Error()
+			continue
+		}
+		chartID := fmt.Sprintf("%s/%s", repo.Name, dep.Name)
+		chart, ok := r.charts[repo.Namespace+"/"+chartID]
+		if !ok {
+			node.Reason = fmt.Sprintf("chart %s not found", chartID)
+			continue
+		}
+		version, err := latestVersion(chart, dep.Version)
+		if err != nil {
+			node.Reason = err.Error()
+			continue
+		}
+		node.Resolved = true
+		node.ChartID = chartID
+		node.Repo = repo
+		node.ChartVersion = version
+
+		id := fmt.Sprintf("%s/%s-%s", repo.Namespace, chartID, version)
+		if exists(path, id) {
+			node.Reason = "dependency cycle"
+			continue
+		}
+		if len(path) >= maxDependencyDepth {
+			node.Reason = "too many levels of dependencies"
+			continue
+		}
+		files, err := manager.getChartFiles(repo.Namespace, fmt.Sprintf("%s-%s", chartID, version))
+		if err != nil {
+			node.Reason = "the dependencies of the chart version are unknown"
+			continue
+		}
+		node.Dependencies = r.resolve(files.Dependencies, append(path[:len(path):len(path)], id))
+	}
+	return nodes
+}
+
+// getChartVersionDependencies returns the direct and transitive dependencies
+// of a chart version
+func getChartVersionDependencies(w http.ResponseWriter, req *http.Request, params Params) {
+	namespace := params["namespace"]
+	chartID := fmt.Sprintf("%s/%s", params["repo"], params["chartName"])
+	fileID := fmt.Sprintf("%s-%s", chartID, params["version"])
+	files, err := manager.getChartFiles(namespace, fileID)
+	if err != nil {
+		log.WithError(err).Errorf("could not find files with id %s", fileID)
+		response.NewErrorResponse(http.StatusNotFound, "could not find chart version files").Write(w)
+		return
+	}
+
+	resolver, err := newDependencyResolver(namespace)
+	if err != nil {
+		log.WithError(err).Error("could not fetch charts")
+		response.NewErrorResponse(http.StatusInternalServerError, "could not fetch all charts").Write(w)
+		return
+	}
+	deps := resolver.resolve(files.Dependencies, []string{fmt.Sprintf("%s/%s", namespace, fileID)})
+	response.NewDataResponse(deps).Write(w)
+}
@@ -0,0 +1,215 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+)
+
+// fakeDependencyManager returns the charts of a namespace and the files of
+// their versions, keyed by namespace and files ID.
+type fakeDependencyManager struct {
+	*fakeChartListManager
+	files map[string]models.ChartFiles
+}
+
+func (f *fakeDependencyManager) getChartFiles(namespace, filesID string) (models.ChartFiles, error) {
+	files, ok := f.files[namespace+"/"+filesID]
+	if !ok {
+		return models.ChartFiles{}, errors.New("not found")
+	}
+	return files, nil
+}
+
+func newFakeDependencyManager() *fakeDependencyManager {
+	stable := &models.Repo{Namespace: "kubeapps", Name: "stable", URL: "https://kubernetes-charts.storage.googleapis.com"}
+	bitnami := &models.Repo{Namespace: "kubeapps", Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"}
+	myBitnami := &models.Repo{Namespace: "my-namespace", Name: "my-bitnami", URL: "https://charts.bitnami.com/bitnami/"}
+	return &fakeDependencyManager{
+		fakeChartListManager: &fakeChartListManager{charts: []*models.Chart{
+			{ID: "stable/wordpress", Name: "wordpress", Repo: stable, ChartVersions: []models.ChartVersion{{Version: "9.0.0"}}},
+			{ID: "stable/mariadb", Name: "mariadb", Repo: stable, ChartVersions: []models.ChartVersion{{Version: "7.3.1"}, {Version: "7.10.0"}, {Version: "6.0.0"}}},
+			{ID: "bitnami/common", Name: "common", Repo: bitnami, ChartVersions: []models.ChartVersion{{Version: "0.1.0"}}},
+			{ID: "my-bitnami/common", Name: "common", Repo: myBitnami, ChartVersions: []models.ChartVersion{{Version: "0.2.0"}}},
+			{ID: "stable/cycle", Name: "cycle", Repo: stable, ChartVersions: []models.ChartVersion{{Version: "1.0.0"}}},
+		}},
+		files: map[string]models.ChartFiles{
+			"kubeapps/stable/wordpress-9.0.0": {Dependencies: []models.ChartDependency{
+				{Name: "mariadb", Version: "7.x.x", Repository: "https://kubernetes-charts.storage.googleapis.com/", Condition: "mariadb.enabled"},
+				{Name: "memcached", Version: "4.x.x", Repository: "https://kubernetes-charts.storage.googleapis.com"},
+				{Name: "redis", Version: "1.x.x", Repository: "https://example.com/charts"},
+				{Name: "local", Repository: "file://../local"},
+			}},
+			"kubeapps/stable/mariadb-7.10.0": {Dependencies: []models.ChartDependency{
+				{Name: "common", Version: "~0.1.0", Repository: "https://charts.bitnami.com/bitnami"},
+			}},
+			"kubeapps/bitnami/common-0.1.0": {},
+			"kubeapps/stable/cycle-1.0.0": {Dependencies: []models.ChartDependency{
+				{Name: "cycle", Version: "1.0.0", Repository: "@stable"},
+			}},
+		},
+	}
+}
+
+func Test_dependencyResolver(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		deps      []models.ChartDependency
+		want      []*dependencyNode
+	}{
+		{
+			"direct and transitive dependencies",
+			"kubeapps",
+			[]models.ChartDependency{
+				{Name: "mariadb", Version: "7.x.x", Repository: "https://kubernetes-charts.storage.googleapis.com/"},
+			},
+			[]*dependencyNode{
+				{
+					ChartDependency: models.ChartDependency{Name: "mariadb", Version: "7.x.x", Repository: "https://kubernetes-charts.storage.googleapis.com/"},
+					Resolved:        true, ChartID: "stable/mariadb", ChartVersion: "7.10.0",
+					Repo: &models.Repo{Namespace: "kubeapps", Name: "stable", URL: "https://kubernetes-charts.storage.googleapis.com"},
+					Dependencies: []*dependencyNode{
+						{
+							ChartDependency: models.ChartDependency{Name: "common", Version: "~0.1.0", Repository: "https://charts.bitnami.com/bitnami"},
+							Resolved:        true, ChartID: "bitnami/common", ChartVersion: "0.1.0",
+							Repo:         &models.Repo{Namespace: "kubeapps", Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"},
+							Dependencies: []*dependencyNode{},
+						},
+					},
+				},
+			},
+		},
+		{
+			"repository of the namespace takes precedence",
+			"my-namespace",
+			[]models.ChartDependency{{Name: "common", Repository: "https://charts.bitnami.com/bitnami"}},
+			[]*dependencyNode{
+				{
+					ChartDependency: models.ChartDependency{Name: "common", Repository: "https://charts.bitnami.com/bitnami"},
+					Resolved:        true, ChartID: "my-bitnami/common", ChartVersion: "0.2.0",
+					Repo:   &models.Repo{Namespace: "my-namespace", Name: "my-bitnami", URL: "https://charts.bitnami.com/bitnami/"},
+					Reason: "the dependencies of the chart version are unknown",
+				},
+			},
+		},
+		{
+			"unresolved dependencies",
+			"kubeapps",
+			[]models.ChartDependency{
+				{Name: "memcached", Repository: "@stable"},
+				{Name: "redis", Repository: "https://example.com/charts"},
+				{Name: "mariadb", Version: "8.x.x", Repository: "alias:stable"},
+				{Name: "mariadb", Version: "not a version", Repository: "@stable"},
+				{Name: "local", Repository: "file://../local"},
+				{Name: "bundled"},
+				{Name: "common", Repository: "@incubator"},
+			},
+			[]*dependencyNode{
+				{ChartDependency: models.ChartDependency{Name: "memcached", Repository: "@stable"}, Reason: "chart stable/memcached not found"},
+				{ChartDependency: models.ChartDependency{Name: "redis", Repository: "https://example.com/charts"}, Reason: "no AppRepository with the URL https://example.com/charts"},
+				{ChartDependency: models.ChartDependency{Name: "mariadb", Version: "8.x.x", Repository: "alias:stable"}, Reason: "no version of stable/mariadb matching 8.x.x"},
+				{ChartDependency: models.ChartDependency{Name: "mariadb", Version: "not a version", Repository: "@stable"}, Reason: "invalid version constraint not a version: improper constraint: not a version"},
+				{ChartDependency: models.ChartDependency{Name: "local", Repository: "file://../local"}, Reason: "the dependency is bundled with the chart"},
+				{ChartDependency: models.ChartDependency{Name: "bundled"}, Reason: "the dependency is bundled with the chart"},
+				{ChartDependency: models.ChartDependency{Name: "common", Repository: "@incubator"}, Reason: "no AppRepository named incubator"},
+			},
+		},
+		{
+			"dependency cycle",
+			"kubeapps",
+			[]models.ChartDependency{{Name: "cycle", Repository: "@stable"}},
+			[]*dependencyNode{
+				{
+					ChartDependency: models.ChartDependency{Name: "cycle", Repository: "@stable"},
+					Resolved:        true, ChartID: "stable/cycle", ChartVersion: "1.0.0",
+					Repo: &models.Repo{Namespace: "kubeapps", Name: "stable", URL: "https://kubernetes-charts.storage.googleapis.com"},
+					Dependencies: []*dependencyNode{
+						{
+							ChartDependency: models.ChartDependency{Name: "cycle", Version: "1.0.0", Repository: "@stable"},
+							Resolved:        true, ChartID: "stable/cycle", ChartVersion: "1.0.0",
+							Repo:   &models.Repo{Namespace: "kubeapps", Name: "stable", URL: "https://kubernetes-charts.storage.googleapis.com"},
+							Reason: "dependency cycle",
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager = newFakeDependencyManager()
+			r, err := newDependencyResolver(tt.namespace)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := r.resolve(tt.deps, []string{}), tt.want; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func Test_getChartVersionDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		wantCode int
+		wantDeps []string
+	}{
+		{"chart version with dependencies", "9.0.0", http.StatusOK, []string{"mariadb", "memcached", "redis", "local"}},
+		{"unknown chart version", "1.0.0", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager = newFakeDependencyManager()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/charts/stable/wordpress/versions/"+tt.version+"/dependencies", nil)
+			getChartVersionDependencies(w, req, Params{"namespace": "kubeapps", "repo": "stable", "chartName": "wordpress", "version": tt.version})
+
+			if got, want := w.Code, tt.wantCode; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var b struct {
+				Data []dependencyNode `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&b); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			got := []string{}
+			for _, d := range b.Data {
+				got = append(got, d.Name)
+			}
+			if !cmp.Equal(tt.wantDeps, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.wantDeps, got))
+			}
+			if !b.Data[0].Resolved || b.Data[0].Dependencies[0].ChartID != "bitnami/common" {
+				t.Errorf("the transitive dependency of %s should be resolved: %+v", b.Data[0].Name, b.Data[0])
+			}
+		})
+	}
+}
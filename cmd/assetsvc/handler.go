@@ -113,15 +113,20 @@ func uniqChartList(charts []*models.Chart) []*models.Chart {
 	return res
 }
 
-func getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool) (apiListResponse, interface{}, error) {
-	charts, totalPages, err := manager.getPaginatedChartList(namespace, repo, pageNumber, pageSize, showDuplicates)
+func getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool, query chartQuery) (apiListResponse, interface{}, error) {
+	charts, totalPages, err := manager.getPaginatedChartList(namespace, repo, pageNumber, pageSize, showDuplicates, query)
 	return newChartListResponse(charts), meta{totalPages}, err
 }
 
 // listCharts returns a list of charts based on filter params
 func listCharts(w http.ResponseWriter, req *http.Request, params Params) {
 	pageNumber, pageSize := getPageNumberAndSize(req)
-	cl, meta, err := getPaginatedChartList(params["namespace"], params["repo"], pageNumber, pageSize, showDuplicates(req))
+	query, err := parseChartQuery(req)
+	if err != nil {
+		response.NewErrorResponse(http.StatusBadRequest, err.Error()).Write(w)
+		return
+	}
+	cl, meta, err := getPaginatedChartList(params["namespace"], params["repo"], pageNumber, pageSize, showDuplicates(req), query)
 	if err != nil {
 		log.WithError(err).Error("could not fetch charts")
 		response.NewErrorResponse(http.StatusInternalServerError, "could not fetch all charts").Write(w)
@@ -240,6 +245,83 @@ func getChartVersionSchema(w http.ResponseWriter, req *http.Request, params Para
 	w.Write([]byte(files.Schema))
 }
 
+// chartVersionFileHandler returns a handler serving a file of a given chart,
+// which is not found when empty
+func chartVersionFileHandler(fileName string, content func(models.ChartFiles) string) func(w http.ResponseWriter, req *http.Request, params Params) {
+	return func(w http.ResponseWriter, req *http.Request, params Params) {
+		fileID := fmt.Sprintf("%s/%s-%s", params["repo"], params["chartName"], params["version"])
+		files, err := manager.getChartFiles(params["namespace"], fileID)
+		if err != nil {
+			log.WithError(err).Errorf("could not find files with id %s", fileID)
+			http.NotFound(w, req)
+			return
+		}
+		data := []byte(content(files))
+		if len(data) == 0 {
+			log.Errorf("could not find a %s for id %s", fileName, fileID)
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+var (
+	// getChartVersionChartYAML returns the Chart.yaml for a given chart
+	getChartVersionChartYAML = chartVersionFileHandler("Chart.yaml", func(f models.ChartFiles) string { return f.ChartYAML })
+	// getChartVersionNotes returns the NOTES.txt for a given chart
+	getChartVersionNotes = chartVersionFileHandler("NOTES.txt", func(f models.ChartFiles) string { return f.Notes })
+	// getChartVersionChangelog returns the CHANGELOG.md for a given chart
+	getChartVersionChangelog = chartVersionFileHandler("CHANGELOG.md", func(f models.ChartFiles) string { return f.Changelog })
+	// getChartVersionLicense returns the LICENSE for a given chart
+	getChartVersionLicense = chartVersionFileHandler("LICENSE", func(f models.ChartFiles) string { return f.License })
+)
+
+// getChartVersionCRDs returns the list of CustomResourceDefinitions in the
+// crds directory of a given chart
+func getChartVersionCRDs(w http.ResponseWriter, req *http.Request, params Params) {
+	fileID := fmt.Sprintf("%s/%s-%s", params["repo"], params["chartName"], params["version"])
+	files, err := manager.getChartFiles(params["namespace"], fileID)
+	if err != nil {
+		log.WithError(err).Errorf("could not find files with id %s", fileID)
+		response.NewErrorResponse(http.StatusNotFound, "could not find chart version files").Write(w)
+		return
+	}
+	crds := files.CRDs
+	if crds == nil {
+		crds = []models.ChartCRD{}
+	}
+	response.NewDataResponse(crds).Write(w)
+}
+
+// getChartVersionTarball returns the tarball of a chart version, if the chart
+// repository is mirrored
+func getChartVersionTarball(w http.ResponseWriter, req *http.Request, params Params) {
+	chartID := fmt.Sprintf("%s/%s", params["repo"], params["chartName"])
+	chart, err := manager.getChartVersion(params["namespace"], chartID, params["version"])
+	if err != nil {
+		log.WithError(err).Errorf("could not find chart with id %s", chartID)
+		http.NotFound(w, req)
+		return
+	}
+
+	digest := chart.ChartVersions[0].Digest
+	if digest == "" {
+		log.Errorf("could not find a digest for chart %s version %s", chartID, params["version"])
+		http.NotFound(w, req)
+		return
+	}
+	tarball, err := manager.getChartTarball(params["namespace"], params["repo"], digest)
+	if err != nil {
+		log.WithError(err).Errorf("could not find the tarball of chart %s version %s", chartID, params["version"])
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Write(tarball)
+}
+
 // listChartsWithFilters returns the list of repos that contains the given chart and the latest version found
 func listChartsWithFilters(w http.ResponseWriter, req *http.Request, params Params) {
 	charts, err := manager.getChartsWithFilters(params["namespace"], params["chartName"], req.FormValue("version"), req.FormValue("appversion"))
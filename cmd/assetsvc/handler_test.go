@@ -45,6 +45,7 @@ type bodyAPIResponse struct {
 }
 
 var chartsList []*models.Chart
+var repoLastChecks []repoLastCheck
 var cc count
 
 const (
@@ -314,6 +315,18 @@ func Test_listCharts(t *testing.T) {
 	}
 }
 
+func Test_listChartsInvalidQuery(t *testing.T) {
+	var m mock.Mock
+	manager = getMockManager(&m)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/charts?sort=stars", nil)
+	listCharts(w, req, Params{"namespace": namespace})
+
+	m.AssertExpectations(t)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func Test_listRepoCharts(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -830,6 +843,97 @@ func Test_getChartVersionSchema(t *testing.T) {
 	}
 }
 
+// fakeTarballManager returns a chart version and the tarballs by digest.
+type fakeTarballManager struct {
+	assetManager
+	chart    models.Chart
+	tarballs map[string][]byte
+}
+
+func (f *fakeTarballManager) getChartVersion(namespace, chartID, version string) (models.Chart, error) {
+	if f.chart.ID != chartID {
+		return models.Chart{}, errors.New("chart not found")
+	}
+	return f.chart, nil
+}
+
+func (f *fakeTarballManager) getChartTarball(namespace, repo, digest string) ([]byte, error) {
+	tarball, ok := f.tarballs[digest]
+	if !ok {
+		return nil, errors.New("tarball not found")
+	}
+	return tarball, nil
+}
+
+func Test_getChartVersionTarball(t *testing.T) {
+	tests := []struct {
+		name     string
+		chartID  string
+		digest   string
+		tarballs map[string][]byte
+		wantCode int
+	}{
+		{
+			"chart does not exist",
+			"my-repo/other-chart",
+			"abc",
+			map[string][]byte{"abc": []byte("tarball")},
+			http.StatusNotFound,
+		},
+		{
+			"chart version has no digest",
+			"my-repo/my-chart",
+			"",
+			map[string][]byte{"abc": []byte("tarball")},
+			http.StatusNotFound,
+		},
+		{
+			"tarball is not mirrored",
+			"my-repo/my-chart",
+			"abc",
+			map[string][]byte{},
+			http.StatusNotFound,
+		},
+		{
+			"tarball is mirrored",
+			"my-repo/my-chart",
+			"abc",
+			map[string][]byte{"abc": []byte("tarball")},
+			http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager = &fakeTarballManager{
+				chart: models.Chart{
+					ID:            "my-repo/my-chart",
+					ChartVersions: []models.ChartVersion{{Version: "0.1.0", Digest: tt.digest}},
+				},
+				tarballs: tt.tarballs,
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/assets/"+tt.chartID+"/versions/0.1.0/chart.tgz", nil)
+			parts := strings.Split(tt.chartID, "/")
+			params := Params{
+				"namespace": namespace,
+				"repo":      parts[0],
+				"chartName": parts[1],
+				"version":   "0.1.0",
+			}
+
+			getChartVersionTarball(w, req, params)
+
+			assert.Equal(t, tt.wantCode, w.Code, "http status code should match")
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, "tarball", w.Body.String(), "content of the tarball should match")
+				assert.Equal(t, "application/gzip", w.Header().Get("Content-Type"), "content type should match")
+			}
+		})
+	}
+}
+
 func Test_findLatestChart(t *testing.T) {
 	t.Run("returns mocked chart", func(t *testing.T) {
 		chart := &models.Chart{
@@ -942,3 +1046,96 @@ func Test_findLatestChart(t *testing.T) {
 		assert.Equal(t, len(data), 2, "it should return both charts")
 	})
 }
+
+func Test_chartVersionFileHandlers(t *testing.T) {
+	files := models.ChartFiles{
+		ID:        "my-repo/my-chart",
+		ChartYAML: "name: my-chart\nkubeVersion: '>=1.14'",
+		Notes:     "Thank you for installing my-chart",
+		Changelog: "# Changelog",
+		License:   "Apache License",
+	}
+	tests := []struct {
+		name     string
+		handler  func(w http.ResponseWriter, req *http.Request, params Params)
+		err      error
+		files    models.ChartFiles
+		wantCode int
+		wantBody string
+	}{
+		{"Chart.yaml", getChartVersionChartYAML, nil, files, http.StatusOK, files.ChartYAML},
+		{"NOTES.txt", getChartVersionNotes, nil, files, http.StatusOK, files.Notes},
+		{"CHANGELOG.md", getChartVersionChangelog, nil, files, http.StatusOK, files.Changelog},
+		{"LICENSE", getChartVersionLicense, nil, files, http.StatusOK, files.License},
+		{"chart without a changelog", getChartVersionChangelog, nil, models.ChartFiles{ID: "my-repo/my-chart"}, http.StatusNotFound, ""},
+		{"chart does not exist", getChartVersionLicense, errors.New("return an error when checking if chart exists"), models.ChartFiles{}, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m mock.Mock
+			manager = getMockManager(&m)
+
+			if tt.err != nil {
+				m.On("One", mock.Anything).Return(tt.err)
+			} else {
+				m.On("One", &models.ChartFiles{}).Return(nil).Run(func(args mock.Arguments) {
+					*args.Get(0).(*models.ChartFiles) = tt.files
+				})
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/assets/my-repo/my-chart/versions/0.1.0/file", nil)
+			params := Params{
+				"repo":      "my-repo",
+				"chartName": "my-chart",
+				"version":   "0.1.0",
+			}
+
+			tt.handler(w, req, params)
+
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.wantCode, w.Code, "http status code should match")
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, tt.wantBody, w.Body.String(), "content of the file should match")
+			}
+		})
+	}
+}
+
+func Test_getChartVersionCRDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    models.ChartFiles
+		wantCRDs []models.ChartCRD
+	}{
+		{"chart without CRDs", models.ChartFiles{ID: "my-repo/my-chart"}, []models.ChartCRD{}},
+		{"chart with CRDs", models.ChartFiles{ID: "my-repo/my-chart", CRDs: []models.ChartCRD{
+			{Name: "foos.example.com", Group: "example.com", Kind: "Foo", File: "crds/foo.yaml"},
+		}}, []models.ChartCRD{
+			{Name: "foos.example.com", Group: "example.com", Kind: "Foo", File: "crds/foo.yaml"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m mock.Mock
+			manager = getMockManager(&m)
+			m.On("One", &models.ChartFiles{}).Return(nil).Run(func(args mock.Arguments) {
+				*args.Get(0).(*models.ChartFiles) = tt.files
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/assets/my-repo/my-chart/versions/0.1.0/crds", nil)
+			getChartVersionCRDs(w, req, Params{"repo": "my-repo", "chartName": "my-chart", "version": "0.1.0"})
+
+			m.AssertExpectations(t)
+			assert.Equal(t, http.StatusOK, w.Code, "http status code should match")
+			var b struct {
+				Data []models.ChartCRD `json:"data"`
+			}
+			json.NewDecoder(w.Body).Decode(&b)
+			assert.Equal(t, tt.wantCRDs, b.Data, "CRDs should match")
+		})
+	}
+}
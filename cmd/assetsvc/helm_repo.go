@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	helmrepo "k8s.io/helm/pkg/repo"
+)
+
+// The charts of a namespace are served as a Helm repository at
+// /v1/ns/{namespace}/helm/ and those of each repository at
+// /v1/ns/{namespace}/helm/{repo}/. The chart URLs in the indexes are relative
+// to the former so that they can be resolved behind any proxy prefix. Unlike
+// the rest of the API, these read-only routes are authenticated by the proxy
+// with either a bearer token or, for the Helm CLI, the token as the basic
+// auth password (see auth.HelmRepoAuthGate).
+
+// helmChartPath returns the path of the tarball of a chart version, relative
+// to the Helm repository of the namespace. The charts of global repositories
+// are served from the namespace of their repository.
+func helmChartPath(namespace string, c *models.Chart, cv models.ChartVersion) string {
+	chartPath := path.Join(
+		url.PathEscape(c.Repo.Name),
+		"charts",
+		url.PathEscape(c.Name),
+		url.PathEscape(cv.Version),
+		url.PathEscape(fmt.Sprintf("%s-%s.tgz", c.Name, cv.Version)),
+	)
+	if c.Repo.Namespace != namespace {
+		return path.Join("..", "..", url.PathEscape(c.Repo.Namespace), "helm", chartPath)
+	}
+	return chartPath
+}
+
+// newHelmIndex returns a Helm repository index for the charts. Since the
+// entries of an index are keyed by name, only the chart of the first
+// repository (by name) is included when several repositories have a chart
+// with the same name.
+func newHelmIndex(namespace, pathPrefix string, charts []*models.Chart) *helmrepo.IndexFile {
+	index := helmrepo.NewIndexFile()
+	repos := map[string]string{}
+	for _, c := range charts {
+		if c.Repo == nil {
+			continue
+		}
+		if repo, ok := repos[c.Name]; ok && repo <= c.Repo.Name {
+			log.Debugf("skipping chart %s from repository %s, already served from %s", c.Name, c.Repo.Name, repo)
+			continue
+		}
+		repos[c.Name] = c.Repo.Name
+
+		maintainers := []*chart.Maintainer{}
+		for i := range c.Maintainers {
+			maintainers = append(maintainers, &c.Maintainers[i])
+		}
+		versions := helmrepo.ChartVersions{}
+		for _, cv := range c.ChartVersions {
+			versions = append(versions, &helmrepo.ChartVersion{
+				Metadata: &chart.Metadata{
+					// The Chart.yaml is not stored, "v1" is accepted by
+					// both Helm 2 and 3 clients.
+					ApiVersion:  "v1",
+					Name:        c.Name,
+					Version:     cv.Version,
+					AppVersion:  cv.AppVersion,
+					Description: c.Description,
+					Home:        c.Home,
+					Icon:        c.Icon,
+					Keywords:    c.Keywords,
+					Sources:     c.Sources,
+					Maintainers: maintainers,
+				},
+				URLs:    []string{pathPrefix + helmChartPath(namespace, c, cv)},
+				Created: cv.Created,
+				Digest:  cv.Digest,
+			})
+		}
+		index.Entries[c.Name] = versions
+	}
+	index.SortEntries()
+	return index
+}
+
+func writeHelmIndex(w http.ResponseWriter, req *http.Request, namespace, repo, pathPrefix string) {
+	charts, _, err := manager.getPaginatedChartList(namespace, repo, 1, 0, true, chartQuery{})
+	if err != nil {
+		log.WithError(err).Error("could not fetch charts")
+		http.Error(w, "could not fetch charts", http.StatusInternalServerError)
+		return
+	}
+	index := newHelmIndex(namespace, pathPrefix, charts)
+	index.Generated = time.Now()
+	body, err := yaml.Marshal(index)
+	if err != nil {
+		log.WithError(err).Error("could not marshal the index")
+		http.Error(w, "could not marshal the index", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(body)
+}
+
+// getNamespaceHelmIndex returns the index of the Helm repository of a
+// namespace, including the charts of the global repositories
+func getNamespaceHelmIndex(w http.ResponseWriter, req *http.Request, params Params) {
+	writeHelmIndex(w, req, params["namespace"], "", "")
+}
+
+// getRepoHelmIndex returns the index of the Helm repository of an app
+// repository
+func getRepoHelmIndex(w http.ResponseWriter, req *http.Request, params Params) {
+	writeHelmIndex(w, req, params["namespace"], params["repo"], "../")
+}
+
+// getHelmChartTarball returns the tarball of a chart version if it is
+// mirrored, redirecting to the chart repository otherwise
+func getHelmChartTarball(w http.ResponseWriter, req *http.Request, params Params) {
+	chartID := fmt.Sprintf("%s/%s", params["repo"], params["chartName"])
+	c, err := manager.getChartVersion(params["namespace"], chartID, params["version"])
+	if err != nil {
+		log.WithError(err).Errorf("could not find chart with id %s", chartID)
+		http.NotFound(w, req)
+		return
+	}
+	cv := c.ChartVersions[0]
+
+	if cv.Digest != "" {
+		tarball, err := manager.getChartTarball(params["namespace"], params["repo"], cv.Digest)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(tarball)
+			return
+		}
+	}
+
+	if len(cv.URLs) == 0 || c.Repo == nil {
+		log.Errorf("could not find a URL for chart %s version %s", chartID, cv.Version)
+		http.NotFound(w, req)
+		return
+	}
+	chartURL, err := resolveChartURL(c.Repo.URL, cv.URLs[0])
+	if err != nil {
+		log.WithError(err).Errorf("could not resolve the URL of chart %s version %s", chartID, cv.Version)
+		http.NotFound(w, req)
+		return
+	}
+	http.Redirect(w, req, chartURL, http.StatusFound)
+}
+
+// resolveChartURL resolves the URL of a chart, which may be relative to its
+// repository.
+func resolveChartURL(repoURL, chartURL string) (string, error) {
+	base, err := url.Parse(repoURL)
+	if err != nil {
+		return "", err
+	}
+	// The chart URLs are relative to the repository directory.
+	if base.Path != "" && base.Path[len(base.Path)-1] != '/' {
+		base.Path += "/"
+	}
+	ref, err := url.Parse(chartURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
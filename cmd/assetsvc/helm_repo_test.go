@@ -0,0 +1,197 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	helmrepo "k8s.io/helm/pkg/repo"
+)
+
+// fakeChartListManager returns the charts of a namespace and repository.
+type fakeChartListManager struct {
+	assetManager
+	charts []*models.Chart
+}
+
+func (f *fakeChartListManager) getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool, query chartQuery) ([]*models.Chart, int, error) {
+	charts := []*models.Chart{}
+	for _, c := range f.charts {
+		if repo == "" || c.Repo.Name == repo {
+			charts = append(charts, c)
+		}
+	}
+	return charts, 1, nil
+}
+
+func Test_getHelmIndex(t *testing.T) {
+	charts := []*models.Chart{
+		{
+			Name:          "my-chart",
+			Repo:          &models.Repo{Name: "my-repo", Namespace: "my-namespace"},
+			ChartVersions: []models.ChartVersion{{Version: "0.2.0", Digest: "def"}, {Version: "0.1.0", Digest: "abc"}},
+		},
+		{
+			Name:          "my-chart",
+			Repo:          &models.Repo{Name: "a-repo", Namespace: "my-namespace"},
+			ChartVersions: []models.ChartVersion{{Version: "1.0.0", Digest: "ghi"}},
+		},
+		{
+			Name:          "global-chart",
+			Repo:          &models.Repo{Name: "stable", Namespace: "kubeapps"},
+			ChartVersions: []models.ChartVersion{{Version: "1.0.0", Digest: "jkl"}},
+		},
+	}
+	tests := []struct {
+		name     string
+		handler  func(http.ResponseWriter, *http.Request, Params)
+		params   Params
+		wantURLs map[string][]string
+	}{
+		{
+			"namespace index",
+			getNamespaceHelmIndex,
+			Params{"namespace": "my-namespace"},
+			map[string][]string{
+				"my-chart":     {"a-repo/charts/my-chart/1.0.0/my-chart-1.0.0.tgz"},
+				"global-chart": {"../../kubeapps/helm/stable/charts/global-chart/1.0.0/global-chart-1.0.0.tgz"},
+			},
+		},
+		{
+			"repo index",
+			getRepoHelmIndex,
+			Params{"namespace": "my-namespace", "repo": "my-repo"},
+			map[string][]string{
+				"my-chart": {
+					"../my-repo/charts/my-chart/0.2.0/my-chart-0.2.0.tgz",
+					"../my-repo/charts/my-chart/0.1.0/my-chart-0.1.0.tgz",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager = &fakeChartListManager{charts: charts}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/index.yaml", nil)
+			tt.handler(w, req, tt.params)
+
+			if got, want := w.Code, http.StatusOK; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+			var index helmrepo.IndexFile
+			if err := yaml.Unmarshal(w.Body.Bytes(), &index); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			gotURLs := map[string][]string{}
+			for name, versions := range index.Entries {
+				for _, cv := range versions {
+					gotURLs[name] = append(gotURLs[name], cv.URLs...)
+				}
+			}
+			if !cmp.Equal(tt.wantURLs, gotURLs) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.wantURLs, gotURLs))
+			}
+		})
+	}
+}
+
+func Test_getHelmChartTarball(t *testing.T) {
+	tests := []struct {
+		name         string
+		chartURLs    []string
+		digest       string
+		tarballs     map[string][]byte
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			"mirrored tarball",
+			[]string{"https://example.com/my-chart-0.1.0.tgz"},
+			"abc",
+			map[string][]byte{"abc": []byte("tarball")},
+			http.StatusOK,
+			"",
+		},
+		{
+			"absolute chart URL",
+			[]string{"https://example.com/my-chart-0.1.0.tgz"},
+			"abc",
+			map[string][]byte{},
+			http.StatusFound,
+			"https://example.com/my-chart-0.1.0.tgz",
+		},
+		{
+			"chart URL relative to the repository",
+			[]string{"charts/my-chart-0.1.0.tgz"},
+			"abc",
+			map[string][]byte{},
+			http.StatusFound,
+			"https://charts.example.com/repo/charts/my-chart-0.1.0.tgz",
+		},
+		{
+			"no chart URL",
+			[]string{},
+			"abc",
+			map[string][]byte{},
+			http.StatusNotFound,
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager = &fakeTarballManager{
+				chart: models.Chart{
+					ID:            "my-repo/my-chart",
+					Repo:          &models.Repo{Name: "my-repo", Namespace: "my-namespace", URL: "https://charts.example.com/repo"},
+					ChartVersions: []models.ChartVersion{{Version: "0.1.0", Digest: tt.digest, URLs: tt.chartURLs}},
+				},
+				tarballs: tt.tarballs,
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/helm/my-repo/charts/my-chart/0.1.0/my-chart-0.1.0.tgz", nil)
+			params := Params{
+				"namespace": "my-namespace",
+				"repo":      "my-repo",
+				"chartName": "my-chart",
+				"version":   "0.1.0",
+				"file":      "my-chart-0.1.0.tgz",
+			}
+
+			getHelmChartTarball(w, req, params)
+
+			if got, want := w.Code, tt.wantCode; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+			if got, want := w.Header().Get("Location"), tt.wantLocation; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if tt.wantCode == http.StatusOK {
+				if got, want := w.Body.String(), "tarball"; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+			}
+		})
+	}
+}
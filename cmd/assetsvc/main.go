@@ -43,29 +43,39 @@ func setupRoutes() http.Handler {
 	// Routes
 	apiv1 := r.PathPrefix(pathPrefix).Subrouter()
 	// TODO: mnelson: Seems we could use path per endpoint handling empty params? Check.
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Queries("name", "{chartName}", "version", "{version}", "appversion", "{appversion}").Handler(WithParams(listChartsWithFilters))
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Queries("name", "{chartName}", "version", "{version}", "appversion", "{appversion}", "showDuplicates", "{showDuplicates}").Handler(WithParams(listChartsWithFilters))
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Handler(WithParams(listCharts))
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Queries("showDuplicates", "{showDuplicates}").Handler(WithParams(listCharts))
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}").Handler(WithParams(listCharts))
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}/{chartName}").Handler(WithParams(getChart))
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}/{chartName}/versions").Handler(WithParams(listChartVersions))
-	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}/{chartName}/versions/{version}").Handler(WithParams(getChartVersion))
-	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/logo").Handler(WithParams(getChartIcon))
-	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/README.md").Handler(WithParams(getChartVersionReadme))
-	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/values.yaml").Handler(WithParams(getChartVersionValues))
-	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/values.schema.json").Handler(WithParams(getChartVersionSchema))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Queries("name", "{chartName}", "version", "{version}", "appversion", "{appversion}").Handler(WithParams(withRepoValidators(cacheControlRevalidate, listChartsWithFilters)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Queries("name", "{chartName}", "version", "{version}", "appversion", "{appversion}", "showDuplicates", "{showDuplicates}").Handler(WithParams(withRepoValidators(cacheControlRevalidate, listChartsWithFilters)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Handler(WithParams(withRepoValidators(cacheControlRevalidate, listCharts)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts").Queries("showDuplicates", "{showDuplicates}").Handler(WithParams(withRepoValidators(cacheControlRevalidate, listCharts)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}").Handler(WithParams(withRepoValidators(cacheControlRevalidate, listCharts)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}/{chartName}").Handler(WithParams(withRepoValidators(cacheControlRevalidate, getChart)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}/{chartName}/versions").Handler(WithParams(withRepoValidators(cacheControlRevalidate, listChartVersions)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}/{chartName}/versions/{version}").Handler(WithParams(withRepoValidators(cacheControlRevalidate, getChartVersion)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/charts/{repo}/{chartName}/versions/{version}/dependencies").Handler(WithParams(withRepoValidators(cacheControlRevalidate, getChartVersionDependencies)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/logo").Handler(WithParams(withRepoValidators(cacheControlIcon, getChartIcon)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/README.md").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionReadme)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/values.yaml").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionValues)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/values.schema.json").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionSchema)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/Chart.yaml").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionChartYAML)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/NOTES.txt").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionNotes)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/CHANGELOG.md").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionChangelog)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/LICENSE").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionLicense)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/crds").Handler(WithParams(withRepoValidators(cacheControlChartFiles, getChartVersionCRDs)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/assets/{repo}/{chartName}/versions/{version}/chart.tgz").Handler(WithParams(getChartVersionTarball))
+	apiv1.Methods("GET").Path("/ns/{namespace}/helm/index.yaml").Handler(WithParams(withRepoValidators(cacheControlRevalidate, getNamespaceHelmIndex)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/helm/{repo}/index.yaml").Handler(WithParams(withRepoValidators(cacheControlRevalidate, getRepoHelmIndex)))
+	apiv1.Methods("GET").Path("/ns/{namespace}/helm/{repo}/charts/{chartName}/{version}/{file}").Handler(WithParams(getHelmChartTarball))
 
 	n := negroni.Classic()
-	n.UseHandler(r)
+	n.UseHandler(gzipHandler(r))
 	return n
 }
 
 func main() {
-	dbURL := flag.String("database-url", "localhost", "Database URL")
+	dbURL := flag.String("database-url", "localhost", "Database URL, or path of the database file for bolt")
 	dbName := flag.String("database-name", "charts", "Database database")
 	dbUsername := flag.String("database-user", "", "Database user")
-	dbType := flag.String("database-type", "mongodb", "Database type")
+	dbType := flag.String("database-type", "mongodb", "Database type. Allowed values: mongodb, postgresql, bolt")
 	dbPassword := os.Getenv("DB_PASSWORD")
 	flag.Parse()
 
@@ -79,6 +79,7 @@ func Test_GetCharts(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			m.On("All", &chartsList).Run(func(args mock.Arguments) {
 				*args.Get(0).(*[]*models.Chart) = tt.charts
 			})
@@ -121,6 +122,7 @@ func Test_GetChartsInRepo(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			m.On("All", &chartsList).Run(func(args mock.Arguments) {
 				*args.Get(0).(*[]*models.Chart) = tt.charts
 			})
@@ -174,6 +176,7 @@ func Test_GetChartInRepo(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			if tt.err != nil {
 				m.On("One", mock.Anything).Return(tt.err)
 			} else {
@@ -227,6 +230,7 @@ func Test_ListChartVersions(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			if tt.err != nil {
 				m.On("One", mock.Anything).Return(tt.err)
 			} else {
@@ -280,6 +284,7 @@ func Test_GetChartVersion(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			if tt.err != nil {
 				m.On("One", mock.Anything).Return(tt.err)
 			} else {
@@ -333,6 +338,7 @@ func Test_GetChartIcon(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			if tt.err != nil {
 				m.On("One", mock.Anything).Return(tt.err)
 			} else {
@@ -390,6 +396,7 @@ func Test_GetChartReadme(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			if tt.err != nil {
 				m.On("One", mock.Anything).Return(tt.err)
 			} else {
@@ -447,6 +454,7 @@ func Test_GetChartValues(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			if tt.err != nil {
 				m.On("One", mock.Anything).Return(tt.err)
 			} else {
@@ -504,6 +512,7 @@ func Test_GetChartSchema(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var m mock.Mock
 			manager = getMockManager(&m)
+			m.On("All", &repoLastChecks)
 			if tt.err != nil {
 				m.On("One", mock.Anything).Return(tt.err)
 			} else {
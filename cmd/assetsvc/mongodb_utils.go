@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"io/ioutil"
 	"math"
 
 	"github.com/globalsign/mgo/bson"
@@ -34,7 +35,7 @@ func newMongoDBManager(config datastore.Config, kubeappsNamespace string) assetM
 	return &mongodbAssetManager{m}
 }
 
-func (m *mongodbAssetManager) getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool) ([]*models.Chart, int, error) {
+func (m *mongodbAssetManager) getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool, query chartQuery) ([]*models.Chart, int, error) {
 	db, closer := m.DBSession.DB()
 	defer closer()
 	var charts []*models.Chart
@@ -48,6 +49,19 @@ func (m *mongodbAssetManager) getPaginatedChartList(namespace, repo string, page
 	if repo != "" {
 		matcher["repo.name"] = repo
 	}
+	if len(query.keywords) > 0 {
+		matcher["keywords"] = bson.M{"$all": query.keywords}
+	}
+	if query.maintainer != "" {
+		matcher["maintainers.name"] = query.maintainer
+	}
+	if query.domain != "" {
+		domain := bson.RegEx{Pattern: query.domainPattern(), Options: "i"}
+		matcher["$or"] = []bson.M{{"home": domain}, {"sources": domain}}
+	}
+	if !query.updatedSince.IsZero() {
+		matcher["chartversions.0.created"] = bson.M{"$gte": query.updatedSince}
+	}
 	if len(matcher) > 0 {
 		pipeline = append(pipeline, bson.M{"$match": matcher})
 	}
@@ -64,8 +78,7 @@ func (m *mongodbAssetManager) getPaginatedChartList(namespace, repo string, page
 		)
 	}
 
-	// Order by name
-	pipeline = append(pipeline, bson.M{"$sort": bson.M{"name": 1}})
+	pipeline = append(pipeline, mongoSortStages(query)...)
 
 	totalPages := 1
 	if pageSize != 0 {
@@ -97,6 +110,26 @@ func (m *mongodbAssetManager) getPaginatedChartList(namespace, repo string, page
 	return charts, totalPages, nil
 }
 
+// mongoSortStages returns the stages of the pipeline sorting the charts by the
+// key of the query, and then by name
+func mongoSortStages(query chartQuery) []bson.M {
+	direction := 1
+	if query.descending {
+		direction = -1
+	}
+	switch query.sortBy {
+	case sortByCreated:
+		return []bson.M{
+			{"$addFields": bson.M{"latestCreated": bson.M{"$arrayElemAt": []interface{}{"$chartversions.created", 0}}}},
+			{"$sort": bson.D{{Name: "latestCreated", Value: direction}, {Name: "name", Value: 1}}},
+		}
+	case sortByRepo:
+		return []bson.M{{"$sort": bson.D{{Name: "repo.name", Value: direction}, {Name: "name", Value: 1}}}}
+	default:
+		return []bson.M{{"$sort": bson.M{"name": direction}}}
+	}
+}
+
 func (m *mongodbAssetManager) getChart(namespace, chartID string) (models.Chart, error) {
 	db, closer := m.DBSession.DB()
 	defer closer()
@@ -128,6 +161,21 @@ func (m *mongodbAssetManager) getChartFiles(namespace, filesID string) (models.C
 	return files, err
 }
 
+func (m *mongodbAssetManager) getChartTarball(namespace, repo, digest string) ([]byte, error) {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	gfs, err := dbutils.GridFS(db, dbutils.ChartTarballsGridFS)
+	if err != nil {
+		return nil, err
+	}
+	f, err := gfs.OpenId(dbutils.ChartTarballID(namespace, repo, digest))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
 func (m *mongodbAssetManager) getChartsWithFilters(namespace, name, version, appVersion string) ([]*models.Chart, error) {
 	db, closer := m.DBSession.DB()
 	defer closer()
@@ -164,3 +212,18 @@ func (m *mongodbAssetManager) searchCharts(query, repo string) ([]*models.Chart,
 	err := db.C(chartCollection).Find(conditions).All(&charts)
 	return charts, err
 }
+
+func (m *mongodbAssetManager) getRepoLastChecks(namespace, repo string) ([]repoLastCheck, error) {
+	db, closer := m.DBSession.DB()
+	defer closer()
+	var checks []repoLastCheck
+	matcher := bson.M{}
+	if namespace != dbutils.AllNamespaces {
+		matcher["namespace"] = bson.M{"$in": []string{namespace, m.KubeappsNamespace}}
+	}
+	if repo != "" {
+		matcher["name"] = repo
+	}
+	err := db.C(dbutils.RepositoryCollection).Find(matcher).All(&checks)
+	return checks, err
+}
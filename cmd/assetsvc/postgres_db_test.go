@@ -385,7 +385,7 @@ func TestGetPaginatedChartList(t *testing.T) {
 			}
 
 			// The actual pagination isn't currently implemented as its not yet used by Kubeapps.
-			charts, _, err := pam.getPaginatedChartList(tc.namespace, tc.repo, 1, 10, tc.showDups)
+			charts, _, err := pam.getPaginatedChartList(tc.namespace, tc.repo, 1, 10, tc.showDups, chartQuery{})
 
 			if got, want := err, tc.expectedErr; got != want {
 				t.Fatalf("got: %+v, want: %+v", got, want)
@@ -396,3 +396,24 @@ func TestGetPaginatedChartList(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPaginatedChartListWithQuery(t *testing.T) {
+	pgtest.SkipIfNoDB(t)
+	pam, cleanup := getInitializedManager(t)
+	defer cleanup()
+	for _, c := range queryTestCharts() {
+		pgtest.EnsureChartsExist(t, pam, []models.Chart{*c}, *c.Repo)
+	}
+
+	for _, tc := range chartQueryTests {
+		t.Run(tc.name, func(t *testing.T) {
+			charts, _, err := pam.getPaginatedChartList(dbutilstest.KubeappsTestNamespace, "", 1, 0, true, tc.query)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := chartIDList(charts), tc.want; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -17,15 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"database/sql"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/kubeapps/common/datastore"
 	"github.com/kubeapps/kubeapps/pkg/chart/models"
 	"github.com/kubeapps/kubeapps/pkg/dbutils"
 	_ "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
 )
 
 // TODO(mnelson): standardise error API for package.
@@ -52,7 +56,7 @@ func exists(current []string, str string) bool {
 	return false
 }
 
-func (m *postgresAssetManager) getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool) ([]*models.Chart, int, error) {
+func (m *postgresAssetManager) getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool, query chartQuery) ([]*models.Chart, int, error) {
 	clauses := []string{}
 	queryParams := []interface{}{}
 	if namespace != dbutils.AllNamespaces {
@@ -63,12 +67,14 @@ func (m *postgresAssetManager) getPaginatedChartList(namespace, repo string, pag
 		queryParams = append(queryParams, repo)
 		clauses = append(clauses, fmt.Sprintf("repo_name = $%d", len(queryParams)))
 	}
+	queryClauses, queryParams := pgChartQueryClauses(query, queryParams)
+	clauses = append(clauses, queryClauses...)
 	repoQuery := ""
 	if len(clauses) > 0 {
 		repoQuery = strings.Join(clauses, " AND ")
 		repoQuery = "WHERE " + repoQuery
 	}
-	dbQuery := fmt.Sprintf("SELECT info FROM %s %s ORDER BY info ->> 'name' ASC", dbutils.ChartTable, repoQuery)
+	dbQuery := fmt.Sprintf("SELECT info FROM %s %s ORDER BY %s", dbutils.ChartTable, repoQuery, pgChartQueryOrder(query))
 	charts, err := m.QueryAllCharts(dbQuery, queryParams...)
 	if err != nil {
 		return nil, 0, nil
@@ -92,6 +98,55 @@ func (m *postgresAssetManager) getPaginatedChartList(namespace, repo string, pag
 	return charts, 1, nil
 }
 
+// pgLatestCreated is the creation time of the latest version of a chart
+const pgLatestCreated = "(info -> 'chartVersions' -> 0 ->> 'created')::timestamptz"
+
+// pgChartQueryClauses returns the clauses filtering the charts of a query,
+// appending their parameters to the given ones
+func pgChartQueryClauses(query chartQuery, queryParams []interface{}) ([]string, []interface{}) {
+	clauses := []string{}
+	if len(query.keywords) > 0 {
+		keywords, _ := json.Marshal(query.keywords)
+		queryParams = append(queryParams, string(keywords))
+		clauses = append(clauses, fmt.Sprintf("info -> 'keywords' @> $%d::jsonb", len(queryParams)))
+	}
+	if query.maintainer != "" {
+		maintainers, _ := json.Marshal([]map[string]string{{"name": query.maintainer}})
+		queryParams = append(queryParams, string(maintainers))
+		clauses = append(clauses, fmt.Sprintf("info -> 'maintainers' @> $%d::jsonb", len(queryParams)))
+	}
+	if query.domain != "" {
+		queryParams = append(queryParams, query.domainPattern())
+		n := len(queryParams)
+		// The sources may be null, which can't be expanded as an array.
+		clauses = append(clauses, fmt.Sprintf(
+			"(info ->> 'home' ~* $%d OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(CASE WHEN jsonb_typeof(info -> 'sources') = 'array' THEN info -> 'sources' ELSE '[]' END) AS source WHERE source ~* $%d))",
+			n, n))
+	}
+	if !query.updatedSince.IsZero() {
+		queryParams = append(queryParams, query.updatedSince)
+		clauses = append(clauses, fmt.Sprintf("%s >= $%d", pgLatestCreated, len(queryParams)))
+	}
+	return clauses, queryParams
+}
+
+// pgChartQueryOrder returns the ORDER BY expression of a query. Charts without
+// versions are sorted first, as MongoDB does.
+func pgChartQueryOrder(query chartQuery) string {
+	direction, nulls := "ASC", "NULLS FIRST"
+	if query.descending {
+		direction, nulls = "DESC", "NULLS LAST"
+	}
+	switch query.sortBy {
+	case sortByCreated:
+		return fmt.Sprintf("%s %s %s, info ->> 'name' ASC", pgLatestCreated, direction, nulls)
+	case sortByRepo:
+		return fmt.Sprintf("repo_name %s, info ->> 'name' ASC", direction)
+	default:
+		return fmt.Sprintf("info ->> 'name' %s", direction)
+	}
+}
+
 func (m *postgresAssetManager) getChart(namespace, chartID string) (models.Chart, error) {
 	var chart models.ChartIconString
 	err := m.QueryOne(&chart, fmt.Sprintf("SELECT info FROM %s WHERE repo_namespace = $1 AND chart_id = $2", dbutils.ChartTable), namespace, chartID)
@@ -149,6 +204,18 @@ func (m *postgresAssetManager) getChartFiles(namespace, filesID string) (models.
 	return chartFiles, nil
 }
 
+func (m *postgresAssetManager) getChartTarball(namespace, repo, digest string) ([]byte, error) {
+	var data []byte
+	err := m.GetDB().QueryRow(
+		fmt.Sprintf("SELECT data FROM %s WHERE repo_namespace = $1 AND repo_name = $2 AND digest = $3", dbutils.ChartTarballsTable),
+		namespace, repo, digest,
+	).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 func containsVersionAndAppVersion(chartVersions []models.ChartVersion, version, appVersion string) (models.ChartVersion, bool) {
 	for _, ch := range chartVersions {
 		if ch.Version == version && ch.AppVersion == appVersion {
@@ -171,3 +238,56 @@ func (m *postgresAssetManager) getChartsWithFilters(namespace, name, version, ap
 	}
 	return result, nil
 }
+
+// parseLastUpdate parses the last update of a repository. The asset-syncer
+// stores it in the last_update varchar column as the time.Time String()
+// format, "2006-01-02 15:04:05.999999999 -0700 MST", which may be followed by
+// a monotonic clock reading such as " m=+12.345".
+func parseLastUpdate(lastUpdate string) (time.Time, error) {
+	if i := strings.Index(lastUpdate, " m="); i >= 0 {
+		lastUpdate = lastUpdate[:i]
+	}
+	return time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", lastUpdate)
+}
+
+func (m *postgresAssetManager) getRepoLastChecks(namespace, repo string) ([]repoLastCheck, error) {
+	clauses := []string{}
+	queryParams := []interface{}{}
+	if namespace != dbutils.AllNamespaces {
+		queryParams = append(queryParams, namespace, m.GetKubeappsNamespace())
+		clauses = append(clauses, "(namespace = $1 OR namespace = $2)")
+	}
+	if repo != "" {
+		queryParams = append(queryParams, repo)
+		clauses = append(clauses, fmt.Sprintf("name = $%d", len(queryParams)))
+	}
+	query := fmt.Sprintf("SELECT namespace, name, checksum, last_update FROM %s", dbutils.RepositoryTable)
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	rows, err := m.GetDB().Query(query, queryParams...)
+	if rows != nil {
+		defer rows.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	checks := []repoLastCheck{}
+	for rows.Next() {
+		var check repoLastCheck
+		var checksum, lastUpdate sql.NullString
+		if err := rows.Scan(&check.Namespace, &check.Name, &checksum, &lastUpdate); err != nil {
+			return nil, err
+		}
+		check.Checksum = checksum.String
+		if lastUpdate.Valid {
+			// The validators only depend on the checksum of the repository
+			// when its last update cannot be parsed.
+			if check.LastUpdate, err = parseLastUpdate(lastUpdate.String); err != nil {
+				log.WithError(err).Warnf("unable to parse the last update of %s/%s", check.Namespace, check.Name)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, rows.Err()
+}
@@ -20,7 +20,9 @@ import (
 	"encoding/base64"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/kubeapps/common/datastore"
 	"github.com/kubeapps/kubeapps/pkg/chart/models"
@@ -158,6 +160,28 @@ func Test_getChartFiles(t *testing.T) {
 	}
 }
 
+func Test_PGgetChartTarball(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	rows := sqlmock.NewRows([]string{"data"}).AddRow([]byte("tarball"))
+	mock.ExpectQuery(`^SELECT data FROM tarballs WHERE repo_namespace = \$1 AND repo_name = \$2 AND digest = \$3$`).
+		WithArgs("namespace", "my-repo", "abc").WillReturnRows(rows)
+	pg := postgresAssetManager{&dbutils.PostgresAssetManager{DB: db}}
+
+	tarball, err := pg.getChartTarball("namespace", "my-repo", "abc")
+	if err != nil {
+		t.Errorf("Found error %v", err)
+	}
+	if got, want := string(tarball), "tarball"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("err %v", err)
+	}
+}
+
 func Test_getChartWithFilters(t *testing.T) {
 	m := &mock.Mock{}
 	fpg := &fakePGManager{m}
@@ -242,7 +266,7 @@ func Test_getPaginatedChartList(t *testing.T) {
 			}
 			expectedQuery = fmt.Sprintf("SELECT info FROM %s %s ORDER BY info ->> 'name' ASC", dbutils.ChartTable, expectedQuery)
 			m.On("QueryAllCharts", expectedQuery, expectedParams)
-			charts, totalPages, err := pg.getPaginatedChartList(tt.namespace, tt.repo, tt.pageNumber, tt.pageSize, tt.showDuplicates)
+			charts, totalPages, err := pg.getPaginatedChartList(tt.namespace, tt.repo, tt.pageNumber, tt.pageSize, tt.showDuplicates, chartQuery{})
 			if err != nil {
 				t.Errorf("Found error %v", err)
 			}
@@ -255,3 +279,89 @@ func Test_getPaginatedChartList(t *testing.T) {
 		})
 	}
 }
+
+func Test_PGgetRepoLastChecks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	lastUpdate := time.Date(2020, 3, 4, 10, 20, 30, 500, time.UTC)
+	rows := sqlmock.NewRows([]string{"namespace", "name", "checksum", "last_update"}).
+		AddRow("namespace", "my-repo", "abc", lastUpdate.String()+" m=+12.345").
+		AddRow("", "my-repo", nil, nil).
+		AddRow("other", "my-repo", "def", "yesterday")
+	mock.ExpectQuery(`^SELECT namespace, name, checksum, last_update FROM repos WHERE \(namespace = \$1 OR namespace = \$2\) AND name = \$3$`).
+		WithArgs("namespace", "", "my-repo").WillReturnRows(rows)
+	pg := postgresAssetManager{&dbutils.PostgresAssetManager{DB: db}}
+
+	checks, err := pg.getRepoLastChecks("namespace", "my-repo")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := []repoLastCheck{
+		{Namespace: "namespace", Name: "my-repo", Checksum: "abc", LastUpdate: lastUpdate},
+		{Namespace: "", Name: "my-repo"},
+		{Namespace: "other", Name: "my-repo", Checksum: "def"},
+	}
+	if !cmp.Equal(want, checks) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, checks))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("err %v", err)
+	}
+}
+
+func Test_getPaginatedChartListWithQuery(t *testing.T) {
+	since := time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		query          chartQuery
+		expectedQuery  string
+		expectedParams []interface{}
+	}{
+		{
+			"sorted by name descending",
+			chartQuery{sortBy: sortByName, descending: true},
+			"SELECT info FROM charts WHERE (repo_namespace = $1 OR repo_namespace = $2) ORDER BY info ->> 'name' DESC",
+			[]interface{}{"other-namespace", "kubeapps"},
+		},
+		{
+			"sorted by creation date",
+			chartQuery{sortBy: sortByCreated, descending: true},
+			"SELECT info FROM charts WHERE (repo_namespace = $1 OR repo_namespace = $2) ORDER BY (info -> 'chartVersions' -> 0 ->> 'created')::timestamptz DESC NULLS LAST, info ->> 'name' ASC",
+			[]interface{}{"other-namespace", "kubeapps"},
+		},
+		{
+			"sorted by repository",
+			chartQuery{sortBy: sortByRepo},
+			"SELECT info FROM charts WHERE (repo_namespace = $1 OR repo_namespace = $2) ORDER BY repo_name ASC, info ->> 'name' ASC",
+			[]interface{}{"other-namespace", "kubeapps"},
+		},
+		{
+			"filtered",
+			chartQuery{keywords: []string{"database", "sql"}, maintainer: "Bitnami", domain: "bitnami.com", updatedSince: since},
+			"SELECT info FROM charts WHERE (repo_namespace = $1 OR repo_namespace = $2)" +
+				" AND info -> 'keywords' @> $3::jsonb" +
+				" AND info -> 'maintainers' @> $4::jsonb" +
+				" AND (info ->> 'home' ~* $5 OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(CASE WHEN jsonb_typeof(info -> 'sources') = 'array' THEN info -> 'sources' ELSE '[]' END) AS source WHERE source ~* $5))" +
+				" AND (info -> 'chartVersions' -> 0 ->> 'created')::timestamptz >= $6" +
+				" ORDER BY info ->> 'name' ASC",
+			[]interface{}{"other-namespace", "kubeapps", `["database","sql"]`, `[{"name":"Bitnami"}]`, `^[a-z][a-z0-9+.-]*://([^/]*\.)?bitnami\.com(:[0-9]+)?(/|$)`, since},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mock.Mock{}
+			fpg := &fakePGManager{m}
+			pg := postgresAssetManager{fpg}
+
+			chartsResponse = []*models.Chart{}
+			m.On("QueryAllCharts", tt.expectedQuery, tt.expectedParams)
+			_, _, err := pg.getPaginatedChartList("other-namespace", "", 1, 0, true, tt.query)
+			if err != nil {
+				t.Errorf("Found error %v", err)
+			}
+			m.AssertExpectations(t)
+		})
+	}
+}
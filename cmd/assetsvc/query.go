@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+)
+
+// Keys to sort a list of charts by
+const (
+	sortByName    = "name"
+	sortByCreated = "created"
+	sortByRepo    = "repo"
+)
+
+// chartQuery holds the order and the filters of a list of charts, besides the
+// namespace and repository. The zero value sorts the charts by name without
+// filtering them.
+type chartQuery struct {
+	sortBy     string
+	descending bool
+	// keywords the charts must all have
+	keywords []string
+	// maintainer is the name of one of the maintainers of the charts
+	maintainer string
+	// domain of the home or one of the sources of the charts, including its
+	// subdomains
+	domain string
+	// updatedSince is the minimum creation time of the latest chart version
+	updatedSince time.Time
+}
+
+// parseChartQuery extracts the order and filters of a list of charts from the
+// query parameters of a request
+func parseChartQuery(req *http.Request) (chartQuery, error) {
+	q := chartQuery{}
+	switch sortBy := req.FormValue("sort"); sortBy {
+	case "", sortByName:
+		q.sortBy = sortByName
+	case sortByCreated, sortByRepo:
+		q.sortBy = sortBy
+	default:
+		return q, fmt.Errorf("unsupported sort key %q", sortBy)
+	}
+	switch order := req.FormValue("order"); order {
+	case "", "asc":
+	case "desc":
+		q.descending = true
+	default:
+		return q, fmt.Errorf("unsupported order %q", order)
+	}
+	if keywords := req.FormValue("keywords"); keywords != "" {
+		for _, k := range strings.Split(keywords, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				q.keywords = append(q.keywords, k)
+			}
+		}
+	}
+	q.maintainer = req.FormValue("maintainer")
+	q.domain = req.FormValue("domain")
+	if strings.ContainsAny(q.domain, "/:") {
+		return q, fmt.Errorf("invalid domain %q", q.domain)
+	}
+	if since := req.FormValue("updatedSince"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return q, fmt.Errorf("invalid updatedSince %q: %v", since, err)
+		}
+		q.updatedSince = t
+	}
+	return q, nil
+}
+
+// domainPattern returns the case insensitive regular expression matching the
+// URLs of the domain of the query, which both MongoDB and PostgreSQL support.
+func (q chartQuery) domainPattern() string {
+	return `^[a-z][a-z0-9+.-]*://([^/]*\.)?` + regexp.QuoteMeta(q.domain) + `(:[0-9]+)?(/|$)`
+}
+
+// latestCreated returns the creation time of the latest version of a chart
+func latestCreated(c *models.Chart) time.Time {
+	if len(c.ChartVersions) == 0 {
+		return time.Time{}
+	}
+	return c.ChartVersions[0].Created
+}
+
+// matches returns whether a chart passes the filters of the query
+func (q chartQuery) matches(c *models.Chart) bool {
+	for _, k := range q.keywords {
+		if !exists(c.Keywords, k) {
+			return false
+		}
+	}
+	if q.maintainer != "" {
+		found := false
+		for _, m := range c.Maintainers {
+			found = found || m.Name == q.maintainer
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.domain != "" {
+		re := regexp.MustCompile("(?i)" + q.domainPattern())
+		found := re.MatchString(c.Home)
+		for _, s := range c.Sources {
+			found = found || re.MatchString(s)
+		}
+		if !found {
+			return false
+		}
+	}
+	if !q.updatedSince.IsZero() && latestCreated(c).Before(q.updatedSince) {
+		return false
+	}
+	return true
+}
+
+// filter returns the charts passing the filters of the query
+func (q chartQuery) filter(charts []*models.Chart) []*models.Chart {
+	filtered := []*models.Chart{}
+	for _, c := range charts {
+		if q.matches(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// sort orders the charts by the key of the query. Charts with the same
+// creation date or repository are ordered by name.
+func (q chartQuery) sort(charts []*models.Chart) {
+	sort.SliceStable(charts, func(i, j int) bool {
+		a, b := charts[i], charts[j]
+		if q.descending {
+			a, b = b, a
+		}
+		switch q.sortBy {
+		case sortByCreated:
+			if ca, cb := latestCreated(a), latestCreated(b); !ca.Equal(cb) {
+				return ca.Before(cb)
+			}
+			return charts[i].Name < charts[j].Name
+		case sortByRepo:
+			if ra, rb := chartRepoName(a), chartRepoName(b); ra != rb {
+				return ra < rb
+			}
+			return charts[i].Name < charts[j].Name
+		default:
+			return a.Name < b.Name
+		}
+	})
+}
+
+func chartRepoName(c *models.Chart) string {
+	if c.Repo == nil {
+		return ""
+	}
+	return c.Repo.Name
+}
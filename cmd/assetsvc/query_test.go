@@ -0,0 +1,165 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func Test_parseChartQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		want      chartQuery
+		wantError bool
+	}{
+		{"defaults", "", chartQuery{sortBy: sortByName}, false},
+		{"sort by creation date", "?sort=created&order=desc", chartQuery{sortBy: sortByCreated, descending: true}, false},
+		{"sort by repository", "?sort=repo&order=asc", chartQuery{sortBy: sortByRepo}, false},
+		{
+			"filters",
+			"?keywords=database,%20sql,&maintainer=Bitnami&domain=bitnami.com&updatedSince=2020-03-04T10:00:00Z",
+			chartQuery{
+				sortBy:       sortByName,
+				keywords:     []string{"database", "sql"},
+				maintainer:   "Bitnami",
+				domain:       "bitnami.com",
+				updatedSince: time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC),
+			},
+			false,
+		},
+		{"unsupported sort key", "?sort=stars", chartQuery{}, true},
+		{"unsupported order", "?order=random", chartQuery{}, true},
+		{"domain with a scheme", "?domain=https://bitnami.com", chartQuery{}, true},
+		{"invalid date", "?updatedSince=yesterday", chartQuery{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseChartQuery(httptest.NewRequest("GET", "/charts"+tt.query, nil))
+			if got, want := err != nil, tt.wantError; got != want {
+				t.Fatalf("got error: %v, want error: %t", err, want)
+			}
+			if tt.wantError {
+				return
+			}
+			if !cmp.Equal(tt.want, q, cmp.AllowUnexported(chartQuery{})) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.want, q, cmp.AllowUnexported(chartQuery{})))
+			}
+		})
+	}
+}
+
+func queryTestCharts() []*models.Chart {
+	bitnami := &models.Repo{Namespace: "kubeapps", Name: "bitnami"}
+	stable := &models.Repo{Namespace: "kubeapps", Name: "stable"}
+	return []*models.Chart{
+		{
+			ID: "stable/mysql", Name: "mysql", Repo: stable,
+			Keywords: []string{"database", "sql"}, Home: "https://www.mysql.com",
+			Maintainers:   []chart.Maintainer{{Name: "olemarkus"}},
+			ChartVersions: []models.ChartVersion{{Version: "1.0.0", Created: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			ID: "bitnami/mariadb", Name: "mariadb", Repo: bitnami,
+			Keywords: []string{"database", "sql", "mysql"}, Home: "https://mariadb.org",
+			Sources:       []string{"https://github.com/bitnami/bitnami-docker-mariadb"},
+			Maintainers:   []chart.Maintainer{{Name: "Bitnami"}},
+			ChartVersions: []models.ChartVersion{{Version: "7.0.0", Created: time.Date(2020, 3, 5, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			ID: "bitnami/redis", Name: "redis", Repo: bitnami,
+			Keywords: []string{"database", "cache"}, Home: "http://redis.io/",
+			Sources:       []string{"https://github.com/bitnami/bitnami-docker-redis"},
+			Maintainers:   []chart.Maintainer{{Name: "Bitnami"}},
+			ChartVersions: []models.ChartVersion{{Version: "10.0.0", Created: time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			ID: "stable/empty", Name: "empty", Repo: stable,
+		},
+	}
+}
+
+// chartQueryTests are the expected IDs of queryTestCharts for each query,
+// which every database must return.
+var chartQueryTests = []struct {
+	name  string
+	query chartQuery
+	want  []string
+}{
+	{"by name", chartQuery{}, []string{"stable/empty", "bitnami/mariadb", "stable/mysql", "bitnami/redis"}},
+	{"by name descending", chartQuery{sortBy: sortByName, descending: true}, []string{"bitnami/redis", "stable/mysql", "bitnami/mariadb", "stable/empty"}},
+	{"by creation date", chartQuery{sortBy: sortByCreated}, []string{"stable/empty", "stable/mysql", "bitnami/redis", "bitnami/mariadb"}},
+	{"by creation date descending", chartQuery{sortBy: sortByCreated, descending: true}, []string{"bitnami/mariadb", "bitnami/redis", "stable/mysql", "stable/empty"}},
+	{"by repository descending", chartQuery{sortBy: sortByRepo, descending: true}, []string{"stable/empty", "stable/mysql", "bitnami/mariadb", "bitnami/redis"}},
+	{"by keywords", chartQuery{keywords: []string{"database", "sql"}}, []string{"bitnami/mariadb", "stable/mysql"}},
+	{"by maintainer", chartQuery{maintainer: "Bitnami"}, []string{"bitnami/mariadb", "bitnami/redis"}},
+	{"by home domain", chartQuery{domain: "MySQL.com"}, []string{"stable/mysql"}},
+	{"by source domain", chartQuery{domain: "github.com"}, []string{"bitnami/mariadb", "bitnami/redis"}},
+	{"by a domain which is a suffix", chartQuery{domain: "is.io"}, []string{}},
+	{"updated since", chartQuery{updatedSince: time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC)}, []string{"bitnami/mariadb", "bitnami/redis"}},
+}
+
+func chartIDList(charts []*models.Chart) []string {
+	ids := []string{}
+	for _, c := range charts {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func Test_chartQueryFilterAndSort(t *testing.T) {
+	for _, tt := range chartQueryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			charts := tt.query.filter(queryTestCharts())
+			tt.query.sort(charts)
+			if got := chartIDList(charts); !cmp.Equal(tt.want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func Test_mongoSortStages(t *testing.T) {
+	tests := []struct {
+		name  string
+		query chartQuery
+		want  []bson.M
+	}{
+		{"by name", chartQuery{}, []bson.M{{"$sort": bson.M{"name": 1}}}},
+		{"by repository descending", chartQuery{sortBy: sortByRepo, descending: true}, []bson.M{
+			{"$sort": bson.D{{Name: "repo.name", Value: -1}, {Name: "name", Value: 1}}},
+		}},
+		{"by creation date", chartQuery{sortBy: sortByCreated}, []bson.M{
+			{"$addFields": bson.M{"latestCreated": bson.M{"$arrayElemAt": []interface{}{"$chartversions.created", 0}}}},
+			{"$sort": bson.D{{Name: "latestCreated", Value: 1}, {Name: "name", Value: 1}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := mongoSortStages(tt.query), tt.want; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -26,11 +26,13 @@ import (
 type assetManager interface {
 	Init() error
 	Close() error
-	getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool) ([]*models.Chart, int, error)
+	getPaginatedChartList(namespace, repo string, pageNumber, pageSize int, showDuplicates bool, query chartQuery) ([]*models.Chart, int, error)
 	getChart(namespace, chartID string) (models.Chart, error)
 	getChartVersion(namespace, chartID, version string) (models.Chart, error)
 	getChartFiles(namespace, filesID string) (models.ChartFiles, error)
 	getChartsWithFilters(namespace, name, version, appVersion string) ([]*models.Chart, error)
+	getChartTarball(namespace, repo, digest string) ([]byte, error)
+	getRepoLastChecks(namespace, repo string) ([]repoLastCheck, error)
 }
 
 func newManager(databaseType string, config datastore.Config, kubeappsNamespace string) (assetManager, error) {
@@ -38,6 +40,8 @@ func newManager(databaseType string, config datastore.Config, kubeappsNamespace
 		return newMongoDBManager(config, kubeappsNamespace), nil
 	} else if databaseType == "postgresql" {
 		return newPGManager(config, kubeappsNamespace)
+	} else if databaseType == "bolt" {
+		return newBoltManager(config, kubeappsNamespace), nil
 	} else {
 		return nil, fmt.Errorf("Unsupported database type %s", databaseType)
 	}
@@ -2,8 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/kubeapps/common/response"
@@ -13,9 +16,13 @@ import (
 	"github.com/kubeapps/kubeapps/pkg/chart/helm3to2"
 	"github.com/kubeapps/kubeapps/pkg/handlerutil"
 	"github.com/kubeapps/kubeapps/pkg/kube"
+	"github.com/kubeapps/kubeapps/pkg/policy"
+	"github.com/kubeapps/kubeapps/pkg/redact"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/negroni"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -29,6 +36,24 @@ const (
 
 const isV1SupportRequired = false
 
+const (
+	// namespacePostRenderersKey is the key of the post-renderers ConfigMap
+	// in the release namespace which configures the post-renderers for any
+	// chart installed in that namespace.
+	namespacePostRenderersKey = "post-renderers.yaml"
+	// appRepoPostRenderersKeyFormat is the format of the key of the
+	// post-renderers ConfigMap in an app repository namespace which
+	// configures the post-renderers for charts from that app repository.
+	appRepoPostRenderersKeyFormat = "%s.post-renderers.yaml"
+	// policyKey is the key of the policy ConfigMap in the kubeapps namespace
+	// which defines the checks run on the rendered manifest of a release.
+	policyKey = "policy.yaml"
+	// namespaceTemplateKey is the key of the namespace template ConfigMap in
+	// the kubeapps namespace which defines the defaults for the namespaces
+	// created when installing a release.
+	namespaceTemplateKey = "namespace-template.yaml"
+)
+
 // This type represents the fact that a regular handler cannot actually be created until we have access to the request,
 // because a valid action config (and hence handler config) cannot be created until then.
 // If the handler config were a "this" argument instead of an explicit argument, it would be easy to create a handler with a "zero" config.
@@ -41,6 +66,23 @@ type Options struct {
 	Timeout           int64
 	UserAgent         string
 	KubeappsNamespace string
+	// PostRenderersConfigMap is the name of the ConfigMaps which configure
+	// the post-renderer chain. Only image pull secrets are added if empty.
+	PostRenderersConfigMap string
+	// PolicyConfigMap is the name of the ConfigMap, in the kubeapps
+	// namespace, defining the policy checks run on rendered manifests.
+	// No checks are run if empty.
+	PolicyConfigMap string
+	// NamespaceTemplateConfigMap is the name of the ConfigMap, in the kubeapps
+	// namespace, defining the labels, annotations and default resources of
+	// the namespaces created on install. Namespaces are created bare if empty.
+	NamespaceTemplateConfigMap string
+	// AssetsvcURL is the URL of the assetsvc, from which the charts of
+	// mirrored app repositories are fetched.
+	AssetsvcURL string
+	// ValuesRedactor masks the secret values of the releases returned. The
+	// values are returned as they are if nil.
+	ValuesRedactor *redact.Redactor
 }
 
 // Config represents data needed by each handler to be able to create Helm 3 actions.
@@ -49,6 +91,10 @@ type Config struct {
 	ActionConfig *action.Configuration
 	Options      Options
 	ChartClient  chartUtils.Resolver
+	KubeHandler  kube.AuthHandler
+	// CheckerForRequest checks the permissions of the user, to reveal the
+	// redacted values of a release.
+	CheckerForRequest auth.CheckerForRequest
 }
 
 // NewInClusterConfig returns an internal cluster config replacing the token.
@@ -102,7 +148,10 @@ func WithHandlerConfig(storageForDriver agent.StorageForDriver, options Options)
 			cfg := Config{
 				Options:      options,
 				ActionConfig: actionConfig,
-				ChartClient:  chartUtils.NewChartClient(kubeHandler, options.KubeappsNamespace, options.UserAgent),
+				ChartClient:  chartUtils.NewChartClient(kubeHandler, options.KubeappsNamespace, options.UserAgent).WithMirror(options.AssetsvcURL),
+				KubeHandler:  kubeHandler,
+
+				CheckerForRequest: auth.AuthCheckerForRequest,
 			}
 			f(cfg, w, req, params)
 		}
@@ -129,7 +178,93 @@ func returnForbiddenActions(forbiddenActions []auth.Action, w http.ResponseWrite
 	response.NewErrorResponse(http.StatusForbidden, string(body)).Write(w)
 }
 
+func returnPolicyViolations(violations []policy.Violation, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	body, err := policy.MarshalViolations(violations)
+	if err != nil {
+		response.NewErrorResponse(http.StatusInternalServerError, err.Error()).Write(w)
+		return
+	}
+	response.NewErrorResponse(http.StatusUnprocessableEntity, body).Write(w)
+}
+
+// addPolicyWarnings adds a Warning header for each policy violation found
+// in warn-only mode. It must be called before writing the response.
+func addPolicyWarnings(enforcer *policy.Enforcer, w http.ResponseWriter) {
+	if enforcer == nil {
+		return
+	}
+	for _, v := range enforcer.Warnings() {
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", fmt.Sprintf("%s (%s): %s", v.Resource, v.Rule, v.Message)))
+	}
+}
+
+// releaseWriter writes a release in the response, in the shape of an API
+// version.
+type releaseWriter func(rel *release.Release, w http.ResponseWriter)
+
+// writeCompatRelease writes the release converted to Helm 2 types, as
+// returned by the v1 API used by the dashboard.
+func writeCompatRelease(rel *release.Release, w http.ResponseWriter) {
+	compatRelease, err := helm3to2.Convert(*rel)
+	if err != nil {
+		returnErrMessage(err, w)
+		return
+	}
+	response.NewDataResponse(compatRelease).Write(w)
+}
+
+// writeNativeRelease writes the Helm 3 release as it is, with its notes, hooks
+// and status, as returned by the v2 API.
+func writeNativeRelease(rel *release.Release, w http.ResponseWriter) {
+	response.NewDataResponse(rel).Write(w)
+}
+
+// redactRelease returns a copy of the release with the secret values of its
+// config and the Secrets of its manifest and hooks masked.
+func redactRelease(cfg Config, rel *release.Release) (*release.Release, error) {
+	redactor := cfg.Options.ValuesRedactor
+	if redactor == nil {
+		return rel, nil
+	}
+	var schema []byte
+	if rel.Chart != nil {
+		schema = rel.Chart.Schema
+	}
+	redacted := *rel
+	redacted.Config = redactor.Values(rel.Config, schema)
+	manifest, err := redact.Manifest(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	redacted.Manifest = manifest
+	redacted.Hooks = make([]*release.Hook, len(rel.Hooks))
+	for i, hook := range rel.Hooks {
+		redactedHook := *hook
+		if redactedHook.Manifest, err = redact.Manifest(hook.Manifest); err != nil {
+			return nil, err
+		}
+		redacted.Hooks[i] = &redactedHook
+	}
+	return &redacted, nil
+}
+
+// writeRedactedRelease writes the release with its secret values masked.
+func writeRedactedRelease(cfg Config, rel *release.Release, w http.ResponseWriter, writeRelease releaseWriter) {
+	redacted, err := redactRelease(cfg, rel)
+	if err != nil {
+		returnErrMessage(err, w)
+		return
+	}
+	writeRelease(redacted, w)
+}
+
 func returnErrMessage(err error, w http.ResponseWriter) {
+	var violationsErr *policy.ViolationsError
+	if errors.As(err, &violationsErr) {
+		returnPolicyViolations(violationsErr.Violations, w)
+		return
+	}
 	code := handlerutil.ErrorCode(err)
 	errMessage := err.Error()
 	if code == http.StatusForbidden {
@@ -145,6 +280,129 @@ func returnErrMessage(err error, w http.ResponseWriter) {
 	}
 }
 
+// postRendererForRequest returns the chain of post-renderers to run when
+// installing or upgrading a chart from the requested app repository in the
+// given namespace. If a policy is configured, its enforcer is run last and
+// also returned so that warnings can be reported.
+func postRendererForRequest(cfg Config, namespace string, chartDetails *chartUtils.Details) (agent.PostRendererChain, *policy.Enforcer, error) {
+	configs, err := postRendererConfigs(cfg, namespace, chartDetails)
+	if err != nil {
+		return nil, nil, err
+	}
+	chain, err := agent.NewPostRendererChain(configs, cfg.ChartClient.RegistrySecretsPerDomain())
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := policyForRequest(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		return chain, nil, nil
+	}
+	enforcer := policy.NewEnforcer(p)
+	return append(chain, enforcer), enforcer, nil
+}
+
+// policyForRequest reads the policy from the policy ConfigMap in the kubeapps
+// namespace, using the service account since the policy is set by the
+// platform operator. It returns nil if no policy is configured.
+func policyForRequest(cfg Config) (*policy.Policy, error) {
+	configMapName := cfg.Options.PolicyConfigMap
+	if configMapName == "" {
+		return nil, nil
+	}
+	configMap, err := cfg.KubeHandler.AsSVC().GetConfigMap(configMapName, cfg.Options.KubeappsNamespace)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get policy config %s/%s: %v", cfg.Options.KubeappsNamespace, configMapName, err)
+	}
+	data, ok := configMap.Data[policyKey]
+	if !ok {
+		return nil, nil
+	}
+	return policy.ParsePolicy([]byte(data))
+}
+
+// postRendererConfigs reads the post-renderer configuration from the
+// post-renderers ConfigMaps, using the service account since the config is
+// set by the platform operator rather than the user. A config specific to
+// the app repository takes precedence over the one for the release namespace.
+func postRendererConfigs(cfg Config, namespace string, chartDetails *chartUtils.Details) ([]agent.PostRendererConfig, error) {
+	configMapName := cfg.Options.PostRenderersConfigMap
+	if configMapName == "" {
+		return nil, nil
+	}
+	lookups := []struct {
+		namespace string
+		key       string
+	}{
+		{chartDetails.AppRepositoryResourceNamespace, fmt.Sprintf(appRepoPostRenderersKeyFormat, chartDetails.AppRepositoryResourceName)},
+		{namespace, namespacePostRenderersKey},
+	}
+	for _, l := range lookups {
+		if l.namespace == "" {
+			continue
+		}
+		configMap, err := cfg.KubeHandler.AsSVC().GetConfigMap(configMapName, l.namespace)
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to get post-renderers config %s/%s: %v", l.namespace, configMapName, err)
+		}
+		if data, ok := configMap.Data[l.key]; ok {
+			return agent.ParsePostRendererConfig([]byte(data))
+		}
+	}
+	return nil, nil
+}
+
+// namespaceTemplateForRequest reads the template for new namespaces from the
+// namespace template ConfigMap in the kubeapps namespace, using the service
+// account. It returns nil if no template is configured.
+func namespaceTemplateForRequest(cfg Config) (*kube.NamespaceTemplate, error) {
+	configMapName := cfg.Options.NamespaceTemplateConfigMap
+	if configMapName == "" {
+		return nil, nil
+	}
+	configMap, err := cfg.KubeHandler.AsSVC().GetConfigMap(configMapName, cfg.Options.KubeappsNamespace)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get namespace template %s/%s: %v", cfg.Options.KubeappsNamespace, configMapName, err)
+	}
+	data, ok := configMap.Data[namespaceTemplateKey]
+	if !ok {
+		return nil, nil
+	}
+	return kube.ParseNamespaceTemplate([]byte(data))
+}
+
+// createNamespace creates the release namespace with the user token. The
+// default resources of the template are then created with the service
+// account, since users who can create namespaces may not be able to create
+// quotas. They are also applied when the namespace already exists, so that a
+// request retried after a failure still applies them to the namespace created
+// by the first attempt. Namespaces not created with a template are untouched.
+func createNamespace(cfg Config, token, namespace string) error {
+	template, err := namespaceTemplateForRequest(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = cfg.KubeHandler.AsUser(token).CreateNamespace(namespace, template)
+	if err != nil {
+		if !k8sErrors.IsAlreadyExists(err) {
+			return err
+		}
+		log.Infof("Namespace %q already exists, skipping creation", namespace)
+	}
+	return cfg.KubeHandler.AsSVC().ApplyNamespaceTemplate(namespace, template)
+}
+
 // ListReleases list existing releases.
 func ListReleases(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params) {
 	apps, err := agent.ListReleases(cfg.ActionConfig, params[namespaceParam], cfg.Options.ListLimit, req.URL.Query().Get("statuses"))
@@ -172,29 +430,51 @@ func CreateRelease(cfg Config, w http.ResponseWriter, req *http.Request, params
 	releaseName := chartDetails.ReleaseName
 	namespace := params[namespaceParam]
 	valuesString := chartDetails.Values
-	release, err := agent.CreateRelease(cfg.ActionConfig, releaseName, namespace, valuesString, ch, cfg.ChartClient.RegistrySecretsPerDomain())
+	postRenderer, enforcer, err := postRendererForRequest(cfg, namespace, chartDetails)
 	if err != nil {
 		returnErrMessage(err, w)
 		return
 	}
-	response.NewDataResponse(release).Write(w)
+	if chartDetails.CreateNamespace {
+		err = createNamespace(cfg, auth.ExtractToken(req.Header.Get(authHeader)), namespace)
+		if err != nil {
+			returnErrMessage(err, w)
+			return
+		}
+	}
+	release, err := agent.CreateRelease(cfg.ActionConfig, releaseName, namespace, valuesString, ch, postRenderer)
+	if err != nil {
+		returnErrMessage(err, w)
+		return
+	}
+	addPolicyWarnings(enforcer, w)
+	writeRedactedRelease(cfg, release, w, writeNativeRelease)
 }
 
 // OperateRelease decides which method to call depending on the "action" query param.
 func OperateRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params) {
+	operateRelease(cfg, w, req, params, writeCompatRelease)
+}
+
+// OperateReleaseV2 is OperateRelease returning the Helm 3 release.
+func OperateReleaseV2(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params) {
+	operateRelease(cfg, w, req, params, writeNativeRelease)
+}
+
+func operateRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params, writeRelease releaseWriter) {
 	switch req.FormValue("action") {
 	case "upgrade":
-		upgradeRelease(cfg, w, req, params)
+		upgradeRelease(cfg, w, req, params, writeRelease)
 	case "rollback":
-		rollbackRelease(cfg, w, req, params)
+		rollbackRelease(cfg, w, req, params, writeRelease)
 	// TODO: Add "test" case here.
 	default:
 		// By default, for maintaining compatibility, we call upgrade.
-		upgradeRelease(cfg, w, req, params)
+		upgradeRelease(cfg, w, req, params, writeRelease)
 	}
 }
 
-func upgradeRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params) {
+func upgradeRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params, writeRelease releaseWriter) {
 	releaseName := params[nameParam]
 	chartDetails, chartMulti, err := handlerutil.ParseAndGetChart(req, cfg.ChartClient, isV1SupportRequired)
 	if err != nil {
@@ -203,20 +483,40 @@ func upgradeRelease(cfg Config, w http.ResponseWriter, req *http.Request, params
 	}
 
 	ch := chartMulti.Helm3Chart
-	rel, err := agent.UpgradeRelease(cfg.ActionConfig, releaseName, chartDetails.Values, ch, cfg.ChartClient.RegistrySecretsPerDomain())
+	values, err := restoreMaskedValues(cfg, releaseName, chartDetails.Values)
 	if err != nil {
 		returnErrMessage(err, w)
 		return
 	}
-	compatRelease, err := helm3to2.Convert(*rel)
+	postRenderer, enforcer, err := postRendererForRequest(cfg, params[namespaceParam], chartDetails)
 	if err != nil {
 		returnErrMessage(err, w)
 		return
 	}
-	response.NewDataResponse(compatRelease).Write(w)
+	rel, err := agent.UpgradeRelease(cfg.ActionConfig, releaseName, values, ch, postRenderer)
+	if err != nil {
+		returnErrMessage(err, w)
+		return
+	}
+	addPolicyWarnings(enforcer, w)
+	writeRedactedRelease(cfg, rel, w, writeRelease)
 }
 
-func rollbackRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params) {
+// restoreMaskedValues replaces the masked values sent to upgrade a release,
+// like the redacted values the dashboard uses as the current ones, with the
+// values of the release.
+func restoreMaskedValues(cfg Config, releaseName, values string) (string, error) {
+	if cfg.Options.ValuesRedactor == nil || !strings.Contains(values, redact.Mask) {
+		return values, nil
+	}
+	previous, err := agent.GetRelease(cfg.ActionConfig, releaseName)
+	if err != nil {
+		return "", err
+	}
+	return redact.RestoreRawValues(values, previous.Config)
+}
+
+func rollbackRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params, writeRelease releaseWriter) {
 	releaseName := params[nameParam]
 	revision := req.FormValue("revision")
 	if revision == "" {
@@ -233,16 +533,20 @@ func rollbackRelease(cfg Config, w http.ResponseWriter, req *http.Request, param
 		returnErrMessage(err, w)
 		return
 	}
-	compatRelease, err := helm3to2.Convert(*rel)
-	if err != nil {
-		returnErrMessage(err, w)
-		return
-	}
-	response.NewDataResponse(compatRelease).Write(w)
+	writeRedactedRelease(cfg, rel, w, writeRelease)
 }
 
 // GetRelease returns a release.
 func GetRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params) {
+	getRelease(cfg, w, req, params, writeCompatRelease)
+}
+
+// GetReleaseV2 returns a release as a Helm 3 release.
+func GetReleaseV2(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params) {
+	getRelease(cfg, w, req, params, writeNativeRelease)
+}
+
+func getRelease(cfg Config, w http.ResponseWriter, req *http.Request, params handlerutil.Params, writeRelease releaseWriter) {
 	// Namespace is already known by the RESTClientGetter.
 	releaseName := params[nameParam]
 	release, err := agent.GetRelease(cfg.ActionConfig, releaseName)
@@ -250,12 +554,27 @@ func GetRelease(cfg Config, w http.ResponseWriter, req *http.Request, params han
 		returnErrMessage(err, w)
 		return
 	}
-	compatRelease, err := helm3to2.Convert(*release)
-	if err != nil {
-		returnErrMessage(err, w)
+	// The secret values are only returned when explicitly revealed, by the
+	// users who can get the secrets of the namespace.
+	if handlerutil.QueryParamIsTruthy(redact.RevealParam, req) {
+		checker, err := cfg.CheckerForRequest(req)
+		if err != nil {
+			returnErrMessage(err, w)
+			return
+		}
+		forbiddenActions, err := redact.ForbiddenToReveal(checker, params[namespaceParam])
+		if err != nil {
+			returnErrMessage(err, w)
+			return
+		}
+		if len(forbiddenActions) > 0 {
+			returnForbiddenActions(forbiddenActions, w)
+			return
+		}
+		writeRelease(release, w)
 		return
 	}
-	response.NewDataResponse(compatRelease).Write(w)
+	writeRedactedRelease(cfg, release, w, writeRelease)
 }
 
 // DeleteRelease deletes a release.
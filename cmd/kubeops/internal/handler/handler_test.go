@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -12,7 +13,13 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/auth"
+	authFake "github.com/kubeapps/kubeapps/pkg/auth/fake"
+	chartUtils "github.com/kubeapps/kubeapps/pkg/chart"
 	chartFake "github.com/kubeapps/kubeapps/pkg/chart/fake"
+	"github.com/kubeapps/kubeapps/pkg/kube"
+	"github.com/kubeapps/kubeapps/pkg/policy"
+	"github.com/kubeapps/kubeapps/pkg/redact"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
@@ -20,6 +27,8 @@ import (
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	helmTime "helm.sh/helm/v3/pkg/time"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"helm.sh/helm/v3/pkg/release"
 )
@@ -520,3 +529,469 @@ func TestUpgradeAction(t *testing.T) {
 		})
 	}
 }
+
+func TestReleaseV2(t *testing.T) {
+	const releaseName = "my-release"
+	pendingRelease := createRelease("apache", releaseName, "default", 1, release.StatusPendingInstall)
+	pendingRelease.Info.Notes = "Thank you for installing apache"
+	pendingRelease.Hooks = []*release.Hook{{Name: "pre-install-job", Kind: "Job", Events: []release.HookEvent{release.HookPreInstall}}}
+
+	testCases := []struct {
+		name             string
+		existingReleases []*release.Release
+		queryString      string
+		requestBody      string
+		handler          dependentHandler
+		expectedRelease  *release.Release
+	}{
+		{
+			name:             "gets a release with its notes, hooks and status",
+			existingReleases: []*release.Release{pendingRelease},
+			handler:          GetReleaseV2,
+			expectedRelease:  pendingRelease,
+		},
+		{
+			name: "upgrades a release",
+			existingReleases: []*release.Release{
+				createRelease("apache", releaseName, "default", 1, release.StatusDeployed),
+			},
+			queryString:     "action=upgrade",
+			requestBody:     `{"chartName": "apache", "releaseName": "my-release", "version": "1.0.0"}`,
+			handler:         OperateReleaseV2,
+			expectedRelease: createRelease("apache", releaseName, "default", 2, release.StatusDeployed),
+		},
+		{
+			name: "rolls back a release",
+			existingReleases: []*release.Release{
+				createRelease("apache", releaseName, "default", 1, release.StatusSuperseded),
+				createRelease("apache", releaseName, "default", 2, release.StatusDeployed),
+			},
+			queryString:     "action=rollback&revision=1",
+			handler:         OperateReleaseV2,
+			expectedRelease: createRelease("apache", releaseName, "default", 3, release.StatusDeployed),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k := &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+			cfg := newConfigFixture(t, k)
+			createExistingReleases(t, cfg, tc.existingReleases)
+			req := httptest.NewRequest("PUT", fmt.Sprintf("https://example.com/whatever?%s", tc.queryString), strings.NewReader(tc.requestBody))
+			response := httptest.NewRecorder()
+
+			tc.handler(*cfg, response, req, map[string]string{namespaceParam: "default", nameParam: releaseName})
+
+			if got, want := response.Code, http.StatusOK; got != want {
+				t.Fatalf("got: %d, want: %d (%s)", got, want, response.Body.String())
+			}
+			var body struct {
+				Data release.Release `json:"data"`
+			}
+			if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			got, want := body.Data, tc.expectedRelease
+			if got.Name != want.Name || got.Version != want.Version || got.Chart.Name() != want.Chart.Name() {
+				t.Errorf("got release %s %d of %s, want: %s %d of %s", got.Name, got.Version, got.Chart.Name(), want.Name, want.Version, want.Chart.Name())
+			}
+			if got.Info.Status != want.Info.Status {
+				t.Errorf("got: %q, want: %q", got.Info.Status, want.Info.Status)
+			}
+			if got.Info.Notes != want.Info.Notes {
+				t.Errorf("got: %q, want: %q", got.Info.Notes, want.Info.Notes)
+			}
+			if !cmp.Equal(want.Hooks, got.Hooks) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want.Hooks, got.Hooks))
+			}
+		})
+	}
+}
+
+func TestPostRendererConfigs(t *testing.T) {
+	const configMapName = "kubeapps-post-renderers"
+	newConfigMap := func(namespace string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: namespace},
+			Data:       data,
+		}
+	}
+	details := &chartUtils.Details{
+		AppRepositoryResourceName:      "my-repo",
+		AppRepositoryResourceNamespace: "kubeapps",
+	}
+	testCases := []struct {
+		name          string
+		configMapName string
+		configMaps    []*corev1.ConfigMap
+		expectedTypes []string
+		expectErr     bool
+	}{
+		{
+			name:          "it returns no configs when not configured",
+			configMapName: "",
+			configMaps: []*corev1.ConfigMap{
+				newConfigMap("default", map[string]string{namespacePostRenderersKey: "- type: labels"}),
+			},
+			expectedTypes: []string{},
+		},
+		{
+			name:          "it returns no configs when no config map exists",
+			configMapName: configMapName,
+			expectedTypes: []string{},
+		},
+		{
+			name:          "it returns the config for the namespace",
+			configMapName: configMapName,
+			configMaps: []*corev1.ConfigMap{
+				newConfigMap("default", map[string]string{namespacePostRenderersKey: "- type: labels"}),
+				newConfigMap("kubeapps", map[string]string{"other-repo.post-renderers.yaml": "- type: podSpecDefaults"}),
+			},
+			expectedTypes: []string{"labels"},
+		},
+		{
+			name:          "it prefers the config for the app repository",
+			configMapName: configMapName,
+			configMaps: []*corev1.ConfigMap{
+				newConfigMap("default", map[string]string{namespacePostRenderersKey: "- type: labels"}),
+				newConfigMap("kubeapps", map[string]string{"my-repo.post-renderers.yaml": "- type: podSpecDefaults"}),
+			},
+			expectedTypes: []string{"podSpecDefaults"},
+		},
+		{
+			name:          "it returns an error for an invalid config",
+			configMapName: configMapName,
+			configMaps: []*corev1.ConfigMap{
+				newConfigMap("default", map[string]string{namespacePostRenderersKey: "- type: unknown"}),
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k := &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+			cfg := newConfigFixture(t, k)
+			cfg.Options.PostRenderersConfigMap = tc.configMapName
+			cfg.KubeHandler = &kube.FakeHandler{ConfigMaps: tc.configMaps}
+
+			configs, err := postRendererConfigs(*cfg, "default", details)
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			types := []string{}
+			for _, c := range configs {
+				types = append(types, c.Type)
+			}
+			if got, want := types, tc.expectedTypes; !cmp.Equal(got, want) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestPolicyForRequest(t *testing.T) {
+	const configMapName = "kubeapps-policy"
+	testCases := []struct {
+		name           string
+		configMapName  string
+		configMaps     []*corev1.ConfigMap
+		expectedPolicy *policy.Policy
+		expectErr      bool
+	}{
+		{
+			name:          "it returns no policy when not configured",
+			configMapName: "",
+			configMaps: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "kubeapps"}, Data: map[string]string{policyKey: "disallowPrivileged: true"}},
+			},
+		},
+		{
+			name:          "it returns no policy when no config map exists",
+			configMapName: configMapName,
+		},
+		{
+			name:          "it ignores a config map in another namespace",
+			configMapName: configMapName,
+			configMaps: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "default"}, Data: map[string]string{policyKey: "disallowPrivileged: true"}},
+			},
+		},
+		{
+			name:          "it returns the policy from the kubeapps namespace",
+			configMapName: configMapName,
+			configMaps: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "kubeapps"}, Data: map[string]string{policyKey: "disallowPrivileged: true"}},
+			},
+			expectedPolicy: &policy.Policy{DisallowPrivileged: true},
+		},
+		{
+			name:          "it returns an error for an invalid policy",
+			configMapName: configMapName,
+			configMaps: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "kubeapps"}, Data: map[string]string{policyKey: "disallowPrivileged: ["}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k := &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+			cfg := newConfigFixture(t, k)
+			cfg.Options.KubeappsNamespace = "kubeapps"
+			cfg.Options.PolicyConfigMap = tc.configMapName
+			cfg.KubeHandler = &kube.FakeHandler{ConfigMaps: tc.configMaps}
+
+			p, err := policyForRequest(*cfg)
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if got, want := p, tc.expectedPolicy; !cmp.Equal(got, want) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestReturnErrMessagePolicyViolations(t *testing.T) {
+	violations := []policy.Violation{
+		{Resource: "Deployment/foo", Rule: policy.RuleNoPrivilegedContainers, Message: `container "nginx" is privileged`},
+	}
+	err := fmt.Errorf("Release \"foo\" failed and has been uninstalled: %w", &policy.ViolationsError{Violations: violations})
+
+	w := httptest.NewRecorder()
+	returnErrMessage(err, w)
+
+	if got, want := w.Code, http.StatusUnprocessableEntity; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got := []policy.Violation{}
+	if err := json.Unmarshal([]byte(body.Message), &got); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !cmp.Equal(violations, got) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(violations, got))
+	}
+}
+
+func TestCreateNamespace(t *testing.T) {
+	const configMapName = "kubeapps-namespace-template"
+	templateConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "kubeapps"},
+		Data:       map[string]string{namespaceTemplateKey: "labels: {team: web}\nannotations: {owner: kubeapps}"},
+	}
+	testCases := []struct {
+		name               string
+		configMapName      string
+		configMaps         []*corev1.ConfigMap
+		existingNamespaces []corev1.Namespace
+		expectedNamespaces []corev1.Namespace
+		expectedTemplated  []string
+		expectErr          bool
+	}{
+		{
+			name:               "it creates the namespace",
+			expectedNamespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+		},
+		{
+			name:          "it creates the namespace with the template labels and annotations",
+			configMapName: configMapName,
+			configMaps:    []*corev1.ConfigMap{templateConfigMap},
+			expectedNamespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+				Name:        "foo",
+				Labels:      map[string]string{"team": "web"},
+				Annotations: map[string]string{"owner": "kubeapps", kube.NamespaceTemplateAnnotation: "true"},
+			}}},
+			expectedTemplated: []string{"foo"},
+		},
+		{
+			name:               "it leaves an existing namespace untouched",
+			configMapName:      configMapName,
+			configMaps:         []*corev1.ConfigMap{templateConfigMap},
+			existingNamespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+			expectedNamespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+		},
+		{
+			name:          "it applies the template to an existing namespace created with the template",
+			configMapName: configMapName,
+			configMaps:    []*corev1.ConfigMap{templateConfigMap},
+			existingNamespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+				Name:        "foo",
+				Annotations: map[string]string{kube.NamespaceTemplateAnnotation: "true"},
+			}}},
+			expectedNamespaces: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+				Name:        "foo",
+				Annotations: map[string]string{kube.NamespaceTemplateAnnotation: "true"},
+			}}},
+			expectedTemplated: []string{"foo"},
+		},
+		{
+			name:          "it returns an error for an invalid template",
+			configMapName: configMapName,
+			configMaps: []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "kubeapps"}, Data: map[string]string{namespaceTemplateKey: "labels: ["}},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k := &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+			cfg := newConfigFixture(t, k)
+			cfg.Options.KubeappsNamespace = "kubeapps"
+			cfg.Options.NamespaceTemplateConfigMap = tc.configMapName
+			kubeHandler := &kube.FakeHandler{ConfigMaps: tc.configMaps, Namespaces: tc.existingNamespaces}
+			cfg.KubeHandler = kubeHandler
+
+			err := createNamespace(*cfg, "token", "foo")
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if got, want := kubeHandler.Namespaces, tc.expectedNamespaces; !cmp.Equal(got, want) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			if got, want := kubeHandler.TemplatedNamespaces, tc.expectedTemplated; !cmp.Equal(got, want) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestGetReleaseRedaction(t *testing.T) {
+	const releaseName = "my-release"
+	redactor, err := redact.NewRedactor(redact.DefaultKeyPatterns)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	values := map[string]interface{}{"dsn": "postgres://user:pass@db", "password": "foo", "replicas": float64(1)}
+	const secretManifest = "---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: foo\ndata:\n  password: Zm9v\n"
+
+	tests := []struct {
+		name               string
+		requestQuery       string
+		namespaceForbidden bool
+		handler            dependentHandler
+		statusCode         int
+		responseBody       string
+		config             map[string]interface{}
+		manifest           string
+	}{
+		{
+			name:       "masks the secret values",
+			handler:    GetReleaseV2,
+			statusCode: http.StatusOK,
+			config:     map[string]interface{}{"dsn": redact.Mask, "password": redact.Mask, "replicas": float64(1)},
+			manifest:   "---\napiVersion: v1\ndata:\n  password: '********'\nkind: Secret\nmetadata:\n  name: foo\n",
+		},
+		{
+			name:         "masks the secret values of the v1 release",
+			handler:      GetRelease,
+			statusCode:   http.StatusOK,
+			responseBody: `{"data":{"name":"my-release","info":{"status":{"code":1}},"chart":{"metadata":{"name":"apache"},"values":{"raw":"{}\n"}},"config":{"raw":"dsn: '********'\npassword: '********'\nreplicas: 1\n"},"manifest":"---\napiVersion: v1\ndata:\n  password: '********'\nkind: Secret\nmetadata:\n  name: foo\n","version":1,"namespace":"default"}}`,
+		},
+		{
+			name:         "reveals the values",
+			requestQuery: "?reveal=true",
+			handler:      GetReleaseV2,
+			statusCode:   http.StatusOK,
+			config:       values,
+			manifest:     secretManifest,
+		},
+		{
+			name:               "reveal requires getting secrets",
+			requestQuery:       "?reveal=1",
+			namespaceForbidden: true,
+			handler:            GetReleaseV2,
+			statusCode:         http.StatusForbidden,
+			responseBody:       `{"code":403,"message":"[{\"apiGroup\":\"\",\"resource\":\"secrets\",\"namespace\":\"default\",\"clusterWide\":false,\"verbs\":[\"get\"]}]"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+			cfg := newConfigFixture(t, k)
+			cfg.Options.ValuesRedactor = redactor
+			cfg.CheckerForRequest = func(req *http.Request) (auth.Checker, error) {
+				return &authFake.FakeAuth{NamespaceForbidden: tt.namespaceForbidden}, nil
+			}
+			rel := createRelease("apache", releaseName, "default", 1, release.StatusDeployed)
+			rel.Chart.Schema = []byte(`{"properties": {"dsn": {"type": "string", "format": "password"}}}`)
+			rel.Config = values
+			rel.Manifest = secretManifest
+			createExistingReleases(t, cfg, []*release.Release{rel})
+			req := httptest.NewRequest("GET", fmt.Sprintf("http://foo.bar%s", tt.requestQuery), nil)
+			response := httptest.NewRecorder()
+
+			tt.handler(*cfg, response, req, map[string]string{namespaceParam: "default", nameParam: releaseName})
+
+			if got, want := response.Code, tt.statusCode; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+			if tt.responseBody != "" {
+				if got, want := response.Body.String(), tt.responseBody; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+			}
+			if tt.config != nil {
+				var body struct {
+					Data release.Release `json:"data"`
+				}
+				if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+					t.Fatalf("%+v", err)
+				}
+				if !cmp.Equal(tt.config, body.Data.Config) {
+					t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.config, body.Data.Config))
+				}
+				if got, want := body.Data.Manifest, tt.manifest; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpgradeReleaseRestoresMaskedValues(t *testing.T) {
+	const releaseName = "my-release"
+	redactor, err := redact.NewRedactor(redact.DefaultKeyPatterns)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	k := &kubefake.FailingKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: ioutil.Discard}}
+	cfg := newConfigFixture(t, k)
+	cfg.Options.ValuesRedactor = redactor
+	rel := createRelease("apache", releaseName, "default", 1, release.StatusDeployed)
+	rel.Config = map[string]interface{}{"password": "foo", "replicas": float64(1)}
+	createExistingReleases(t, cfg, []*release.Release{rel})
+	// The dashboard sends the redacted values back, with the masks
+	requestBody := `{"chartName": "apache", "releaseName": "my-release", "version": "1.0.0", "values": "password: '********'\nreplicas: 2\n"}`
+	req := httptest.NewRequest("PUT", "https://example.com/whatever?action=upgrade", strings.NewReader(requestBody))
+	response := httptest.NewRecorder()
+
+	OperateReleaseV2(*cfg, response, req, map[string]string{namespaceParam: "default", nameParam: releaseName})
+
+	if got, want := response.Code, http.StatusOK; got != want {
+		t.Fatalf("got: %d, want: %d", got, want)
+	}
+	upgraded, err := cfg.ActionConfig.Releases.Get(releaseName, 2)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := map[string]interface{}{"password": "foo", "replicas": float64(2)}
+	if !cmp.Equal(want, upgraded.Config) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, upgraded.Config))
+	}
+}
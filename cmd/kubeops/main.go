@@ -16,6 +16,7 @@ import (
 	"github.com/kubeapps/kubeapps/pkg/agent"
 	"github.com/kubeapps/kubeapps/pkg/auth"
 	backendHandlers "github.com/kubeapps/kubeapps/pkg/http-handler"
+	"github.com/kubeapps/kubeapps/pkg/redact"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/urfave/negroni"
@@ -29,6 +30,11 @@ var (
 	userAgentComment string
 	listLimit        int
 	timeout          int64
+
+	postRenderersConfigMap     string
+	policyConfigMap            string
+	namespaceTemplateConfigMap string
+	redactValuePatterns        []string
 )
 
 func init() {
@@ -39,6 +45,10 @@ func init() {
 	pflag.StringVar(&userAgentComment, "user-agent-comment", "", "UserAgent comment used during outbound requests")
 	// Default timeout from https://github.com/helm/helm/blob/b0b0accdfc84e154b3d48ec334cd5b4f9b345667/cmd/helm/install.go#L216
 	pflag.Int64Var(&timeout, "timeout", 300, "Timeout to perform release operations (install, upgrade, rollback, delete)")
+	pflag.StringVar(&postRenderersConfigMap, "post-renderers-configmap", "", "Name of the ConfigMaps, in release or app repository namespaces, configuring the post-renderers run on install and upgrade")
+	pflag.StringVar(&policyConfigMap, "policy-configmap", "", "Name of the ConfigMap, in the kubeapps namespace, defining the policy checks run on rendered manifests before install and upgrade")
+	pflag.StringVar(&namespaceTemplateConfigMap, "namespace-template-configmap", "", "Name of the ConfigMap, in the kubeapps namespace, defining the labels, annotations and default resources of the namespaces created on install")
+	pflag.StringSliceVar(&redactValuePatterns, "redact-value-patterns", redact.DefaultKeyPatterns, "Patterns of the keys whose values are masked in the releases returned, unless revealed by a user who can get secrets. The values marked as writeOnly or with the password format in the chart values schema are always masked")
 }
 
 func main() {
@@ -50,10 +60,21 @@ func main() {
 		log.Fatal("POD_NAMESPACE should be defined")
 	}
 
+	valuesRedactor, err := redact.NewRedactor(redactValuePatterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	options := handler.Options{
 		ListLimit:         listLimit,
 		Timeout:           timeout,
 		KubeappsNamespace: kubeappsNamespace,
+
+		PostRenderersConfigMap:     postRenderersConfigMap,
+		PolicyConfigMap:            policyConfigMap,
+		NamespaceTemplateConfigMap: namespaceTemplateConfigMap,
+		AssetsvcURL:                assetsvcURL,
+		ValuesRedactor:             valuesRedactor,
 	}
 
 	storageForDriver := agent.StorageForSecrets
@@ -83,8 +104,18 @@ func main() {
 	addRoute("PUT", "/namespaces/{namespace}/releases/{releaseName}", handler.OperateRelease)
 	addRoute("DELETE", "/namespaces/{namespace}/releases/{releaseName}", handler.DeleteRelease)
 
+	// The v2 API returns Helm 3 releases rather than converting them to Helm 2
+	// types, the v1 API is kept for the dashboard.
+	addRouteV2 := handler.AddRouteWith(r.PathPrefix("/v2").Subrouter(), withHandlerConfig)
+	addRouteV2("GET", "/releases", handler.ListAllReleases)
+	addRouteV2("GET", "/namespaces/{namespace}/releases", handler.ListReleases)
+	addRouteV2("POST", "/namespaces/{namespace}/releases", handler.CreateRelease)
+	addRouteV2("GET", "/namespaces/{namespace}/releases/{releaseName}", handler.GetReleaseV2)
+	addRouteV2("PUT", "/namespaces/{namespace}/releases/{releaseName}", handler.OperateReleaseV2)
+	addRouteV2("DELETE", "/namespaces/{namespace}/releases/{releaseName}", handler.DeleteRelease)
+
 	// Backend routes unrelated to kubeops functionality.
-	err := backendHandlers.SetupDefaultRoutes(r.PathPrefix("/backend/v1").Subrouter())
+	err = backendHandlers.SetupDefaultRoutes(r.PathPrefix("/backend/v1").Subrouter())
 	if err != nil {
 		log.Fatalf("Unable to setup backend routes: %+v", err)
 	}
@@ -105,6 +136,12 @@ func main() {
 	assetsvcRouter.Methods("GET").Path("/v1/ns/{namespace}/assets/{repo}/{id}/logo").Handler(negroni.New(
 		negroni.Wrap(http.StripPrefix(assetsvcPrefix, assetsvcProxy)),
 	))
+	// The Helm repositories also accept the token as the basic auth password
+	// for the Helm CLI
+	assetsvcRouter.Methods("GET").PathPrefix("/v1/ns/{namespace}/helm/").Handler(negroni.New(
+		auth.HelmRepoAuthGate(kubeappsNamespace),
+		negroni.Wrap(http.StripPrefix(assetsvcPrefix, assetsvcProxy)),
+	))
 	assetsvcRouter.PathPrefix("/v1/ns/{namespace}/").Handler(negroni.New(
 		authGate,
 		negroni.Wrap(http.StripPrefix(assetsvcPrefix, assetsvcProxy)),
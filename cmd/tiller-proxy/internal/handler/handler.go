@@ -20,17 +20,25 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/ghodss/yaml"
 	"github.com/kubeapps/common/response"
 	"github.com/kubeapps/kubeapps/pkg/auth"
 	chartUtils "github.com/kubeapps/kubeapps/pkg/chart"
 	"github.com/kubeapps/kubeapps/pkg/handlerutil"
 	proxy "github.com/kubeapps/kubeapps/pkg/proxy"
+	"github.com/kubeapps/kubeapps/pkg/redact"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/proto/hapi/release"
 )
 
 const requireV1Support = true
 
+// valuesSchemaFile is the file of a chart with the JSON schema of its values
+const valuesSchemaFile = "values.schema.json"
+
 func returnForbiddenActions(forbiddenActions []auth.Action, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	body, err := json.Marshal(forbiddenActions)
@@ -47,6 +55,73 @@ type TillerProxy struct {
 	ListLimit         int
 	ChartClient       chartUtils.Resolver
 	ProxyClient       proxy.TillerClient
+	// ValuesRedactor masks the secret values of the releases returned. The
+	// values are returned as they are if nil.
+	ValuesRedactor *redact.Redactor
+}
+
+// redactRelease returns a copy of the release with the secret values of its
+// config and the Secrets of its manifest and hooks masked
+func (h *TillerProxy) redactRelease(rel *release.Release) (*release.Release, error) {
+	if h.ValuesRedactor == nil {
+		return rel, nil
+	}
+	redacted := *rel
+	if rel.Config != nil {
+		var schema []byte
+		for _, f := range rel.GetChart().GetFiles() {
+			if f.GetTypeUrl() == valuesSchemaFile {
+				schema = f.GetValue()
+			}
+		}
+		raw, err := h.ValuesRedactor.RawValues(rel.Config.Raw, schema)
+		if err != nil {
+			return nil, err
+		}
+		redacted.Config = &chart.Config{Raw: raw}
+	}
+	manifest, err := redact.Manifest(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	redacted.Manifest = manifest
+	redacted.Hooks = make([]*release.Hook, len(rel.Hooks))
+	for i, hook := range rel.Hooks {
+		redactedHook := *hook
+		if redactedHook.Manifest, err = redact.Manifest(hook.Manifest); err != nil {
+			return nil, err
+		}
+		redacted.Hooks[i] = &redactedHook
+	}
+	return &redacted, nil
+}
+
+// restoreMaskedValues replaces the masked values sent to upgrade a release,
+// like the redacted values the dashboard uses as the current ones, with the
+// values of the release
+func (h *TillerProxy) restoreMaskedValues(name, namespace, values string) (string, error) {
+	if h.ValuesRedactor == nil || !strings.Contains(values, redact.Mask) {
+		return values, nil
+	}
+	previous, err := h.ProxyClient.GetRelease(name, namespace)
+	if err != nil {
+		return "", err
+	}
+	var previousValues map[string]interface{}
+	if err := yaml.Unmarshal([]byte(previous.GetConfig().GetRaw()), &previousValues); err != nil {
+		return "", err
+	}
+	return redact.RestoreRawValues(values, previousValues)
+}
+
+// writeRedactedRelease writes the release with its secret values masked
+func (h *TillerProxy) writeRedactedRelease(w http.ResponseWriter, rel *release.Release) {
+	redacted, err := h.redactRelease(rel)
+	if err != nil {
+		response.NewErrorResponse(handlerutil.ErrorCode(err), err.Error()).Write(w)
+		return
+	}
+	response.NewDataResponse(*redacted).Write(w)
 }
 
 func (h *TillerProxy) logStatus(name string) {
@@ -83,7 +158,7 @@ func (h *TillerProxy) CreateRelease(w http.ResponseWriter, req *http.Request, pa
 	}
 	log.Printf("Installed release %s", rel.Name)
 	h.logStatus(rel.Name)
-	response.NewDataResponse(*rel).Write(w)
+	h.writeRedactedRelease(w, rel)
 }
 
 // OperateRelease decides which method to call depending in the "action" query param
@@ -132,7 +207,7 @@ func (h *TillerProxy) RollbackRelease(w http.ResponseWriter, req *http.Request,
 	}
 	log.Printf("Rollback release for %s to %d", rel.Name, revisionInt)
 	h.logStatus(rel.Name)
-	response.NewDataResponse(*rel).Write(w)
+	h.writeRedactedRelease(w, rel)
 }
 
 // UpgradeRelease upgrades a release in the namespace given as Param
@@ -144,7 +219,12 @@ func (h *TillerProxy) UpgradeRelease(w http.ResponseWriter, req *http.Request, p
 		response.NewErrorResponse(handlerutil.ErrorCode(err), err.Error()).Write(w)
 		return
 	}
-	manifest, err := h.ProxyClient.ResolveManifest(params["namespace"], chartDetails.Values, ch)
+	values, err := h.restoreMaskedValues(params["releaseName"], params["namespace"], chartDetails.Values)
+	if err != nil {
+		response.NewErrorResponse(handlerutil.ErrorCode(err), err.Error()).Write(w)
+		return
+	}
+	manifest, err := h.ProxyClient.ResolveManifest(params["namespace"], values, ch)
 	if err != nil {
 		response.NewErrorResponse(handlerutil.ErrorCode(err), err.Error()).Write(w)
 		return
@@ -153,14 +233,14 @@ func (h *TillerProxy) UpgradeRelease(w http.ResponseWriter, req *http.Request, p
 		return
 	}
 
-	rel, err := h.ProxyClient.UpdateRelease(params["releaseName"], params["namespace"], chartDetails.Values, ch)
+	rel, err := h.ProxyClient.UpdateRelease(params["releaseName"], params["namespace"], values, ch)
 	if err != nil {
 		response.NewErrorResponse(handlerutil.ErrorCodeWithDefault(err, http.StatusUnprocessableEntity), err.Error()).Write(w)
 		return
 	}
 	log.Printf("Upgraded release %s", rel.Name)
 	h.logStatus(rel.Name)
-	response.NewDataResponse(*rel).Write(w)
+	h.writeRedactedRelease(w, rel)
 }
 
 // ListAllReleases list all releases that Tiller stores
@@ -215,7 +295,27 @@ func (h *TillerProxy) GetRelease(w http.ResponseWriter, req *http.Request, param
 		return
 	}
 
-	response.NewDataResponse(*rel).Write(w)
+	// The secret values are only returned when explicitly revealed, by the
+	// users who can get the secrets of the namespace
+	if handlerutil.QueryParamIsTruthy(redact.RevealParam, req) {
+		userAuth, err := h.CheckerForRequest(req)
+		if err != nil {
+			response.NewErrorResponse(handlerutil.ErrorCode(err), err.Error()).Write(w)
+			return
+		}
+		forbiddenActions, err := redact.ForbiddenToReveal(userAuth, params["namespace"])
+		if err != nil {
+			response.NewErrorResponse(handlerutil.ErrorCode(err), err.Error()).Write(w)
+			return
+		}
+		if len(forbiddenActions) > 0 {
+			returnForbiddenActions(forbiddenActions, w)
+			return
+		}
+		response.NewDataResponse(*rel).Write(w)
+		return
+	}
+	h.writeRedactedRelease(w, rel)
 }
 
 // DeleteRelease removes a release from a namespace
@@ -17,12 +17,14 @@ limitations under the License.
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/any"
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/helm/pkg/proto/hapi/chart"
 	"k8s.io/helm/pkg/proto/hapi/release"
@@ -31,6 +33,7 @@ import (
 	authFake "github.com/kubeapps/kubeapps/pkg/auth/fake"
 	chartFake "github.com/kubeapps/kubeapps/pkg/chart/fake"
 	proxyFake "github.com/kubeapps/kubeapps/pkg/proxy/fake"
+	"github.com/kubeapps/kubeapps/pkg/redact"
 )
 
 func TestActions(t *testing.T) {
@@ -453,3 +456,106 @@ func TestActions(t *testing.T) {
 		})
 	}
 }
+
+func TestGetReleaseRedaction(t *testing.T) {
+	rel := release.Release{
+		Name:      "foobar",
+		Namespace: "default",
+		Config:    &chart.Config{Raw: "dsn: postgres://user:pass@db\npassword: foo\nreplicas: 1\n"},
+		Manifest:  "---\napiVersion: v1\nkind: Secret\nstringData:\n  password: foo\n",
+		Chart: &chart.Chart{
+			Files: []*any.Any{{TypeUrl: "values.schema.json", Value: []byte(`{"properties": {"dsn": {"writeOnly": true}}}`)}},
+		},
+	}
+	redactor, err := redact.NewRedactor(redact.DefaultKeyPatterns)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	tests := []struct {
+		name               string
+		requestQuery       string
+		namespaceForbidden bool
+		statusCode         int
+		config             string
+
+		manifest           string
+	}{
+		{"masks the secret values", "", false, http.StatusOK, "dsn: '********'\npassword: '********'\nreplicas: 1\n", "---\napiVersion: v1\nkind: Secret\nstringData:\n  password: '********'\n"},
+		{"reveals the values", "?reveal=true", false, http.StatusOK, rel.Config.Raw, rel.Manifest},
+		{"reveal requires getting secrets", "?reveal=true", true, http.StatusForbidden, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := &proxyFake.FakeProxy{Releases: []release.Release{rel}}
+			handler := TillerProxy{
+				CheckerForRequest: func(req *http.Request) (auth.Checker, error) {
+					return &authFake.FakeAuth{NamespaceForbidden: tt.namespaceForbidden}, nil
+				},
+				ChartClient:    &chartFake.FakeChart{},
+				ProxyClient:    proxy,
+				ValuesRedactor: redactor,
+			}
+			req := httptest.NewRequest("GET", fmt.Sprintf("http://foo.bar%s", tt.requestQuery), nil)
+			response := httptest.NewRecorder()
+
+			handler.GetRelease(response, req, map[string]string{"namespace": "default", "releaseName": "foobar"})
+
+			if got, want := response.Code, tt.statusCode; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data release.Release `json:"data"`
+			}
+			if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := body.Data.Config.Raw, tt.config; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got, want := body.Data.Manifest, tt.manifest; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			// The stored release is left untouched
+			if got, want := proxy.Releases[0].Config.Raw, rel.Config.Raw; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestUpgradeReleaseRestoresMaskedValues(t *testing.T) {
+	rel := release.Release{
+		Name:      "foobar",
+		Namespace: "default",
+		Config:    &chart.Config{Raw: "password: foo\nreplicas: 1\n"},
+	}
+	redactor, err := redact.NewRedactor(redact.DefaultKeyPatterns)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	proxy := &proxyFake.FakeProxy{Releases: []release.Release{rel}}
+	handler := TillerProxy{
+		CheckerForRequest: func(req *http.Request) (auth.Checker, error) {
+			return &authFake.FakeAuth{}, nil
+		},
+		ChartClient:    &chartFake.FakeChart{},
+		ProxyClient:    proxy,
+		ValuesRedactor: redactor,
+	}
+	// The dashboard sends the redacted values back, with the masks
+	requestBody := `{"chartName": "foo", "releaseName": "foobar", "version": "1.0.0", "values": "password: '********'\nreplicas: 2\n"}`
+	req := httptest.NewRequest("PUT", "https://foo.bar/backend/v1/namespaces/default/releases/foobar", strings.NewReader(requestBody))
+	response := httptest.NewRecorder()
+
+	handler.UpgradeRelease(response, req, map[string]string{"namespace": "default", "releaseName": "foobar"})
+
+	if got, want := response.Code, http.StatusOK; got != want {
+		t.Fatalf("got: %d, want: %d", got, want)
+	}
+	if got, want := proxy.Releases[0].Config.Raw, "password: foo\nreplicas: 2\n"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
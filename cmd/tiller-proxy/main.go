@@ -36,6 +36,7 @@ import (
 	backendHandlers "github.com/kubeapps/kubeapps/pkg/http-handler"
 	"github.com/kubeapps/kubeapps/pkg/kube"
 	tillerProxy "github.com/kubeapps/kubeapps/pkg/proxy"
+	"github.com/kubeapps/kubeapps/pkg/redact"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/urfave/negroni"
@@ -63,7 +64,8 @@ var (
 	tlsCertDefault   = fmt.Sprintf("%s/tls.crt", os.Getenv("HELM_HOME"))
 	tlsKeyDefault    = fmt.Sprintf("%s/tls.key", os.Getenv("HELM_HOME"))
 
-	assetsvcURL string
+	assetsvcURL         string
+	redactValuePatterns []string
 )
 
 func init() {
@@ -79,6 +81,7 @@ func init() {
 	// Default timeout from https://github.com/helm/helm/blob/b0b0accdfc84e154b3d48ec334cd5b4f9b345667/cmd/helm/install.go#L216
 	pflag.Int64Var(&timeout, "timeout", 300, "Timeout to perform release operations (install, upgrade, rollback, delete)")
 	pflag.StringVar(&assetsvcURL, "assetsvc-url", "http://kubeapps-internal-assetsvc:8080", "URL to the internal assetsvc")
+	pflag.StringSliceVar(&redactValuePatterns, "redact-value-patterns", redact.DefaultKeyPatterns, "Patterns of the keys whose values are masked in the releases returned, unless revealed by a user who can get secrets. The values marked as writeOnly or with the password format in the chart values schema are always masked")
 }
 
 func main() {
@@ -147,12 +150,18 @@ func main() {
 	r.Handle("/live", health)
 	r.Handle("/ready", health)
 
+	valuesRedactor, err := redact.NewRedactor(redactValuePatterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// HTTP Handler
 	h := handler.TillerProxy{
 		CheckerForRequest: auth.AuthCheckerForRequest,
 		ListLimit:         listLimit,
 		ChartClient:       chartClient,
 		ProxyClient:       proxy,
+		ValuesRedactor:    valuesRedactor,
 	}
 
 	// Routes
@@ -183,6 +192,12 @@ func main() {
 	assetsvcRouter := r.PathPrefix(assetsvcPrefix).Subrouter()
 	// Logos don't require authentication so bypass that step
 	assetsvcRouter.Methods("GET").Path("/v1/ns/{ns}/assets/{repo}/{id}/logo").Handler(http.StripPrefix(assetsvcPrefix, assetsvcProxy))
+	// The Helm repositories also accept the token as the basic auth password
+	// for the Helm CLI
+	assetsvcRouter.Methods("GET").PathPrefix("/v1/ns/{namespace}/helm/").Handler(negroni.New(
+		auth.HelmRepoAuthGate(kubeappsNamespace),
+		negroni.Wrap(http.StripPrefix(assetsvcPrefix, assetsvcProxy)),
+	))
 	authGate := auth.AuthGate(kubeappsNamespace)
 	assetsvcRouter.PathPrefix("/v1/ns/{namespace}/").Handler(negroni.New(
 		authGate,
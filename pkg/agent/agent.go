@@ -12,6 +12,7 @@ import (
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
@@ -68,57 +69,76 @@ func ListReleases(actionConfig *action.Configuration, namespace string, listLimi
 	return appOverviews, nil
 }
 
-// CreateRelease creates a release.
-func CreateRelease(actionConfig *action.Configuration, name, namespace, valueString string, ch *chart.Chart, registrySecrets map[string]string) (*release.Release, error) {
+// CreateRelease creates a release, running the rendered manifests through the
+// given post-renderer (which may be nil).
+func CreateRelease(actionConfig *action.Configuration, name, namespace, valueString string, ch *chart.Chart, postRenderer postrender.PostRenderer) (*release.Release, error) {
 	// Check if the release already exists
 	_, err := GetRelease(actionConfig, name)
 	if err == nil {
 		return nil, fmt.Errorf("release %s already exists", name)
 	}
-	cmd := action.NewInstall(actionConfig)
-	cmd.ReleaseName = name
-	cmd.Namespace = namespace
-	cmd.PostRenderer, err = NewDockerSecretsPostRenderer(registrySecrets)
-	if err != nil {
-		return nil, err
-	}
 	values, err := getValues([]byte(valueString))
 	if err != nil {
 		return nil, err
 	}
+	if checkers := hooksCheckers(postRenderer); len(checkers) > 0 {
+		dryRun := action.NewInstall(actionConfig)
+		dryRun.ReleaseName = name
+		dryRun.Namespace = namespace
+		dryRun.DryRun = true
+		rendered, err := dryRun.Run(ch, values)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkHooks(checkers, rendered.Hooks); err != nil {
+			return nil, err
+		}
+	}
+	cmd := action.NewInstall(actionConfig)
+	cmd.ReleaseName = name
+	cmd.Namespace = namespace
+	cmd.PostRenderer = postRenderer
 	release, err := cmd.Run(ch, values)
 	if err != nil {
 		// Simulate the Atomic flag and delete the release if failed
 		errDelete := DeleteRelease(actionConfig, name, false)
 		if errDelete != nil && !strings.Contains(errDelete.Error(), "release: not found") {
-			return nil, fmt.Errorf("Release %q failed: %v. Unable to delete failed release: %v", name, err, errDelete)
+			return nil, fmt.Errorf("Release %q failed: %w. Unable to delete failed release: %v", name, err, errDelete)
 		}
-		return nil, fmt.Errorf("Release %q failed and has been uninstalled: %v", name, err)
+		return nil, fmt.Errorf("Release %q failed and has been uninstalled: %w", name, err)
 	}
 	return release, nil
 }
 
-// UpgradeRelease upgrades a release.
-func UpgradeRelease(actionConfig *action.Configuration, name, valuesYaml string, ch *chart.Chart, registrySecrets map[string]string) (*release.Release, error) {
+// UpgradeRelease upgrades a release, running the rendered manifests through the
+// given post-renderer (which may be nil).
+func UpgradeRelease(actionConfig *action.Configuration, name, valuesYaml string, ch *chart.Chart, postRenderer postrender.PostRenderer) (*release.Release, error) {
 	// Check if the release already exists:
 	_, err := GetRelease(actionConfig, name)
 	if err != nil {
 		return nil, err
 	}
 	log.Printf("Upgrading release %s", name)
-	cmd := action.NewUpgrade(actionConfig)
-
-	cmd.PostRenderer, err = NewDockerSecretsPostRenderer(registrySecrets)
-	if err != nil {
-		return nil, err
-	}
 	values, err := chartutil.ReadValues([]byte(valuesYaml))
 	if err != nil {
 		return nil, fmt.Errorf("Unable to upgrade the release because values could not be parsed: %v", err)
 	}
+	if checkers := hooksCheckers(postRenderer); len(checkers) > 0 {
+		dryRun := action.NewUpgrade(actionConfig)
+		dryRun.DryRun = true
+		rendered, err := dryRun.Run(name, ch, values)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to upgrade the release: %w", err)
+		}
+		if err := checkHooks(checkers, rendered.Hooks); err != nil {
+			return nil, err
+		}
+	}
+	cmd := action.NewUpgrade(actionConfig)
+	cmd.PostRenderer = postRenderer
 	res, err := cmd.Run(name, ch, values)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to upgrade the release: %v", err)
+		return nil, fmt.Errorf("Unable to upgrade the release: %w", err)
 	}
 	return res, nil
 }
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"errors"
 	"io/ioutil"
 	"sort"
 	"testing"
@@ -18,6 +19,7 @@ import (
 	chartv1 "k8s.io/helm/pkg/proto/hapi/chart"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/kubeapps/pkg/policy"
 	"github.com/kubeapps/kubeapps/pkg/proxy"
 )
 
@@ -708,3 +710,81 @@ func TestUpgradeRelease(t *testing.T) {
 		})
 	}
 }
+
+const privilegedHookTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+  name: "{{ .Release.Name }}-test"
+  annotations:
+    "helm.sh/hook": test
+spec:
+  containers:
+  - name: test
+    image: busybox
+    securityContext:
+      privileged: true
+`
+
+func TestReleaseHooksPolicy(t *testing.T) {
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "hooked", Version: "1.0.0"},
+		Templates: []*chart.File{
+			{Name: "templates/test.yaml", Data: []byte(privilegedHookTemplate)},
+		},
+	}
+	testCases := []struct {
+		description    string
+		policy         policy.Policy
+		expectErr      bool
+		expectWarnings int
+	}{
+		{
+			description: "it deploys a release whose hooks satisfy the policy",
+			policy:      policy.Policy{DisallowHostPath: true},
+		},
+		{
+			description: "it refuses a release with a hook which does not satisfy the policy",
+			policy:      policy.Policy{DisallowPrivileged: true},
+			expectErr:   true,
+		},
+		{
+			description:    "it deploys a release with a violating hook in warn-only mode",
+			policy:         policy.Policy{WarnOnly: true, DisallowPrivileged: true},
+			expectWarnings: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			for _, op := range []string{"create", "upgrade"} {
+				cfg := newActionConfigFixture(t)
+				enforcer := policy.NewEnforcer(&tc.policy)
+				postRenderer := PostRendererChain{enforcer}
+				var err error
+				version := 1
+				if op == "create" {
+					_, err = CreateRelease(cfg, "foo", "default", "", ch, postRenderer)
+				} else {
+					makeReleases(t, cfg, []releaseStub{{"foo", "default", 1, "1.0.0", release.StatusDeployed}})
+					_, err = UpgradeRelease(cfg, "foo", "", ch, postRenderer)
+					version = 2
+				}
+				if got, want := err != nil, tc.expectErr; got != want {
+					t.Fatalf("%s: got: %t, want: %t. err: %+v", op, got, want, err)
+				}
+				if tc.expectErr {
+					var violationsErr *policy.ViolationsError
+					if !errors.As(err, &violationsErr) {
+						t.Errorf("%s: got: %+v, want a violations error", op, err)
+					}
+					if _, getErr := cfg.Releases.Get("foo", version); getErr == nil {
+						t.Errorf("%s: the release should not have been stored", op)
+					}
+				}
+				if got, want := len(enforcer.Warnings()), tc.expectWarnings; got != want {
+					t.Errorf("%s: got: %d, want: %d", op, got, want)
+				}
+			}
+		})
+	}
+}
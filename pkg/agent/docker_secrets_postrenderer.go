@@ -2,13 +2,11 @@ package agent
 
 import (
 	"bytes"
-	"io"
 	"net/url"
 	"strings"
 
 	"github.com/docker/distribution/reference"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -52,40 +50,6 @@ func NewDockerSecretsPostRenderer(secrets map[string]string) (*DockerSecretsPost
 	return r, nil
 }
 
-func (r *DockerSecretsPostRenderer) processResourceList(resourceList []interface{}) {
-	for _, resourceItem := range resourceList {
-		resource, ok := resourceItem.(map[interface{}]interface{})
-		if !ok {
-			continue
-		}
-		kindValue, ok := resource["kind"]
-		if !ok {
-			log.Errorf("invalid resource: no kind. %+v", resource)
-			continue
-		}
-
-		kind, ok := kindValue.(string)
-		if !ok {
-			log.Errorf("invalid resource: non-string resource kind. %+v", resource)
-			continue
-		}
-		if items, ok := resource["items"]; ok {
-			if itemsSlice, ok := items.([]interface{}); ok {
-				r.processResourceList(itemsSlice)
-			} else {
-				log.Errorf("Items of list type did not contain a slice: %+v", resource)
-			}
-			continue
-		}
-
-		podSpec := getResourcePodSpec(kind, resource)
-		if podSpec == nil {
-			continue
-		}
-		r.updatePodSpecWithPullSecrets(podSpec)
-	}
-}
-
 // Run returns the rendered yaml including any additions of the post-renderer.
 // An error is only returned if the manifests cannot be parsed or re-rendered.
 func (r *DockerSecretsPostRenderer) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
@@ -93,38 +57,27 @@ func (r *DockerSecretsPostRenderer) Run(renderedManifests *bytes.Buffer) (modifi
 		return renderedManifests, nil
 	}
 
-	decoder := yaml.NewDecoder(renderedManifests)
-	var resourceList []interface{}
-	for {
-		var resource interface{}
-		err := decoder.Decode(&resource)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		resourceList = append(resourceList, resource)
+	resourceList, err := decodeResources(renderedManifests)
+	if err != nil {
+		return nil, err
 	}
 
 	// TODO(mnelson): If re-rendering the entire manifest creates issues, we
 	// could instead find the correct byte position and insert the image pull
 	// secret into the byte stream at the relevant points, but this will be
 	// more complex.
-	r.processResourceList(resourceList)
-
-	modifiedManifests = bytes.NewBuffer([]byte{})
-	encoder := yaml.NewEncoder(modifiedManifests)
-	defer encoder.Close()
-
-	for _, resource := range resourceList {
-		err = encoder.Encode(resource)
-		if err != nil {
-			return nil, err
+	err = visitResources(resourceList, func(kind string, resource map[interface{}]interface{}) error {
+		podSpec := getResourcePodSpec(kind, resource)
+		if podSpec != nil {
+			r.updatePodSpecWithPullSecrets(podSpec)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return modifiedManifests, nil
+	return encodeResources(resourceList)
 }
 
 // updatePodSpecWithPullSecrets updates the podSpec inline with the relevant pull secrets.
@@ -209,19 +162,30 @@ func (r *DockerSecretsPostRenderer) updatePodSpecWithPullSecrets(podSpec map[int
 // - A resource doc is a map with a "kind" key with a string value
 // - A pod resource doc has a "spec" key containing a map
 func getResourcePodSpec(kind string, resource map[interface{}]interface{}) map[interface{}]interface{} {
+	podTemplate := getResourcePodTemplate(kind, resource)
+	if podTemplate == nil {
+		return nil
+	}
+	return getMapForKeys([]string{"spec"}, podTemplate)
+}
+
+// getResourcePodTemplate checks the kind of the resource and extracts the map
+// which holds the pod metadata and spec accordingly. For a Pod, this is the
+// resource itself.
+func getResourcePodTemplate(kind string, resource map[interface{}]interface{}) map[interface{}]interface{} {
 	switch kind {
 	case "Pod":
-		return getMapForKeys([]string{"spec"}, resource)
+		return resource
 	case "DaemonSet", "Deployment", "Job", "ReplicaSet", "ReplicationController", "StatefulSet":
-		// These resources all include a spec.template.spec PodSpec.
+		// These resources all include a spec.template PodTemplateSpec.
 		// https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.18/#podtemplatespec-v1-core
-		return getMapForKeys([]string{"spec", "template", "spec"}, resource)
+		return getMapForKeys([]string{"spec", "template"}, resource)
 	case "PodTemplate":
-		return getMapForKeys([]string{"template", "spec"}, resource)
+		return getMapForKeys([]string{"template"}, resource)
 	case "CronJob":
 		// A CronJob spec contains a jobTemplate:
 		// https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.18/#cronjobspec-v1beta1-batch
-		return getMapForKeys([]string{"spec", "jobTemplate", "spec", "template", "spec"}, resource)
+		return getMapForKeys([]string{"spec", "jobTemplate", "spec", "template"}, resource)
 	}
 
 	return nil
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"bytes"
+)
+
+// LabelsPostRenderer is a helm post-renderer which sets common labels and
+// annotations on every resource, as well as on the pod templates of workloads
+// so that they are also present on the resulting pods.
+type LabelsPostRenderer struct {
+	labels      map[string]string
+	annotations map[string]string
+}
+
+// NewLabelsPostRenderer returns a post renderer configured with the specified
+// labels and annotations.
+func NewLabelsPostRenderer(labels, annotations map[string]string) (*LabelsPostRenderer, error) {
+	return &LabelsPostRenderer{
+		labels:      labels,
+		annotations: annotations,
+	}, nil
+}
+
+// Run returns the rendered yaml including any additions of the post-renderer.
+// An error is only returned if the manifests cannot be parsed or re-rendered.
+func (r *LabelsPostRenderer) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
+	if len(r.labels) == 0 && len(r.annotations) == 0 {
+		return renderedManifests, nil
+	}
+
+	resourceList, err := decodeResources(renderedManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	err = visitResources(resourceList, func(kind string, resource map[interface{}]interface{}) error {
+		r.updateMetadata(resource)
+		// The pod of a Pod resource is the resource itself, which is already updated.
+		if kind == "Pod" {
+			return nil
+		}
+		if podTemplate := getResourcePodTemplate(kind, resource); podTemplate != nil {
+			r.updateMetadata(podTemplate)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeResources(resourceList)
+}
+
+// updateMetadata sets the labels and annotations in the metadata of the given
+// object, overriding any existing values for the same keys.
+func (r *LabelsPostRenderer) updateMetadata(object map[interface{}]interface{}) {
+	metadata := ensureMapForKey("metadata", object)
+	if metadata == nil {
+		return
+	}
+	setStringValues("labels", metadata, r.labels)
+	setStringValues("annotations", metadata, r.annotations)
+}
+
+func setStringValues(key string, m map[interface{}]interface{}, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	current := ensureMapForKey(key, m)
+	if current == nil {
+		return
+	}
+	for k, v := range values {
+		current[k] = v
+	}
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLabelsPostRenderer(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       *bytes.Buffer
+		labels      map[string]string
+		annotations map[string]string
+		output      *bytes.Buffer
+		expectErr   bool
+	}{
+		{
+			name:   "it returns the input without parsing when no labels or annotations set",
+			input:  bytes.NewBuffer([]byte(`anything at : all`)),
+			output: bytes.NewBuffer([]byte(`anything at : all`)),
+		},
+		{
+			name:      "it returns an error if the input cannot be parsed as yaml",
+			input:     bytes.NewBuffer([]byte("v: [A,")),
+			labels:    map[string]string{"team": "a"},
+			expectErr: true,
+		},
+		{
+			name: "it sets labels and annotations on resources and pod templates",
+			input: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: foo
+    team: b
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+`)),
+			labels:      map[string]string{"team": "a"},
+			annotations: map[string]string{"owner": "platform"},
+			output: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    owner: platform
+  labels:
+    team: a
+  name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    owner: platform
+  labels:
+    app: foo
+    team: a
+  name: foo
+spec:
+  template:
+    metadata:
+      annotations:
+        owner: platform
+      labels:
+        team: a
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+`)),
+		},
+		{
+			name: "it sets labels on the items of a list",
+			input: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: foo
+`)),
+			labels: map[string]string{"team": "a"},
+			output: bytes.NewBuffer([]byte(`apiVersion: v1
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    labels:
+      team: a
+    name: foo
+kind: List
+`)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewLabelsPostRenderer(tc.labels, tc.annotations)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			renderedManifests, err := r.Run(tc.input)
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+
+			if got, want := renderedManifests.String(), tc.output.String(); got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// PatchPostRenderer is a helm post-renderer which applies a JSON patch
+// (RFC 6902) and/or a strategic merge patch to the rendered resources of the
+// selected kinds. When both are configured, the strategic merge patch is
+// applied first.
+type PatchPostRenderer struct {
+	kinds               map[string]bool
+	jsonPatch           jsonpatch.Patch
+	strategicMergePatch []byte
+}
+
+// NewPatchPostRenderer returns a post renderer configured with the specified
+// patches, which are applied to resources of the given kinds.
+func NewPatchPostRenderer(kinds []string, jsonPatch, strategicMergePatch []byte) (*PatchPostRenderer, error) {
+	r := &PatchPostRenderer{
+		kinds:               map[string]bool{},
+		strategicMergePatch: strategicMergePatch,
+	}
+	for _, k := range kinds {
+		r.kinds[k] = true
+	}
+	if len(jsonPatch) > 0 {
+		var err error
+		r.jsonPatch, err = jsonpatch.DecodePatch(jsonPatch)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode JSON patch: %v", err)
+		}
+	}
+	return r, nil
+}
+
+// Run returns the rendered yaml including any patches of the post-renderer.
+// An error is returned if the manifests cannot be parsed or re-rendered, or
+// if a patch cannot be applied to a selected resource.
+func (r *PatchPostRenderer) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
+	if len(r.kinds) == 0 {
+		return renderedManifests, nil
+	}
+
+	resourceList, err := decodeResources(renderedManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	err = visitResources(resourceList, func(kind string, resource map[interface{}]interface{}) error {
+		if !r.kinds[kind] {
+			return nil
+		}
+		return r.patchResource(kind, resource)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeResources(resourceList)
+}
+
+// patchResource applies the patches to the resource inline. The resource is
+// converted to JSON to apply the patches and back to the untyped yaml
+// representation afterwards.
+func (r *PatchPostRenderer) patchResource(kind string, resource map[interface{}]interface{}) error {
+	yamlDoc, err := yaml.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	doc, err := k8syaml.YAMLToJSON(yamlDoc)
+	if err != nil {
+		return err
+	}
+
+	if len(r.strategicMergePatch) > 0 {
+		doc, err = r.applyStrategicMergePatch(kind, resource, doc)
+		if err != nil {
+			return fmt.Errorf("unable to apply strategic merge patch to %s: %v", kind, err)
+		}
+	}
+	if r.jsonPatch != nil {
+		doc, err = r.jsonPatch.Apply(doc)
+		if err != nil {
+			return fmt.Errorf("unable to apply JSON patch to %s: %v", kind, err)
+		}
+	}
+
+	// JSON is valid yaml, so the patched doc can be parsed as such.
+	patched := map[interface{}]interface{}{}
+	err = yaml.Unmarshal(doc, &patched)
+	if err != nil {
+		return err
+	}
+	for k := range resource {
+		delete(resource, k)
+	}
+	for k, v := range patched {
+		resource[k] = v
+	}
+	return nil
+}
+
+// applyStrategicMergePatch uses the registered type of the resource to
+// determine the patch strategy. Kinds which are not known, such as custom
+// resources, fall back to a JSON merge patch (RFC 7386), as kubectl does.
+func (r *PatchPostRenderer) applyStrategicMergePatch(kind string, resource map[interface{}]interface{}, doc []byte) ([]byte, error) {
+	apiVersion, _ := resource["apiVersion"].(string)
+	gvk := schema.FromAPIVersionAndKind(apiVersion, kind)
+	dataStruct, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return jsonpatch.MergePatch(doc, r.strategicMergePatch)
+	}
+	return strategicpatch.StrategicMergePatch(doc, r.strategicMergePatch, dataStruct)
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPatchPostRenderer(t *testing.T) {
+	testCases := []struct {
+		name                string
+		input               *bytes.Buffer
+		kinds               []string
+		jsonPatch           string
+		strategicMergePatch string
+		output              *bytes.Buffer
+		expectErr           bool
+	}{
+		{
+			name:   "it returns the input without parsing when no kinds set",
+			input:  bytes.NewBuffer([]byte(`anything at : all`)),
+			output: bytes.NewBuffer([]byte(`anything at : all`)),
+		},
+		{
+			name: "it applies a JSON patch to the selected kinds only",
+			input: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`)),
+			kinds:     []string{"Service"},
+			jsonPatch: `[{"op": "add", "path": "/spec", "value": {"type": "ClusterIP"}}]`,
+			output: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+spec:
+  type: ClusterIP
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`)),
+		},
+		{
+			name: "it applies a strategic merge patch merging containers by name",
+			input: bytes.NewBuffer([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+      - image: sidecar
+        name: sidecar
+`)),
+			kinds:               []string{"Deployment"},
+			strategicMergePatch: `{"spec": {"template": {"spec": {"containers": [{"name": "nginx", "imagePullPolicy": "Always"}]}}}}`,
+			output: bytes.NewBuffer([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        imagePullPolicy: Always
+        name: nginx
+      - image: sidecar
+        name: sidecar
+`)),
+		},
+		{
+			name: "it falls back to a merge patch for unknown kinds",
+			input: bytes.NewBuffer([]byte(`apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: foo
+spec:
+  items:
+  - a
+`)),
+			kinds:               []string{"Foo"},
+			strategicMergePatch: `{"spec": {"items": ["b"]}}`,
+			output: bytes.NewBuffer([]byte(`apiVersion: example.com/v1
+kind: Foo
+metadata:
+  name: foo
+spec:
+  items:
+  - b
+`)),
+		},
+		{
+			name: "it returns an error if the JSON patch cannot be applied",
+			input: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`)),
+			kinds:     []string{"Service"},
+			jsonPatch: `[{"op": "replace", "path": "/spec/type", "value": "ClusterIP"}]`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewPatchPostRenderer(tc.kinds, []byte(tc.jsonPatch), []byte(tc.strategicMergePatch))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			renderedManifests, err := r.Run(tc.input)
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+
+			if got, want := renderedManifests.String(), tc.output.String(); got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
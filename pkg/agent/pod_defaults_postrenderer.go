@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"bytes"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodSpecDefaultsPostRenderer is a helm post-renderer which adds default
+// resource requests and limits, a node selector and tolerations to the pod
+// specs of the rendered resources. Values already set by the chart are kept.
+type PodSpecDefaultsPostRenderer struct {
+	// resources, nodeSelector and tolerations are stored in their untyped
+	// form so that they can be compared with and added to the yaml docs.
+	resources    map[interface{}]interface{}
+	nodeSelector map[string]string
+	tolerations  []interface{}
+}
+
+// NewPodSpecDefaultsPostRenderer returns a post renderer configured with the
+// specified defaults.
+func NewPodSpecDefaultsPostRenderer(resources *corev1.ResourceRequirements, nodeSelector map[string]string, tolerations []corev1.Toleration) (*PodSpecDefaultsPostRenderer, error) {
+	r := &PodSpecDefaultsPostRenderer{
+		nodeSelector: nodeSelector,
+	}
+	if resources != nil {
+		untyped, err := toUntyped(resources)
+		if err != nil {
+			return nil, err
+		}
+		r.resources, _ = untyped.(map[interface{}]interface{})
+	}
+	if len(tolerations) > 0 {
+		untyped, err := toUntyped(tolerations)
+		if err != nil {
+			return nil, err
+		}
+		r.tolerations, _ = untyped.([]interface{})
+	}
+	return r, nil
+}
+
+// Run returns the rendered yaml including any additions of the post-renderer.
+// An error is only returned if the manifests cannot be parsed or re-rendered.
+func (r *PodSpecDefaultsPostRenderer) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
+	if len(r.resources) == 0 && len(r.nodeSelector) == 0 && len(r.tolerations) == 0 {
+		return renderedManifests, nil
+	}
+
+	resourceList, err := decodeResources(renderedManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	err = visitResources(resourceList, func(kind string, resource map[interface{}]interface{}) error {
+		podSpec := getResourcePodSpec(kind, resource)
+		if podSpec != nil {
+			r.updatePodSpec(podSpec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeResources(resourceList)
+}
+
+// updatePodSpec updates the podSpec inline with the defaults which are not
+// already set. As with the image pull secrets, the pod spec is handled untyped
+// and invalid values are logged but left for the k8s API to respond to.
+func (r *PodSpecDefaultsPostRenderer) updatePodSpec(podSpec map[interface{}]interface{}) {
+	if len(r.resources) > 0 {
+		for _, key := range []string{"initContainers", "containers"} {
+			containers, ok := podSpec[key].([]interface{})
+			if !ok {
+				continue
+			}
+			for _, c := range containers {
+				container, ok := c.(map[interface{}]interface{})
+				if !ok {
+					log.Errorf("pod spec container is not a map: %+v", c)
+					continue
+				}
+				r.updateContainerResources(container)
+			}
+		}
+	}
+
+	if len(r.nodeSelector) > 0 {
+		nodeSelector := ensureMapForKey("nodeSelector", podSpec)
+		if nodeSelector != nil {
+			for k, v := range r.nodeSelector {
+				if _, ok := nodeSelector[k]; !ok {
+					nodeSelector[k] = v
+				}
+			}
+		}
+	}
+
+	if len(r.tolerations) > 0 {
+		var tolerations []interface{}
+		if existing, ok := podSpec["tolerations"]; ok && existing != nil {
+			tolerations, ok = existing.([]interface{})
+			if !ok {
+				log.Errorf("podSpec tolerations key is not a slice: %+v", podSpec)
+				return
+			}
+		}
+		for _, t := range r.tolerations {
+			if !containsValue(tolerations, t) {
+				tolerations = append(tolerations, t)
+			}
+		}
+		podSpec["tolerations"] = tolerations
+	}
+}
+
+// updateContainerResources adds the default requests and limits for each
+// resource name not set in the container. A default request is not added when
+// the container sets a limit for the same resource, since the request then
+// defaults to the limit.
+func (r *PodSpecDefaultsPostRenderer) updateContainerResources(container map[interface{}]interface{}) {
+	existing, _ := container["resources"].(map[interface{}]interface{})
+	// Record the limits set by the chart before any defaults are added.
+	chartLimits := map[interface{}]bool{}
+	if limits, ok := existing["limits"].(map[interface{}]interface{}); ok {
+		for name := range limits {
+			chartLimits[name] = true
+		}
+	}
+	for _, key := range []string{"limits", "requests"} {
+		defaults, _ := r.resources[key].(map[interface{}]interface{})
+		current, _ := existing[key].(map[interface{}]interface{})
+		for name, quantity := range defaults {
+			if _, ok := current[name]; ok {
+				continue
+			}
+			if key == "requests" && chartLimits[name] {
+				continue
+			}
+			if current == nil {
+				resources := ensureMapForKey("resources", container)
+				if resources == nil {
+					return
+				}
+				current = ensureMapForKey(key, resources)
+				if current == nil {
+					break
+				}
+			}
+			current[name] = quantity
+		}
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
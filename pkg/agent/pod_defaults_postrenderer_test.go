@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPodSpecDefaultsPostRenderer(t *testing.T) {
+	defaultResources := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+	defaultTolerations := []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "apps", Effect: corev1.TaintEffectNoSchedule},
+	}
+
+	testCases := []struct {
+		name         string
+		input        *bytes.Buffer
+		resources    *corev1.ResourceRequirements
+		nodeSelector map[string]string
+		tolerations  []corev1.Toleration
+		output       *bytes.Buffer
+		expectErr    bool
+	}{
+		{
+			name:   "it returns the input without parsing when no defaults set",
+			input:  bytes.NewBuffer([]byte(`anything at : all`)),
+			output: bytes.NewBuffer([]byte(`anything at : all`)),
+		},
+		{
+			name:         "it returns an error if the input cannot be parsed as yaml",
+			input:        bytes.NewBuffer([]byte("v: [A,")),
+			nodeSelector: map[string]string{"pool": "apps"},
+			expectErr:    true,
+		},
+		{
+			name: "it adds the defaults to a pod spec",
+			input: bytes.NewBuffer([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+`)),
+			resources:    defaultResources,
+			nodeSelector: map[string]string{"pool": "apps"},
+			tolerations:  defaultTolerations,
+			output: bytes.NewBuffer([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: nginx
+        resources:
+          limits:
+            memory: 256Mi
+          requests:
+            cpu: 100m
+            memory: 128Mi
+      nodeSelector:
+        pool: apps
+      tolerations:
+      - effect: NoSchedule
+        key: dedicated
+        operator: Equal
+        value: apps
+`)),
+		},
+		{
+			name: "it keeps the values set by the chart",
+			input: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: foo
+spec:
+  containers:
+  - image: nginx
+    name: nginx
+    resources:
+      limits:
+        cpu: 50m
+        memory: 64Mi
+  nodeSelector:
+    pool: special
+  tolerations:
+  - effect: NoSchedule
+    key: dedicated
+    operator: Equal
+    value: apps
+`)),
+			resources:    defaultResources,
+			nodeSelector: map[string]string{"pool": "apps"},
+			tolerations:  defaultTolerations,
+			output: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: foo
+spec:
+  containers:
+  - image: nginx
+    name: nginx
+    resources:
+      limits:
+        cpu: 50m
+        memory: 64Mi
+  nodeSelector:
+    pool: special
+  tolerations:
+  - effect: NoSchedule
+    key: dedicated
+    operator: Equal
+    value: apps
+`)),
+		},
+		{
+			name: "it ignores resources without a pod spec",
+			input: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`)),
+			nodeSelector: map[string]string{"pool": "apps"},
+			output: bytes.NewBuffer([]byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewPodSpecDefaultsPostRenderer(tc.resources, tc.nodeSelector, tc.tolerations)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			renderedManifests, err := r.Run(tc.input)
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+
+			if got, want := renderedManifests.String(), tc.output.String(); got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/postrender"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// Types of the post-renderers which can be configured in a chain.
+const (
+	PostRendererImagePullSecrets = "imagePullSecrets"
+	PostRendererLabels           = "labels"
+	PostRendererPodSpecDefaults  = "podSpecDefaults"
+	PostRendererPatch            = "patch"
+)
+
+// PostRendererConfig configures a single post-renderer within a chain. Only
+// the fields relevant to the given Type are used.
+type PostRendererConfig struct {
+	Type string `json:"type"`
+
+	// Labels and Annotations are set on every resource (and pod template)
+	// by the labels post-renderer.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Resources, NodeSelector and Tolerations are added to every pod spec
+	// by the podSpecDefaults post-renderer, unless already set.
+	Resources    *corev1.ResourceRequirements `json:"resources,omitempty"`
+	NodeSelector map[string]string            `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration          `json:"tolerations,omitempty"`
+
+	// Kinds selects the resources to which the patch post-renderer applies
+	// the JSONPatch and/or StrategicMergePatch.
+	Kinds               []string        `json:"kinds,omitempty"`
+	JSONPatch           json.RawMessage `json:"jsonPatch,omitempty"`
+	StrategicMergePatch json.RawMessage `json:"strategicMergePatch,omitempty"`
+}
+
+// ParsePostRendererConfig parses a YAML (or JSON) list of post-renderer configs,
+// as stored in a ConfigMap.
+func ParsePostRendererConfig(data []byte) ([]PostRendererConfig, error) {
+	configs := []PostRendererConfig{}
+	err := k8syaml.Unmarshal(data, &configs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse post-renderer config: %v", err)
+	}
+	for i, c := range configs {
+		switch c.Type {
+		case PostRendererImagePullSecrets, PostRendererLabels, PostRendererPodSpecDefaults:
+		case PostRendererPatch:
+			if len(c.Kinds) == 0 {
+				return nil, fmt.Errorf("post-renderer %d: a patch requires at least one kind", i)
+			}
+			if len(c.JSONPatch) == 0 && len(c.StrategicMergePatch) == 0 {
+				return nil, fmt.Errorf("post-renderer %d: a patch requires a jsonPatch or strategicMergePatch", i)
+			}
+		default:
+			return nil, fmt.Errorf("post-renderer %d: unknown type %q", i, c.Type)
+		}
+	}
+	return configs, nil
+}
+
+// PostRendererChain is a helm post-renderer which runs a sequence of
+// post-renderers, passing the output of each as the input of the next.
+type PostRendererChain []postrender.PostRenderer
+
+// Run returns the rendered manifests after running each post-renderer in turn.
+func (c PostRendererChain) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
+	modifiedManifests = renderedManifests
+	for _, r := range c {
+		modifiedManifests, err = r.Run(modifiedManifests)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return modifiedManifests, nil
+}
+
+// NewPostRendererChain returns a chain of post-renderers for the given configs.
+// The image pull secrets post-renderer is always included so that the registry
+// secrets of the app repository are used. It runs first unless its position is
+// explicitly configured.
+//
+// Helm only post-renders the manifest of a release, never its hooks, so the
+// labels, pod defaults, patches and image pull secrets are not applied to the
+// resources created by hooks (such as test pods or migration jobs).
+func NewPostRendererChain(configs []PostRendererConfig, registrySecrets map[string]string) (PostRendererChain, error) {
+	includesPullSecrets := false
+	for _, c := range configs {
+		if c.Type == PostRendererImagePullSecrets {
+			includesPullSecrets = true
+			break
+		}
+	}
+	if !includesPullSecrets {
+		configs = append([]PostRendererConfig{{Type: PostRendererImagePullSecrets}}, configs...)
+	}
+
+	chain := PostRendererChain{}
+	for _, c := range configs {
+		var r postrender.PostRenderer
+		var err error
+		switch c.Type {
+		case PostRendererImagePullSecrets:
+			r, err = NewDockerSecretsPostRenderer(registrySecrets)
+		case PostRendererLabels:
+			r, err = NewLabelsPostRenderer(c.Labels, c.Annotations)
+		case PostRendererPodSpecDefaults:
+			r, err = NewPodSpecDefaultsPostRenderer(c.Resources, c.NodeSelector, c.Tolerations)
+		case PostRendererPatch:
+			r, err = NewPatchPostRenderer(c.Kinds, c.JSONPatch, c.StrategicMergePatch)
+		default:
+			err = fmt.Errorf("unknown post-renderer type %q", c.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, r)
+	}
+	return chain, nil
+}
+
+// HooksChecker is implemented by the post-renderers which also check the
+// hooks of a release. Helm only runs the post-renderers on the manifest, so
+// the hooks are rendered with a dry run and checked before the release is
+// installed or upgraded.
+type HooksChecker interface {
+	CheckHooks(hooks []*release.Hook) error
+}
+
+// hooksCheckers returns the post-renderers, of a chain or on their own, which
+// check hooks.
+func hooksCheckers(postRenderer postrender.PostRenderer) []HooksChecker {
+	checkers := []HooksChecker{}
+	switch r := postRenderer.(type) {
+	case PostRendererChain:
+		for _, p := range r {
+			checkers = append(checkers, hooksCheckers(p)...)
+		}
+	case HooksChecker:
+		checkers = append(checkers, r)
+	}
+	return checkers
+}
+
+func checkHooks(checkers []HooksChecker, hooks []*release.Hook) error {
+	for _, c := range checkers {
+		if err := c.CheckHooks(hooks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeResources parses each of the yaml docs in the rendered manifests.
+func decodeResources(renderedManifests *bytes.Buffer) ([]interface{}, error) {
+	decoder := yaml.NewDecoder(renderedManifests)
+	var resourceList []interface{}
+	for {
+		var resource interface{}
+		err := decoder.Decode(&resource)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		resourceList = append(resourceList, resource)
+	}
+	return resourceList, nil
+}
+
+// encodeResources re-renders the resources as a multi-doc yaml.
+func encodeResources(resourceList []interface{}) (*bytes.Buffer, error) {
+	modifiedManifests := bytes.NewBuffer([]byte{})
+	encoder := yaml.NewEncoder(modifiedManifests)
+	defer encoder.Close()
+
+	for _, resource := range resourceList {
+		err := encoder.Encode(resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return modifiedManifests, nil
+}
+
+// visitResources calls visit with each resource doc and its kind, including
+// the items of list resources. Invalid resource docs are logged and skipped,
+// leaving the k8s API to respond to them.
+func visitResources(resourceList []interface{}, visit func(kind string, resource map[interface{}]interface{}) error) error {
+	for _, resourceItem := range resourceList {
+		resource, ok := resourceItem.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		kindValue, ok := resource["kind"]
+		if !ok {
+			log.Errorf("invalid resource: no kind. %+v", resource)
+			continue
+		}
+
+		kind, ok := kindValue.(string)
+		if !ok {
+			log.Errorf("invalid resource: non-string resource kind. %+v", resource)
+			continue
+		}
+		if items, ok := resource["items"]; ok {
+			if itemsSlice, ok := items.([]interface{}); ok {
+				err := visitResources(itemsSlice, visit)
+				if err != nil {
+					return err
+				}
+			} else {
+				log.Errorf("Items of list type did not contain a slice: %+v", resource)
+			}
+			continue
+		}
+
+		err := visit(kind, resource)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureMapForKey returns the map for the given key, creating it if it is
+// not yet present. Nil is returned if the key holds a value which is not a map.
+func ensureMapForKey(key string, m map[interface{}]interface{}) map[interface{}]interface{} {
+	value, ok := m[key]
+	if !ok || value == nil {
+		newMap := map[interface{}]interface{}{}
+		m[key] = newMap
+		return newMap
+	}
+	current, ok := value.(map[interface{}]interface{})
+	if !ok {
+		log.Errorf("invalid resource: non-map %q, in %+v", key, m)
+		return nil
+	}
+	return current
+}
+
+// toUntyped converts a typed value to the untyped representation used when
+// handling the yaml docs, via its JSON encoding.
+func toUntyped(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var untyped interface{}
+	err = yaml.Unmarshal(data, &untyped)
+	if err != nil {
+		return nil, err
+	}
+	return untyped, nil
+}
@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePostRendererConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          string
+		expectedTypes []string
+		expectErr     bool
+	}{
+		{
+			name: "it parses a chain of post-renderers",
+			data: `
+- type: labels
+  labels:
+    team: a
+- type: imagePullSecrets
+- type: podSpecDefaults
+  nodeSelector:
+    pool: apps
+  resources:
+    requests:
+      cpu: 100m
+- type: patch
+  kinds: [Deployment]
+  jsonPatch:
+    - op: add
+      path: /spec/paused
+      value: false
+`,
+			expectedTypes: []string{"labels", "imagePullSecrets", "podSpecDefaults", "patch"},
+		},
+		{
+			name:          "it parses an empty config",
+			data:          "",
+			expectedTypes: []string{},
+		},
+		{
+			name:      "it returns an error for an unknown type",
+			data:      "- type: unknown",
+			expectErr: true,
+		},
+		{
+			name:      "it returns an error for a patch without kinds",
+			data:      "- type: patch\n  strategicMergePatch: {}",
+			expectErr: true,
+		},
+		{
+			name:      "it returns an error for a patch without a patch",
+			data:      "- type: patch\n  kinds: [Deployment]",
+			expectErr: true,
+		},
+		{
+			name:      "it returns an error for invalid yaml",
+			data:      "v: [A,",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configs, err := ParsePostRendererConfig([]byte(tc.data))
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			types := []string{}
+			for _, c := range configs {
+				types = append(types, c.Type)
+			}
+			if got, want := types, tc.expectedTypes; !cmp.Equal(got, want) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestNewPostRendererChain(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configs       []PostRendererConfig
+		expectedTypes []string
+	}{
+		{
+			name:          "it includes the image pull secrets post-renderer by default",
+			configs:       nil,
+			expectedTypes: []string{"*agent.DockerSecretsPostRenderer"},
+		},
+		{
+			name: "it runs the image pull secrets post-renderer first when not configured",
+			configs: []PostRendererConfig{
+				{Type: PostRendererLabels},
+			},
+			expectedTypes: []string{"*agent.DockerSecretsPostRenderer", "*agent.LabelsPostRenderer"},
+		},
+		{
+			name: "it respects the configured position of the image pull secrets post-renderer",
+			configs: []PostRendererConfig{
+				{Type: PostRendererLabels},
+				{Type: PostRendererPodSpecDefaults},
+				{Type: PostRendererImagePullSecrets},
+			},
+			expectedTypes: []string{"*agent.LabelsPostRenderer", "*agent.PodSpecDefaultsPostRenderer", "*agent.DockerSecretsPostRenderer"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain, err := NewPostRendererChain(tc.configs, nil)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			types := []string{}
+			for _, r := range chain {
+				types = append(types, fmt.Sprintf("%T", r))
+			}
+			if got, want := types, tc.expectedTypes; !cmp.Equal(got, want) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+type appendPostRenderer string
+
+func (a appendPostRenderer) Run(b *bytes.Buffer) (*bytes.Buffer, error) {
+	return bytes.NewBufferString(b.String() + string(a)), nil
+}
+
+type failingPostRenderer struct{}
+
+func (failingPostRenderer) Run(b *bytes.Buffer) (*bytes.Buffer, error) {
+	return nil, errors.New("boom")
+}
+
+func TestPostRendererChainRun(t *testing.T) {
+	testCases := []struct {
+		name      string
+		chain     PostRendererChain
+		output    string
+		expectErr bool
+	}{
+		{
+			name:   "it returns the input for an empty chain",
+			chain:  PostRendererChain{},
+			output: "input",
+		},
+		{
+			name:   "it passes the output of each post-renderer to the next",
+			chain:  PostRendererChain{appendPostRenderer("-a"), appendPostRenderer("-b")},
+			output: "input-a-b",
+		},
+		{
+			name:      "it returns the error of a failing post-renderer",
+			chain:     PostRendererChain{appendPostRenderer("-a"), failingPostRenderer{}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := tc.chain.Run(bytes.NewBufferString("input"))
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if got, want := output.String(), tc.output; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
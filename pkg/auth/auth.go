@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/kubeapps/kubeapps/pkg/tokencache"
 	yamlUtils "github.com/kubeapps/kubeapps/pkg/yaml"
 	authorizationapi "k8s.io/api/authorization/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
@@ -40,6 +41,7 @@ type resource struct {
 type k8sAuthInterface interface {
 	GetResourceList(groupVersion string) (*metav1.APIResourceList, error)
 	CanI(verb, group, resource, namespace string) (bool, error)
+	GetRules(namespace string) (*authorizationapi.SubjectRulesReviewStatus, error)
 }
 
 type k8sAuth struct {
@@ -69,9 +71,24 @@ func (u k8sAuth) CanI(verb, group, resource, namespace string) (bool, error) {
 	return res.Status.Allowed, nil
 }
 
+func (u k8sAuth) GetRules(namespace string) (*authorizationapi.SubjectRulesReviewStatus, error) {
+	res, err := u.AuthCli.SelfSubjectRulesReviews().Create(&authorizationapi.SelfSubjectRulesReview{
+		Spec: authorizationapi.SelfSubjectRulesReviewSpec{
+			Namespace: namespace,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res.Status, nil
+}
+
 // UserAuth contains information to check user permissions
 type UserAuth struct {
 	k8sAuth k8sAuthInterface
+	// tokenHash identifies the user in the rules cache.
+	tokenHash  string
+	rulesCache *tokencache.Cache
 }
 
 // Action represents a specific set of verbs against a resource
@@ -109,7 +126,11 @@ func NewAuth(token string) (*UserAuth, error) {
 		DiscoveryCli: discoveryCli,
 	}
 
-	return &UserAuth{k8sAuthCli}, nil
+	return &UserAuth{
+		k8sAuth:    k8sAuthCli,
+		tokenHash:  tokencache.HashToken(token),
+		rulesCache: defaultRulesCache,
+	}, nil
 }
 
 // ValidateForNamespace checks if the user can access secrets in the given
@@ -173,24 +194,10 @@ func (u *UserAuth) isAllowed(verb string, itemsToCheck []resource) ([]Action, er
 			}
 			return []Action{}, err
 		}
-		group := i.APIVersion
-		if group == "v1" {
-			// The group should be empty for the core API group
-			group = ""
-		}
-		allowed, err := u.k8sAuth.CanI(verb, group, rInfo.Name, i.Namespace)
+		allowed, err := u.canI(verb, i.APIVersion, rInfo, i.Namespace)
 		if err != nil {
 			return []Action{}, err
 		}
-		// If the "group" is versioned the user may be able to have access to any
-		// version of the group but the above call may return "false"
-		if !allowed && strings.Contains(group, "/") {
-			groupID := strings.Split(group, "/")[0]
-			allowed, err = u.k8sAuth.CanI(verb, groupID, rInfo.Name, i.Namespace)
-			if err != nil {
-				return []Action{}, err
-			}
-		}
 		if !allowed {
 			rejectedAction := Action{
 				APIVersion:  i.APIVersion,
@@ -207,6 +214,64 @@ func (u *UserAuth) isAllowed(verb string, itemsToCheck []resource) ([]Action, er
 	return rejectedActions, nil
 }
 
+// getRules returns the rules of the user in the namespace, reusing the
+// result of a recent review if any.
+func (u *UserAuth) getRules(namespace string) (*authorizationapi.SubjectRulesReviewStatus, error) {
+	if u.rulesCache == nil {
+		return u.k8sAuth.GetRules(namespace)
+	}
+	key := fmt.Sprintf("%s/%s", u.tokenHash, namespace)
+	if status, ok := u.rulesCache.Get(key); ok {
+		return status.(*authorizationapi.SubjectRulesReviewStatus), nil
+	}
+	status, err := u.k8sAuth.GetRules(namespace)
+	if err != nil {
+		return nil, err
+	}
+	u.rulesCache.Set(key, status)
+	return status, nil
+}
+
+// canI checks whether the user can perform the verb on a resource. The rules
+// of the namespace are evaluated locally for namespaced resources, falling
+// back to an access review when they do not allow the action but are
+// incomplete (eg. when an authorizer other than RBAC is in use). Cluster-wide
+// resources are always checked with an access review since the rules of a
+// namespace include those granted by RoleBindings, which do not apply to them.
+func (u *UserAuth) canI(verb, apiVersion string, rInfo resourceInfo, namespace string) (bool, error) {
+	if rInfo.Namespaced {
+		status, err := u.getRules(namespace)
+		if err != nil {
+			return false, err
+		}
+		if rulesAllow(status.ResourceRules, verb, apiGroup(apiVersion), rInfo.Name) {
+			return true, nil
+		}
+		if !status.Incomplete {
+			return false, nil
+		}
+	}
+
+	group := apiVersion
+	if group == "v1" {
+		// The group should be empty for the core API group
+		group = ""
+	}
+	allowed, err := u.k8sAuth.CanI(verb, group, rInfo.Name, namespace)
+	if err != nil {
+		return false, err
+	}
+	// If the "group" is versioned the user may be able to have access to any
+	// version of the group but the above call may return "false"
+	if !allowed && strings.Contains(group, "/") {
+		allowed, err = u.k8sAuth.CanI(verb, apiGroup(group), rInfo.Name, namespace)
+		if err != nil {
+			return false, err
+		}
+	}
+	return allowed, nil
+}
+
 func uniqVerbs(current []string, new []string) []string {
 	resMap := map[string]bool{}
 	for _, v := range current {
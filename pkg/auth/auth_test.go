@@ -23,18 +23,24 @@ import (
 	"strings"
 	"testing"
 
+	authorizationapi "k8s.io/api/authorization/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	discovery "k8s.io/client-go/discovery"
 	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubeapps/kubeapps/pkg/tokencache"
 )
 
 type fakeK8sAuth struct {
 	DiscoveryCli discovery.DiscoveryInterface
 	canIResult   bool
 	canIError    error
+	rules        *authorizationapi.SubjectRulesReviewStatus
+	// calls counts the reviews issued, by kind.
+	calls map[string]int
 }
 
 func (u fakeK8sAuth) Validate() error {
@@ -50,10 +56,21 @@ func (u fakeK8sAuth) GetResourceList(groupVersion string) (*metav1.APIResourceLi
 }
 
 func (u fakeK8sAuth) CanI(verb, group, resource, namespace string) (bool, error) {
+	u.calls["access"]++
 	return u.canIResult, u.canIError
 }
 
+func (u fakeK8sAuth) GetRules(namespace string) (*authorizationapi.SubjectRulesReviewStatus, error) {
+	u.calls["rules"]++
+	return u.rules, nil
+}
+
 func newFakeUserAuth(canIResult bool, canIError error) *UserAuth {
+	// Incomplete rules without any resource rule so that access reviews are used.
+	return newFakeUserAuthWithRules(canIResult, canIError, &authorizationapi.SubjectRulesReviewStatus{Incomplete: true})
+}
+
+func newFakeUserAuthWithRules(canIResult bool, canIError error, rules *authorizationapi.SubjectRulesReviewStatus) *UserAuth {
 	resourceListV1 := metav1.APIResourceList{
 		GroupVersion: "v1",
 		APIResources: []metav1.APIResource{
@@ -87,8 +104,18 @@ func newFakeUserAuth(canIResult bool, canIError error) *UserAuth {
 		&resourceListExtensionsV1Beta1,
 		&resourceListClusterRoleRBAC,
 	}
-	fakeK8sAuthCli := fakeK8sAuth{cli.Discovery(), canIResult, canIError}
-	return &UserAuth{fakeK8sAuthCli}
+	fakeK8sAuthCli := fakeK8sAuth{
+		DiscoveryCli: cli.Discovery(),
+		canIResult:   canIResult,
+		canIError:    canIError,
+		rules:        rules,
+		calls:        map[string]int{},
+	}
+	return &UserAuth{
+		k8sAuth:    fakeK8sAuthCli,
+		tokenHash:  tokencache.HashToken("token"),
+		rulesCache: tokencache.New(rulesCacheTTL),
+	}
 }
 
 func TestGetForbidden(t *testing.T) {
@@ -206,6 +233,86 @@ kind: FooBar
 	}
 }
 
+func TestGetForbiddenWithRules(t *testing.T) {
+	const namespace = "test-namespace"
+	const manifest = `---
+apiVersion: v1
+kind: Pod
+---
+apiVersion: apps/v1beta1
+kind: Deployment
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+`
+	testCases := []struct {
+		name                 string
+		rules                *authorizationapi.SubjectRulesReviewStatus
+		canIResult           bool
+		expectedActions      []Action
+		expectedAccessChecks int
+	}{
+		{
+			name: "it evaluates namespaced resources with the rules",
+			rules: &authorizationapi.SubjectRulesReviewStatus{
+				ResourceRules: []authorizationapi.ResourceRule{
+					{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+					{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"*"}},
+				},
+			},
+			canIResult:           true,
+			expectedActions:      []Action{},
+			expectedAccessChecks: 1,
+		},
+		{
+			name: "it reports the resources not allowed by complete rules",
+			rules: &authorizationapi.SubjectRulesReviewStatus{
+				ResourceRules: []authorizationapi.ResourceRule{
+					{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+					{Verbs: []string{"create"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"foo"}},
+				},
+			},
+			canIResult: true,
+			expectedActions: []Action{
+				{APIVersion: "apps/v1beta1", Resource: "deployments", Namespace: namespace, Verbs: []string{"create"}},
+			},
+			expectedAccessChecks: 1,
+		},
+		{
+			name: "it falls back to access reviews when the rules are incomplete",
+			rules: &authorizationapi.SubjectRulesReviewStatus{
+				ResourceRules: []authorizationapi.ResourceRule{
+					{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				},
+				Incomplete: true,
+			},
+			canIResult:           true,
+			expectedActions:      []Action{},
+			expectedAccessChecks: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := newFakeUserAuthWithRules(tc.canIResult, nil, tc.rules)
+			res, err := auth.GetForbiddenActions(namespace, "create", manifest)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := res, tc.expectedActions; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			calls := auth.k8sAuth.(fakeK8sAuth).calls
+			if got, want := calls["rules"], 1; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			if got, want := calls["access"], tc.expectedAccessChecks; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
 func TestParseForbiddenActions(t *testing.T) {
 	testSuite := []struct {
 		Description     string
@@ -26,6 +26,18 @@ func AuthCheckerForRequest(req *http.Request) (Checker, error) {
 	return NewAuth(token)
 }
 
+// HelmRepoAuthCheckerForRequest also accepts the token as the basic auth
+// password, for clients only supporting basic auth such as the Helm CLI. It
+// must only be used for read-only routes, since browsers send cached basic
+// auth credentials along with cross-site requests.
+func HelmRepoAuthCheckerForRequest(req *http.Request) (Checker, error) {
+	token := tokenForRequest(req)
+	if token == "" {
+		return nil, fmt.Errorf("Authorization token missing")
+	}
+	return NewAuth(token)
+}
+
 // AuthGate implements middleware to check if the user has access to read from
 // the specific namespace before continuing.
 //   * If the path being handled by the
@@ -34,8 +46,18 @@ func AuthCheckerForRequest(req *http.Request) (Checker, error) {
 //   * If the namespace is the global chart namespace (ie. kubeappsNamespace) then
 //     we allow read access regardless.
 func AuthGate(kubeappsNamespace string) negroni.HandlerFunc {
+	return authGate(kubeappsNamespace, AuthCheckerForRequest)
+}
+
+// HelmRepoAuthGate is the AuthGate of the Helm repositories served by the
+// assetsvc, which also accepts the token as the basic auth password.
+func HelmRepoAuthGate(kubeappsNamespace string) negroni.HandlerFunc {
+	return authGate(kubeappsNamespace, HelmRepoAuthCheckerForRequest)
+}
+
+func authGate(kubeappsNamespace string, checkerForRequest CheckerForRequest) negroni.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
-		userAuth, err := AuthCheckerForRequest(req)
+		userAuth, err := checkerForRequest(req)
 		if err != nil {
 			response.NewErrorResponse(http.StatusUnauthorized, err.Error()).Write(w)
 			return
@@ -66,6 +88,18 @@ func AuthGate(kubeappsNamespace string) negroni.HandlerFunc {
 	}
 }
 
+// tokenForRequest returns the bearer token of the request or, for clients
+// only supporting basic auth such as the Helm CLI, the password.
+func tokenForRequest(req *http.Request) string {
+	if token := ExtractToken(req.Header.Get("Authorization")); token != "" {
+		return token
+	}
+	if _, password, ok := req.BasicAuth(); ok {
+		return password
+	}
+	return ""
+}
+
 // ExtractToken extracts the token from a correctly formatted Authorization header.
 func ExtractToken(headerValue string) string {
 	if strings.HasPrefix(headerValue, tokenPrefix) {
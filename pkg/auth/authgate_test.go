@@ -0,0 +1,71 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenForRequest(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		username string
+		password string
+		expected string
+	}{
+		{
+			name:     "returns the bearer token",
+			header:   "Bearer foo",
+			expected: "foo",
+		},
+		{
+			name:     "returns the basic auth password",
+			username: "kubeapps",
+			password: "foo",
+			expected: "foo",
+		},
+		{
+			name:     "returns an empty token without credentials",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/ns/default/helm/index.yaml", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			if tc.password != "" {
+				req.SetBasicAuth(tc.username, tc.password)
+			}
+			if got, want := tokenForRequest(req), tc.expected; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAuthCheckerForRequestBasicAuth(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/ns/default/charts", nil)
+	req.SetBasicAuth("kubeapps", "foo")
+	if _, err := AuthCheckerForRequest(req); err == nil {
+		t.Errorf("got: nil, want: error")
+	}
+}
@@ -22,6 +22,8 @@ import (
 
 type FakeAuth struct {
 	ForbiddenActions []authUtils.Action
+	// NamespaceForbidden denies access to the secrets of every namespace
+	NamespaceForbidden bool
 }
 
 func (f *FakeAuth) Validate() error {
@@ -29,7 +31,7 @@ func (f *FakeAuth) Validate() error {
 }
 
 func (f *FakeAuth) ValidateForNamespace(namespace string) (bool, error) {
-	return true, nil
+	return !f.NamespaceForbidden, nil
 }
 
 func (f *FakeAuth) GetForbiddenActions(namespace, action, manifest string) ([]authUtils.Action, error) {
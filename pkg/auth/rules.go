@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kubeapps/kubeapps/pkg/tokencache"
+	authorizationapi "k8s.io/api/authorization/v1"
+)
+
+// rulesCacheTTL is how long the rules of a user in a namespace are reused.
+// It is kept short so that RBAC changes are picked up quickly, while still
+// avoiding repeated reviews for the requests issued by a single page load.
+const rulesCacheTTL = 10 * time.Second
+
+// defaultRulesCache is shared by the UserAuth created for each request.
+var defaultRulesCache = tokencache.New(rulesCacheTTL)
+
+// ruleWildcard matches any verb, API group or resource in a rule.
+const ruleWildcard = "*"
+
+// apiGroup returns the API group of an apiVersion (eg. "apps" for "apps/v1"
+// and "" for the core "v1").
+func apiGroup(apiVersion string) string {
+	if !strings.Contains(apiVersion, "/") {
+		return ""
+	}
+	return strings.Split(apiVersion, "/")[0]
+}
+
+func matchesRuleValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == ruleWildcard || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// rulesAllow checks whether any of the rules allows the verb on every
+// resource of the group. Rules restricted to resource names are ignored
+// since they cannot grant access to any resource of the kind.
+func rulesAllow(rules []authorizationapi.ResourceRule, verb, group, resource string) bool {
+	for _, r := range rules {
+		if len(r.ResourceNames) > 0 {
+			continue
+		}
+		if matchesRuleValue(r.Verbs, verb) && matchesRuleValue(r.APIGroups, group) && matchesRuleValue(r.Resources, resource) {
+			return true
+		}
+	}
+	return false
+}
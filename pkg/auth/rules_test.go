@@ -0,0 +1,51 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"testing"
+
+	authorizationapi "k8s.io/api/authorization/v1"
+)
+
+func TestRulesAllow(t *testing.T) {
+	rules := []authorizationapi.ResourceRule{
+		{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+		{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		{Verbs: []string{"delete"}, APIGroups: []string{"*"}, Resources: []string{"*"}, ResourceNames: []string{"foo"}},
+	}
+	testCases := []struct {
+		name     string
+		verb     string
+		group    string
+		resource string
+		expected bool
+	}{
+		{"it allows a listed verb", "get", "", "secrets", true},
+		{"it denies a verb not listed", "create", "", "secrets", false},
+		{"it allows any verb with a wildcard", "delete", "apps", "deployments", true},
+		{"it denies a resource of another group", "get", "apps", "secrets", false},
+		{"it ignores rules restricted to resource names", "delete", "", "pods", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := rulesAllow(rules, tc.verb, tc.group, tc.resource), tc.expected; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+		})
+	}
+}
@@ -27,10 +27,11 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
-	"github.com/ghodss/yaml"
-	appRepov1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	appRepov1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	"github.com/kubeapps/kubeapps/pkg/kube"
+	"github.com/kubeapps/kubeapps/pkg/repoindex"
 	helm3chart "helm.sh/helm/v3/pkg/chart"
 	helm3loader "helm.sh/helm/v3/pkg/chart/loader"
 	corev1 "k8s.io/api/core/v1"
@@ -43,6 +44,9 @@ import (
 const (
 	dockerConfigJSONType = "kubernetes.io/dockerconfigjson"
 	dockerConfigJSONKey  = ".dockerconfigjson"
+	// mirrorTimeout bounds the requests to the assetsvc for mirrored charts,
+	// so that the fallback to the repository is not delayed for too long.
+	mirrorTimeout = 30 * time.Second
 )
 
 type repoIndex struct {
@@ -72,6 +76,9 @@ type Details struct {
 	Version string `json:"version"`
 	// Values is a string containing (unparsed) YAML values.
 	Values string `json:"values,omitempty"`
+	// CreateNamespace specifies whether the release namespace should be
+	// created before installing the chart.
+	CreateNamespace bool `json:"createNamespace,omitempty"`
 }
 
 // ChartMultiVersion includes both Helm2Chart and Helm3Chart
@@ -101,6 +108,10 @@ type ChartClient struct {
 	kubeappsNamespace        string
 	appRepo                  *appRepov1.AppRepository
 	registrySecretsPerDomain map[string]string
+	// assetsvcURL and mirrorClient are used to fetch the charts of mirrored
+	// repositories.
+	assetsvcURL  string
+	mirrorClient kube.HTTPClient
 }
 
 // NewChartClient returns a new ChartClient
@@ -112,6 +123,14 @@ func NewChartClient(appRepoHandler kube.AuthHandler, kubeappsNamespace, userAgen
 	}
 }
 
+// WithMirror configures the client to fetch the charts of app repositories
+// with mirroring enabled from the assetsvc, falling back to the repository.
+func (c *ChartClient) WithMirror(assetsvcURL string) *ChartClient {
+	c.assetsvcURL = strings.TrimSuffix(strings.TrimSpace(assetsvcURL), "/")
+	c.mirrorClient = &http.Client{Timeout: mirrorTimeout}
+	return c
+}
+
 func getReq(rawURL string) (*http.Request, error) {
 	parsedURL, err := url.ParseRequestURI(rawURL)
 	if err != nil {
@@ -163,14 +182,10 @@ func storeIndexInCache(repoURL string, index *repo.IndexFile, sha string) {
 	repoIndexes[repoURL] = &repoIndex{sha, index}
 }
 
+// parseIndex decodes the index one chart at a time, it can be in YAML or JSON
+// and gzip compressed
 func parseIndex(data []byte) (*repo.IndexFile, error) {
-	index := &repo.IndexFile{}
-	err := yaml.Unmarshal(data, index)
-	if err != nil {
-		return index, err
-	}
-	index.SortEntries()
-	return index, nil
+	return repoindex.Load(bytes.NewReader(data))
 }
 
 // fetchRepoIndex returns a Helm repository
@@ -179,6 +194,9 @@ func fetchRepoIndex(netClient *kube.HTTPClient, repoURL string) (*repo.IndexFile
 	if err != nil {
 		return nil, err
 	}
+	// Keep the index compressed in memory when the server supports it, it is
+	// decompressed while it is parsed
+	req.Header.Set("Accept-Encoding", "gzip")
 
 	res, err := (*netClient).Do(req)
 	if err != nil {
@@ -302,8 +320,7 @@ func (c *ChartClient) parseDetailsForHTTPClient(details *Details, userAuthToken
 	}
 
 	var authSecret *corev1.Secret
-	if auth.Header != nil {
-		secretName := auth.Header.SecretKeyRef.Name
+	if secretName := kube.AuthSecretName(auth); secretName != "" {
 		authSecret, err = client.GetSecret(secretName, details.AppRepositoryResourceNamespace)
 		if err != nil {
 			return nil, nil, nil, err
@@ -332,6 +349,16 @@ func (c *ChartClient) InitNetClient(details *Details, userAuthToken string) (kub
 // GetChart retrieves and loads a Chart from a registry in both
 // v2 and v3 formats.
 func (c *ChartClient) GetChart(details *Details, netClient kube.HTTPClient, requireV1Support bool) (*ChartMultiVersion, error) {
+	if c.isMirrored(details) {
+		chartURL := c.mirroredChartURL(details)
+		log.Printf("Downloading %s ...", chartURL)
+		chart, err := fetchChart(&c.mirrorClient, chartURL, requireV1Support)
+		if err == nil {
+			return chart, nil
+		}
+		log.Printf("Unable to get the mirrored chart, falling back to the repository: %v", err)
+	}
+
 	indexURL := strings.TrimSuffix(strings.TrimSpace(c.appRepo.Spec.URL), "/") + "/index.yaml"
 
 	repoIndex, err := fetchRepoIndex(&netClient, indexURL)
@@ -353,6 +380,23 @@ func (c *ChartClient) GetChart(details *Details, netClient kube.HTTPClient, requ
 	return chart, nil
 }
 
+// isMirrored checks whether the chart can be fetched from the assetsvc. The
+// version is required since the mirrored tarballs are found by version.
+func (c *ChartClient) isMirrored(details *Details) bool {
+	return c.appRepo.Spec.Mirror && c.mirrorClient != nil && c.assetsvcURL != "" && details.Version != ""
+}
+
+// mirroredChartURL returns the URL of the tarball of the chart in the assetsvc.
+func (c *ChartClient) mirroredChartURL(details *Details) string {
+	return fmt.Sprintf("%s/v1/ns/%s/assets/%s/%s/versions/%s/chart.tgz",
+		c.assetsvcURL,
+		url.PathEscape(details.AppRepositoryResourceNamespace),
+		url.PathEscape(c.appRepo.Name),
+		url.PathEscape(details.ChartName),
+		url.PathEscape(details.Version),
+	)
+}
+
 // RegistrySecretsPerDomain checks the app repo and available secrets
 // to return the secret names per registry domain.
 //
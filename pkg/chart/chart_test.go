@@ -17,6 +17,7 @@ package chart
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
@@ -31,7 +32,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/arschles/assert"
-	appRepov1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	appRepov1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	"github.com/kubeapps/kubeapps/pkg/kube"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -546,6 +547,107 @@ func TestGetChart(t *testing.T) {
 	}
 }
 
+// fakeMirrorClient serves the test charts as an assetsvc with mirrored
+// tarballs, if mirrored is set.
+type fakeMirrorClient struct {
+	mirrored bool
+	requests []string
+}
+
+func (f *fakeMirrorClient) Do(h *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, h.URL.String())
+	if !f.mirrored {
+		return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	}
+	version := path.Base(path.Dir(h.URL.Path))
+	chart, err := os.Open(path.Join(".", "testdata", fmt.Sprintf("nginx-%s.tgz", version)))
+	if err != nil {
+		return &http.Response{StatusCode: 404}, err
+	}
+	return &http.Response{StatusCode: 200, Body: chart}, nil
+}
+
+func TestGetMirroredChart(t *testing.T) {
+	const repoName = "foo-repo"
+	const repoURL = "http://example.com/"
+	testCases := []struct {
+		name                 string
+		mirror               bool
+		mirrored             bool
+		expectedMirrorReqs   int
+		expectedUpstreamReqs int
+	}{
+		{
+			name:                 "gets the chart from the assetsvc",
+			mirror:               true,
+			mirrored:             true,
+			expectedMirrorReqs:   1,
+			expectedUpstreamReqs: 0,
+		},
+		{
+			name:                 "falls back to the repository if the chart is not mirrored",
+			mirror:               true,
+			mirrored:             false,
+			expectedMirrorReqs:   1,
+			expectedUpstreamReqs: 2,
+		},
+		{
+			name:                 "does not use the assetsvc if mirroring is disabled",
+			mirror:               false,
+			mirrored:             true,
+			expectedMirrorReqs:   0,
+			expectedUpstreamReqs: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		target := Details{
+			AppRepositoryResourceName:      repoName,
+			AppRepositoryResourceNamespace: "repo-namespace",
+			ChartName:                      "nginx",
+			ReleaseName:                    "foo",
+			Version:                        "5.1.1-apiVersionV1",
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			httpClient := newHTTPClient(repoURL, []Details{target}, "")
+			mirrorClient := &fakeMirrorClient{mirrored: tc.mirrored}
+			chUtils := (&ChartClient{
+				appRepo: &appRepov1.AppRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      repoName,
+						Namespace: "repo-namespace",
+					},
+					Spec: appRepov1.AppRepositorySpec{
+						URL:    repoURL,
+						Mirror: tc.mirror,
+					},
+				},
+			}).WithMirror("http://assetsvc:8080/")
+			chUtils.mirrorClient = mirrorClient
+
+			ch, err := chUtils.GetChart(&target, httpClient, true)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got, want := ch.Helm2Chart.GetMetadata().GetName(), "nginx"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+
+			if got, want := len(mirrorClient.requests), tc.expectedMirrorReqs; got != want {
+				t.Fatalf("got: %d, want: %d", got, want)
+			}
+			if tc.expectedMirrorReqs > 0 {
+				if got, want := mirrorClient.requests[0], "http://assetsvc:8080/v1/ns/repo-namespace/assets/foo-repo/nginx/versions/5.1.1-apiVersionV1/chart.tgz"; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+			}
+			if got, want := len(getFakeClientRequests(t, httpClient)), tc.expectedUpstreamReqs; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
 func TestGetIndexFromCache(t *testing.T) {
 	repoURL := "https://test.com"
 	data := []byte("foo")
@@ -561,6 +663,38 @@ func TestGetIndexFromCache(t *testing.T) {
 	}
 }
 
+func TestParseIndex(t *testing.T) {
+	jsonIndex := `{"apiVersion": "v1", "entries": {"nginx": [{"name": "nginx", "version": "1.0.0"}, {"name": "nginx", "version": "2.0.0"}]}}`
+	var gzipIndex bytes.Buffer
+	w := gzip.NewWriter(&gzipIndex)
+	w.Write([]byte(jsonIndex))
+	w.Close()
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"json index", []byte(jsonIndex)},
+		{"gzip compressed index", gzipIndex.Bytes()},
+		{"yaml index", []byte("apiVersion: v1\nentries:\n  nginx:\n  - name: nginx\n    version: 1.0.0\n  - name: nginx\n    version: 2.0.0\n")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := parseIndex(tc.data)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			cv, err := index.Get("nginx", "")
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := cv.Version, "2.0.0"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
 func TestClientWithDefaultHeaders(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -39,6 +39,8 @@ type RepoInternal struct {
 	URL                 string `json:"url"`
 	AuthorizationHeader string `bson:"-"`
 	Checksum            string
+	// Mirror specifies whether the chart tarballs are stored when syncing.
+	Mirror bool `bson:"-"`
 }
 
 // Chart is a higher-level representation of a chart package
@@ -79,14 +81,47 @@ type ChartVersion struct {
 	Schema string `json:"schema" bson:"-"`
 }
 
-// ChartFiles holds the README and values for a given chart version
+// ChartFiles holds the README, values and other files for a given chart version
 type ChartFiles struct {
 	ID     string `bson:"file_id"`
 	Readme string
 	Values string
 	Schema string
-	Repo   *Repo
-	Digest string
+	// ChartYAML is the Chart.yaml of the chart, including the dependencies
+	// and kubeVersion not found in the repository index
+	ChartYAML string
+	// Notes are the templates/NOTES.txt rendered after installing the chart
+	Notes     string
+	Changelog string
+	License   string
+	// CRDs are the CustomResourceDefinitions in the crds directory
+	CRDs []ChartCRD
+	// Dependencies are the subcharts declared in the Chart.yaml or, for
+	// charts with apiVersion v1, in the requirements.yaml
+	Dependencies []ChartDependency
+	Repo         *Repo
+	Digest       string
+}
+
+// ChartDependency is a subchart a chart depends on
+type ChartDependency struct {
+	Name string `json:"name"`
+	// Version is the semver constraint of the subchart versions
+	Version string `json:"version"`
+	// Repository is the URL or the name (prefixed by @ or alias:) of the
+	// repository of the subchart, or empty when it is in the charts directory
+	Repository string `json:"repository"`
+	Alias      string `json:"alias,omitempty"`
+	Condition  string `json:"condition,omitempty"`
+}
+
+// ChartCRD is a CustomResourceDefinition installed by a chart
+type ChartCRD struct {
+	Name  string `json:"name"`
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	// File is the path of the definition in the chart
+	File string `json:"file"`
 }
 
 // Allow to convert ChartFiles to a sql JSON
@@ -95,7 +130,17 @@ func (a ChartFiles) Value() (driver.Value, error) {
 }
 
 type RepoCheck struct {
-	ID         string    `bson:"_id"`
-	LastUpdate time.Time `bson:"last_update"`
-	Checksum   string    `bson:"checksum"`
+	ID             string               `bson:"_id"`
+	LastUpdate     time.Time            `bson:"last_update"`
+	Checksum       string               `bson:"checksum"`
+	FailedVersions []FailedChartVersion `bson:"failed_versions"`
+}
+
+// FailedChartVersion is a chart version whose files could not be imported
+// after retrying, so that it is retried by the next sync of the repository.
+// The Version is empty when the icon of the chart could not be imported.
+type FailedChartVersion struct {
+	Chart   string `json:"chart" bson:"chart"`
+	Version string `json:"version" bson:"version"`
+	Error   string `json:"error" bson:"error"`
 }
@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kubeapps/common/datastore"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// BoltRepositoryBucket bucket containing repositories sync info, keyed by
+	// namespace/name
+	BoltRepositoryBucket = "repos"
+	// BoltChartBucket bucket containing Charts info, keyed by
+	// namespace/chart ID
+	BoltChartBucket = "charts"
+	// BoltChartFilesBucket bucket containing files related to other charts,
+	// keyed by namespace/chart files ID
+	BoltChartFilesBucket = "files"
+	// BoltChartTarballsBucket bucket containing the tarballs of mirrored
+	// charts, keyed by ChartTarballID
+	BoltChartTarballsBucket = "tarballs"
+	// BoltFailedVersionsBucket bucket containing the chart versions of a
+	// repository whose files could not be imported, keyed by namespace/name
+	BoltFailedVersionsBucket = "failed_versions"
+
+	// boltTimeout is the time to wait for the lock of the database file held
+	// by another process.
+	boltTimeout = 30 * time.Second
+)
+
+var boltBuckets = []string{BoltRepositoryBucket, BoltChartBucket, BoltChartFilesBucket, BoltChartTarballsBucket, BoltFailedVersionsBucket}
+
+// ErrBoltKeyNotFound is returned when a key is not found in a bucket
+var ErrBoltKeyNotFound = errors.New("key not found")
+
+// BoltRepo is the value stored for a repository
+type BoltRepo struct {
+	Namespace  string    `json:"namespace"`
+	Name       string    `json:"name"`
+	Checksum   string    `json:"checksum"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+// BoltAssetManager asset manager for a bolt database file. The file is only
+// open during a transaction so that the asset-syncer jobs and assetsvc can
+// share it, as bolt locks it for the process opening it.
+//
+// The lock is an flock of the file, which is only reliable between processes
+// on the same node. The bolt database is meant for single node installations:
+// a single assetsvc replica, with the file on a volume pinned to a node, such
+// as a local PersistentVolume, so that the sync jobs mounting it are scheduled
+// on the same node, or a cluster with a single node.
+type BoltAssetManager struct {
+	path              string
+	mutex             sync.RWMutex
+	KubeappsNamespace string
+}
+
+// NewBoltManager creates an asset manager for the bolt database file at the
+// URL of the config
+func NewBoltManager(config datastore.Config, kubeappsNamespace string) *BoltAssetManager {
+	return &BoltAssetManager{path: config.URL, KubeappsNamespace: kubeappsNamespace}
+}
+
+// Init creates the database file and its buckets
+func (m *BoltAssetManager) Init() error {
+	return m.Update(createBoltBuckets)
+}
+
+// Close does nothing since the file is closed after every transaction
+func (m *BoltAssetManager) Close() error {
+	return nil
+}
+
+// InvalidateCache for bolt deletes and re-creates the buckets
+func (m *BoltAssetManager) InvalidateCache() error {
+	return m.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltBuckets {
+			if err := tx.DeleteBucket([]byte(name)); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		return createBoltBuckets(tx)
+	})
+}
+
+func createBoltBuckets(tx *bolt.Tx) error {
+	for _, name := range boltBuckets {
+		if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *BoltAssetManager) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(m.path, 0600, &bolt.Options{Timeout: boltTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %v", m.path, err)
+	}
+	return db, nil
+}
+
+// View runs a read-only transaction
+func (m *BoltAssetManager) View(fn func(tx *bolt.Tx) error) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	db, err := m.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// Update runs a read-write transaction
+func (m *BoltAssetManager) Update(fn func(tx *bolt.Tx) error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	db, err := m.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// BoltKey returns the key of an item in a namespace
+func BoltKey(namespace, id string) []byte {
+	return []byte(fmt.Sprintf("%s/%s", namespace, id))
+}
+
+// BoltRepoPrefix returns the prefix of the keys of the charts and chart files
+// of a repository, whose IDs start with the name of the repository.
+func BoltRepoPrefix(namespace, repo string) []byte {
+	return BoltKey(namespace, repo+"/")
+}
+
+// BoltGet unmarshals the value of a key in a bucket into the target
+func BoltGet(tx *bolt.Tx, bucket string, key []byte, target interface{}) error {
+	value := tx.Bucket([]byte(bucket)).Get(key)
+	if value == nil {
+		return ErrBoltKeyNotFound
+	}
+	return json.Unmarshal(value, target)
+}
+
+// BoltPut stores the value marshaled for a key in a bucket
+func BoltPut(tx *bolt.Tx, bucket string, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(bucket)).Put(key, data)
+}
+
+// BoltForEachPrefix calls fn for the keys of a bucket with a prefix, in
+// order. The values are only valid during the transaction.
+func BoltForEachPrefix(tx *bolt.Tx, bucket string, prefix []byte, fn func(k, v []byte) error) error {
+	c := tx.Bucket([]byte(bucket)).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BoltCharts returns the charts whose keys have a prefix
+func BoltCharts(tx *bolt.Tx, prefix []byte) ([]*models.Chart, error) {
+	charts := []*models.Chart{}
+	err := BoltForEachPrefix(tx, BoltChartBucket, prefix, func(k, v []byte) error {
+		var chart models.Chart
+		if err := json.Unmarshal(v, &chart); err != nil {
+			return err
+		}
+		charts = append(charts, &chart)
+		return nil
+	})
+	return charts, err
+}
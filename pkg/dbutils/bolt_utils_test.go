@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dbutils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubeapps/common/datastore"
+	bolt "go.etcd.io/bbolt"
+)
+
+func Test_BoltInvalidateCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbutils")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(dir)
+	manager := NewBoltManager(datastore.Config{URL: filepath.Join(dir, "assets.db")}, "kubeapps")
+	if err := manager.Init(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	err = manager.Update(func(tx *bolt.Tx) error {
+		for _, k := range []string{"ns/repo/a", "ns/repo/b", "ns/repo-2/a", "other/repo/a"} {
+			if err := BoltPut(tx, BoltChartBucket, []byte(k), k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	keys := []string{}
+	err = manager.View(func(tx *bolt.Tx) error {
+		return BoltForEachPrefix(tx, BoltChartBucket, BoltRepoPrefix("ns", "repo"), func(k, v []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := []string{"ns/repo/a", "ns/repo/b"}; !cmp.Equal(want, keys) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, keys))
+	}
+
+	if err := manager.InvalidateCache(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	err = manager.View(func(tx *bolt.Tx) error {
+		var value string
+		if err := BoltGet(tx, BoltChartBucket, []byte("ns/repo/a"), &value); err != ErrBoltKeyNotFound {
+			t.Errorf("got: %v, want: %v", err, ErrBoltKeyNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
+
+// Test_BoltConcurrentManagers checks that managers of the same file, as in
+// assetsvc and a sync job, wait for each other's lock instead of failing or
+// losing writes.
+func Test_BoltConcurrentManagers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbutils")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(dir)
+	config := datastore.Config{URL: filepath.Join(dir, "assets.db")}
+	managers := []*BoltAssetManager{NewBoltManager(config, "kubeapps"), NewBoltManager(config, "kubeapps")}
+	if err := managers[0].Init(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	const writes = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, len(managers)*writes)
+	for i, manager := range managers {
+		wg.Add(1)
+		go func(i int, manager *BoltAssetManager) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				key := fmt.Sprintf("ns/repo-%d/%d", i, j)
+				errs <- manager.Update(func(tx *bolt.Tx) error {
+					return BoltPut(tx, BoltChartBucket, []byte(key), key)
+				})
+			}
+		}(i, manager)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	for i := range managers {
+		err = managers[1-i].View(func(tx *bolt.Tx) error {
+			keys := 0
+			err := BoltForEachPrefix(tx, BoltChartBucket, BoltRepoPrefix("ns", fmt.Sprintf("repo-%d", i)), func(k, v []byte) error {
+				keys++
+				return nil
+			})
+			if got, want := keys, writes; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bolttest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubeapps/common/datastore"
+	"github.com/kubeapps/kubeapps/pkg/chart/models"
+	"github.com/kubeapps/kubeapps/pkg/dbutils"
+	"github.com/kubeapps/kubeapps/pkg/dbutils/dbutilstest"
+	bolt "go.etcd.io/bbolt"
+)
+
+// GetInitializedManager returns a bolt manager for a temporary database file
+// ready for testing.
+func GetInitializedManager(t *testing.T) (*dbutils.BoltAssetManager, func()) {
+	dir, err := ioutil.TempDir("", "bolttest")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	bam := dbutils.NewBoltManager(datastore.Config{URL: filepath.Join(dir, "assets.db")}, dbutilstest.KubeappsTestNamespace)
+	if err := bam.Init(); err != nil {
+		cleanup()
+		t.Fatalf("%+v", err)
+	}
+	return bam, cleanup
+}
+
+// EnsureChartsExist stores the repository and its charts.
+func EnsureChartsExist(t *testing.T, bam *dbutils.BoltAssetManager, charts []models.Chart, repo models.Repo) {
+	err := bam.Update(func(tx *bolt.Tx) error {
+		err := dbutils.BoltPut(tx, dbutils.BoltRepositoryBucket, dbutils.BoltKey(repo.Namespace, repo.Name), dbutils.BoltRepo{Namespace: repo.Namespace, Name: repo.Name})
+		if err != nil {
+			return err
+		}
+		for _, chart := range charts {
+			if err := dbutils.BoltPut(tx, dbutils.BoltChartBucket, dbutils.BoltKey(repo.Namespace, chart.ID), chart); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
+
+// CountKeys returns the number of keys in a bucket.
+func CountKeys(t *testing.T, bam *dbutils.BoltAssetManager, bucket string) int {
+	var count int
+	err := bam.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket([]byte(bucket)).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return count
+}
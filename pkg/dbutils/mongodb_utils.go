@@ -27,8 +27,33 @@ const (
 	ChartCollection      = "charts"
 	RepositoryCollection = "repos"
 	ChartFilesCollection = "files"
+	// ChartTarballsGridFS is the prefix of the GridFS collections storing the
+	// tarballs of mirrored charts.
+	ChartTarballsGridFS = "tarballs"
 )
 
+// ErrGridFSNotSupported is returned when the database does not give access to
+// GridFS, as it is the case for the mocked datastore.
+var ErrGridFSNotSupported = fmt.Errorf("GridFS is not supported by the database")
+
+// GridFS returns the GridFS with the given prefix. It is only available for
+// the mgo implementation of datastore.Database.
+func GridFS(db datastore.Database, prefix string) (*mgo.GridFS, error) {
+	gfsDB, ok := db.(interface {
+		GridFS(prefix string) *mgo.GridFS
+	})
+	if !ok {
+		return nil, ErrGridFSNotSupported
+	}
+	return gfsDB.GridFS(prefix), nil
+}
+
+// ChartTarballID returns the GridFS ID of the tarball with the digest in a
+// repository.
+func ChartTarballID(repoNamespace, repoName, digest string) string {
+	return fmt.Sprintf("%s/%s/%s", repoNamespace, repoName, digest)
+}
+
 // MongodbAssetManager struct containing mongodb info
 type MongodbAssetManager struct {
 	mongoConfig       datastore.Config
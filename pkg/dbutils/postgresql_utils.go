@@ -33,6 +33,11 @@ const (
 	RepositoryTable = "repos"
 	// ChartFilesTable table containing files related to other charts
 	ChartFilesTable = "files"
+	// ChartTarballsTable table containing the tarballs of mirrored charts
+	ChartTarballsTable = "tarballs"
+	// FailedVersionsTable table containing the chart versions of a repository
+	// whose files could not be imported
+	FailedVersionsTable = "failed_versions"
 	// EnvvarPostgresTests enables tests that run against a local postgres
 	EnvvarPostgresTests = "ENABLE_PG_INTEGRATION_TESTS"
 )
@@ -175,12 +180,41 @@ CREATE TABLE IF NOT EXISTS %s (
 	if err != nil {
 		return err
 	}
+
+	// Tarballs are stored per repository, keyed by digest, so that a chart
+	// mirrored from a private repository is only served for its namespace.
+	_, err = m.DB.Exec(fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	ID serial NOT NULL PRIMARY KEY,
+	repo_name varchar NOT NULL,
+	repo_namespace varchar NOT NULL,
+	digest varchar NOT NULL,
+	data bytea NOT NULL,
+	UNIQUE(repo_namespace, repo_name, digest),
+	FOREIGN KEY (repo_name, repo_namespace) REFERENCES %s (name, namespace) ON DELETE CASCADE
+)`, ChartTarballsTable, RepositoryTable))
+	if err != nil {
+		return err
+	}
+
+	_, err = m.DB.Exec(fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	ID serial NOT NULL PRIMARY KEY,
+	repo_name varchar NOT NULL,
+	repo_namespace varchar NOT NULL,
+	info jsonb NOT NULL,
+	UNIQUE(repo_namespace, repo_name),
+	FOREIGN KEY (repo_name, repo_namespace) REFERENCES %s (name, namespace) ON DELETE CASCADE
+)`, FailedVersionsTable, RepositoryTable))
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
 // InvalidateCache for postgresql deletes and re-writes the schema
 func (m *PostgresAssetManager) InvalidateCache() error {
-	tables := strings.Join([]string{RepositoryTable, ChartTable, ChartFilesTable}, ",")
+	tables := strings.Join([]string{RepositoryTable, ChartTable, ChartFilesTable, ChartTarballsTable, FailedVersionsTable}, ",")
 	_, err := m.DB.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tables))
 	if err != nil {
 		return err
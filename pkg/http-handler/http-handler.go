@@ -18,28 +18,34 @@ package httphandler
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
-	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	"github.com/kubeapps/kubeapps/pkg/auth"
 	"github.com/kubeapps/kubeapps/pkg/kube"
+	"github.com/kubeapps/kubeapps/pkg/repovalidation"
 	log "github.com/sirupsen/logrus"
-	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 // namespacesResponse is used to marshal the JSON response
 type namespacesResponse struct {
-	Namespaces []corev1.Namespace `json:"namespaces"`
+	Namespaces []kube.Namespace `json:"namespaces"`
 }
 
+// maxValidationSampleSize limits the number of charts fetched by a
+// validation report request
+const maxValidationSampleSize = 50
+
 // appRepositoryResponse is used to marshal the JSON response
 type appRepositoryResponse struct {
-	AppRepository v1alpha1.AppRepository `json:"appRepository"`
+	AppRepository v1beta1.AppRepository `json:"appRepository"`
 }
 
 // JSONError returns an error code and a JSON response
@@ -130,6 +136,36 @@ func ValidateAppRepository(handler kube.AuthHandler) func(w http.ResponseWriter,
 	}
 }
 
+// ValidateAppRepositoryReport returns the report of the validation of the
+// index of an AppRepository and of a sample of its charts, whose size can be
+// set with the samples query parameter
+func ValidateAppRepositoryReport(handler kube.AuthHandler) func(w http.ResponseWriter, req *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		sampleSize := repovalidation.DefaultSampleSize
+		if samples := req.URL.Query().Get("samples"); samples != "" {
+			var err error
+			sampleSize, err = strconv.Atoi(samples)
+			if err != nil || sampleSize < 0 || sampleSize > maxValidationSampleSize {
+				JSONError(w, fmt.Sprintf("samples must be a number between 0 and %d", maxValidationSampleSize), http.StatusBadRequest)
+				return
+			}
+		}
+		token := auth.ExtractToken(req.Header.Get("Authorization"))
+		report, err := handler.AsUser(token).ValidateAppRepositoryReport(req.Body, mux.Vars(req)["namespace"], sampleSize)
+		if err != nil {
+			returnK8sError(err, w)
+			return
+		}
+		responseBody, err := json.Marshal(report)
+		if err != nil {
+			JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(responseBody)
+	}
+}
+
 // DeleteAppRepository deletes an App Repository
 func DeleteAppRepository(kubeHandler kube.AuthHandler) func(w http.ResponseWriter, req *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
@@ -145,13 +181,20 @@ func DeleteAppRepository(kubeHandler kube.AuthHandler) func(w http.ResponseWrite
 	}
 }
 
-// GetNamespaces return the list of namespaces
+// GetNamespaces return the list of namespaces, with the access of the user
+// to each of them. The namespaces can be filtered with the "labelSelector"
+// and "prefix" query params.
 func GetNamespaces(kubeHandler kube.AuthHandler) func(w http.ResponseWriter, req *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
 		token := auth.ExtractToken(req.Header.Get("Authorization"))
-		namespaces, err := kubeHandler.AsUser(token).GetNamespaces()
+		options := kube.NamespaceListOptions{
+			LabelSelector: req.URL.Query().Get("labelSelector"),
+			NamePrefix:    req.URL.Query().Get("prefix"),
+		}
+		namespaces, err := kubeHandler.AsUser(token).GetNamespaces(options)
 		if err != nil {
 			returnK8sError(err, w)
+			return
 		}
 		response := namespacesResponse{
 			Namespaces: namespaces,
@@ -194,6 +237,7 @@ func SetupDefaultRoutes(r *mux.Router) error {
 	r.Methods("GET").Path("/namespaces").Handler(http.HandlerFunc(GetNamespaces(backendHandler)))
 	r.Methods("POST").Path("/namespaces/{namespace}/apprepositories").Handler(http.HandlerFunc(CreateAppRepository(backendHandler)))
 	r.Methods("POST").Path("/namespaces/{namespace}/apprepositories/validate").Handler(http.HandlerFunc(ValidateAppRepository(backendHandler)))
+	r.Methods("POST").Path("/namespaces/{namespace}/apprepositories/validate/report").Handler(http.HandlerFunc(ValidateAppRepositoryReport(backendHandler)))
 	r.Methods("PUT").Path("/namespaces/{namespace}/apprepositories/{name}").Handler(http.HandlerFunc(UpdateAppRepository(backendHandler)))
 	r.Methods("DELETE").Path("/namespaces/{namespace}/apprepositories/{name}").Handler(http.HandlerFunc(DeleteAppRepository(backendHandler)))
 	r.Methods("GET").Path("/namespaces/{namespace}/operator/{name}/logo").Handler(http.HandlerFunc(GetOperatorLogo(backendHandler)))
@@ -27,15 +27,16 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/gorilla/mux"
 	"github.com/kubeapps/kubeapps/pkg/kube"
+	"github.com/kubeapps/kubeapps/pkg/repovalidation"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
-	v1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	v1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 )
 
-func checkAppResponse(t *testing.T, response *httptest.ResponseRecorder, expectedRepo *v1alpha1.AppRepository) {
+func checkAppResponse(t *testing.T, response *httptest.ResponseRecorder, expectedRepo *v1beta1.AppRepository) {
 	var appRepoResponse appRepositoryResponse
 	err := json.NewDecoder(response.Body).Decode(&appRepoResponse)
 	if err != nil {
@@ -74,13 +75,13 @@ func checkError(t *testing.T, response *httptest.ResponseRecorder, expectedError
 func TestCreateAppRepository(t *testing.T) {
 	testCases := []struct {
 		name         string
-		appRepo      *v1alpha1.AppRepository
+		appRepo      *v1beta1.AppRepository
 		err          error
 		expectedCode int
 	}{
 		{
 			name:         "it should return the repo and a 200 if the repo is created",
-			appRepo:      &v1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+			appRepo:      &v1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
 			expectedCode: 201,
 		},
 		{
@@ -124,13 +125,13 @@ func TestCreateAppRepository(t *testing.T) {
 func TestUpdateAppRepository(t *testing.T) {
 	testCases := []struct {
 		name         string
-		appRepo      *v1alpha1.AppRepository
+		appRepo      *v1beta1.AppRepository
 		err          error
 		expectedCode int
 	}{
 		{
 			name:         "it should return the repo and a 200 if the repo is updated",
-			appRepo:      &v1alpha1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+			appRepo:      &v1beta1.AppRepository{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
 			expectedCode: 200,
 		},
 		{
@@ -204,16 +205,34 @@ func TestDeleteAppRepository(t *testing.T) {
 }
 
 func TestGetNamespaces(t *testing.T) {
+	access := kube.NamespaceAccess{ReadReleases: true, CreateSecrets: true}
 	testCases := []struct {
-		name         string
-		namespaces   []corev1.Namespace
-		err          error
-		expectedCode int
+		name               string
+		query              string
+		namespaces         []corev1.Namespace
+		err                error
+		expectedCode       int
+		expectedNamespaces []kube.Namespace
 	}{
 		{
 			name:         "it should return the list of namespaces and a 200 if the repo is created",
 			namespaces:   []corev1.Namespace{corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
 			expectedCode: 200,
+			expectedNamespaces: []kube.Namespace{
+				{Namespace: corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}, Access: access},
+			},
+		},
+		{
+			name:  "it should filter the namespaces by prefix",
+			query: "?prefix=team-",
+			namespaces: []corev1.Namespace{
+				corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+				corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			},
+			expectedCode: 200,
+			expectedNamespaces: []kube.Namespace{
+				{Namespace: corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}, Access: access},
+			},
 		},
 		{
 			name:         "it should return a 403 when forbidden",
@@ -223,8 +242,8 @@ func TestGetNamespaces(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			getNSFunc := GetNamespaces(&kube.FakeHandler{Namespaces: tc.namespaces, Err: tc.err})
-			req := httptest.NewRequest("GET", "https://foo.bar/backend/v1/namespaces", nil)
+			getNSFunc := GetNamespaces(&kube.FakeHandler{Namespaces: tc.namespaces, NamespaceAccess: access, Err: tc.err})
+			req := httptest.NewRequest("GET", "https://foo.bar/backend/v1/namespaces"+tc.query, nil)
 
 			response := httptest.NewRecorder()
 			getNSFunc(response, req)
@@ -239,7 +258,7 @@ func TestGetNamespaces(t *testing.T) {
 				if err != nil {
 					t.Fatalf("%+v", err)
 				}
-				expectedResponse := namespacesResponse{Namespaces: tc.namespaces}
+				expectedResponse := namespacesResponse{Namespaces: tc.expectedNamespaces}
 				if got, want := nsResponse, expectedResponse; !cmp.Equal(want, got) {
 					t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
 				}
@@ -279,6 +298,59 @@ func TestValidateAppRepository(t *testing.T) {
 	}
 }
 
+func TestValidateAppRepositoryReport(t *testing.T) {
+	report := &repovalidation.Report{URL: "https://example.com", Valid: true, Charts: 1, Versions: 2}
+	testCases := []struct {
+		name         string
+		samples      string
+		err          error
+		expectedCode int
+	}{
+		{
+			name:         "it should return the report",
+			expectedCode: 200,
+		},
+		{
+			name:         "it should accept a number of samples",
+			samples:      "?samples=10",
+			expectedCode: 200,
+		},
+		{
+			name:         "it should reject an invalid number of samples",
+			samples:      "?samples=1000",
+			expectedCode: 400,
+		},
+		{
+			name:         "it should return the error code if given",
+			err:          fmt.Errorf("Boom"),
+			expectedCode: 500,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validateFunc := ValidateAppRepositoryReport(&kube.FakeHandler{ValidationReport: report, Err: tc.err})
+			req := httptest.NewRequest("POST", "https://foo.bar/backend/v1/namespaces/kubeapps/apprepositories/validate/report"+tc.samples, strings.NewReader("data"))
+
+			response := httptest.NewRecorder()
+			validateFunc(response, req)
+
+			if got, want := response.Code, tc.expectedCode; got != want {
+				t.Fatalf("got: %d, want: %d\nBody: %s", got, want, response.Body)
+			}
+			if tc.expectedCode != 200 {
+				return
+			}
+			var got repovalidation.Report
+			if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !cmp.Equal(*report, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(*report, got))
+			}
+		})
+	}
+}
+
 func TestGetOperatorLogo(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -23,18 +23,29 @@ import (
 	"net/http"
 	"strings"
 
-	v1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	v1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	"github.com/kubeapps/kubeapps/pkg/repovalidation"
 	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // FakeHandler represents a fake Handler for testing purposes
 type FakeHandler struct {
-	AppRepos    []*v1alpha1.AppRepository
-	CreatedRepo *v1alpha1.AppRepository
-	UpdatedRepo *v1alpha1.AppRepository
+	AppRepos    []*v1beta1.AppRepository
+	CreatedRepo *v1beta1.AppRepository
+	UpdatedRepo *v1beta1.AppRepository
 	Namespaces  []corev1.Namespace
-	Secrets     []*corev1.Secret
-	Err         error
+	// NamespaceAccess is returned for every namespace by GetNamespaces.
+	NamespaceAccess NamespaceAccess
+	Secrets         []*corev1.Secret
+	ConfigMaps      []*corev1.ConfigMap
+	// TemplatedNamespaces lists the namespaces to which ApplyNamespaceTemplate
+	// applied a template.
+	TemplatedNamespaces []string
+	// ValidationReport is returned by ValidateAppRepositoryReport.
+	ValidationReport *repovalidation.Report
+	Err              error
 }
 
 // AsUser fakes user auth
@@ -48,13 +59,13 @@ func (c *FakeHandler) AsSVC() handler {
 }
 
 // CreateAppRepository fake
-func (c *FakeHandler) CreateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1alpha1.AppRepository, error) {
+func (c *FakeHandler) CreateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1beta1.AppRepository, error) {
 	c.AppRepos = append(c.AppRepos, c.CreatedRepo)
 	return c.CreatedRepo, c.Err
 }
 
 // UpdateAppRepository fake
-func (c *FakeHandler) UpdateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1alpha1.AppRepository, error) {
+func (c *FakeHandler) UpdateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1beta1.AppRepository, error) {
 	return c.UpdatedRepo, c.Err
 }
 
@@ -64,7 +75,7 @@ func (c *FakeHandler) DeleteAppRepository(name, namespace string) error {
 }
 
 // GetAppRepository fake
-func (c *FakeHandler) GetAppRepository(name, namespace string) (*v1alpha1.AppRepository, error) {
+func (c *FakeHandler) GetAppRepository(name, namespace string) (*v1beta1.AppRepository, error) {
 	for _, r := range c.AppRepos {
 		if r.Name == name && r.Namespace == namespace {
 			return r, nil
@@ -74,8 +85,58 @@ func (c *FakeHandler) GetAppRepository(name, namespace string) (*v1alpha1.AppRep
 }
 
 // GetNamespaces fake
-func (c *FakeHandler) GetNamespaces() ([]corev1.Namespace, error) {
-	return c.Namespaces, c.Err
+func (c *FakeHandler) GetNamespaces(options NamespaceListOptions) ([]Namespace, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	namespaces := []Namespace{}
+	for _, ns := range c.Namespaces {
+		if strings.HasPrefix(ns.Name, options.NamePrefix) {
+			namespaces = append(namespaces, Namespace{Namespace: ns, Access: c.NamespaceAccess})
+		}
+	}
+	return namespaces, nil
+}
+
+// CreateNamespace fake
+func (c *FakeHandler) CreateNamespace(name string, template *NamespaceTemplate) (*corev1.Namespace, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	for _, ns := range c.Namespaces {
+		if ns.Name == name {
+			return nil, k8sErrors.NewAlreadyExists(corev1.Resource("namespaces"), name)
+		}
+	}
+	namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if template != nil {
+		namespace.ObjectMeta.Labels = template.Labels
+		namespace.ObjectMeta.Annotations = map[string]string{NamespaceTemplateAnnotation: "true"}
+		for k, v := range template.Annotations {
+			namespace.ObjectMeta.Annotations[k] = v
+		}
+	}
+	c.Namespaces = append(c.Namespaces, namespace)
+	return &namespace, nil
+}
+
+// ApplyNamespaceTemplate fake
+func (c *FakeHandler) ApplyNamespaceTemplate(name string, template *NamespaceTemplate) error {
+	if c.Err != nil {
+		return c.Err
+	}
+	if template == nil {
+		return nil
+	}
+	for _, ns := range c.Namespaces {
+		if ns.Name == name {
+			if _, ok := ns.ObjectMeta.Annotations[NamespaceTemplateAnnotation]; ok {
+				c.TemplatedNamespaces = append(c.TemplatedNamespaces, name)
+			}
+			return nil
+		}
+	}
+	return k8sErrors.NewNotFound(corev1.Resource("namespaces"), name)
 }
 
 // GetSecret fake
@@ -88,11 +149,29 @@ func (c *FakeHandler) GetSecret(name, namespace string) (*corev1.Secret, error)
 	return nil, fmt.Errorf("not found")
 }
 
+// GetConfigMap fake
+func (c *FakeHandler) GetConfigMap(name, namespace string) (*corev1.ConfigMap, error) {
+	for _, r := range c.ConfigMaps {
+		if r.Name == name && r.Namespace == namespace {
+			return r, nil
+		}
+	}
+	return nil, k8sErrors.NewNotFound(corev1.Resource("configmaps"), name)
+}
+
 // ValidateAppRepository fake
 func (c *FakeHandler) ValidateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*http.Response, error) {
 	return &http.Response{Body: ioutil.NopCloser(strings.NewReader("valid: yaml")), StatusCode: 200}, c.Err
 }
 
+// ValidateAppRepositoryReport fake
+func (c *FakeHandler) ValidateAppRepositoryReport(appRepoBody io.ReadCloser, requestNamespace string, sampleSize int) (*repovalidation.Report, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	return c.ValidationReport, nil
+}
+
 // GetOperatorLogo fake
 func (c *FakeHandler) GetOperatorLogo(namespace, name string) ([]byte, error) {
 	return []byte{}, nil
@@ -19,12 +19,13 @@ package kube
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"os"
 	"time"
 
-	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -56,9 +57,80 @@ func (c *clientWithDefaultHeaders) Do(req *http.Request) (*http.Response, error)
 	return c.client.Do(req)
 }
 
+// AuthSecretName returns the name of the secret with the credentials of the
+// app repository, if any. The header, basic auth, bearer token and client
+// certificate credentials are all read from this secret.
+func AuthSecretName(auth v1beta1.AppRepositoryAuth) string {
+	switch {
+	case auth.Header != nil:
+		return auth.Header.SecretKeyRef.Name
+	case auth.BasicAuth != nil:
+		return auth.BasicAuth.UsernameSecretKeyRef.Name
+	case auth.BearerToken != nil:
+		return auth.BearerToken.SecretKeyRef.Name
+	case auth.ClientCert != nil:
+		return auth.ClientCert.CertSecretKeyRef.Name
+	}
+	return ""
+}
+
+// secretValue returns the value of the selected key of the secret, which may
+// be set in either its data or string data.
+func secretValue(secret *corev1.Secret, selector corev1.SecretKeySelector) ([]byte, error) {
+	if value, ok := secret.StringData[selector.Key]; ok {
+		return []byte(value), nil
+	}
+	if value, ok := secret.Data[selector.Key]; ok {
+		return value, nil
+	}
+	return nil, fmt.Errorf("secret %q did not contain key %q", selector.Name, selector.Key)
+}
+
+// authorizationHeader returns the Authorization header for the credentials
+// of the app repository, or an empty string if none is configured.
+func authorizationHeader(auth v1beta1.AppRepositoryAuth, authSecret *corev1.Secret) (string, error) {
+	switch {
+	case auth.Header != nil:
+		header, err := secretValue(authSecret, auth.Header.SecretKeyRef)
+		if err != nil {
+			return "", err
+		}
+		return string(header), nil
+	case auth.BasicAuth != nil:
+		username, err := secretValue(authSecret, auth.BasicAuth.UsernameSecretKeyRef)
+		if err != nil {
+			return "", err
+		}
+		password, err := secretValue(authSecret, auth.BasicAuth.PasswordSecretKeyRef)
+		if err != nil {
+			return "", err
+		}
+		return BasicAuthHeader(string(username), string(password)), nil
+	case auth.BearerToken != nil:
+		token, err := secretValue(authSecret, auth.BearerToken.SecretKeyRef)
+		if err != nil {
+			return "", err
+		}
+		return BearerTokenHeader(string(token)), nil
+	}
+	return "", nil
+}
+
+// BasicAuthHeader returns the value of an Authorization header for the
+// username and password.
+func BasicAuthHeader(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// BearerTokenHeader returns the value of an Authorization header for the token.
+func BearerTokenHeader(token string) string {
+	return "Bearer " + token
+}
+
 // InitNetClient returns an HTTP client based on the chart details loading a
-// custom CA if provided (as a secret)
-func InitNetClient(appRepo *v1alpha1.AppRepository, caCertSecret, authSecret *corev1.Secret, defaultHeaders http.Header) (HTTPClient, error) {
+// custom CA if provided (as a secret), and the credentials of the app
+// repository from the authSecret.
+func InitNetClient(appRepo *v1beta1.AppRepository, caCertSecret, authSecret *corev1.Secret, defaultHeaders http.Header) (HTTPClient, error) {
 	// Require the SystemCertPool unless the env var is explicitly set.
 	caCertPool, err := x509.SystemCertPool()
 	if err != nil {
@@ -70,14 +142,9 @@ func InitNetClient(appRepo *v1alpha1.AppRepository, caCertSecret, authSecret *co
 
 	if caCertSecret != nil && appRepo.Spec.Auth.CustomCA != nil {
 		// Append our cert to the system pool
-		key := appRepo.Spec.Auth.CustomCA.SecretKeyRef.Key
-		customData, ok := caCertSecret.Data[key]
-		if !ok {
-			customDataString, ok := caCertSecret.StringData[key]
-			if !ok {
-				return nil, fmt.Errorf("secret %q did not contain key %q", appRepo.Spec.Auth.CustomCA.SecretKeyRef.Name, key)
-			}
-			customData = []byte(customDataString)
+		customData, err := secretValue(caCertSecret, appRepo.Spec.Auth.CustomCA.SecretKeyRef)
+		if err != nil {
+			return nil, err
 		}
 		if ok := caCertPool.AppendCertsFromPEM(customData); !ok {
 			return nil, fmt.Errorf("Failed to append %s to RootCAs", appRepo.Spec.Auth.CustomCA.SecretKeyRef.Name)
@@ -87,17 +154,33 @@ func InitNetClient(appRepo *v1alpha1.AppRepository, caCertSecret, authSecret *co
 	if defaultHeaders == nil {
 		defaultHeaders = http.Header{}
 	}
-	if authSecret != nil && appRepo.Spec.Auth.Header != nil {
-		key := appRepo.Spec.Auth.Header.SecretKeyRef.Key
-		auth, ok := authSecret.StringData[key]
-		if !ok {
-			authBytes, ok := authSecret.Data[key]
-			if !ok {
-				return nil, fmt.Errorf("secret %q did not contain key %q", appRepo.Spec.Auth.Header.SecretKeyRef.Name, key)
+	tlsConfig := &tls.Config{
+		RootCAs: caCertPool,
+	}
+	if authSecret != nil {
+		auth, err := authorizationHeader(appRepo.Spec.Auth, authSecret)
+		if err != nil {
+			return nil, err
+		}
+		if auth != "" {
+			defaultHeaders.Set("Authorization", auth)
+		}
+
+		if clientCert := appRepo.Spec.Auth.ClientCert; clientCert != nil {
+			certPEM, err := secretValue(authSecret, clientCert.CertSecretKeyRef)
+			if err != nil {
+				return nil, err
+			}
+			keyPEM, err := secretValue(authSecret, clientCert.KeySecretKeyRef)
+			if err != nil {
+				return nil, err
+			}
+			cert, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load the client certificate from secret %q: %v", clientCert.CertSecretKeyRef.Name, err)
 			}
-			auth = string(authBytes)
+			tlsConfig.Certificates = []tls.Certificate{cert}
 		}
-		defaultHeaders.Set("Authorization", string(auth))
 	}
 
 	// Return Transport for testing purposes
@@ -105,10 +188,8 @@ func InitNetClient(appRepo *v1alpha1.AppRepository, caCertSecret, authSecret *co
 		client: &http.Client{
 			Timeout: time.Second * defaultTimeoutSeconds,
 			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				TLSClientConfig: &tls.Config{
-					RootCAs: caCertPool,
-				},
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
 			},
 		},
 		defaultHeaders: defaultHeaders,
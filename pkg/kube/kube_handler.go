@@ -21,13 +21,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
-	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	apprepoclientset "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned"
-	v1alpha1typed "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned/typed/apprepository/v1alpha1"
+	v1beta1typed "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned/typed/apprepository/v1beta1"
+	"github.com/kubeapps/kubeapps/pkg/repovalidation"
+	"github.com/kubeapps/kubeapps/pkg/tokencache"
 	log "github.com/sirupsen/logrus"
-	authorizationapi "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,11 +37,12 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
 )
 
 // combinedClientsetInterface provides both the app repository clientset and the corev1 clientset.
 type combinedClientsetInterface interface {
-	KubeappsV1alpha1() v1alpha1typed.KubeappsV1alpha1Interface
+	KubeappsV1beta1() v1beta1typed.KubeappsV1beta1Interface
 	CoreV1() corev1typed.CoreV1Interface
 	AuthorizationV1() authorizationv1.AuthorizationV1Interface
 	RestClient() rest.Interface
@@ -79,6 +80,10 @@ type kubeHandler struct {
 	// version (and since this is a private struct, external code cannot change
 	// the function).
 	clientsetForConfig func(*rest.Config) (combinedClientsetInterface, error)
+
+	// namespaceAccessCache is shared by the user handlers so that namespace
+	// access checks are reused across requests.
+	namespaceAccessCache *tokencache.Cache
 }
 
 // userHandler is an extension of kubeHandler for a specific service account
@@ -91,6 +96,10 @@ type userHandler struct {
 
 	// clientset for the given serviceccount
 	clientset combinedClientsetInterface
+
+	// tokenHash identifies the user in the namespace access cache.
+	tokenHash            string
+	namespaceAccessCache *tokencache.Cache
 }
 
 // This interface is explicitly private so that it cannot be used in function
@@ -100,13 +109,17 @@ type userHandler struct {
 // this one and adds one method, to force call-sites to explicitly use a UserHandler
 // or ServiceHandler.
 type handler interface {
-	CreateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1alpha1.AppRepository, error)
-	UpdateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1alpha1.AppRepository, error)
+	CreateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1beta1.AppRepository, error)
+	UpdateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1beta1.AppRepository, error)
 	DeleteAppRepository(name, namespace string) error
-	GetNamespaces() ([]corev1.Namespace, error)
+	GetNamespaces(options NamespaceListOptions) ([]Namespace, error)
+	CreateNamespace(name string, template *NamespaceTemplate) (*corev1.Namespace, error)
+	ApplyNamespaceTemplate(name string, template *NamespaceTemplate) error
 	GetSecret(name, namespace string) (*corev1.Secret, error)
-	GetAppRepository(repoName, repoNamespace string) (*v1alpha1.AppRepository, error)
+	GetConfigMap(name, namespace string) (*corev1.ConfigMap, error)
+	GetAppRepository(repoName, repoNamespace string) (*v1beta1.AppRepository, error)
 	ValidateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*http.Response, error)
+	ValidateAppRepositoryReport(appRepoBody io.ReadCloser, requestNamespace string, sampleSize int) (*repovalidation.Report, error)
 	GetOperatorLogo(namespace, name string) ([]byte, error)
 }
 
@@ -122,9 +135,11 @@ func (a *kubeHandler) AsUser(token string) handler {
 		log.Errorf("unable to create clientset: %v", err)
 	}
 	return &userHandler{
-		kubeappsNamespace: a.kubeappsNamespace,
-		svcClientset:      a.svcClientset,
-		clientset:         clientset,
+		kubeappsNamespace:    a.kubeappsNamespace,
+		svcClientset:         a.svcClientset,
+		clientset:            clientset,
+		tokenHash:            tokencache.HashToken(token),
+		namespaceAccessCache: a.namespaceAccessCache,
 	}
 }
 
@@ -145,10 +160,16 @@ type appRepositoryRequestDetails struct {
 	Name               string                 `json:"name"`
 	RepoURL            string                 `json:"repoURL"`
 	AuthHeader         string                 `json:"authHeader"`
+	BasicAuthUsername  string                 `json:"basicAuthUsername,omitempty"`
+	BasicAuthPassword  string                 `json:"basicAuthPassword,omitempty"`
+	BearerToken        string                 `json:"bearerToken,omitempty"`
+	ClientCert         string                 `json:"clientCert,omitempty"`
+	ClientKey          string                 `json:"clientKey,omitempty"`
 	CustomCA           string                 `json:"customCA"`
 	RegistrySecrets    []string               `json:"registrySecrets"`
 	SyncJobPodTemplate corev1.PodTemplateSpec `json:"syncJobPodTemplate"`
 	ResyncRequests     uint                   `json:"resyncRequests"`
+	Mirror             bool                   `json:"mirror,omitempty"`
 }
 
 // ErrGlobalRepositoryWithSecrets defines the error returned when an attempt is
@@ -194,8 +215,9 @@ func NewHandler(kubeappsNamespace string) (AuthHandler, error) {
 		config:            *config,
 		kubeappsNamespace: kubeappsNamespace,
 		// See comment in the struct defn above.
-		clientsetForConfig: clientsetForConfig,
-		svcClientset:       &combinedClientset{svcAppRepoClient, svcKubeClient, svcKubeClient.RESTClient()},
+		clientsetForConfig:   clientsetForConfig,
+		svcClientset:         &combinedClientset{svcAppRepoClient, svcKubeClient, svcKubeClient.RESTClient()},
+		namespaceAccessCache: tokencache.New(namespaceAccessCacheTTL),
 	}, nil
 }
 
@@ -227,7 +249,7 @@ func (a *kubeHandler) clientsetForRequest(token string) (combinedClientsetInterf
 	return clientset, err
 }
 
-func parseRepoAndSecret(appRepoBody io.ReadCloser) (*v1alpha1.AppRepository, *corev1.Secret, error) {
+func parseRepoAndSecret(appRepoBody io.ReadCloser) (*v1beta1.AppRepository, *corev1.Secret, error) {
 	var appRepoRequest appRepositoryRequest
 	err := json.NewDecoder(appRepoBody).Decode(&appRepoRequest)
 	if err != nil {
@@ -240,11 +262,20 @@ func parseRepoAndSecret(appRepoBody io.ReadCloser) (*v1alpha1.AppRepository, *co
 	return appRepo, repoSecret, nil
 }
 
-func (a *userHandler) applyAppRepositorySecret(repoSecret *corev1.Secret, requestNamespace string, appRepo *v1alpha1.AppRepository) error {
-	// TODO(#1655) Fixes the immediate issue, but the proper fix would no
-	// longer set the complete owner reference during secretForRequest and
-	// rather do so explicitly here.
-	repoSecret.ObjectMeta.OwnerReferences[0].UID = appRepo.ObjectMeta.UID
+// applyAppRepositorySecret creates or updates the secret of an AppRepository,
+// together with its copy in the kubeapps namespace when required. The secret
+// is owned by the AppRepository only when owned is true, as the UID of the
+// AppRepository is unknown until it is created.
+func (a *userHandler) applyAppRepositorySecret(repoSecret *corev1.Secret, requestNamespace string, appRepo *v1beta1.AppRepository, owned bool) error {
+	repoSecret = repoSecret.DeepCopy()
+	if owned {
+		// TODO(#1655) Fixes the immediate issue, but the proper fix would no
+		// longer set the complete owner reference during secretForRequest and
+		// rather do so explicitly here.
+		repoSecret.ObjectMeta.OwnerReferences[0].UID = appRepo.ObjectMeta.UID
+	} else {
+		repoSecret.ObjectMeta.OwnerReferences = nil
+	}
 	_, err := a.clientset.CoreV1().Secrets(requestNamespace).Create(repoSecret)
 	if err != nil && k8sErrors.IsAlreadyExists(err) {
 		_, err = a.clientset.CoreV1().Secrets(requestNamespace).Update(repoSecret)
@@ -255,7 +286,7 @@ func (a *userHandler) applyAppRepositorySecret(repoSecret *corev1.Secret, reques
 
 	// TODO(#1647): Move app repo sync to namespaces so secret copy not required.
 	if requestNamespace != a.kubeappsNamespace {
-		repoSecret.ObjectMeta.Name = KubeappsSecretNameForRepo(appRepo.ObjectMeta.Name, appRepo.ObjectMeta.Namespace)
+		repoSecret.ObjectMeta.Name = KubeappsSecretNameForRepo(appRepo.ObjectMeta.Name, requestNamespace)
 		repoSecret.ObjectMeta.OwnerReferences = nil
 		_, err = a.svcClientset.CoreV1().Secrets(a.kubeappsNamespace).Create(repoSecret)
 		if err != nil && k8sErrors.IsAlreadyExists(err) {
@@ -268,8 +299,24 @@ func (a *userHandler) applyAppRepositorySecret(repoSecret *corev1.Secret, reques
 	return nil
 }
 
+// deleteAppRepositorySecret removes the secret of an AppRepository which
+// could not be created, together with its copy in the kubeapps namespace.
+func (a *userHandler) deleteAppRepositorySecret(repoSecret *corev1.Secret, requestNamespace string, appRepo *v1beta1.AppRepository) {
+	err := a.clientset.CoreV1().Secrets(requestNamespace).Delete(repoSecret.ObjectMeta.Name, &metav1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		log.Errorf("unable to delete secret %q: %v", repoSecret.ObjectMeta.Name, err)
+	}
+	if requestNamespace != a.kubeappsNamespace {
+		name := KubeappsSecretNameForRepo(appRepo.ObjectMeta.Name, requestNamespace)
+		err = a.svcClientset.CoreV1().Secrets(a.kubeappsNamespace).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			log.Errorf("unable to delete secret %q: %v", name, err)
+		}
+	}
+}
+
 // CreateAppRepository creates an AppRepository resource based on the request data
-func (a *userHandler) CreateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1alpha1.AppRepository, error) {
+func (a *userHandler) CreateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1beta1.AppRepository, error) {
 	if a.kubeappsNamespace == "" {
 		log.Errorf("attempt to use app repositories handler without kubeappsNamespace configured")
 		return nil, fmt.Errorf("kubeappsNamespace must be configured to enable app repository handler")
@@ -284,23 +331,45 @@ func (a *userHandler) CreateAppRepository(appRepoBody io.ReadCloser, requestName
 		return nil, ErrGlobalRepositoryWithSecrets
 	}
 
-	appRepo, err = a.clientset.KubeappsV1alpha1().AppRepositories(requestNamespace).Create(appRepo)
+	if repoSecret != nil {
+		// The secret is created before the AppRepository, which is validated
+		// against the secrets it references when the admission webhook is
+		// enabled. The secret of an existing AppRepository is not replaced.
+		_, err = a.clientset.KubeappsV1beta1().AppRepositories(requestNamespace).Get(appRepo.Name, metav1.GetOptions{})
+		if err == nil {
+			return nil, k8sErrors.NewAlreadyExists(v1beta1.Resource("apprepositories"), appRepo.Name)
+		}
+		if !k8sErrors.IsNotFound(err) {
+			return nil, err
+		}
+		err = a.applyAppRepositorySecret(repoSecret, requestNamespace, appRepo, false)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	createdAppRepo, err := a.clientset.KubeappsV1beta1().AppRepositories(requestNamespace).Create(appRepo)
 	if err != nil {
+		if repoSecret != nil {
+			a.deleteAppRepositorySecret(repoSecret, requestNamespace, appRepo)
+		}
 		return nil, err
 	}
 
 	if repoSecret != nil {
-		a.applyAppRepositorySecret(repoSecret, requestNamespace, appRepo)
+		// Now that its UID is known, the AppRepository can own its secret.
+		repoSecret = repoSecret.DeepCopy()
+		repoSecret.ObjectMeta.OwnerReferences[0].UID = createdAppRepo.ObjectMeta.UID
+		_, err = a.clientset.CoreV1().Secrets(requestNamespace).Update(repoSecret)
 		if err != nil {
 			return nil, err
 		}
 	}
-	return appRepo, nil
+	return createdAppRepo, nil
 }
 
 // UpdateAppRepository updates an AppRepository resource based on the request data
-func (a *userHandler) UpdateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1alpha1.AppRepository, error) {
+func (a *userHandler) UpdateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*v1beta1.AppRepository, error) {
 	if a.kubeappsNamespace == "" {
 		log.Errorf("attempt to use app repositories handler without kubeappsNamespace configured")
 		return nil, fmt.Errorf("kubeappsNamespace must be configured to enable app repository handler")
@@ -315,35 +384,33 @@ func (a *userHandler) UpdateAppRepository(appRepoBody io.ReadCloser, requestName
 		return nil, ErrGlobalRepositoryWithSecrets
 	}
 
-	existingAppRepo, err := a.clientset.KubeappsV1alpha1().AppRepositories(requestNamespace).Get(appRepo.Name, metav1.GetOptions{})
-	if err != nil {
-		return nil, err
-	}
-
-	// Update existing repo with the new spec
-	existingAppRepo.Spec = appRepo.Spec
-	appRepo, err = a.clientset.KubeappsV1alpha1().AppRepositories(requestNamespace).Update(existingAppRepo)
+	existingAppRepo, err := a.clientset.KubeappsV1beta1().AppRepositories(requestNamespace).Get(appRepo.Name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 
+	// The secret is updated first so that the new spec is validated against
+	// the new credentials.
 	if repoSecret != nil {
-		a.applyAppRepositorySecret(repoSecret, requestNamespace, appRepo)
+		err = a.applyAppRepositorySecret(repoSecret, requestNamespace, existingAppRepo, true)
 		if err != nil {
 			return nil, err
 		}
 	}
-	return appRepo, nil
+
+	// Update existing repo with the new spec
+	existingAppRepo.Spec = appRepo.Spec
+	return a.clientset.KubeappsV1beta1().AppRepositories(requestNamespace).Update(existingAppRepo)
 }
 
 // DeleteAppRepository deletes an AppRepository resource from a namespace.
 func (a *userHandler) DeleteAppRepository(repoName, repoNamespace string) error {
-	appRepo, err := a.clientset.KubeappsV1alpha1().AppRepositories(repoNamespace).Get(repoName, metav1.GetOptions{})
+	appRepo, err := a.clientset.KubeappsV1beta1().AppRepositories(repoNamespace).Get(repoName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 	hasCredentials := appRepo.Spec.Auth.Header != nil || appRepo.Spec.Auth.CustomCA != nil
-	err = a.clientset.KubeappsV1alpha1().AppRepositories(repoNamespace).Delete(repoName, &metav1.DeleteOptions{})
+	err = a.clientset.KubeappsV1beta1().AppRepositories(repoNamespace).Delete(repoName, &metav1.DeleteOptions{})
 	if err != nil {
 		return err
 	}
@@ -357,7 +424,8 @@ func (a *userHandler) DeleteAppRepository(repoName, repoNamespace string) error
 	return err
 }
 
-func getValidationCliAndReq(appRepoBody io.ReadCloser, requestNamespace, kubeappsNamespace string) (HTTPClient, *http.Request, error) {
+// parseValidationRequest returns the AppRepository to validate and its secret
+func parseValidationRequest(appRepoBody io.ReadCloser, requestNamespace, kubeappsNamespace string) (*v1beta1.AppRepository, *corev1.Secret, error) {
 	appRepo, repoSecret, err := parseRepoAndSecret(appRepoBody)
 	if err != nil {
 		return nil, nil, err
@@ -367,17 +435,15 @@ func getValidationCliAndReq(appRepoBody io.ReadCloser, requestNamespace, kubeapp
 		// already exist in the namespace.
 		return nil, nil, ErrGlobalRepositoryWithSecrets
 	}
+	return appRepo, repoSecret, nil
+}
 
-	cli, err := InitNetClient(appRepo, repoSecret, repoSecret, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("Unable to create HTTP client: %w", err)
-	}
-	indexURL := strings.TrimSuffix(strings.TrimSpace(appRepo.Spec.URL), "/") + "/index.yaml"
-	req, err := http.NewRequest("GET", indexURL, nil)
+func getValidationCliAndReq(appRepoBody io.ReadCloser, requestNamespace, kubeappsNamespace string) (HTTPClient, *http.Request, error) {
+	appRepo, repoSecret, err := parseValidationRequest(appRepoBody, requestNamespace, kubeappsNamespace)
 	if err != nil {
 		return nil, nil, err
 	}
-	return cli, req, nil
+	return appRepositoryIndexRequest(appRepo, repoSecret, repoSecret)
 }
 
 func (a *userHandler) ValidateAppRepository(appRepoBody io.ReadCloser, requestNamespace string) (*http.Response, error) {
@@ -390,63 +456,106 @@ func (a *userHandler) ValidateAppRepository(appRepoBody io.ReadCloser, requestNa
 	return cli.Do(req)
 }
 
+// ValidateAppRepositoryReport validates the index of an AppRepository and a
+// sample of its charts, with its credentials, and returns the report.
+func (a *userHandler) ValidateAppRepositoryReport(appRepoBody io.ReadCloser, requestNamespace string, sampleSize int) (*repovalidation.Report, error) {
+	appRepo, repoSecret, err := parseValidationRequest(appRepoBody, requestNamespace, a.kubeappsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := InitNetClient(appRepo, repoSecret, repoSecret, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create HTTP client: %w", err)
+	}
+	auth := appRepo.Spec.Auth
+	return repovalidation.Validate(cli, appRepo.Spec.URL, repovalidation.Options{
+		Authenticated: auth.Header != nil || auth.BasicAuth != nil || auth.BearerToken != nil || auth.ClientCert != nil,
+		SampleSize:    sampleSize,
+	}), nil
+}
+
 // GetAppRepository returns an AppRepository resource from a namespace.
 // Optionally set a token to get the AppRepository using a custom serviceaccount
-func (a *userHandler) GetAppRepository(repoName, repoNamespace string) (*v1alpha1.AppRepository, error) {
-	return a.clientset.KubeappsV1alpha1().AppRepositories(repoNamespace).Get(repoName, metav1.GetOptions{})
+func (a *userHandler) GetAppRepository(repoName, repoNamespace string) (*v1beta1.AppRepository, error) {
+	return a.clientset.KubeappsV1beta1().AppRepositories(repoNamespace).Get(repoName, metav1.GetOptions{})
 }
 
 // appRepositoryForRequest takes care of parsing the request data into an AppRepository.
-func appRepositoryForRequest(appRepoRequest appRepositoryRequest) *v1alpha1.AppRepository {
+func appRepositoryForRequest(appRepoRequest appRepositoryRequest) *v1beta1.AppRepository {
 	appRepo := appRepoRequest.AppRepository
 
-	var auth v1alpha1.AppRepositoryAuth
-	if appRepo.AuthHeader != "" || appRepo.CustomCA != "" {
-		secretName := secretNameForRepo(appRepo.Name)
-		if appRepo.AuthHeader != "" {
-			auth.Header = &v1alpha1.AppRepositoryAuthHeader{
-				SecretKeyRef: corev1.SecretKeySelector{
-					Key: "authorizationHeader",
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: secretName,
-					},
-				},
-			}
+	var auth v1beta1.AppRepositoryAuth
+	secretName := secretNameForRepo(appRepo.Name)
+	keyRef := func(key string) corev1.SecretKeySelector {
+		return corev1.SecretKeySelector{
+			Key: key,
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: secretName,
+			},
 		}
-		if appRepo.CustomCA != "" {
-			auth.CustomCA = &v1alpha1.AppRepositoryCustomCA{
-				SecretKeyRef: corev1.SecretKeySelector{
-					Key: "ca.crt",
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: secretName,
-					},
-				},
-			}
+	}
+	if appRepo.AuthHeader != "" {
+		auth.Header = &v1beta1.AppRepositoryAuthHeader{
+			SecretKeyRef: keyRef("authorizationHeader"),
+		}
+	}
+	if appRepo.BasicAuthUsername != "" {
+		auth.BasicAuth = &v1beta1.AppRepositoryBasicAuth{
+			UsernameSecretKeyRef: keyRef("username"),
+			PasswordSecretKeyRef: keyRef("password"),
+		}
+	}
+	if appRepo.BearerToken != "" {
+		auth.BearerToken = &v1beta1.AppRepositoryBearerToken{
+			SecretKeyRef: keyRef("token"),
+		}
+	}
+	if appRepo.ClientCert != "" && appRepo.ClientKey != "" {
+		auth.ClientCert = &v1beta1.AppRepositoryClientCert{
+			CertSecretKeyRef: keyRef(corev1.TLSCertKey),
+			KeySecretKeyRef:  keyRef(corev1.TLSPrivateKeyKey),
+		}
+	}
+	if appRepo.CustomCA != "" {
+		auth.CustomCA = &v1beta1.AppRepositoryCustomCA{
+			SecretKeyRef: keyRef("ca.crt"),
 		}
 	}
 
-	return &v1alpha1.AppRepository{
+	return &v1beta1.AppRepository{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: appRepo.Name,
 		},
-		Spec: v1alpha1.AppRepositorySpec{
+		Spec: v1beta1.AppRepositorySpec{
 			URL:                   appRepo.RepoURL,
 			Type:                  "helm",
 			Auth:                  auth,
 			DockerRegistrySecrets: appRepo.RegistrySecrets,
 			SyncJobPodTemplate:    appRepo.SyncJobPodTemplate,
 			ResyncRequests:        appRepo.ResyncRequests,
+			Mirror:                appRepo.Mirror,
 		},
 	}
 }
 
 // secretForRequest takes care of parsing the request data into a secret for an AppRepository.
-func secretForRequest(appRepoRequest appRepositoryRequest, appRepo *v1alpha1.AppRepository) *corev1.Secret {
+func secretForRequest(appRepoRequest appRepositoryRequest, appRepo *v1beta1.AppRepository) *corev1.Secret {
 	appRepoDetails := appRepoRequest.AppRepository
 	secrets := map[string]string{}
 	if appRepoDetails.AuthHeader != "" {
 		secrets["authorizationHeader"] = appRepoDetails.AuthHeader
 	}
+	if appRepoDetails.BasicAuthUsername != "" {
+		secrets["username"] = appRepoDetails.BasicAuthUsername
+		secrets["password"] = appRepoDetails.BasicAuthPassword
+	}
+	if appRepoDetails.BearerToken != "" {
+		secrets["token"] = appRepoDetails.BearerToken
+	}
+	if appRepoDetails.ClientCert != "" && appRepoDetails.ClientKey != "" {
+		secrets[corev1.TLSCertKey] = appRepoDetails.ClientCert
+		secrets[corev1.TLSPrivateKeyKey] = appRepoDetails.ClientKey
+	}
 	if appRepoDetails.CustomCA != "" {
 		secrets["ca.crt"] = appRepoDetails.CustomCA
 	}
@@ -460,7 +569,7 @@ func secretForRequest(appRepoRequest appRepositoryRequest, appRepo *v1alpha1.App
 			Name: secretNameForRepo(appRepo.Name),
 			OwnerReferences: []metav1.OwnerReference{
 				metav1.OwnerReference{
-					APIVersion:         "kubeapps.com/v1alpha1",
+					APIVersion:         "kubeapps.com/v1beta1",
 					Kind:               "AppRepository",
 					Name:               appRepo.ObjectMeta.Name,
 					UID:                appRepo.ObjectMeta.UID,
@@ -482,49 +591,88 @@ func KubeappsSecretNameForRepo(repoName, namespace string) string {
 	return fmt.Sprintf("%s-%s", namespace, secretNameForRepo(repoName))
 }
 
-func filterAllowedNamespaces(userClientset combinedClientsetInterface, namespaces *corev1.NamespaceList) ([]corev1.Namespace, error) {
-	allowedNamespaces := []corev1.Namespace{}
-	for _, namespace := range namespaces.Items {
-		res, err := userClientset.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorizationapi.SelfSubjectAccessReview{
-			Spec: authorizationapi.SelfSubjectAccessReviewSpec{
-				ResourceAttributes: &authorizationapi.ResourceAttributes{
-					Group:     "",
-					Resource:  "secrets",
-					Verb:      "get",
-					Namespace: namespace.Name,
-				},
-			},
-		})
-		if err != nil {
-			return nil, err
-		}
-		if res.Status.Allowed {
-			allowedNamespaces = append(allowedNamespaces, namespace)
+// NamespaceTemplate defines the defaults applied to the namespaces created
+// for a user, for example when installing a release in a new namespace.
+type NamespaceTemplate struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// ResourceQuota and LimitRange are optionally created in the new
+	// namespace. Their name defaults to "default".
+	ResourceQuota *corev1.ResourceQuota `json:"resourceQuota,omitempty"`
+	LimitRange    *corev1.LimitRange    `json:"limitRange,omitempty"`
+}
+
+const defaultNamespaceTemplateResourceName = "default"
+
+// NamespaceTemplateAnnotation marks the namespaces created with a template, so
+// that its default resources are only ever applied to those namespaces.
+const NamespaceTemplateAnnotation = "kubeapps.com/namespace-template"
+
+// ParseNamespaceTemplate parses a YAML (or JSON) namespace template.
+func ParseNamespaceTemplate(data []byte) (*NamespaceTemplate, error) {
+	template := &NamespaceTemplate{}
+	err := yaml.Unmarshal(data, template)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse namespace template: %v", err)
+	}
+	return template, nil
+}
+
+// CreateNamespace creates a namespace with the labels and annotations of the
+// template (which may be nil). The default resources of the template are
+// created separately by ApplyNamespaceTemplate.
+func (a *userHandler) CreateNamespace(name string, template *NamespaceTemplate) (*corev1.Namespace, error) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if template != nil {
+		namespace.ObjectMeta.Labels = template.Labels
+		namespace.ObjectMeta.Annotations = map[string]string{NamespaceTemplateAnnotation: "true"}
+		for k, v := range template.Annotations {
+			namespace.ObjectMeta.Annotations[k] = v
 		}
 	}
-	return allowedNamespaces, nil
+	return a.clientset.CoreV1().Namespaces().Create(namespace)
 }
 
-// GetNamespaces return the list of namespaces that the user has permission to access
-func (a *userHandler) GetNamespaces() ([]corev1.Namespace, error) {
-	// Try to list namespaces with the user token, for backward compatibility
-	namespaces, err := a.clientset.CoreV1().Namespaces().List(metav1.ListOptions{})
+// ApplyNamespaceTemplate creates the resource quota and limit range of the
+// template in a namespace created with a template. Resources which already
+// exist are left as they are, so it can be retried after a partial failure.
+// Namespaces which were not created with a template are not modified.
+func (a *userHandler) ApplyNamespaceTemplate(name string, template *NamespaceTemplate) error {
+	if template == nil || (template.ResourceQuota == nil && template.LimitRange == nil) {
+		return nil
+	}
+	namespace, err := a.clientset.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
 	if err != nil {
-		if k8sErrors.IsForbidden(err) {
-			// The user doesn't have permissions to list namespaces, use the current serviceaccount
-			namespaces, err = a.svcClientset.CoreV1().Namespaces().List(metav1.ListOptions{})
+		return err
+	}
+	if _, ok := namespace.ObjectMeta.Annotations[NamespaceTemplateAnnotation]; !ok {
+		return nil
+	}
+
+	if template.ResourceQuota != nil {
+		quota := template.ResourceQuota.DeepCopy()
+		quota.ObjectMeta.Namespace = name
+		if quota.ObjectMeta.Name == "" {
+			quota.ObjectMeta.Name = defaultNamespaceTemplateResourceName
 		}
-		if err != nil {
-			return nil, err
+		_, err = a.clientset.CoreV1().ResourceQuotas(name).Create(quota)
+		if err != nil && !k8sErrors.IsAlreadyExists(err) {
+			return fmt.Errorf("unable to create the resource quota for namespace %q: %v", name, err)
 		}
 	}
 
-	allowedNamespaces, err := filterAllowedNamespaces(a.clientset, namespaces)
-	if err != nil {
-		return nil, err
+	if template.LimitRange != nil {
+		limitRange := template.LimitRange.DeepCopy()
+		limitRange.ObjectMeta.Namespace = name
+		if limitRange.ObjectMeta.Name == "" {
+			limitRange.ObjectMeta.Name = defaultNamespaceTemplateResourceName
+		}
+		_, err = a.clientset.CoreV1().LimitRanges(name).Create(limitRange)
+		if err != nil && !k8sErrors.IsAlreadyExists(err) {
+			return fmt.Errorf("unable to create the limit range for namespace %q: %v", name, err)
+		}
 	}
-
-	return allowedNamespaces, nil
+	return nil
 }
 
 // GetSecret return the a secret from a namespace using a token if given
@@ -532,6 +680,11 @@ func (a *userHandler) GetSecret(name, namespace string) (*corev1.Secret, error)
 	return a.clientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
 }
 
+// GetConfigMap return a configmap from a namespace
+func (a *userHandler) GetConfigMap(name, namespace string) (*corev1.ConfigMap, error) {
+	return a.clientset.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+}
+
 // GetNamespaces return the list of namespaces that the user has permission to access
 func (a *userHandler) GetOperatorLogo(namespace, name string) ([]byte, error) {
 	return a.clientset.RestClient().Get().AbsPath(fmt.Sprintf("/apis/packages.operators.coreos.com/v1/namespaces/%s/packagemanifests/%s/icon", namespace, name)).Do().Raw()
@@ -29,6 +29,7 @@ import (
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
@@ -37,8 +38,9 @@ import (
 	fakeRest "k8s.io/client-go/rest/fake"
 	k8stesting "k8s.io/client-go/testing"
 
-	v1alpha1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1alpha1"
+	v1beta1 "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
 	fakeapprepoclientset "github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/client/clientset/versioned/fake"
+	"github.com/kubeapps/kubeapps/pkg/tokencache"
 )
 
 type repoStub struct {
@@ -56,14 +58,14 @@ func makeAppRepoObjects(reposPerNamespace map[string][]repoStub) []runtime.Objec
 	objects := []runtime.Object{}
 	for namespace, repoStubs := range reposPerNamespace {
 		for _, repoStub := range repoStubs {
-			appRepo := &v1alpha1.AppRepository{
+			appRepo := &v1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      repoStub.name,
 					Namespace: namespace,
 				},
 			}
 			if repoStub.private {
-				authHeader := &v1alpha1.AppRepositoryAuthHeader{}
+				authHeader := &v1beta1.AppRepositoryAuthHeader{}
 				authHeader.SecretKeyRef.LocalObjectReference.Name = secretNameForRepo(repoStub.name)
 				appRepo.Spec.Auth.Header = authHeader
 			}
@@ -150,7 +152,7 @@ func checkErr(t *testing.T, err error, expectedError error) {
 	}
 }
 
-func checkAppRepo(t *testing.T, requestData string, requestNamespace string, cs fakeCombinedClientset) (appRepositoryRequest, *v1alpha1.AppRepository, *v1alpha1.AppRepository) {
+func checkAppRepo(t *testing.T, requestData string, requestNamespace string, cs fakeCombinedClientset) (appRepositoryRequest, *v1beta1.AppRepository, *v1beta1.AppRepository) {
 	var appRepoRequest appRepositoryRequest
 	err := json.NewDecoder(strings.NewReader(requestData)).Decode(&appRepoRequest)
 	if err != nil {
@@ -161,7 +163,7 @@ func checkAppRepo(t *testing.T, requestData string, requestNamespace string, cs
 	expectedAppRepo := appRepositoryForRequest(appRepoRequest)
 	expectedAppRepo.ObjectMeta.Namespace = requestNamespace
 
-	responseAppRepo, err := cs.KubeappsV1alpha1().AppRepositories(requestNamespace).Get(expectedAppRepo.ObjectMeta.Name, metav1.GetOptions{})
+	responseAppRepo, err := cs.KubeappsV1beta1().AppRepositories(requestNamespace).Get(expectedAppRepo.ObjectMeta.Name, metav1.GetOptions{})
 	if err != nil {
 		t.Fatalf("expected data %v not present: %+v", expectedAppRepo, err)
 	}
@@ -172,7 +174,7 @@ func checkAppRepo(t *testing.T, requestData string, requestNamespace string, cs
 	return appRepoRequest, expectedAppRepo, responseAppRepo
 }
 
-func checkSecrets(t *testing.T, requestNamespace string, appRepoRequest appRepositoryRequest, expectedAppRepo *v1alpha1.AppRepository, responseAppRepo *v1alpha1.AppRepository, handler userHandler) {
+func checkSecrets(t *testing.T, requestNamespace string, appRepoRequest appRepositoryRequest, expectedAppRepo *v1beta1.AppRepository, responseAppRepo *v1beta1.AppRepository, handler userHandler) {
 	// TODO(#1655)
 	// The fake k8s API does not generate UID's for created object
 	// (among other things). We would need to add reactors to the fake
@@ -319,6 +321,68 @@ func TestAppRepositoryCreate(t *testing.T) {
 	}
 }
 
+func TestAppRepositoryCreateSecretFirst(t *testing.T) {
+	requestData := `{"appRepository": {"name": "test-repo", "url": "http://example.com/test-repo", "authHeader": "test-me"}}`
+	testCases := []struct {
+		name          string
+		createError   error
+		expectSecrets bool
+	}{
+		{
+			name:          "it creates the secrets before the app repository",
+			expectSecrets: true,
+		},
+		{
+			name:          "it deletes the secrets when the app repository is rejected",
+			createError:   errors.New("admission webhook denied the request"),
+			expectSecrets: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := fakeCombinedClientset{
+				fakeapprepoclientset.NewSimpleClientset(),
+				fakecoreclientset.NewSimpleClientset(),
+				&fakeRest.RESTClient{},
+			}
+			handler := userHandler{
+				kubeappsNamespace: kubeappsNamespace,
+				svcClientset:      cs,
+				clientset:         cs,
+			}
+			secretsOnCreate := []string{}
+			cs.fakeAppRepoClientset.PrependReactor("create", "apprepositories", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				for _, ns := range []string{"test-namespace", kubeappsNamespace} {
+					secrets, err := cs.Clientset.CoreV1().Secrets(ns).List(metav1.ListOptions{})
+					if err != nil {
+						t.Fatalf("%+v", err)
+					}
+					for _, s := range secrets.Items {
+						secretsOnCreate = append(secretsOnCreate, ns+"/"+s.Name)
+					}
+				}
+				return tc.createError != nil, nil, tc.createError
+			})
+
+			_, err := handler.CreateAppRepository(ioutil.NopCloser(strings.NewReader(requestData)), "test-namespace")
+			checkErr(t, err, tc.createError)
+
+			if got, want := secretsOnCreate, []string{"test-namespace/apprepo-test-repo", "kubeapps/test-namespace-apprepo-test-repo"}; !cmp.Equal(want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+			_, err = cs.Clientset.CoreV1().Secrets("test-namespace").Get("apprepo-test-repo", metav1.GetOptions{})
+			if got, want := err == nil, tc.expectSecrets; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+			_, err = cs.Clientset.CoreV1().Secrets(kubeappsNamespace).Get("test-namespace-apprepo-test-repo", metav1.GetOptions{})
+			if got, want := err == nil, tc.expectSecrets; got != want {
+				t.Errorf("got: %t, want: %t", got, want)
+			}
+		})
+	}
+}
+
 func TestAppRepositoryUpdate(t *testing.T) {
 	const kubeappsNamespace = "kubeapps"
 	testCases := []struct {
@@ -474,7 +538,7 @@ func TestDeleteAppRepository(t *testing.T) {
 
 			if err == nil {
 				// Ensure the repo has been deleted, so expecting a 404.
-				_, err = cs.KubeappsV1alpha1().AppRepositories(tc.requestNamespace).Get(tc.repoName, metav1.GetOptions{})
+				_, err = cs.KubeappsV1beta1().AppRepositories(tc.requestNamespace).Get(tc.repoName, metav1.GetOptions{})
 				if got, want := errorCodeForK8sError(t, err), 404; got != want {
 					t.Errorf("got: %d, want: %d", got, want)
 				}
@@ -526,7 +590,7 @@ func TestAppRepositoryForRequest(t *testing.T) {
 	testCases := []struct {
 		name    string
 		request appRepositoryRequestDetails
-		appRepo v1alpha1.AppRepository
+		appRepo v1beta1.AppRepository
 	}{
 		{
 			name: "it creates an app repo without auth",
@@ -534,11 +598,11 @@ func TestAppRepositoryForRequest(t *testing.T) {
 				Name:    "test-repo",
 				RepoURL: "http://example.com/test-repo",
 			},
-			appRepo: v1alpha1.AppRepository{
+			appRepo: v1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test-repo",
 				},
-				Spec: v1alpha1.AppRepositorySpec{
+				Spec: v1beta1.AppRepositorySpec{
 					URL:  "http://example.com/test-repo",
 					Type: "helm",
 				},
@@ -551,15 +615,15 @@ func TestAppRepositoryForRequest(t *testing.T) {
 				RepoURL:    "http://example.com/test-repo",
 				AuthHeader: "testing",
 			},
-			appRepo: v1alpha1.AppRepository{
+			appRepo: v1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test-repo",
 				},
-				Spec: v1alpha1.AppRepositorySpec{
+				Spec: v1beta1.AppRepositorySpec{
 					URL:  "http://example.com/test-repo",
 					Type: "helm",
-					Auth: v1alpha1.AppRepositoryAuth{
-						Header: &v1alpha1.AppRepositoryAuthHeader{
+					Auth: v1beta1.AppRepositoryAuth{
+						Header: &v1beta1.AppRepositoryAuthHeader{
 							SecretKeyRef: corev1.SecretKeySelector{
 								LocalObjectReference: corev1.LocalObjectReference{
 									Name: "apprepo-test-repo",
@@ -578,15 +642,15 @@ func TestAppRepositoryForRequest(t *testing.T) {
 				RepoURL:  "http://example.com/test-repo",
 				CustomCA: "test-me",
 			},
-			appRepo: v1alpha1.AppRepository{
+			appRepo: v1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test-repo",
 				},
-				Spec: v1alpha1.AppRepositorySpec{
+				Spec: v1beta1.AppRepositorySpec{
 					URL:  "http://example.com/test-repo",
 					Type: "helm",
-					Auth: v1alpha1.AppRepositoryAuth{
-						CustomCA: &v1alpha1.AppRepositoryCustomCA{
+					Auth: v1beta1.AppRepositoryAuth{
+						CustomCA: &v1beta1.AppRepositoryCustomCA{
 							SecretKeyRef: corev1.SecretKeySelector{
 								LocalObjectReference: corev1.LocalObjectReference{
 									Name: "apprepo-test-repo",
@@ -598,6 +662,101 @@ func TestAppRepositoryForRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "it creates an app repo with basic auth and a bearer token",
+			request: appRepositoryRequestDetails{
+				Name:              "test-repo",
+				RepoURL:           "http://example.com/test-repo",
+				BasicAuthUsername: "user",
+				BasicAuthPassword: "pass",
+				BearerToken:       "token",
+			},
+			appRepo: v1beta1.AppRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-repo",
+				},
+				Spec: v1beta1.AppRepositorySpec{
+					URL:  "http://example.com/test-repo",
+					Type: "helm",
+					Auth: v1beta1.AppRepositoryAuth{
+						BasicAuth: &v1beta1.AppRepositoryBasicAuth{
+							UsernameSecretKeyRef: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: "apprepo-test-repo",
+								},
+								Key: "username",
+							},
+							PasswordSecretKeyRef: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: "apprepo-test-repo",
+								},
+								Key: "password",
+							},
+						},
+						BearerToken: &v1beta1.AppRepositoryBearerToken{
+							SecretKeyRef: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: "apprepo-test-repo",
+								},
+								Key: "token",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "it creates an app repo with a client certificate",
+			request: appRepositoryRequestDetails{
+				Name:       "test-repo",
+				RepoURL:    "http://example.com/test-repo",
+				ClientCert: "cert",
+				ClientKey:  "key",
+			},
+			appRepo: v1beta1.AppRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-repo",
+				},
+				Spec: v1beta1.AppRepositorySpec{
+					URL:  "http://example.com/test-repo",
+					Type: "helm",
+					Auth: v1beta1.AppRepositoryAuth{
+						ClientCert: &v1beta1.AppRepositoryClientCert{
+							CertSecretKeyRef: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: "apprepo-test-repo",
+								},
+								Key: "tls.crt",
+							},
+							KeySecretKeyRef: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: "apprepo-test-repo",
+								},
+								Key: "tls.key",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "it creates an app repo with mirroring",
+			request: appRepositoryRequestDetails{
+				Name:    "test-repo",
+				RepoURL: "http://example.com/test-repo",
+				Mirror:  true,
+			},
+			appRepo: v1beta1.AppRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-repo",
+				},
+				Spec: v1beta1.AppRepositorySpec{
+					URL:    "http://example.com/test-repo",
+					Type:   "helm",
+					Mirror: true,
+				},
+			},
+		},
 		{
 			name: "it creates an app repo with a sync job",
 			request: appRepositoryRequestDetails{
@@ -609,11 +768,11 @@ func TestAppRepositoryForRequest(t *testing.T) {
 					},
 				},
 			},
-			appRepo: v1alpha1.AppRepository{
+			appRepo: v1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test-repo",
 				},
-				Spec: v1alpha1.AppRepositorySpec{
+				Spec: v1beta1.AppRepositorySpec{
 					URL:  "http://example.com/test-repo",
 					Type: "helm",
 					SyncJobPodTemplate: corev1.PodTemplateSpec{
@@ -631,11 +790,11 @@ func TestAppRepositoryForRequest(t *testing.T) {
 				RepoURL:        "http://example.com/test-repo",
 				ResyncRequests: 99,
 			},
-			appRepo: v1alpha1.AppRepository{
+			appRepo: v1beta1.AppRepository{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test-repo",
 				},
-				Spec: v1alpha1.AppRepositorySpec{
+				Spec: v1beta1.AppRepositorySpec{
 					URL:            "http://example.com/test-repo",
 					Type:           "helm",
 					ResyncRequests: 99,
@@ -655,7 +814,7 @@ func TestAppRepositoryForRequest(t *testing.T) {
 
 func TestSecretForRequest(t *testing.T) {
 	// Reuse the same app repo metadata for each test.
-	appRepo := v1alpha1.AppRepository{
+	appRepo := v1beta1.AppRepository{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "AppRepository",
 			APIVersion: "v1",
@@ -670,7 +829,7 @@ func TestSecretForRequest(t *testing.T) {
 	blockOwnerDeletion := true
 	ownerRefs := []metav1.OwnerReference{
 		metav1.OwnerReference{
-			APIVersion:         "kubeapps.com/v1alpha1",
+			APIVersion:         "kubeapps.com/v1beta1",
 			Kind:               "AppRepository",
 			Name:               "test-repo",
 			UID:                "abcd1234",
@@ -725,6 +884,31 @@ func TestSecretForRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "it creates a secret with typed credentials",
+			request: appRepositoryRequestDetails{
+				Name:              "test-repo",
+				RepoURL:           "http://example.com/test-repo",
+				BasicAuthUsername: "user",
+				BasicAuthPassword: "pass",
+				BearerToken:       "token",
+				ClientCert:        "cert",
+				ClientKey:         "key",
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "apprepo-test-repo",
+					OwnerReferences: ownerRefs,
+				},
+				StringData: map[string]string{
+					"username": "user",
+					"password": "pass",
+					"token":    "token",
+					"tls.crt":  "cert",
+					"tls.key":  "key",
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -739,27 +923,67 @@ func TestSecretForRequest(t *testing.T) {
 func TestGetNamespaces(t *testing.T) {
 	testCases := []struct {
 		name             string
-		existingNS       []string
-		expectedResponse []corev1.Namespace
-		allowed          bool
+		existingNS       []corev1.Namespace
+		options          NamespaceListOptions
+		allowed          map[string]bool
+		expectedResponse []Namespace
+		expectedReviews  int
 	}{
 		{
 			name:       "it list namespaces",
-			existingNS: []string{"foo"},
-			expectedResponse: []corev1.Namespace{
-				corev1.Namespace{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "foo",
-					},
+			existingNS: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+			allowed:    map[string]bool{"get/secrets/foo": true},
+			expectedResponse: []Namespace{
+				{
+					Namespace: corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+					Access:    NamespaceAccess{ReadReleases: true},
 				},
 			},
-			allowed: true,
+			expectedReviews: 3,
 		},
 		{
 			name:             "it returns an empty list if not allowed",
-			existingNS:       []string{"foo"},
-			expectedResponse: []corev1.Namespace{},
-			allowed:          false,
+			existingNS:       []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+			allowed:          map[string]bool{},
+			expectedResponse: []Namespace{},
+			expectedReviews:  1,
+		},
+		{
+			name:       "it returns the access of the user to each namespace",
+			existingNS: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+			allowed: map[string]bool{
+				"get/secrets/foo":            true,
+				"create/secrets/foo":         true,
+				"create/apprepositories/foo": true,
+			},
+			expectedResponse: []Namespace{
+				{
+					Namespace: corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+					Access:    NamespaceAccess{ReadReleases: true, CreateSecrets: true, ManageRepos: true},
+				},
+			},
+			expectedReviews: 3,
+		},
+		{
+			name: "it filters namespaces by name prefix and label selector",
+			existingNS: []corev1.Namespace{
+				{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"env": "dev"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"env": "prod"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{"env": "dev"}}},
+			},
+			options: NamespaceListOptions{LabelSelector: "env=dev", NamePrefix: "team-"},
+			allowed: map[string]bool{
+				"get/secrets/team-a": true,
+				"get/secrets/team-b": true,
+				"get/secrets/other":  true,
+			},
+			expectedResponse: []Namespace{
+				{
+					Namespace: corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"env": "dev"}}},
+					Access:    NamespaceAccess{ReadReleases: true},
+				},
+			},
+			expectedReviews: 3,
 		},
 	}
 	for _, tc := range testCases {
@@ -771,20 +995,20 @@ func TestGetNamespaces(t *testing.T) {
 			}
 
 			for _, ns := range tc.existingNS {
-				cs.Clientset.CoreV1().Namespaces().Create(&corev1.Namespace{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: ns,
-					},
-				})
+				cs.Clientset.CoreV1().Namespaces().Create(ns.DeepCopy())
 			}
 
+			reviews := 0
 			cs.Clientset.Fake.PrependReactor(
 				"create",
 				"selfsubjectaccessreviews",
 				func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+					review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+					attr := review.Spec.ResourceAttributes
+					reviews++
 					mysar := &authorizationv1.SelfSubjectAccessReview{
 						Status: authorizationv1.SubjectAccessReviewStatus{
-							Allowed: tc.allowed,
+							Allowed: tc.allowed[fmt.Sprintf("%s/%s/%s", attr.Verb, attr.Resource, attr.Namespace)],
 							Reason:  "I want to test it",
 						},
 					}
@@ -793,12 +1017,13 @@ func TestGetNamespaces(t *testing.T) {
 			)
 
 			handler := kubeHandler{
-				clientsetForConfig: func(*rest.Config) (combinedClientsetInterface, error) { return cs, nil },
-				kubeappsNamespace:  "kubeapps",
-				svcClientset:       cs,
+				clientsetForConfig:   func(*rest.Config) (combinedClientsetInterface, error) { return cs, nil },
+				kubeappsNamespace:    "kubeapps",
+				svcClientset:         cs,
+				namespaceAccessCache: tokencache.New(namespaceAccessCacheTTL),
 			}
 
-			namespaces, err := handler.AsSVC().GetNamespaces()
+			namespaces, err := handler.AsUser("token").GetNamespaces(tc.options)
 			if err != nil {
 				t.Errorf("Unexpected error %v", err)
 			}
@@ -806,6 +1031,15 @@ func TestGetNamespaces(t *testing.T) {
 			if !cmp.Equal(namespaces, tc.expectedResponse) {
 				t.Errorf("Unexpected response: %s", cmp.Diff(namespaces, tc.expectedResponse))
 			}
+
+			// A second request with the same token is served from the cache.
+			_, err = handler.AsUser("token").GetNamespaces(tc.options)
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+			if got, want := reviews, tc.expectedReviews; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
 		})
 	}
 }
@@ -859,3 +1093,159 @@ func TestValidateAppRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateNamespace(t *testing.T) {
+	quota := &corev1.ResourceQuota{
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+		},
+	}
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "limits"},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{Type: corev1.LimitTypeContainer, Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}},
+			},
+		},
+	}
+	testCases := []struct {
+		name               string
+		existingNS         []corev1.Namespace
+		existingQuota      *corev1.ResourceQuota
+		template           *NamespaceTemplate
+		expectedNamespace  *corev1.Namespace
+		expectedQuota      *corev1.ResourceQuota
+		expectedLimitRange *corev1.LimitRange
+		expectedErrCode    int
+	}{
+		{
+			name:              "it creates a namespace without a template",
+			expectedNamespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+		},
+		{
+			name: "it creates a namespace with the template labels, annotations and resources",
+			template: &NamespaceTemplate{
+				Labels:        map[string]string{"team": "web"},
+				Annotations:   map[string]string{"owner": "kubeapps"},
+				ResourceQuota: quota,
+				LimitRange:    limitRange,
+			},
+			expectedNamespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "foo",
+				Labels:      map[string]string{"team": "web"},
+				Annotations: map[string]string{"owner": "kubeapps", NamespaceTemplateAnnotation: "true"},
+			}},
+			expectedQuota: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "foo"},
+				Spec:       quota.Spec,
+			},
+			expectedLimitRange: &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "foo"},
+				Spec:       limitRange.Spec,
+			},
+		},
+		{
+			name:            "it returns an error if the namespace exists",
+			existingNS:      []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+			expectedErrCode: 409,
+		},
+		{
+			name: "it applies the template resources missing from an existing namespace created with a template",
+			existingNS: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{
+				Name:        "foo",
+				Annotations: map[string]string{NamespaceTemplateAnnotation: "true"},
+			}}},
+			existingQuota: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "foo"},
+				Spec:       quota.Spec,
+			},
+			template: &NamespaceTemplate{
+				ResourceQuota: quota,
+				LimitRange:    limitRange,
+			},
+			expectedErrCode: 409,
+			expectedQuota: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "foo"},
+				Spec:       quota.Spec,
+			},
+			expectedLimitRange: &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "foo"},
+				Spec:       limitRange.Spec,
+			},
+		},
+		{
+			name:       "it does not apply the template resources to a namespace created without a template",
+			existingNS: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+			template: &NamespaceTemplate{
+				ResourceQuota: quota,
+			},
+			expectedErrCode: 409,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := fakeCombinedClientset{
+				fakeapprepoclientset.NewSimpleClientset(),
+				fakecoreclientset.NewSimpleClientset(),
+				&fakeRest.RESTClient{},
+			}
+			for _, ns := range tc.existingNS {
+				cs.Clientset.CoreV1().Namespaces().Create(ns.DeepCopy())
+			}
+			if tc.existingQuota != nil {
+				cs.Clientset.CoreV1().ResourceQuotas("foo").Create(tc.existingQuota)
+			}
+
+			handler := kubeHandler{
+				clientsetForConfig: func(*rest.Config) (combinedClientsetInterface, error) { return cs, nil },
+				kubeappsNamespace:  kubeappsNamespace,
+				svcClientset:       cs,
+			}
+
+			namespace, err := handler.AsUser("token").CreateNamespace("foo", tc.template)
+			if tc.expectedErrCode != 0 {
+				if got, want := errorCodeForK8sError(t, err), tc.expectedErrCode; got != want {
+					t.Errorf("got: %d, want: %d", got, want)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				if got, want := namespace, tc.expectedNamespace; !cmp.Equal(want, got) {
+					t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+				}
+			}
+
+			err = handler.AsSVC().ApplyNamespaceTemplate("foo", tc.template)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			quotas, err := cs.CoreV1().ResourceQuotas("foo").List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := len(quotas.Items), 0; tc.expectedQuota == nil && got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+
+			if tc.expectedQuota != nil {
+				got, err := cs.CoreV1().ResourceQuotas("foo").Get(tc.expectedQuota.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				if want := tc.expectedQuota; !cmp.Equal(want, got) {
+					t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+				}
+			}
+			if tc.expectedLimitRange != nil {
+				got, err := cs.CoreV1().LimitRanges("foo").Get(tc.expectedLimitRange.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				if want := tc.expectedLimitRange; !cmp.Equal(want, got) {
+					t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+				}
+			}
+		})
+	}
+}
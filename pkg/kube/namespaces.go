@@ -0,0 +1,174 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationapi "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// namespaceChecksConcurrency bounds the access reviews run at once when
+	// listing namespaces.
+	namespaceChecksConcurrency = 10
+	// namespaceAccessCacheTTL is how long the access of a user to a namespace
+	// is reused, so that RBAC changes are still picked up quickly.
+	namespaceAccessCacheTTL = 30 * time.Second
+)
+
+// NamespaceListOptions filters the namespaces returned by GetNamespaces.
+type NamespaceListOptions struct {
+	// LabelSelector is a k8s label selector, evaluated by the API server.
+	LabelSelector string
+	// NamePrefix only includes the namespaces with a name starting with it.
+	NamePrefix string
+}
+
+// NamespaceAccess describes what a user can do with Kubeapps in a namespace.
+type NamespaceAccess struct {
+	// ReadReleases is required for a namespace to be listed.
+	ReadReleases bool `json:"readReleases"`
+	// CreateSecrets is whether Helm 3 can store a release in the namespace.
+	// Installing a chart also requires creating its resources, which is only
+	// checked against the rendered manifest when installing.
+	CreateSecrets bool `json:"createSecrets"`
+	ManageRepos   bool `json:"manageRepos"`
+}
+
+// Namespace is a namespace with the access of the user to it.
+type Namespace struct {
+	corev1.Namespace
+	Access NamespaceAccess `json:"access"`
+}
+
+// namespaceAccessChecks are the access reviews for each field of a
+// NamespaceAccess.
+var namespaceAccessChecks = []struct {
+	attributes authorizationapi.ResourceAttributes
+	set        func(*NamespaceAccess, bool)
+}{
+	{
+		// Helm 3 releases are stored as secrets.
+		authorizationapi.ResourceAttributes{Group: "", Resource: "secrets", Verb: "get"},
+		func(a *NamespaceAccess, allowed bool) { a.ReadReleases = allowed },
+	},
+	{
+		authorizationapi.ResourceAttributes{Group: "", Resource: "secrets", Verb: "create"},
+		func(a *NamespaceAccess, allowed bool) { a.CreateSecrets = allowed },
+	},
+	{
+		authorizationapi.ResourceAttributes{Group: "kubeapps.com", Resource: "apprepositories", Verb: "create"},
+		func(a *NamespaceAccess, allowed bool) { a.ManageRepos = allowed },
+	},
+}
+
+// namespaceAccess returns the access of the user to the namespace. The
+// secrets and repository checks are skipped when the user cannot read the
+// namespace releases since the namespace will not be listed.
+func (a *userHandler) namespaceAccess(namespace string) (NamespaceAccess, error) {
+	key := fmt.Sprintf("%s/%s", a.tokenHash, namespace)
+	if a.namespaceAccessCache != nil {
+		if access, ok := a.namespaceAccessCache.Get(key); ok {
+			return access.(NamespaceAccess), nil
+		}
+	}
+
+	access := NamespaceAccess{}
+	for i, check := range namespaceAccessChecks {
+		attributes := check.attributes
+		attributes.Namespace = namespace
+		res, err := a.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorizationapi.SelfSubjectAccessReview{
+			Spec: authorizationapi.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		})
+		if err != nil {
+			return NamespaceAccess{}, err
+		}
+		check.set(&access, res.Status.Allowed)
+		if i == 0 && !access.ReadReleases {
+			break
+		}
+	}
+
+	if a.namespaceAccessCache != nil {
+		a.namespaceAccessCache.Set(key, access)
+	}
+	return access, nil
+}
+
+// filterAllowedNamespaces returns the namespaces in which the user can read
+// releases, checking up to namespaceChecksConcurrency namespaces at once.
+func (a *userHandler) filterAllowedNamespaces(namespaces []corev1.Namespace) ([]Namespace, error) {
+	accesses := make([]NamespaceAccess, len(namespaces))
+	errs := make([]error, len(namespaces))
+	semaphore := make(chan struct{}, namespaceChecksConcurrency)
+	var wg sync.WaitGroup
+	for i := range namespaces {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			accesses[i], errs[i] = a.namespaceAccess(namespaces[i].Name)
+		}(i)
+	}
+	wg.Wait()
+
+	allowedNamespaces := []Namespace{}
+	for i, namespace := range namespaces {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if accesses[i].ReadReleases {
+			allowedNamespaces = append(allowedNamespaces, Namespace{Namespace: namespace, Access: accesses[i]})
+		}
+	}
+	return allowedNamespaces, nil
+}
+
+// GetNamespaces return the list of namespaces that the user has permission to access
+func (a *userHandler) GetNamespaces(options NamespaceListOptions) ([]Namespace, error) {
+	listOptions := metav1.ListOptions{LabelSelector: options.LabelSelector}
+	// Try to list namespaces with the user token, for backward compatibility
+	namespaces, err := a.clientset.CoreV1().Namespaces().List(listOptions)
+	if err != nil {
+		if k8sErrors.IsForbidden(err) {
+			// The user doesn't have permissions to list namespaces, use the current serviceaccount
+			namespaces, err = a.svcClientset.CoreV1().Namespaces().List(listOptions)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := []corev1.Namespace{}
+	for _, namespace := range namespaces.Items {
+		if strings.HasPrefix(namespace.Name, options.NamePrefix) {
+			candidates = append(candidates, namespace)
+		}
+	}
+
+	return a.filterAllowedNamespaces(candidates)
+}
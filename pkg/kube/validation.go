@@ -0,0 +1,316 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// syncContainerName is the name given by the controller to the first
+// container of the pod template of the sync jobs.
+const syncContainerName = "sync"
+
+// SecretGetter returns a secret of the namespace of an AppRepository
+type SecretGetter func(name string) (*corev1.Secret, error)
+
+// ValidateAppRepositorySpec returns the errors of the spec of an
+// AppRepository, without looking up the secrets it references.
+func ValidateAppRepositorySpec(appRepo *v1beta1.AppRepository, kubeappsNamespace string) field.ErrorList {
+	specPath := field.NewPath("spec")
+	spec := appRepo.Spec
+	errs := field.ErrorList{}
+
+	errs = append(errs, validateRepoURL(spec.URL, specPath.Child("url"))...)
+	if spec.Type != "" && spec.Type != "helm" {
+		errs = append(errs, field.NotSupported(specPath.Child("type"), spec.Type, []string{"helm"}))
+	}
+
+	secretsPath := specPath.Child("dockerRegistrySecrets")
+	if len(spec.DockerRegistrySecrets) > 0 && appRepo.GetNamespace() == kubeappsNamespace {
+		errs = append(errs, field.Forbidden(secretsPath, ErrGlobalRepositoryWithSecrets.Error()))
+	}
+	for i, name := range spec.DockerRegistrySecrets {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			errs = append(errs, field.Invalid(secretsPath.Index(i), name, msg))
+		}
+	}
+
+	errs = append(errs, validateAuth(spec.Auth, specPath.Child("auth"))...)
+	if spec.Webhook != nil {
+		errs = append(errs, validateSecretKeyRef(spec.Webhook.SecretKeyRef, specPath.Child("webhook", "secretKeyRef"))...)
+	}
+	errs = append(errs, validateSyncJobPodTemplate(spec, specPath.Child("syncJobPodTemplate"))...)
+	return errs
+}
+
+func validateRepoURL(repoURL string, fldPath *field.Path) field.ErrorList {
+	if strings.TrimSpace(repoURL) == "" {
+		return field.ErrorList{field.Required(fldPath, "")}
+	}
+	u, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, repoURL, err.Error())}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return field.ErrorList{field.Invalid(fldPath, repoURL, "must be an http or https URL")}
+	}
+	if u.Host == "" {
+		return field.ErrorList{field.Invalid(fldPath, repoURL, "must have a host")}
+	}
+	return nil
+}
+
+func validateSecretKeyRef(ref corev1.SecretKeySelector, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if ref.Name == "" {
+		errs = append(errs, field.Required(fldPath.Child("name"), ""))
+	}
+	if ref.Key == "" {
+		return append(errs, field.Required(fldPath.Child("key"), ""))
+	}
+	for _, msg := range validation.IsConfigMapKey(ref.Key) {
+		errs = append(errs, field.Invalid(fldPath.Child("key"), ref.Key, msg))
+	}
+	return errs
+}
+
+// validateAuth checks that a single kind of Authorization header is set and
+// that the credentials are read from a single secret, as AuthSecretName
+// expects.
+func validateAuth(auth v1beta1.AppRepositoryAuth, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	refs := []secretKeyRefPath{}
+	headers := []string{}
+	if auth.Header != nil {
+		headers = append(headers, "header")
+		refs = append(refs, secretKeyRefPath{auth.Header.SecretKeyRef, fldPath.Child("header", "secretKeyRef")})
+	}
+	if auth.BasicAuth != nil {
+		headers = append(headers, "basicAuth")
+		refs = append(refs,
+			secretKeyRefPath{auth.BasicAuth.UsernameSecretKeyRef, fldPath.Child("basicAuth", "usernameSecretKeyRef")},
+			secretKeyRefPath{auth.BasicAuth.PasswordSecretKeyRef, fldPath.Child("basicAuth", "passwordSecretKeyRef")})
+	}
+	if auth.BearerToken != nil {
+		headers = append(headers, "bearerToken")
+		refs = append(refs, secretKeyRefPath{auth.BearerToken.SecretKeyRef, fldPath.Child("bearerToken", "secretKeyRef")})
+	}
+	if auth.ClientCert != nil {
+		refs = append(refs,
+			secretKeyRefPath{auth.ClientCert.CertSecretKeyRef, fldPath.Child("clientCert", "certSecretKeyRef")},
+			secretKeyRefPath{auth.ClientCert.KeySecretKeyRef, fldPath.Child("clientCert", "keySecretKeyRef")})
+	}
+	if len(headers) > 1 {
+		errs = append(errs, field.Forbidden(fldPath, fmt.Sprintf("only one of %s may be set", strings.Join(headers, ", "))))
+	}
+
+	authSecretName := AuthSecretName(auth)
+	for _, r := range refs {
+		errs = append(errs, validateSecretKeyRef(r.ref, r.path)...)
+		if r.ref.Name != "" && r.ref.Name != authSecretName {
+			errs = append(errs, field.Invalid(r.path.Child("name"), r.ref.Name, fmt.Sprintf("the credentials must be read from a single secret, %q", authSecretName)))
+		}
+	}
+	if auth.CustomCA != nil {
+		errs = append(errs, validateSecretKeyRef(auth.CustomCA.SecretKeyRef, fldPath.Child("customCA", "secretKeyRef"))...)
+	}
+	return errs
+}
+
+type secretKeyRefPath struct {
+	ref  corev1.SecretKeySelector
+	path *field.Path
+}
+
+// validateSyncJobPodTemplate checks the pod template the controller completes
+// with the sync container, which is its first container.
+func validateSyncJobPodTemplate(spec v1beta1.AppRepositorySpec, fldPath *field.Path) field.ErrorList {
+	template := spec.SyncJobPodTemplate
+	errs := metav1validation.ValidateLabels(template.ObjectMeta.Labels, fldPath.Child("metadata", "labels"))
+	errs = append(errs, apivalidation.ValidateAnnotations(template.ObjectMeta.Annotations, fldPath.Child("metadata", "annotations"))...)
+
+	volumes := sets.NewString()
+	if spec.Auth.CustomCA != nil {
+		// The custom CA is mounted by the controller.
+		volumes.Insert(spec.Auth.CustomCA.SecretKeyRef.Name)
+	}
+	volumesPath := fldPath.Child("spec", "volumes")
+	for i, v := range template.Spec.Volumes {
+		for _, msg := range validation.IsDNS1123Label(v.Name) {
+			errs = append(errs, field.Invalid(volumesPath.Index(i).Child("name"), v.Name, msg))
+		}
+		if volumes.Has(v.Name) {
+			errs = append(errs, field.Duplicate(volumesPath.Index(i).Child("name"), v.Name))
+		}
+		volumes.Insert(v.Name)
+	}
+
+	containers := sets.NewString()
+	containersPath := fldPath.Child("spec", "containers")
+	for i, c := range template.Spec.Containers {
+		cPath := containersPath.Index(i)
+		name := c.Name
+		if i == 0 {
+			// The name, image and command of the sync container are set by
+			// the controller.
+			name = syncContainerName
+		} else {
+			if c.Image == "" {
+				errs = append(errs, field.Required(cPath.Child("image"), ""))
+			}
+			for _, msg := range validation.IsDNS1123Label(c.Name) {
+				errs = append(errs, field.Invalid(cPath.Child("name"), c.Name, msg))
+			}
+		}
+		if containers.Has(name) {
+			errs = append(errs, field.Duplicate(cPath.Child("name"), name))
+		}
+		containers.Insert(name)
+		for j, m := range c.VolumeMounts {
+			if !volumes.Has(m.Name) {
+				errs = append(errs, field.NotFound(cPath.Child("volumeMounts").Index(j).Child("name"), m.Name))
+			}
+		}
+		for j, e := range c.Env {
+			for _, msg := range validation.IsEnvVarName(e.Name) {
+				errs = append(errs, field.Invalid(cPath.Child("env").Index(j).Child("name"), e.Name, msg))
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateAppRepositorySecrets returns the errors of the secrets referenced by
+// an AppRepository, which must exist in its namespace with the referenced
+// keys and the expected type.
+func ValidateAppRepositorySecrets(appRepo *v1beta1.AppRepository, getSecret SecretGetter) field.ErrorList {
+	specPath := field.NewPath("spec")
+	spec := appRepo.Spec
+	errs := field.ErrorList{}
+
+	secrets := map[string]*corev1.Secret{}
+	lookup := func(ref corev1.SecretKeySelector, fldPath *field.Path) ([]byte, bool) {
+		secret, ok := secrets[ref.Name]
+		if !ok {
+			var err error
+			secret, err = getSecret(ref.Name)
+			if err != nil {
+				errs = append(errs, field.Invalid(fldPath.Child("name"), ref.Name, fmt.Sprintf("unable to get the secret: %v", err)))
+				return nil, false
+			}
+			secrets[ref.Name] = secret
+		}
+		value, err := secretValue(secret, ref)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("key"), ref.Key, err.Error()))
+			return nil, false
+		}
+		return value, true
+	}
+
+	authPath := specPath.Child("auth")
+	auth := spec.Auth
+	if auth.Header != nil {
+		lookup(auth.Header.SecretKeyRef, authPath.Child("header", "secretKeyRef"))
+	}
+	if auth.BasicAuth != nil {
+		lookup(auth.BasicAuth.UsernameSecretKeyRef, authPath.Child("basicAuth", "usernameSecretKeyRef"))
+		lookup(auth.BasicAuth.PasswordSecretKeyRef, authPath.Child("basicAuth", "passwordSecretKeyRef"))
+	}
+	if auth.BearerToken != nil {
+		lookup(auth.BearerToken.SecretKeyRef, authPath.Child("bearerToken", "secretKeyRef"))
+	}
+	if auth.ClientCert != nil {
+		certPath := authPath.Child("clientCert")
+		certPEM, certOK := lookup(auth.ClientCert.CertSecretKeyRef, certPath.Child("certSecretKeyRef"))
+		keyPEM, keyOK := lookup(auth.ClientCert.KeySecretKeyRef, certPath.Child("keySecretKeyRef"))
+		if certOK && keyOK {
+			if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+				errs = append(errs, field.Invalid(certPath, auth.ClientCert.CertSecretKeyRef.Name, fmt.Sprintf("invalid client certificate: %v", err)))
+			}
+		}
+	}
+	if auth.CustomCA != nil {
+		caPath := authPath.Child("customCA", "secretKeyRef")
+		if ca, ok := lookup(auth.CustomCA.SecretKeyRef, caPath); ok && !x509.NewCertPool().AppendCertsFromPEM(ca) {
+			errs = append(errs, field.Invalid(caPath, auth.CustomCA.SecretKeyRef.Name, "no PEM encoded certificate found"))
+		}
+	}
+	if spec.Webhook != nil {
+		lookup(spec.Webhook.SecretKeyRef, specPath.Child("webhook", "secretKeyRef"))
+	}
+
+	for i, name := range spec.DockerRegistrySecrets {
+		fldPath := specPath.Child("dockerRegistrySecrets").Index(i)
+		secret, err := getSecret(name)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath, name, fmt.Sprintf("unable to get the secret: %v", err)))
+			continue
+		}
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			errs = append(errs, field.Invalid(fldPath, name, fmt.Sprintf("the secret must be of type %s", corev1.SecretTypeDockerConfigJson)))
+		}
+	}
+	return errs
+}
+
+// appRepositoryIndexRequest returns the client and request to get the index
+// of an AppRepository with its credentials.
+func appRepositoryIndexRequest(appRepo *v1beta1.AppRepository, caCertSecret, authSecret *corev1.Secret) (HTTPClient, *http.Request, error) {
+	cli, err := InitNetClient(appRepo, caCertSecret, authSecret, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to create HTTP client: %w", err)
+	}
+	indexURL := strings.TrimSuffix(strings.TrimSpace(appRepo.Spec.URL), "/") + "/index.yaml"
+	req, err := http.NewRequest("GET", indexURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cli, req, nil
+}
+
+// ProbeAppRepositoryIndex gets the index of an AppRepository with its
+// credentials, as ValidateAppRepository, and returns an error unless it
+// succeeds.
+func ProbeAppRepositoryIndex(appRepo *v1beta1.AppRepository, caCertSecret, authSecret *corev1.Secret) error {
+	cli, req, err := appRepositoryIndexRequest(appRepo, caCertSecret, authSecret)
+	if err != nil {
+		return err
+	}
+	res, err := cli.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to get the index of the repository: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to get the index of the repository: %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/kubeapps/kubeapps/cmd/apprepository-controller/pkg/apis/apprepository/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func secretKeyRef(name, key string) corev1.SecretKeySelector {
+	return corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+}
+
+func TestValidateAppRepositorySpec(t *testing.T) {
+	testCases := []struct {
+		name      string
+		namespace string
+		spec      v1beta1.AppRepositorySpec
+		expected  []string
+	}{
+		{
+			name:      "a valid repository",
+			namespace: "default",
+			spec: v1beta1.AppRepositorySpec{
+				URL:                   "https://charts.example.com/stable",
+				Type:                  "helm",
+				DockerRegistrySecrets: []string{"regcred"},
+				Auth: v1beta1.AppRepositoryAuth{
+					Header:   &v1beta1.AppRepositoryAuthHeader{SecretKeyRef: secretKeyRef("apprepo-foo", "authorizationHeader")},
+					CustomCA: &v1beta1.AppRepositoryCustomCA{SecretKeyRef: secretKeyRef("my-ca", "ca.crt")},
+				},
+			},
+		},
+		{
+			name:      "a missing URL",
+			namespace: "default",
+			expected:  []string{"spec.url: Required value"},
+		},
+		{
+			name:      "a URL without an http scheme",
+			namespace: "default",
+			spec:      v1beta1.AppRepositorySpec{URL: "oci://charts.example.com"},
+			expected:  []string{`spec.url: Invalid value: "oci://charts.example.com": must be an http or https URL`},
+		},
+		{
+			name:      "a URL without a host",
+			namespace: "default",
+			spec:      v1beta1.AppRepositorySpec{URL: "http:///stable"},
+			expected:  []string{`spec.url: Invalid value: "http:///stable": must have a host`},
+		},
+		{
+			name:      "an unsupported type",
+			namespace: "default",
+			spec:      v1beta1.AppRepositorySpec{URL: "https://charts.example.com", Type: "oci"},
+			expected:  []string{`spec.type: Unsupported value: "oci": supported values: "helm"`},
+		},
+		{
+			name:      "docker registry secrets for a global repository",
+			namespace: kubeappsNamespace,
+			spec:      v1beta1.AppRepositorySpec{URL: "https://charts.example.com", DockerRegistrySecrets: []string{"regcred"}},
+			expected:  []string{"spec.dockerRegistrySecrets: Forbidden: " + ErrGlobalRepositoryWithSecrets.Error()},
+		},
+		{
+			name:      "several authorization headers",
+			namespace: "default",
+			spec: v1beta1.AppRepositorySpec{
+				URL: "https://charts.example.com",
+				Auth: v1beta1.AppRepositoryAuth{
+					Header:      &v1beta1.AppRepositoryAuthHeader{SecretKeyRef: secretKeyRef("creds", "authorizationHeader")},
+					BearerToken: &v1beta1.AppRepositoryBearerToken{SecretKeyRef: secretKeyRef("creds", "token")},
+				},
+			},
+			expected: []string{"spec.auth: Forbidden: only one of header, bearerToken may be set"},
+		},
+		{
+			name:      "credentials in several secrets",
+			namespace: "default",
+			spec: v1beta1.AppRepositorySpec{
+				URL: "https://charts.example.com",
+				Auth: v1beta1.AppRepositoryAuth{
+					BasicAuth: &v1beta1.AppRepositoryBasicAuth{
+						UsernameSecretKeyRef: secretKeyRef("creds", "username"),
+						PasswordSecretKeyRef: secretKeyRef("other", ""),
+					},
+				},
+			},
+			expected: []string{
+				"spec.auth.basicAuth.passwordSecretKeyRef.key: Required value",
+				`spec.auth.basicAuth.passwordSecretKeyRef.name: Invalid value: "other": the credentials must be read from a single secret, "creds"`,
+			},
+		},
+		{
+			name:      "a webhook without a secret",
+			namespace: "default",
+			spec: v1beta1.AppRepositorySpec{
+				URL:     "https://charts.example.com",
+				Webhook: &v1beta1.AppRepositoryWebhook{},
+			},
+			expected: []string{
+				"spec.webhook.secretKeyRef.name: Required value",
+				"spec.webhook.secretKeyRef.key: Required value",
+			},
+		},
+		{
+			name:      "a broken sync job pod template",
+			namespace: "default",
+			spec: v1beta1.AppRepositorySpec{
+				URL: "https://charts.example.com",
+				Auth: v1beta1.AppRepositoryAuth{
+					CustomCA: &v1beta1.AppRepositoryCustomCA{SecretKeyRef: secretKeyRef("my-ca", "ca.crt")},
+				},
+				SyncJobPodTemplate: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"foo": "not valid!"}},
+					Spec: corev1.PodSpec{
+						Volumes: []corev1.Volume{{Name: "cache"}, {Name: "cache"}},
+						Containers: []corev1.Container{
+							{
+								VolumeMounts: []corev1.VolumeMount{{Name: "my-ca"}, {Name: "cache"}, {Name: "missing"}},
+							},
+							{Name: "sync", Image: "busybox"},
+							{Name: "sidecar"},
+						},
+					},
+				},
+			},
+			expected: []string{
+				`spec.syncJobPodTemplate.metadata.labels: Invalid value: "not valid!": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+				`spec.syncJobPodTemplate.spec.volumes[1].name: Duplicate value: "cache"`,
+				`spec.syncJobPodTemplate.spec.containers[0].volumeMounts[2].name: Not found: "missing"`,
+				`spec.syncJobPodTemplate.spec.containers[1].name: Duplicate value: "sync"`,
+				"spec.syncJobPodTemplate.spec.containers[2].image: Required value",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			appRepo := &v1beta1.AppRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: tc.namespace},
+				Spec:       tc.spec,
+			}
+			got := []string{}
+			for _, err := range ValidateAppRepositorySpec(appRepo, kubeappsNamespace) {
+				got = append(got, err.Error())
+			}
+			if want := tc.expected; !cmp.Equal(want, got, cmpopts.EquateEmpty()) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestValidateAppRepositorySecrets(t *testing.T) {
+	secrets := map[string]*corev1.Secret{
+		"creds": &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+			Data: map[string][]byte{
+				"authorizationHeader":   []byte("Bearer foo"),
+				corev1.TLSCertKey:       []byte(pemClientCert),
+				corev1.TLSPrivateKeyKey: []byte(pemClientKey),
+				"ca.crt":                []byte(pemCert),
+				"not-a-cert":            []byte("foo"),
+			},
+		},
+		"regcred": &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "regcred"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+		},
+		"opaque": &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque"},
+			Type:       corev1.SecretTypeOpaque,
+		},
+	}
+	getSecret := func(name string) (*corev1.Secret, error) {
+		if secret, ok := secrets[name]; ok {
+			return secret, nil
+		}
+		return nil, k8sErrors.NewNotFound(corev1.Resource("secrets"), name)
+	}
+
+	testCases := []struct {
+		name     string
+		spec     v1beta1.AppRepositorySpec
+		expected []string
+	}{
+		{
+			name: "valid secrets",
+			spec: v1beta1.AppRepositorySpec{
+				DockerRegistrySecrets: []string{"regcred"},
+				Auth: v1beta1.AppRepositoryAuth{
+					Header: &v1beta1.AppRepositoryAuthHeader{SecretKeyRef: secretKeyRef("creds", "authorizationHeader")},
+					ClientCert: &v1beta1.AppRepositoryClientCert{
+						CertSecretKeyRef: secretKeyRef("creds", corev1.TLSCertKey),
+						KeySecretKeyRef:  secretKeyRef("creds", corev1.TLSPrivateKeyKey),
+					},
+					CustomCA: &v1beta1.AppRepositoryCustomCA{SecretKeyRef: secretKeyRef("creds", "ca.crt")},
+				},
+				Webhook: &v1beta1.AppRepositoryWebhook{SecretKeyRef: secretKeyRef("creds", "authorizationHeader")},
+			},
+		},
+		{
+			name: "a missing secret",
+			spec: v1beta1.AppRepositorySpec{
+				Auth: v1beta1.AppRepositoryAuth{
+					BearerToken: &v1beta1.AppRepositoryBearerToken{SecretKeyRef: secretKeyRef("missing", "token")},
+				},
+			},
+			expected: []string{`spec.auth.bearerToken.secretKeyRef.name: Invalid value: "missing": unable to get the secret: secrets "missing" not found`},
+		},
+		{
+			name: "a missing key",
+			spec: v1beta1.AppRepositorySpec{
+				Webhook: &v1beta1.AppRepositoryWebhook{SecretKeyRef: secretKeyRef("creds", "secret")},
+			},
+			expected: []string{`spec.webhook.secretKeyRef.key: Invalid value: "secret": secret "creds" did not contain key "secret"`},
+		},
+		{
+			name: "an invalid CA",
+			spec: v1beta1.AppRepositorySpec{
+				Auth: v1beta1.AppRepositoryAuth{
+					CustomCA: &v1beta1.AppRepositoryCustomCA{SecretKeyRef: secretKeyRef("creds", "not-a-cert")},
+				},
+			},
+			expected: []string{`spec.auth.customCA.secretKeyRef: Invalid value: "creds": no PEM encoded certificate found`},
+		},
+		{
+			name: "an invalid client certificate",
+			spec: v1beta1.AppRepositorySpec{
+				Auth: v1beta1.AppRepositoryAuth{
+					ClientCert: &v1beta1.AppRepositoryClientCert{
+						CertSecretKeyRef: secretKeyRef("creds", corev1.TLSCertKey),
+						KeySecretKeyRef:  secretKeyRef("creds", "not-a-cert"),
+					},
+				},
+			},
+			expected: []string{`spec.auth.clientCert: Invalid value: "creds": invalid client certificate: tls: failed to find any PEM data in key input`},
+		},
+		{
+			name: "docker registry secrets of the wrong type",
+			spec: v1beta1.AppRepositorySpec{
+				DockerRegistrySecrets: []string{"regcred", "opaque", "missing"},
+			},
+			expected: []string{
+				`spec.dockerRegistrySecrets[1]: Invalid value: "opaque": the secret must be of type kubernetes.io/dockerconfigjson`,
+				`spec.dockerRegistrySecrets[2]: Invalid value: "missing": unable to get the secret: secrets "missing" not found`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			appRepo := &v1beta1.AppRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+				Spec:       tc.spec,
+			}
+			got := []string{}
+			for _, err := range ValidateAppRepositorySecrets(appRepo, getSecret) {
+				got = append(got, err.Error())
+			}
+			if want := tc.expected; !cmp.Equal(want, got, cmpopts.EquateEmpty()) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestProbeAppRepositoryIndex(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		expectedError bool
+	}{
+		{
+			name:   "it succeeds when the index is found",
+			status: http.StatusOK,
+		},
+		{
+			name:          "it fails when the index is not found",
+			status:        http.StatusNotFound,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got, want := r.URL.Path, "/index.yaml"; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			appRepo := &v1beta1.AppRepository{Spec: v1beta1.AppRepositorySpec{URL: server.URL + "/"}}
+			err := ProbeAppRepositoryIndex(appRepo, nil, nil)
+			if got, want := err != nil, tc.expectedError; got != want {
+				t.Errorf("got: %v, want error: %t", err, want)
+			}
+		})
+	}
+}
+
+func TestValidateAppRepositoryReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer foo"; got != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/index.yaml":
+			w.Write([]byte("apiVersion: v1\nentries:\n  foo:\n  - version: 1.0.0\n    urls:\n    - foo-1.0.0.tgz\n"))
+		case "/foo-1.0.0.tgz":
+			w.Write([]byte("tarball"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	handler := &userHandler{kubeappsNamespace: kubeappsNamespace}
+	requestData := fmt.Sprintf(`{"appRepository": {"name": "test-repo", "repoURL": %q, "authHeader": "Bearer foo"}}`, server.URL)
+	report, err := handler.ValidateAppRepositoryReport(ioutil.NopCloser(strings.NewReader(requestData)), "default", 1)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !report.Valid || !report.Authenticated {
+		t.Errorf("got valid: %t, authenticated: %t, want both (issues: %v)", report.Valid, report.Authenticated, report.Issues)
+	}
+	if got, want := len(report.Samples), 1; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+}
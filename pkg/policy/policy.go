@@ -0,0 +1,274 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	yamlUtils "github.com/kubeapps/kubeapps/pkg/yaml"
+	log "github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Names of the rules which can be enabled in a Policy.
+const (
+	RuleNoPrivilegedContainers = "no-privileged-containers"
+	RuleNoHostPath             = "no-host-path"
+	RuleAllowedRegistries      = "allowed-registries"
+	RuleRequiredLabels         = "required-labels"
+	RuleNoLatestTag            = "no-latest-tag"
+)
+
+// Policy defines the rules which the rendered manifest of a release must
+// satisfy before it is applied.
+type Policy struct {
+	// WarnOnly reports violations without rejecting the release.
+	WarnOnly bool `json:"warnOnly"`
+
+	DisallowPrivileged bool `json:"disallowPrivileged"`
+	DisallowHostPath   bool `json:"disallowHostPath"`
+	DisallowLatestTag  bool `json:"disallowLatestTag"`
+	// AllowedRegistries lists the registry domains, optionally followed by
+	// a repository path prefix, from which container images can be used.
+	// Any registry is allowed if empty.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// RequiredLabels lists the label keys which every resource must set.
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+}
+
+// Violation represents a resource of the manifest which breaks a rule.
+type Violation struct {
+	Resource string `json:"resource"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// ViolationsError is returned when a manifest breaks the rules of a policy.
+type ViolationsError struct {
+	Violations []Violation
+}
+
+func (e *ViolationsError) Error() string {
+	messages := []string{}
+	for _, v := range e.Violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", v.Resource, v.Message))
+	}
+	return fmt.Sprintf("manifest violates policy: %s", strings.Join(messages, "; "))
+}
+
+// ParsePolicy parses a YAML (or JSON) policy, as stored in a ConfigMap.
+func ParsePolicy(data []byte) (*Policy, error) {
+	p := &Policy{}
+	err := yaml.Unmarshal(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse policy: %v", err)
+	}
+	return p, nil
+}
+
+// Evaluate returns the violations of the policy found in the manifest.
+func (p *Policy) Evaluate(manifest string) ([]Violation, error) {
+	objs, err := yamlUtils.ParseObjects(manifest)
+	if err != nil {
+		return nil, err
+	}
+	violations := []Violation{}
+	for _, obj := range objs {
+		violations = append(violations, p.evaluateObject(obj)...)
+	}
+	return violations, nil
+}
+
+func (p *Policy) evaluateObject(obj *unstructured.Unstructured) []Violation {
+	resource := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+	violations := []Violation{}
+	addViolation := func(rule, format string, args ...interface{}) {
+		violations = append(violations, Violation{
+			Resource: resource,
+			Rule:     rule,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	labels := obj.GetLabels()
+	for _, l := range p.RequiredLabels {
+		if _, ok := labels[l]; !ok {
+			addViolation(RuleRequiredLabels, "missing required label %q", l)
+		}
+	}
+
+	podSpec := getPodSpec(obj)
+	if podSpec == nil {
+		return violations
+	}
+
+	if p.DisallowHostPath {
+		volumes, _, _ := unstructured.NestedSlice(podSpec, "volumes")
+		for _, v := range volumes {
+			volume, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, ok := volume["hostPath"]; ok {
+				addViolation(RuleNoHostPath, "volume %q uses a hostPath", volume["name"])
+			}
+		}
+	}
+
+	for _, key := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(podSpec, key)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			if p.DisallowPrivileged {
+				privileged, _, _ := unstructured.NestedBool(container, "securityContext", "privileged")
+				if privileged {
+					addViolation(RuleNoPrivilegedContainers, "container %q is privileged", name)
+				}
+			}
+			image, _, _ := unstructured.NestedString(container, "image")
+			if image == "" || (len(p.AllowedRegistries) == 0 && !p.DisallowLatestTag) {
+				continue
+			}
+			ref, err := reference.ParseNormalizedNamed(image)
+			if err != nil {
+				log.Errorf("unable to parse image reference: %q", image)
+				continue
+			}
+			if len(p.AllowedRegistries) > 0 && !p.isAllowedImage(ref) {
+				addViolation(RuleAllowedRegistries, "container %q uses image %q from a registry which is not allowed", name, image)
+			}
+			if p.DisallowLatestTag && usesLatestTag(ref) {
+				addViolation(RuleNoLatestTag, "container %q uses image %q with the latest tag", name, image)
+			}
+		}
+	}
+	return violations
+}
+
+// isAllowedImage checks whether the normalized image name (eg.
+// docker.io/bitnami/nginx) is within one of the allowed registries.
+func (p *Policy) isAllowedImage(ref reference.Named) bool {
+	name := ref.Name()
+	for _, r := range p.AllowedRegistries {
+		r = strings.TrimSuffix(r, "/")
+		if name == r || strings.HasPrefix(name, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// usesLatestTag checks whether the image is (implicitly or explicitly) tagged
+// as latest. Images referenced by digest are considered pinned.
+func usesLatestTag(ref reference.Named) bool {
+	if _, ok := ref.(reference.Digested); ok {
+		return false
+	}
+	tagged, ok := reference.TagNameOnly(ref).(reference.Tagged)
+	return ok && tagged.Tag() == "latest"
+}
+
+// getPodSpec returns the pod spec of the resources which define pods.
+func getPodSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	var path []string
+	switch obj.GetKind() {
+	case "Pod":
+		path = []string{"spec"}
+	case "DaemonSet", "Deployment", "Job", "ReplicaSet", "ReplicationController", "StatefulSet":
+		path = []string{"spec", "template", "spec"}
+	case "PodTemplate":
+		path = []string{"template", "spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+	podSpec, _, _ := unstructured.NestedMap(obj.Object, path...)
+	return podSpec
+}
+
+// Enforcer is a helm post-renderer which evaluates the policy on the rendered
+// (and post-rendered) manifest without modifying it. It should run last in a
+// chain of post-renderers so that the manifest which is applied is evaluated.
+type Enforcer struct {
+	policy *Policy
+	// warnings records the violations found in warn-only mode.
+	warnings []Violation
+}
+
+// NewEnforcer returns a post-renderer enforcing the given policy.
+func NewEnforcer(p *Policy) *Enforcer {
+	return &Enforcer{policy: p}
+}
+
+// Run returns the rendered manifests unchanged, or a *ViolationsError if the
+// policy is not satisfied and it is not in warn-only mode.
+func (e *Enforcer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	violations, err := e.policy.Evaluate(renderedManifests.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) == 0 {
+		return renderedManifests, nil
+	}
+	if e.policy.WarnOnly {
+		for _, v := range violations {
+			log.Warningf("policy violation (warn only) in %s, %s: %s", v.Resource, v.Rule, v.Message)
+		}
+		e.warnings = append(e.warnings, violations...)
+		return renderedManifests, nil
+	}
+	return nil, &ViolationsError{Violations: violations}
+}
+
+// CheckHooks evaluates the policy on the hooks of a release, which helm does
+// not pass to the post-renderers.
+func (e *Enforcer) CheckHooks(hooks []*release.Hook) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	manifests := []string{}
+	for _, h := range hooks {
+		manifests = append(manifests, h.Manifest)
+	}
+	_, err := e.Run(bytes.NewBufferString(strings.Join(manifests, "\n---\n")))
+	return err
+}
+
+// Warnings returns the violations found while in warn-only mode.
+func (e *Enforcer) Warnings() []Violation {
+	return e.warnings
+}
+
+// MarshalViolations returns the JSON encoding of the violations.
+func MarshalViolations(violations []Violation) (string, error) {
+	body, err := json.Marshal(violations)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
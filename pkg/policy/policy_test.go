@@ -0,0 +1,259 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+const deploymentManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  labels:
+    team: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox
+      containers:
+      - name: nginx
+        image: docker.io/bitnami/nginx:1.17.9
+        securityContext:
+          privileged: true
+      - name: sidecar
+        image: quay.io/example/sidecar@sha256:0123456789012345678901234567890123456789012345678901234567890123
+      volumes:
+      - name: data
+        hostPath:
+          path: /var/data
+      - name: config
+        configMap:
+          name: foo
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+`
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		expected  *Policy
+		expectErr bool
+	}{
+		{
+			name: "it parses a policy",
+			data: `warnOnly: true
+disallowPrivileged: true
+allowedRegistries:
+- docker.io/bitnami
+requiredLabels:
+- team
+`,
+			expected: &Policy{
+				WarnOnly:           true,
+				DisallowPrivileged: true,
+				AllowedRegistries:  []string{"docker.io/bitnami"},
+				RequiredLabels:     []string{"team"},
+			},
+		},
+		{
+			name:      "it returns an error for an invalid policy",
+			data:      `disallowPrivileged: [`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParsePolicy([]byte(tc.data))
+			if got, want := err != nil, tc.expectErr; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if !cmp.Equal(tc.expected, p) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tc.expected, p))
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   Policy
+		expected []Violation
+	}{
+		{
+			name:     "it returns no violations for an empty policy",
+			expected: []Violation{},
+		},
+		{
+			name:   "it reports privileged containers",
+			policy: Policy{DisallowPrivileged: true},
+			expected: []Violation{
+				{Resource: "Deployment/foo", Rule: RuleNoPrivilegedContainers, Message: `container "nginx" is privileged`},
+			},
+		},
+		{
+			name:   "it reports hostPath volumes",
+			policy: Policy{DisallowHostPath: true},
+			expected: []Violation{
+				{Resource: "Deployment/foo", Rule: RuleNoHostPath, Message: `volume "data" uses a hostPath`},
+			},
+		},
+		{
+			name:   "it reports images from registries which are not allowed",
+			policy: Policy{AllowedRegistries: []string{"docker.io/bitnami", "quay.io/"}},
+			expected: []Violation{
+				{Resource: "Deployment/foo", Rule: RuleAllowedRegistries, Message: `container "init" uses image "busybox" from a registry which is not allowed`},
+			},
+		},
+		{
+			name:   "it reports images with an implicit or explicit latest tag",
+			policy: Policy{DisallowLatestTag: true},
+			expected: []Violation{
+				{Resource: "Deployment/foo", Rule: RuleNoLatestTag, Message: `container "init" uses image "busybox" with the latest tag`},
+			},
+		},
+		{
+			name:   "it reports missing labels for every resource",
+			policy: Policy{RequiredLabels: []string{"team"}},
+			expected: []Violation{
+				{Resource: "Service/foo", Rule: RuleRequiredLabels, Message: `missing required label "team"`},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := tc.policy.Evaluate(deploymentManifest)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !cmp.Equal(tc.expected, violations) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tc.expected, violations))
+			}
+		})
+	}
+}
+
+func TestEnforcerRun(t *testing.T) {
+	testCases := []struct {
+		name             string
+		policy           Policy
+		expectViolations bool
+		expectWarnings   int
+	}{
+		{
+			name:   "it returns the manifest when the policy is satisfied",
+			policy: Policy{RequiredLabels: []string{}},
+		},
+		{
+			name:             "it returns a violations error when the policy is not satisfied",
+			policy:           Policy{DisallowPrivileged: true, DisallowHostPath: true},
+			expectViolations: true,
+		},
+		{
+			name:           "it returns the manifest and records warnings in warn-only mode",
+			policy:         Policy{WarnOnly: true, DisallowPrivileged: true, DisallowHostPath: true},
+			expectWarnings: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEnforcer(&tc.policy)
+			renderedManifests, err := e.Run(bytes.NewBufferString(deploymentManifest))
+			var violationsErr *ViolationsError
+			if got, want := errors.As(err, &violationsErr), tc.expectViolations; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if tc.expectViolations {
+				return
+			}
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := renderedManifests.String(), deploymentManifest; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got, want := len(e.Warnings()), tc.expectWarnings; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
+func TestEnforcerCheckHooks(t *testing.T) {
+	hooks := []*release.Hook{
+		{Name: "foo-test", Kind: "Deployment", Manifest: deploymentManifest},
+	}
+	testCases := []struct {
+		name             string
+		policy           Policy
+		hooks            []*release.Hook
+		expectViolations bool
+		expectWarnings   int
+	}{
+		{
+			name:   "it accepts a release without hooks",
+			policy: Policy{DisallowPrivileged: true},
+		},
+		{
+			name:             "it returns a violations error when a hook does not satisfy the policy",
+			policy:           Policy{DisallowPrivileged: true},
+			hooks:            hooks,
+			expectViolations: true,
+		},
+		{
+			name:           "it records warnings for the hooks in warn-only mode",
+			policy:         Policy{WarnOnly: true, DisallowPrivileged: true, DisallowHostPath: true},
+			hooks:          hooks,
+			expectWarnings: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEnforcer(&tc.policy)
+			err := e.CheckHooks(tc.hooks)
+			var violationsErr *ViolationsError
+			if got, want := errors.As(err, &violationsErr), tc.expectViolations; got != want {
+				t.Fatalf("got: %t, want: %t. err: %+v", got, want, err)
+			}
+			if !tc.expectViolations && err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got, want := len(e.Warnings()), tc.expectWarnings; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
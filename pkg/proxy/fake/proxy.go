@@ -78,8 +78,11 @@ func (f *FakeProxy) CreateRelease(name, namespace, values string, ch *chart.Char
 }
 
 func (f *FakeProxy) UpdateRelease(name, namespace string, values string, ch *chart.Chart) (*release.Release, error) {
-	for _, r := range f.Releases {
+	for i, r := range f.Releases {
 		if r.Name == name {
+			if values != "" {
+				f.Releases[i].Config = &chart.Config{Raw: values}
+			}
 			return &r, nil
 		}
 	}
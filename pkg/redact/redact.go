@@ -0,0 +1,265 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package redact masks the secret values supplied by the users when
+// installing or upgrading a release, and the Secrets rendered from them, so
+// that they are not returned to everyone who can read the release.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/kubeapps/kubeapps/pkg/auth"
+)
+
+// Mask replaces the redacted values
+const Mask = "********"
+
+// RevealParam is the query parameter to get the values without redaction
+const RevealParam = "reveal"
+
+// DefaultKeyPatterns are the patterns of the keys whose values are redacted
+// by default
+var DefaultKeyPatterns = []string{"password", "passwd", "secret", "token", "api-?key", "private-?key", "credentials"}
+
+// Redactor masks the values whose keys match one of its patterns or that are
+// marked as writeOnly or with the password format in the values schema
+type Redactor struct {
+	keyPatterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor for the given key patterns, regular
+// expressions matched case-insensitively against each key of the values
+func NewRedactor(keyPatterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range keyPatterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %v", p, err)
+		}
+		r.keyPatterns = append(r.keyPatterns, re)
+	}
+	return r, nil
+}
+
+// Values returns a copy of the values with the secret ones masked. The schema
+// is the values.schema.json of the chart, it can be empty. An invalid schema
+// is ignored, the values are then only redacted by key.
+func (r *Redactor) Values(values map[string]interface{}, schema []byte) map[string]interface{} {
+	redacted, _ := r.values(values, schema)
+	return redacted
+}
+
+// RawValues masks the secret values of values in YAML, as stored in Helm 2
+// releases. The values are returned as they are if none is masked.
+func (r *Redactor) RawValues(raw string, schema []byte) (string, error) {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(raw), &values); err != nil {
+		return "", fmt.Errorf("unable to parse the values to redact: %v", err)
+	}
+	redacted, masked := r.values(values, schema)
+	if !masked {
+		return raw, nil
+	}
+	out, err := yaml.Marshal(redacted)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (r *Redactor) values(values map[string]interface{}, schema []byte) (map[string]interface{}, bool) {
+	if values == nil {
+		return nil, false
+	}
+	var root map[string]interface{}
+	if len(schema) > 0 {
+		if err := json.Unmarshal(schema, &root); err != nil {
+			root = nil
+		}
+	}
+	redacted, masked := r.redact(values, root, false)
+	return redacted.(map[string]interface{}), masked
+}
+
+// redact returns the value with its leaves masked if the value is secret,
+// walking its schema along with it
+func (r *Redactor) redact(value interface{}, schema map[string]interface{}, secret bool) (interface{}, bool) {
+	secret = secret || isWriteOnly(schema)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		masked := false
+		for key, child := range v {
+			redacted, m := r.redact(child, propertySchema(schema, key), secret || r.matchesKey(key))
+			result[key] = redacted
+			masked = masked || m
+		}
+		return result, masked
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		masked := false
+		items, _ := schema["items"].(map[string]interface{})
+		for i, child := range v {
+			redacted, m := r.redact(child, items, secret)
+			result[i] = redacted
+			masked = masked || m
+		}
+		return result, masked
+	case nil:
+		return nil, false
+	default:
+		if secret {
+			return Mask, true
+		}
+		return v, false
+	}
+}
+
+func (r *Redactor) matchesKey(key string) bool {
+	for _, re := range r.keyPatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// propertySchema returns the schema of a property of an object
+func propertySchema(schema map[string]interface{}, key string) map[string]interface{} {
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		if s, ok := properties[key].(map[string]interface{}); ok {
+			return s
+		}
+	}
+	s, _ := schema["additionalProperties"].(map[string]interface{})
+	return s
+}
+
+func isWriteOnly(schema map[string]interface{}) bool {
+	return schema["writeOnly"] == true || schema["format"] == "password"
+}
+
+// RestoreRawValues replaces the masked values of values in YAML with the
+// value at the same path in the previous values of the release, so that the
+// redacted values of a release can be sent back to upgrade it. The masked
+// values missing from the previous values are kept. The values are returned
+// as they are if none is masked.
+func RestoreRawValues(raw string, previous map[string]interface{}) (string, error) {
+	if !strings.Contains(raw, Mask) {
+		return raw, nil
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(raw), &values); err != nil {
+		return "", fmt.Errorf("unable to parse the values to restore: %v", err)
+	}
+	out, err := yaml.Marshal(restore(values, previous))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func restore(value, previous interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		p, _ := previous.(map[string]interface{})
+		for key, child := range v {
+			v[key] = restore(child, p[key])
+		}
+	case []interface{}:
+		p, _ := previous.([]interface{})
+		for i, child := range v {
+			var prev interface{}
+			if i < len(p) {
+				prev = p[i]
+			}
+			v[i] = restore(child, prev)
+		}
+	case string:
+		if v == Mask && previous != nil {
+			return previous
+		}
+	}
+	return value
+}
+
+// manifestSeparator separates the documents of a release manifest
+var manifestSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// Manifest returns the manifest of a release with the data and stringData
+// values of its Secrets masked, the other documents are kept as they are
+func Manifest(manifest string) (string, error) {
+	docs := manifestSeparator.Split(manifest, -1)
+	separators := manifestSeparator.FindAllString(manifest, -1)
+	var b strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			b.WriteString(separators[i-1])
+		}
+		redacted, err := secretDocument(doc)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(redacted)
+	}
+	return b.String(), nil
+}
+
+func secretDocument(doc string) (string, error) {
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+		return "", fmt.Errorf("unable to parse the manifest to redact: %v", err)
+	}
+	if obj["kind"] != "Secret" {
+		return doc, nil
+	}
+	masked := false
+	for _, field := range []string{"data", "stringData"} {
+		data, _ := obj[field].(map[string]interface{})
+		for key, value := range data {
+			if value != nil {
+				data[key] = Mask
+				masked = true
+			}
+		}
+	}
+	if !masked {
+		return doc, nil
+	}
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return "\n" + string(out), nil
+}
+
+// ForbiddenToReveal returns the actions the user is missing to reveal the
+// values of the releases of a namespace, which requires get on its secrets
+func ForbiddenToReveal(checker auth.Checker, namespace string) ([]auth.Action, error) {
+	allowed, err := checker.ValidateForNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if allowed {
+		return nil, nil
+	}
+	return []auth.Action{{Resource: "secrets", Namespace: namespace, Verbs: []string{"get"}}}, nil
+}
@@ -0,0 +1,376 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package repoindex decodes chart repository indexes in YAML or JSON,
+// optionally gzip compressed, one chart at a time, so that the whole index is
+// never held in memory. JSON indexes are read from the token stream of the
+// decoder, the block of each chart of YAML indexes is split from the lines of
+// its entries and parsed on its own by the yaml decoder. Only YAML entries in
+// flow style, which Helm does not write, are parsed as a whole.
+package repoindex
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/repo"
+)
+
+// entriesKey is the key of the charts of an index
+const entriesKey = "entries"
+
+// Decompress returns a reader of the content of an index, decompressing it
+// when it is gzip compressed
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// Decode calls fn with the versions of each chart of an index, sorted from the
+// latest, and returns the index without its entries. The index can be in YAML
+// or JSON and gzip compressed. The charts without versions are skipped.
+func Decode(r io.Reader, fn func(name string, versions repo.ChartVersions) error) (*repo.IndexFile, error) {
+	content, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(content)
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			// An empty index has no charts
+			return &repo.IndexFile{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+		br.UnreadByte()
+		if c == '{' {
+			return decodeJSON(br, fn)
+		}
+		return decodeYAML(br, fn)
+	}
+}
+
+// Load decodes an index into an IndexFile
+func Load(r io.Reader) (*repo.IndexFile, error) {
+	entries := map[string]repo.ChartVersions{}
+	index, err := Decode(r, func(name string, versions repo.ChartVersions) error {
+		entries[name] = versions
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	index.Entries = entries
+	return index, nil
+}
+
+func emit(name string, versions repo.ChartVersions, fn func(string, repo.ChartVersions) error) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	sort.Sort(sort.Reverse(versions))
+	return fn(name, versions)
+}
+
+// decodeJSON reads the tokens of the index up to its entries and decodes each
+// chart on its own
+func decodeJSON(r io.Reader, fn func(string, repo.ChartVersions) error) (*repo.IndexFile, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	index := &repo.IndexFile{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch key, _ := tok.(string); key {
+		case "apiVersion":
+			err = dec.Decode(&index.APIVersion)
+		case "generated":
+			err = dec.Decode(&index.Generated)
+		case "publicKeys":
+			err = dec.Decode(&index.PublicKeys)
+		case entriesKey:
+			err = decodeJSONEntries(dec, fn)
+		default:
+			var value json.RawMessage
+			err = dec.Decode(&value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func decodeJSONEntries(dec *json.Decoder, fn func(string, repo.ChartVersions) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("unable to parse the index: %v is not an object of charts", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		var versions repo.ChartVersions
+		if err := dec.Decode(&versions); err != nil {
+			return fmt.Errorf("unable to parse the versions of %q: %v", name, err)
+		}
+		if err := emit(name, versions, fn); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("unable to parse the index: got %v, want %v", tok, want)
+	}
+	return nil
+}
+
+// yamlIndex is the header of an index decoded by the yaml decoder, with the
+// entries in flow style if any
+type yamlIndex struct {
+	APIVersion string                        `yaml:"apiVersion"`
+	Generated  time.Time                     `yaml:"generated"`
+	Entries    map[string][]yamlChartVersion `yaml:"entries"`
+	PublicKeys []string                      `yaml:"publicKeys"`
+}
+
+// yamlChartVersion has the fields of a repo.ChartVersion with their YAML
+// names, its string fields keep values like "version: 1.10" as written
+type yamlChartVersion struct {
+	Name          string            `yaml:"name"`
+	Home          string            `yaml:"home"`
+	Sources       []string          `yaml:"sources"`
+	Version       string            `yaml:"version"`
+	Description   string            `yaml:"description"`
+	Keywords      []string          `yaml:"keywords"`
+	Maintainers   []yamlMaintainer  `yaml:"maintainers"`
+	Engine        string            `yaml:"engine"`
+	Icon          string            `yaml:"icon"`
+	APIVersion    string            `yaml:"apiVersion"`
+	Condition     string            `yaml:"condition"`
+	Tags          string            `yaml:"tags"`
+	AppVersion    string            `yaml:"appVersion"`
+	Deprecated    bool              `yaml:"deprecated"`
+	TillerVersion string            `yaml:"tillerVersion"`
+	Annotations   map[string]string `yaml:"annotations"`
+	KubeVersion   string            `yaml:"kubeVersion"`
+	URLs          []string          `yaml:"urls"`
+	Created       time.Time         `yaml:"created"`
+	Removed       bool              `yaml:"removed"`
+	Digest        string            `yaml:"digest"`
+}
+
+type yamlMaintainer struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email"`
+	URL   string `yaml:"url"`
+}
+
+func (v yamlChartVersion) chartVersion() *repo.ChartVersion {
+	var maintainers []*chart.Maintainer
+	for _, m := range v.Maintainers {
+		maintainers = append(maintainers, &chart.Maintainer{Name: m.Name, Email: m.Email, Url: m.URL})
+	}
+	return &repo.ChartVersion{
+		Metadata: &chart.Metadata{
+			Name:          v.Name,
+			Home:          v.Home,
+			Sources:       v.Sources,
+			Version:       v.Version,
+			Description:   v.Description,
+			Keywords:      v.Keywords,
+			Maintainers:   maintainers,
+			Engine:        v.Engine,
+			Icon:          v.Icon,
+			ApiVersion:    v.APIVersion,
+			Condition:     v.Condition,
+			Tags:          v.Tags,
+			AppVersion:    v.AppVersion,
+			Deprecated:    v.Deprecated,
+			TillerVersion: v.TillerVersion,
+			Annotations:   v.Annotations,
+			KubeVersion:   v.KubeVersion,
+		},
+		URLs:    v.URLs,
+		Created: v.Created,
+		Removed: v.Removed,
+		Digest:  v.Digest,
+	}
+}
+
+// decodeYAML splits the entries of a block style index into the blocks of
+// each chart, delimited by the lines indented as the first chart name, and
+// parses each block on its own. The rest of the index, including entries in
+// flow style, is parsed at the end as its header. Aliases to the anchors of
+// another chart are not supported.
+func decodeYAML(r io.Reader, fn func(string, repo.ChartVersions) error) (*repo.IndexFile, error) {
+	br := bufio.NewReader(r)
+	var header, chart bytes.Buffer
+	inEntries := false
+	chartIndent := 0
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			break
+		}
+
+		content := bytes.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+		blank := len(bytes.TrimSpace(content)) == 0 || content[0] == '#'
+		switch {
+		case blank:
+			if inEntries {
+				chart.Write(line)
+			} else {
+				header.Write(line)
+			}
+		case inEntries && indent > 0 && (chartIndent == 0 || indent > chartIndent || (indent == chartIndent && isYAMLSequenceEntry(content))):
+			if chartIndent == 0 {
+				chartIndent = indent
+			}
+			chart.Write(line)
+		case inEntries && indent > 0 && indent == chartIndent:
+			// The name of the next chart
+			if err := decodeYAMLChart(chart.Bytes(), fn); err != nil {
+				return nil, err
+			}
+			chart.Reset()
+			chart.Write(line)
+		default:
+			if inEntries {
+				if err := decodeYAMLChart(chart.Bytes(), fn); err != nil {
+					return nil, err
+				}
+				chart.Reset()
+				inEntries = false
+			}
+			if indent == 0 && isYAMLEntriesKey(content) {
+				inEntries = true
+				chartIndent = 0
+			} else {
+				header.Write(line)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	if err := decodeYAMLChart(chart.Bytes(), fn); err != nil {
+		return nil, err
+	}
+
+	var decoded yamlIndex
+	if err := yaml.Unmarshal(header.Bytes(), &decoded); err != nil {
+		return nil, fmt.Errorf("unable to parse the index: %v", err)
+	}
+	if err := emitYAMLEntries(decoded.Entries, fn); err != nil {
+		return nil, err
+	}
+	return &repo.IndexFile{APIVersion: decoded.APIVersion, Generated: decoded.Generated, PublicKeys: decoded.PublicKeys}, nil
+}
+
+// isYAMLEntriesKey returns whether a line is the key of block style entries,
+// with no value on the same line
+func isYAMLEntriesKey(line []byte) bool {
+	if !bytes.HasPrefix(line, []byte(entriesKey)) {
+		return false
+	}
+	rest := bytes.TrimLeft(line[len(entriesKey):], " \t")
+	if len(rest) == 0 || rest[0] != ':' {
+		return false
+	}
+	rest = bytes.TrimSpace(rest[1:])
+	return len(rest) == 0 || rest[0] == '#'
+}
+
+// isYAMLSequenceEntry returns whether a line is an entry of a block sequence,
+// such as the versions of a chart indented as its name
+func isYAMLSequenceEntry(line []byte) bool {
+	return line[0] == '-' && (len(line) == 1 || line[1] == ' ' || line[1] == '\r' || line[1] == '\n')
+}
+
+// decodeYAMLChart parses the block of a chart of the entries
+func decodeYAMLChart(block []byte, fn func(string, repo.ChartVersions) error) error {
+	if len(bytes.TrimSpace(block)) == 0 {
+		return nil
+	}
+	var entries map[string][]yamlChartVersion
+	if err := yaml.Unmarshal(block, &entries); err != nil {
+		return fmt.Errorf("unable to parse the index: %v", err)
+	}
+	return emitYAMLEntries(entries, fn)
+}
+
+func emitYAMLEntries(entries map[string][]yamlChartVersion, fn func(string, repo.ChartVersions) error) error {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		versions := make(repo.ChartVersions, 0, len(entries[name]))
+		for _, v := range entries[name] {
+			versions = append(versions, v.chartVersion())
+		}
+		if err := emit(name, versions, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repoindex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/helm/pkg/repo"
+)
+
+const testIndexYAML = `apiVersion: v1
+entries:
+  foo:
+  - name: foo
+    version: 0.1.0
+    description: |
+      The foo chart
+
+      with a blank line
+    urls:
+    - foo-0.1.0.tgz
+  - name: foo
+    version: 1.0.0
+    apiVersion: v1
+    appVersion: "1.0"
+    created: 2020-01-01T10:00:00.123456789Z
+    digest: abc
+    maintainers:
+    - name: me
+      email: me@example.com
+    annotations:
+      category: Test
+    urls:
+    - foo-1.0.0.tgz
+  "bar":
+  - name: bar
+    version: 2.0.0
+    urls:
+    - bar-2.0.0.tgz
+  empty: []
+generated: 2020-01-01T00:00:00Z
+publicKeys:
+- key
+`
+
+const testIndexJSON = `{
+  "apiVersion": "v1",
+  "generated": "2020-01-01T00:00:00Z",
+  "entries": {
+    "foo": [
+      {"name": "foo", "version": "0.1.0", "description": "The foo chart\n\nwith a blank line\n", "urls": ["foo-0.1.0.tgz"]},
+      {"name": "foo", "version": "1.0.0", "apiVersion": "v1", "appVersion": "1.0", "created": "2020-01-01T10:00:00.123456789Z", "digest": "abc",
+        "maintainers": [{"name": "me", "email": "me@example.com"}], "annotations": {"category": "Test"}, "urls": ["foo-1.0.0.tgz"]}
+    ],
+    "bar": [{"name": "bar", "version": "2.0.0", "urls": ["bar-2.0.0.tgz"]}],
+    "empty": []
+  },
+  "publicKeys": ["key"]
+}`
+
+func gzipped(s string) string {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write([]byte(s))
+	w.Close()
+	return b.String()
+}
+
+// versionsOf returns the name and version of the charts decoded from an index
+func versionsOf(t *testing.T, index string) map[string][]string {
+	got := map[string][]string{}
+	_, err := Decode(strings.NewReader(index), func(name string, versions repo.ChartVersions) error {
+		for _, v := range versions {
+			got[name] = append(got[name], v.GetName()+"-"+v.Version)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return got
+}
+
+func TestDecode(t *testing.T) {
+	want := map[string][]string{
+		"foo": {"foo-1.0.0", "foo-0.1.0"},
+		"bar": {"bar-2.0.0"},
+	}
+	tests := []struct {
+		name  string
+		index string
+		want  map[string][]string
+	}{
+		{"yaml", testIndexYAML, want},
+		{"json", testIndexJSON, want},
+		{"gzip yaml", gzipped(testIndexYAML), want},
+		{"gzip json", gzipped(testIndexJSON), want},
+		{"yaml with a document start", "---\n" + testIndexYAML, want},
+		{"yaml with flow entries", `apiVersion: v1
+entries: {"foo": [{"name": "foo", "version": "1.0.0"}]}
+`, map[string][]string{"foo": {"foo-1.0.0"}}},
+		{"yaml with four spaces of indentation", `entries:
+    foo:
+        - name: foo
+          version: 1.0.0
+    bar:
+        - name: bar
+          version: 2.0.0
+`, map[string][]string{"foo": {"foo-1.0.0"}, "bar": {"bar-2.0.0"}}},
+		{"yaml with comments and blank lines", `# An index
+entries:
+  # The foo chart
+  foo:
+
+  - name: foo
+    version: 1.0.0
+# The bar chart
+  bar:
+    - name: bar
+      description: |
+        A description
+
+        - with: a list
+      version: 2.0.0
+`, map[string][]string{"foo": {"foo-1.0.0"}, "bar": {"bar-2.0.0"}}},
+		{"yaml version as a number", `entries:
+  foo:
+  - name: foo
+    version: 1.10
+`, map[string][]string{"foo": {"foo-1.10"}}},
+		{"empty entries", "apiVersion: v1\nentries: {}\n", map[string][]string{}},
+		{"null entries", "apiVersion: v1\nentries:\n", map[string][]string{}},
+		{"json without entries", `{"apiVersion": "v1"}`, map[string][]string{}},
+		{"empty index", "", map[string][]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionsOf(t, tt.index); !cmp.Equal(tt.want, got) {
+				t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		index string
+	}{
+		{"not an index", "invalid"},
+		{"invalid yaml chart", "entries:\n  foo: [\n"},
+		{"yaml entries not a mapping", "entries: []\n"},
+		{"yaml versions not a list", "entries:\n  foo: bar\n"},
+		{"yaml entries a list", "entries:\n  - foo\n"},
+		{"invalid json", `{"entries": {"foo": [`},
+		{"json entries not an object", `{"entries": []}`},
+		{"invalid gzip", "\x1f\x8b invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.index), func(string, repo.ChartVersions) error { return nil })
+			if err == nil {
+				t.Errorf("got: nil, want: error")
+			}
+		})
+	}
+
+	t.Run("callback error", func(t *testing.T) {
+		want := errors.New("stop")
+		_, err := Decode(strings.NewReader(testIndexYAML), func(string, repo.ChartVersions) error { return want })
+		if err != want {
+			t.Errorf("got: %v, want: %v", err, want)
+		}
+	})
+}
+
+// errReader returns an error once its content is read
+type errReader struct {
+	io.Reader
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestDecodeStreamsYAML(t *testing.T) {
+	// The charts read before the error must have been decoded
+	index := testIndexYAML[:strings.Index(testIndexYAML, "  empty:")]
+	got := []string{}
+	_, err := Decode(errReader{strings.NewReader(index)}, func(name string, versions repo.ChartVersions) error {
+		got = append(got, name)
+		return nil
+	})
+	if err == nil {
+		t.Errorf("got: nil, want: error")
+	}
+	if want := []string{"foo"}; !cmp.Equal(want, got) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	// Loading the index must give the same charts as unmarshalling it whole
+	var want repo.IndexFile
+	if err := yaml.Unmarshal([]byte(testIndexYAML), &want); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want.SortEntries()
+	delete(want.Entries, "empty")
+
+	for _, index := range []string{testIndexYAML, testIndexJSON} {
+		got, err := Load(strings.NewReader(index))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !cmp.Equal(&want, got) {
+			t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(&want, got))
+		}
+	}
+}
@@ -0,0 +1,386 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package repovalidation validates a chart repository: its index, a sample of
+// its chart tarballs and icons, and the TLS and credentials used to reach it.
+package repovalidation
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// SeverityError is the severity of the issues making a repository
+	// invalid
+	SeverityError = "error"
+	// SeverityWarning is the severity of the issues which do not prevent
+	// syncing the repository
+	SeverityWarning = "warning"
+
+	// DefaultSampleSize is the default number of charts whose tarball and
+	// icon are fetched
+	DefaultSampleSize = 5
+
+	// certificateExpiryWarning is the remaining validity of the certificate
+	// of the repository below which a warning is reported
+	certificateExpiryWarning = 30 * 24 * time.Hour
+)
+
+// HTTPClient sends the requests to the repository
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Options configures the validation of a repository
+type Options struct {
+	// AuthorizationHeader is sent with every request when not empty
+	AuthorizationHeader string
+	// Authenticated reports that the client already sends credentials, such
+	// as a client certificate or a default Authorization header
+	Authenticated bool
+	// UserAgent is sent with every request when not empty
+	UserAgent string
+	// SampleSize is the number of charts whose latest tarball and icon are
+	// fetched
+	SampleSize int
+}
+
+// Report is the result of the validation of a repository
+type Report struct {
+	URL string `json:"url"`
+	// Valid is false when an issue with the error severity was found
+	Valid bool `json:"valid"`
+	// StatusCode is the status of the response to the index request
+	StatusCode    int        `json:"statusCode,omitempty"`
+	Authenticated bool       `json:"authenticated"`
+	TLS           *TLSReport `json:"tls,omitempty"`
+	Charts        int        `json:"charts"`
+	Versions      int        `json:"versions"`
+	Samples       []Sample   `json:"samples,omitempty"`
+	Issues        []Issue    `json:"issues,omitempty"`
+}
+
+// TLSReport describes the TLS connection to the repository
+type TLSReport struct {
+	Version  string    `json:"version"`
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// Sample is the result of fetching the tarball or the icon of a chart
+type Sample struct {
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	// Kind is either "tarball" or "icon"
+	Kind       string `json:"kind"`
+	URL        string `json:"url"`
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Issue is a problem found in the repository, related to a chart version
+// when they are set
+type Issue struct {
+	Severity string `json:"severity"`
+	Chart    string `json:"chart,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Message  string `json:"message"`
+}
+
+// indexFile is the part of a repository index which is validated
+type indexFile struct {
+	APIVersion string                         `json:"apiVersion"`
+	Entries    map[string][]indexChartVersion `json:"entries"`
+}
+
+type indexChartVersion struct {
+	Version string   `json:"version"`
+	Icon    string   `json:"icon"`
+	URLs    []string `json:"urls"`
+	Digest  string   `json:"digest"`
+}
+
+// Validate validates the repository at the URL. The problems found are
+// reported as issues, the report is only valid when none of them is an error.
+func Validate(client HTTPClient, repoURL string, opts Options) *Report {
+	v := validator{client: client, opts: opts, report: &Report{URL: repoURL, Authenticated: opts.Authenticated || opts.AuthorizationHeader != ""}}
+	v.validate(strings.TrimSpace(repoURL))
+	v.report.Valid = true
+	for _, issue := range v.report.Issues {
+		if issue.Severity == SeverityError {
+			v.report.Valid = false
+		}
+	}
+	return v.report
+}
+
+type validator struct {
+	client HTTPClient
+	opts   Options
+	report *Report
+}
+
+func (v *validator) addIssue(severity, chart, version, format string, args ...interface{}) {
+	v.report.Issues = append(v.report.Issues, Issue{Severity: severity, Chart: chart, Version: version, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(repoURL string) {
+	u, err := url.ParseRequestURI(repoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addIssue(SeverityError, "", "", "invalid repository URL %q, it must be an http or https URL", repoURL)
+		return
+	}
+	if u.Scheme == "http" && v.report.Authenticated {
+		v.addIssue(SeverityWarning, "", "", "the credentials are sent without TLS")
+	}
+
+	indexURL := strings.TrimSuffix(repoURL, "/") + "/index.yaml"
+	res, err := v.get(indexURL)
+	if err != nil {
+		if isCertificateError(err) {
+			v.addIssue(SeverityError, "", "", "unable to verify the certificate of the repository: %v", err)
+		} else {
+			v.addIssue(SeverityError, "", "", "unable to get the index: %v", err)
+		}
+		return
+	}
+	defer res.Body.Close()
+	v.report.StatusCode = res.StatusCode
+	v.validateTLS(res.TLS)
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		if v.report.Authenticated {
+			v.addIssue(SeverityError, "", "", "the credentials were rejected: %s", res.Status)
+		} else {
+			v.addIssue(SeverityError, "", "", "the repository requires credentials: %s", res.Status)
+		}
+		return
+	case res.StatusCode != http.StatusOK:
+		v.addIssue(SeverityError, "", "", "unable to get the index: %s", res.Status)
+		return
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		v.addIssue(SeverityError, "", "", "unable to read the index: %v", err)
+		return
+	}
+	var index indexFile
+	if err := yaml.Unmarshal(body, &index); err != nil {
+		v.addIssue(SeverityError, "", "", "unable to parse the index: %v", err)
+		return
+	}
+	v.validateIndex(index)
+	v.sample(u, index)
+}
+
+func (v *validator) validateTLS(state *tls.ConnectionState) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return
+	}
+	cert := state.PeerCertificates[0]
+	v.report.TLS = &TLSReport{
+		Version:  tlsVersionName(state.Version),
+		Subject:  cert.Subject.String(),
+		Issuer:   cert.Issuer.String(),
+		NotAfter: cert.NotAfter,
+	}
+	if state.Version < tls.VersionTLS12 {
+		v.addIssue(SeverityWarning, "", "", "the repository uses %s, TLS 1.2 or later is recommended", v.report.TLS.Version)
+	}
+	if remaining := time.Until(cert.NotAfter); remaining < certificateExpiryWarning {
+		v.addIssue(SeverityWarning, "", "", "the certificate of the repository expires on %s", cert.NotAfter.Format(time.RFC3339))
+	}
+}
+
+func (v *validator) validateIndex(index indexFile) {
+	if index.APIVersion == "" {
+		v.addIssue(SeverityError, "", "", "the index has no apiVersion")
+	}
+	if len(index.Entries) == 0 {
+		v.addIssue(SeverityError, "", "", "the index has no charts")
+	}
+	for _, name := range sortedChartNames(index) {
+		v.report.Charts++
+		seen := map[string]bool{}
+		for _, cv := range index.Entries[name] {
+			v.report.Versions++
+			if _, err := semver.NewVersion(cv.Version); err != nil {
+				v.addIssue(SeverityError, name, cv.Version, "unable to parse the version: %v", err)
+			}
+			if seen[cv.Version] {
+				v.addIssue(SeverityError, name, cv.Version, "duplicate version")
+			}
+			seen[cv.Version] = true
+			if len(cv.URLs) == 0 {
+				v.addIssue(SeverityError, name, cv.Version, "no URLs to download the chart")
+			}
+			if cv.Digest == "" {
+				v.addIssue(SeverityWarning, name, cv.Version, "no digest, the tarball cannot be verified")
+			}
+		}
+	}
+}
+
+// sample fetches the tarball and the icon of the latest version of the first
+// charts of the index, in alphabetical order.
+func (v *validator) sample(repoURL *url.URL, index indexFile) {
+	sampled := 0
+	for _, name := range sortedChartNames(index) {
+		if sampled >= v.opts.SampleSize {
+			return
+		}
+		cv, ok := latestVersion(index.Entries[name])
+		if !ok {
+			continue
+		}
+		sampled++
+		tarball := v.fetchSample(name, cv.Version, "tarball", resolveURL(repoURL, cv.URLs[0]), cv.Digest)
+		if !tarball.Reachable {
+			v.addIssue(SeverityError, name, cv.Version, "unable to download the chart: %s", tarball.Error)
+		}
+		if cv.Icon != "" {
+			icon := v.fetchSample(name, cv.Version, "icon", cv.Icon, "")
+			if !icon.Reachable {
+				v.addIssue(SeverityWarning, name, cv.Version, "unable to download the icon: %s", icon.Error)
+			}
+		}
+	}
+}
+
+// fetchSample gets the URL, verifying the digest of the response when set.
+func (v *validator) fetchSample(chart, version, kind, sampleURL, digest string) Sample {
+	sample := Sample{Chart: chart, Version: version, Kind: kind, URL: sampleURL}
+	defer func() { v.report.Samples = append(v.report.Samples, sample) }()
+
+	res, err := v.get(sampleURL)
+	if err != nil {
+		sample.Error = err.Error()
+		return sample
+	}
+	defer res.Body.Close()
+	sample.StatusCode = res.StatusCode
+	if res.StatusCode != http.StatusOK {
+		sample.Error = res.Status
+		return sample
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, res.Body); err != nil {
+		sample.Error = err.Error()
+		return sample
+	}
+	if got := fmt.Sprintf("%x", h.Sum(nil)); digest != "" && got != digest {
+		sample.Error = fmt.Sprintf("digest mismatch: got %s, want %s", got, digest)
+		return sample
+	}
+	sample.Reachable = true
+	return sample
+}
+
+func (v *validator) get(u string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if v.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", v.opts.UserAgent)
+	}
+	if v.opts.AuthorizationHeader != "" {
+		req.Header.Set("Authorization", v.opts.AuthorizationHeader)
+	}
+	return v.client.Do(req)
+}
+
+func sortedChartNames(index indexFile) []string {
+	names := make([]string, 0, len(index.Entries))
+	for name := range index.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// latestVersion returns the latest version of a chart which can be
+// downloaded, preferring those with a valid semver.
+func latestVersion(versions []indexChartVersion) (indexChartVersion, bool) {
+	var latest indexChartVersion
+	var latestSemver *semver.Version
+	found := false
+	for _, cv := range versions {
+		if len(cv.URLs) == 0 {
+			continue
+		}
+		sv, err := semver.NewVersion(cv.Version)
+		if !found || (err == nil && (latestSemver == nil || sv.GreaterThan(latestSemver))) {
+			latest, found = cv, true
+			if err == nil {
+				latestSemver = sv
+			}
+		}
+	}
+	return latest, found
+}
+
+// resolveURL returns the URL of a chart tarball, relative to the repository
+// URL unless it is absolute.
+func resolveURL(repoURL *url.URL, chartURL string) string {
+	if _, err := url.ParseRequestURI(chartURL); err == nil {
+		return chartURL
+	}
+	u := *repoURL
+	u.Path = path.Join(u.Path, chartURL)
+	return u.String()
+}
+
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &invalid) || errors.As(err, &hostname)
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
@@ -0,0 +1,277 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repovalidation
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const testTarball = "chart tarball"
+
+var testDigest = fmt.Sprintf("%x", sha256.Sum256([]byte(testTarball)))
+
+// newTestRepository serves the index with {{URL}} replaced by the URL of the
+// server, a chart tarball at /foo-1.0.0.tgz and an icon at /icon.png. The
+// requests must have the authorization header when it is not empty.
+func newTestRepository(index, authorizationHeader string, tls bool) *httptest.Server {
+	var server *httptest.Server
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if authorizationHeader != "" && req.Header.Get("Authorization") != authorizationHeader {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch req.URL.Path {
+		case "/index.yaml":
+			w.Write([]byte(strings.Replace(index, "{{URL}}", server.URL, -1)))
+		case "/foo-1.0.0.tgz":
+			w.Write([]byte(testTarball))
+		case "/icon.png":
+			w.Write([]byte("icon"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	if tls {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	return server
+}
+
+var validIndex = `apiVersion: v1
+entries:
+  foo:
+  - version: 1.0.0
+    icon: {{URL}}/icon.png
+    urls:
+    - foo-1.0.0.tgz
+    digest: ` + testDigest + `
+`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name                string
+		index               string
+		serverAuthorization string
+		opts                Options
+		wantValid           bool
+		wantCharts          int
+		wantVersions        int
+		wantSamples         []Sample
+		wantIssues          []Issue
+	}{
+		{
+			name:         "valid repository",
+			index:        validIndex,
+			opts:         Options{SampleSize: 1},
+			wantValid:    true,
+			wantCharts:   1,
+			wantVersions: 1,
+			wantSamples: []Sample{
+				{Chart: "foo", Version: "1.0.0", Kind: "tarball", URL: "{{URL}}/foo-1.0.0.tgz", Reachable: true, StatusCode: 200},
+				{Chart: "foo", Version: "1.0.0", Kind: "icon", URL: "{{URL}}/icon.png", Reachable: true, StatusCode: 200},
+			},
+		},
+		{
+			name: "invalid entries",
+			index: `apiVersion: v1
+entries:
+  bar:
+  - version: not-semver
+    urls:
+    - bar.tgz
+    digest: abc
+  foo:
+  - version: 1.0.0
+    urls:
+    - foo-1.0.0.tgz
+  - version: 1.0.0
+  - version: 0.1.0
+    urls:
+    - foo-0.1.0.tgz
+`,
+			opts:         Options{SampleSize: 0},
+			wantCharts:   2,
+			wantVersions: 4,
+			wantIssues: []Issue{
+				{Severity: SeverityError, Chart: "bar", Version: "not-semver", Message: "unable to parse the version: Invalid Semantic Version"},
+				{Severity: SeverityWarning, Chart: "foo", Version: "1.0.0", Message: "no digest, the tarball cannot be verified"},
+				{Severity: SeverityError, Chart: "foo", Version: "1.0.0", Message: "duplicate version"},
+				{Severity: SeverityError, Chart: "foo", Version: "1.0.0", Message: "no URLs to download the chart"},
+				{Severity: SeverityWarning, Chart: "foo", Version: "1.0.0", Message: "no digest, the tarball cannot be verified"},
+				{Severity: SeverityWarning, Chart: "foo", Version: "0.1.0", Message: "no digest, the tarball cannot be verified"},
+			},
+		},
+		{
+			name:       "empty index",
+			index:      "apiVersion: v1\nentries: {}\n",
+			wantIssues: []Issue{{Severity: SeverityError, Message: "the index has no charts"}},
+		},
+		{
+			name:       "unparseable index",
+			index:      "not an index",
+			wantIssues: []Issue{{Severity: SeverityError, Message: "unable to parse the index: error unmarshaling JSON: json: cannot unmarshal string into Go value of type repovalidation.indexFile"}},
+		},
+		{
+			name: "unreachable tarball and icon",
+			index: `apiVersion: v1
+entries:
+  foo:
+  - version: 2.0.0
+    icon: {{URL}}/missing.png
+    urls:
+    - {{URL}}/missing.tgz
+  - version: 1.0.0
+    urls:
+    - foo-1.0.0.tgz
+    digest: ` + testDigest + `
+`,
+			opts:         Options{SampleSize: 1},
+			wantCharts:   1,
+			wantVersions: 2,
+			wantSamples: []Sample{
+				{Chart: "foo", Version: "2.0.0", Kind: "tarball", URL: "{{URL}}/missing.tgz", StatusCode: 404, Error: "404 Not Found"},
+				{Chart: "foo", Version: "2.0.0", Kind: "icon", URL: "{{URL}}/missing.png", StatusCode: 404, Error: "404 Not Found"},
+			},
+			wantIssues: []Issue{
+				{Severity: SeverityWarning, Chart: "foo", Version: "2.0.0", Message: "no digest, the tarball cannot be verified"},
+				{Severity: SeverityError, Chart: "foo", Version: "2.0.0", Message: "unable to download the chart: 404 Not Found"},
+				{Severity: SeverityWarning, Chart: "foo", Version: "2.0.0", Message: "unable to download the icon: 404 Not Found"},
+			},
+		},
+		{
+			name: "digest mismatch",
+			index: `apiVersion: v1
+entries:
+  foo:
+  - version: 1.0.0
+    urls:
+    - foo-1.0.0.tgz
+    digest: abc
+`,
+			opts:         Options{SampleSize: 1},
+			wantCharts:   1,
+			wantVersions: 1,
+			wantSamples: []Sample{
+				{Chart: "foo", Version: "1.0.0", Kind: "tarball", URL: "{{URL}}/foo-1.0.0.tgz", StatusCode: 200, Error: "digest mismatch: got " + testDigest + ", want abc"},
+			},
+			wantIssues: []Issue{
+				{Severity: SeverityError, Chart: "foo", Version: "1.0.0", Message: "unable to download the chart: digest mismatch: got " + testDigest + ", want abc"},
+			},
+		},
+		{
+			name:                "authenticated repository",
+			index:               validIndex,
+			serverAuthorization: "Bearer foo",
+			opts:                Options{AuthorizationHeader: "Bearer foo"},
+			wantValid:           true,
+			wantCharts:          1,
+			wantVersions:        1,
+			wantIssues:          []Issue{{Severity: SeverityWarning, Message: "the credentials are sent without TLS"}},
+		},
+		{
+			name:                "missing credentials",
+			index:               validIndex,
+			serverAuthorization: "Bearer foo",
+			wantIssues:          []Issue{{Severity: SeverityError, Message: "the repository requires credentials: 401 Unauthorized"}},
+		},
+		{
+			name:                "rejected credentials",
+			index:               validIndex,
+			serverAuthorization: "Bearer foo",
+			opts:                Options{AuthorizationHeader: "Bearer bar"},
+			wantIssues: []Issue{
+				{Severity: SeverityWarning, Message: "the credentials are sent without TLS"},
+				{Severity: SeverityError, Message: "the credentials were rejected: 401 Unauthorized"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestRepository(tt.index, tt.serverAuthorization, false)
+			defer server.Close()
+
+			report := Validate(server.Client(), server.URL, tt.opts)
+			if got, want := report.Valid, tt.wantValid; got != want {
+				t.Errorf("got valid: %t, want: %t (issues: %v)", got, want, report.Issues)
+			}
+			if got, want := report.Charts, tt.wantCharts; got != want {
+				t.Errorf("got charts: %d, want: %d", got, want)
+			}
+			if got, want := report.Versions, tt.wantVersions; got != want {
+				t.Errorf("got versions: %d, want: %d", got, want)
+			}
+			for i := range tt.wantSamples {
+				tt.wantSamples[i].URL = strings.Replace(tt.wantSamples[i].URL, "{{URL}}", server.URL, -1)
+			}
+			if !cmp.Equal(tt.wantSamples, report.Samples, cmpopts.EquateEmpty()) {
+				t.Errorf("samples mismatch (-want +got):\n%s", cmp.Diff(tt.wantSamples, report.Samples, cmpopts.EquateEmpty()))
+			}
+			if !cmp.Equal(tt.wantIssues, report.Issues, cmpopts.EquateEmpty()) {
+				t.Errorf("issues mismatch (-want +got):\n%s", cmp.Diff(tt.wantIssues, report.Issues, cmpopts.EquateEmpty()))
+			}
+		})
+	}
+}
+
+func TestValidateInvalidURL(t *testing.T) {
+	report := Validate(http.DefaultClient, "ftp://example.com", Options{})
+	want := []Issue{{Severity: SeverityError, Message: `invalid repository URL "ftp://example.com", it must be an http or https URL`}}
+	if !cmp.Equal(want, report.Issues) {
+		t.Errorf("mismatch (-want +got):\n%s", cmp.Diff(want, report.Issues))
+	}
+	if report.Valid {
+		t.Errorf("got valid: true, want: false")
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	server := newTestRepository(validIndex, "", true)
+	defer server.Close()
+
+	t.Run("trusted certificate", func(t *testing.T) {
+		report := Validate(server.Client(), server.URL, Options{})
+		if !report.Valid {
+			t.Errorf("got valid: false, want: true (issues: %v)", report.Issues)
+		}
+		if report.TLS == nil {
+			t.Fatalf("got no TLS report")
+		}
+		if got, want := report.TLS.Version, "TLS 1.3"; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		report := Validate(&http.Client{}, server.URL, Options{})
+		if report.Valid {
+			t.Errorf("got valid: true, want: false")
+		}
+		if len(report.Issues) != 1 || !strings.HasPrefix(report.Issues[0].Message, "unable to verify the certificate of the repository") {
+			t.Errorf("got issues: %v, want a certificate error", report.Issues)
+		}
+	})
+}
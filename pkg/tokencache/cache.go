@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tokencache caches the results of the checks done for a user, such
+// as access reviews, keyed by a hash of the user token.
+package tokencache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HashToken returns an identifier for a token which can be kept in memory
+// without keeping the token itself.
+func HashToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+type entry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// Cache stores values for a limited time. Keys usually combine a token hash
+// with what was checked, eg. "<hash>/<namespace>".
+type Cache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]entry
+	// now is a field so that it can be switched when testing.
+	now func() time.Time
+}
+
+// New returns a cache whose values expire after the given TTL.
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: map[string]entry{},
+		now:     time.Now,
+	}
+}
+
+// Get returns the value stored for the key unless it has expired.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.now().After(e.expiresAt) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores the value for the key.
+func (c *Cache) Set(key string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	// Drop expired entries so that the cache does not grow with every token.
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry{value: value, expiresAt: now.Add(c.ttl)}
+}
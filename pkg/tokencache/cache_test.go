@@ -0,0 +1,56 @@
+/*
+Copyright (c) 2020 Bitnami
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tokencache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(10 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("hash/default", "foo")
+
+	if got, ok := cache.Get("hash/default"); !ok || got != "foo" {
+		t.Errorf("got: %v, %t, want: foo, true", got, ok)
+	}
+	if _, ok := cache.Get("otherhash/default"); ok {
+		t.Errorf("got: true, want: false")
+	}
+
+	now = now.Add(11 * time.Second)
+	if _, ok := cache.Get("hash/default"); ok {
+		t.Errorf("got: true, want: false")
+	}
+
+	cache.Set("hash/other", "bar")
+	if got, want := len(cache.entries), 1; got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	if got, want := HashToken("foo"), HashToken("foo"); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got := HashToken("foo"); got == HashToken("bar") || got == "foo" {
+		t.Errorf("got: %q, want a distinct hash", got)
+	}
+}